@@ -0,0 +1,170 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const (
+	// maxLevelRequestSize bounds the size of the requests LevelHandler reads.
+	maxLevelRequestSize = 4096
+
+	invalidLevelRequestFailMsg = "cni-log: invalid level request: %v"
+)
+
+// levelState is the state LevelHandler serves.
+type levelState struct {
+	Level       string        `json:"level"`
+	StderrLevel string        `json:"stderrLevel"`
+	FileLevel   string        `json:"fileLevel"`
+	LogToStderr bool          `json:"logToStderr"`
+	Config      []configState `json:"config"`
+}
+
+// configState is an effective configuration value, see ExplainConfig.
+type configState struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// levelRequest is the change of the state LevelHandler accepts. Unset fields are not changed, an empty stderrLevel or
+// fileLevel makes the output follow level again.
+type levelRequest struct {
+	Level       *string `json:"level"`
+	StderrLevel *string `json:"stderrLevel"`
+	FileLevel   *string `json:"fileLevel"`
+	LogToStderr *bool   `json:"logToStderr"`
+}
+
+// LevelHandler returns an http.Handler which serves the logging level and the configuration of the outputs, so that
+// daemons can switch a node to debug logging at runtime without being restarted, e.g.
+//
+//	http.Handle("/loglevel", logging.LevelHandler())
+//
+// GET returns the state as JSON:
+//
+//	{"level":"info","stderrLevel":"","fileLevel":"","logToStderr":true,"config":[...]}
+//
+// An empty stderrLevel or fileLevel means that the output follows level, see SetStderrLogLevel and SetFileLogLevel.
+// config lists the effective configuration together with its sources, see ExplainConfig. PUT takes a JSON object with
+// any of the fields level, stderrLevel, fileLevel and logToStderr, applies them like the corresponding setters and
+// returns the new state. A request with an invalid level is rejected without changing anything. The handler does not
+// authenticate requests, expose it on a local or otherwise protected address only.
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(serveLevel)
+}
+
+// serveLevel implements LevelHandler.
+func serveLevel(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		if err := applyLevelRequest(http.MaxBytesReader(w, r.Body, maxLevelRequestSize)); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(currentLevelState())
+}
+
+// applyLevelRequest decodes a levelRequest and applies it. Nothing is changed if it is invalid.
+func applyLevelRequest(body io.Reader) error {
+	var req levelRequest
+	dec := json.NewDecoder(body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		return fmt.Errorf(invalidLevelRequestFailMsg, err)
+	}
+
+	level, err := requestLevel(req.Level, false)
+	if err != nil {
+		return err
+	}
+	stderrLevel, err := requestLevel(req.StderrLevel, true)
+	if err != nil {
+		return err
+	}
+	fileLevel, err := requestLevel(req.FileLevel, true)
+	if err != nil {
+		return err
+	}
+
+	if req.Level != nil {
+		SetLogLevel(level)
+	}
+	if req.StderrLevel != nil {
+		SetStderrLogLevel(stderrLevel)
+	}
+	if req.FileLevel != nil {
+		SetFileLogLevel(fileLevel)
+	}
+	if req.LogToStderr != nil {
+		SetLogStderr(*req.LogToStderr)
+	}
+	return nil
+}
+
+// requestLevel parses a level of a levelRequest. If follow is set, an empty level is InvalidLevel, the level of an
+// output which follows the global level.
+func requestLevel(name *string, follow bool) (Level, error) {
+	if name == nil || (follow && *name == "") {
+		return InvalidLevel, nil
+	}
+	level := StringToLevel(*name)
+	if level == InvalidLevel {
+		return InvalidLevel, fmt.Errorf(invalidLevelRequestFailMsg, fmt.Sprintf("unknown level %q", *name))
+	}
+	return level, nil
+}
+
+// currentLevelState returns the state served by LevelHandler.
+func currentLevelState() levelState {
+	config := ExplainConfig()
+
+	mu.RLock()
+	state := levelState{
+		Level:       logLevel.String(),
+		StderrLevel: outputLevelName(stderrLogLevel),
+		FileLevel:   outputLevelName(fileLogLevel),
+		LogToStderr: logToStderr,
+		Config:      make([]configState, 0, len(config)),
+	}
+	mu.RUnlock()
+
+	for _, v := range config {
+		state.Config = append(state.Config, configState{Name: v.Name, Value: fmt.Sprint(v.Value),
+			Source: v.Source.String()})
+	}
+	return state
+}
+
+// outputLevelName returns the name of the level of an output, empty if it follows the global level.
+func outputLevelName(level Level) string {
+	if level == InvalidLevel {
+		return ""
+	}
+	return level.String()
+}
@@ -0,0 +1,79 @@
+package logging
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LevelHandler", func() {
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+	})
+
+	serve := func(method, body string) (*httptest.ResponseRecorder, levelState) {
+		rec := httptest.NewRecorder()
+		LevelHandler().ServeHTTP(rec, httptest.NewRequest(method, "/loglevel", strings.NewReader(body)))
+		var state levelState
+		if rec.Code == http.StatusOK {
+			Expect(json.Unmarshal(rec.Body.Bytes(), &state)).To(Succeed())
+		}
+		return rec, state
+	}
+
+	It("returns the current levels and configuration", func() {
+		SetLogLevel(DebugLevel)
+		SetFileLogLevel(ErrorLevel)
+
+		rec, state := serve(http.MethodGet, "")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Header().Get("Content-Type")).To(Equal("application/json"))
+		Expect(state.Level).To(Equal("debug"))
+		Expect(state.StderrLevel).To(BeEmpty())
+		Expect(state.FileLevel).To(Equal("error"))
+		Expect(state.LogToStderr).To(BeFalse())
+		Expect(state.Config).To(ContainElement(configState{Name: "logLevel", Value: "debug", Source: "api"}))
+	})
+
+	It("changes the levels", func() {
+		SetFileLogLevel(ErrorLevel)
+
+		rec, state := serve(http.MethodPut, `{"level":"debug","stderrLevel":"warning","fileLevel":"","logToStderr":true}`)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(state.Level).To(Equal("debug"))
+		Expect(state.StderrLevel).To(Equal("warning"))
+		Expect(state.FileLevel).To(BeEmpty())
+		Expect(state.LogToStderr).To(BeTrue())
+		Expect(GetLogLevel()).To(Equal(DebugLevel))
+		Expect(stderrLogLevel).To(Equal(WarningLevel))
+		Expect(fileLogLevel).To(Equal(InvalidLevel))
+	})
+
+	It("keeps the fields which are not set", func() {
+		SetStderrLogLevel(ErrorLevel)
+
+		_, state := serve(http.MethodPut, `{"level":"trace"}`)
+		Expect(state.Level).To(Equal("trace"))
+		Expect(state.StderrLevel).To(Equal("error"))
+	})
+
+	It("rejects invalid requests without changing anything", func() {
+		for _, body := range []string{`{"level":"debug","fileLevel":"loud"}`, `{"level":""}`, `{"lvl":"debug"}`, `debug`} {
+			rec, _ := serve(http.MethodPut, body)
+			Expect(rec.Code).To(Equal(http.StatusBadRequest), body)
+		}
+		Expect(GetLogLevel()).To(Equal(defaultLogLevel))
+		Expect(fileLogLevel).To(Equal(InvalidLevel))
+	})
+
+	It("rejects other methods", func() {
+		rec, _ := serve(http.MethodPost, `{"level":"debug"}`)
+		Expect(rec.Code).To(Equal(http.StatusMethodNotAllowed))
+		Expect(rec.Header().Get("Allow")).To(Equal("GET, PUT"))
+	})
+})
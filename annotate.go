@@ -0,0 +1,65 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import "sync"
+
+// pendingAnnotations holds the fields attached with Annotate until the next entry is written.
+var pendingAnnotations struct {
+	mu     sync.Mutex
+	fields []interface{}
+}
+
+// Annotate attaches the alternating keys and values of kv to the next log message which is written, e.g. information
+// which only becomes available after the message describing an operation was logged:
+//
+//	logging.Annotate("mac", link.Attrs().HardwareAddr)
+//	logging.InfoStructured("interface configured", "ifname", ifname)
+//
+// The fields are appended to the fields of a structured message and after a printf style message. Messages which are
+// filtered, e.g. by the logging level or rate limiting, leave the annotations pending. Subsequent calls add to the
+// pending annotations.
+func Annotate(kv ...interface{}) {
+	if len(kv) == 0 {
+		return
+	}
+	pendingAnnotations.mu.Lock()
+	defer pendingAnnotations.mu.Unlock()
+	pendingAnnotations.fields = append(pendingAnnotations.fields, kv...)
+}
+
+// annotations takes the pending annotations, prepared like the arguments of a structured message.
+func (s *snapshot) annotations() []interface{} {
+	pendingAnnotations.mu.Lock()
+	kv := pendingAnnotations.fields
+	pendingAnnotations.fields = nil
+	pendingAnnotations.mu.Unlock()
+
+	if len(kv) == 0 {
+		return nil
+	}
+	kv = s.evenArgs("", structuredLoggingOddArguments, evaluateLazy(kv))
+	if s.sanitize {
+		kv = sanitizeKeys(kv)
+	}
+	return kv
+}
+
+// resetAnnotations discards the pending annotations.
+func resetAnnotations() {
+	pendingAnnotations.mu.Lock()
+	defer pendingAnnotations.mu.Unlock()
+	pendingAnnotations.fields = nil
+}
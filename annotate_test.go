@@ -0,0 +1,50 @@
+package logging
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Annotate", func() {
+	var sink *captureSink
+
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+		sink = &captureSink{}
+		AddSink(sink)
+	})
+
+	It("attaches the fields to the next structured message only", func() {
+		Annotate("mac", "0a:58:0a:f4:00:05")
+		Annotate("mtu", 1400)
+		InfoStructured(infoMsg, "ifname", "net1")
+		InfoStructured(infoMsg)
+
+		Expect(sink.entries[0].String()).To(HaveSuffix(`ifname="net1" mac="0a:58:0a:f4:00:05" mtu="1400"`))
+		Expect(sink.entries[1].String()).To(HaveSuffix(fmt.Sprintf("msg=%q", infoMsg)))
+	})
+
+	It("appends the fields after printf style messages", func() {
+		Annotate("mac", "0a:58:0a:f4:00:05")
+		Infof(infoMsg)
+		Expect(sink.entries[0].String()).To(HaveSuffix(infoMsg + ` mac="0a:58:0a:f4:00:05"`))
+	})
+
+	It("keeps the fields pending while messages are filtered", func() {
+		Annotate("mac", "0a:58:0a:f4:00:05")
+		DebugStructured(debugMsg)
+		WarningStructured(warningMsg)
+		Expect(sink.entries).To(HaveLen(1))
+		Expect(sink.entries[0].String()).To(HaveSuffix(`mac="0a:58:0a:f4:00:05"`))
+	})
+
+	It("reports a dangling key", func() {
+		Annotate("mac")
+		InfoStructured(infoMsg)
+		Expect(sink.entries[0].String()).To(HaveSuffix(fmt.Sprintf(`logging_error="%s, dropped \"mac\""`,
+			structuredLoggingOddArguments)))
+	})
+})
@@ -0,0 +1,186 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+var errAsyncBufferFull = errors.New("cni-log: asynchronous logging buffer is full, message dropped")
+
+const (
+	defaultAsyncBatchSize = 64 * 1024
+	defaultAsyncMaxAge    = time.Second
+)
+
+// AsyncOptions defines the configuration of asynchronous logging to the log file or custom output.
+type AsyncOptions struct {
+	// BatchSize is the number of buffered bytes which triggers a write. Defaults to 64KiB.
+	BatchSize int `json:"batchSize,omitempty"`
+	// MaxAge is the maximum time an entry stays buffered before it is written, even if BatchSize is not reached.
+	// Defaults to 1s.
+	MaxAge time.Duration `json:"maxAge,omitempty"`
+	// MaxBufferSize is the number of buffered bytes beyond which entries are dropped, e.g. while the output is too
+	// slow. The fullness of the buffer is reported by Pressure. 0 does not limit the buffer, which is the default.
+	MaxBufferSize int `json:"maxBufferSize,omitempty"`
+}
+
+// asyncWriter buffers writes in memory and writes them to out in batches. A batch is written once it reaches
+// batchSize or once its oldest entry is older than maxAge, whichever comes first. Full batches are written by a single
+// goroutine, which runs until stop is called.
+type asyncWriter struct {
+	out       io.Writer
+	batchSize int
+	maxAge    time.Duration
+	maxBuffer int
+
+	mu    sync.Mutex // guards buf and timer
+	buf   bytes.Buffer
+	timer *time.Timer
+
+	flushMu sync.Mutex // guards out and serializes writes to it so that batches are written in order
+
+	flush chan struct{} // signals the flushing goroutine that a batch is full, holds at most one pending signal
+	done  chan struct{} // closed by stop to end the flushing goroutine
+}
+
+// newAsyncWriter returns an asyncWriter writing to out. Missing options are defaulted.
+func newAsyncWriter(out io.Writer, options *AsyncOptions) *asyncWriter {
+	w := &asyncWriter{
+		out:       out,
+		batchSize: defaultAsyncBatchSize,
+		maxAge:    defaultAsyncMaxAge,
+		maxBuffer: options.MaxBufferSize,
+		flush:     make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+	if options.BatchSize > 0 {
+		w.batchSize = options.BatchSize
+	}
+	if options.MaxAge > 0 {
+		w.maxAge = options.MaxAge
+	}
+	go w.flushBatches()
+	return w
+}
+
+// flushBatches writes the buffered entries whenever Write signals a full batch, until stop is called.
+func (w *asyncWriter) flushBatches() {
+	for {
+		select {
+		case <-w.flush:
+			_ = w.Flush()
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// stop ends the goroutine writing full batches. Entries written afterwards are still written once they reach the
+// maximum age.
+func (w *asyncWriter) stop() {
+	close(w.done)
+}
+
+// Write implements io.Writer. It only fails if the buffer is full, errors of the underlying writer are reported by
+// Flush.
+func (w *asyncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.maxBuffer > 0 {
+		if w.buf.Len()+len(p) > w.maxBuffer {
+			return 0, errAsyncBufferFull
+		}
+		defer func() { reportPressure(pressureAsync, w.buf.Len(), w.maxBuffer) }()
+	}
+	if w.buf.Len() == 0 {
+		// The first entry of a batch starts the clock.
+		w.timer = time.AfterFunc(w.maxAge, func() { _ = w.Flush() })
+	}
+	w.buf.Write(p)
+
+	if w.buf.Len() >= w.batchSize {
+		// A pending signal covers this batch as well.
+		select {
+		case w.flush <- struct{}{}:
+		default:
+		}
+	}
+	return len(p), nil
+}
+
+// Flush writes all buffered entries to the underlying writer.
+func (w *asyncWriter) Flush() error {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	w.mu.Lock()
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	data := make([]byte, w.buf.Len())
+	copy(data, w.buf.Bytes())
+	w.buf.Reset()
+	w.mu.Unlock()
+	if w.maxBuffer > 0 {
+		reportPressure(pressureAsync, 0, w.maxBuffer)
+	}
+
+	if len(data) == 0 || w.out == nil {
+		return nil
+	}
+	_, err := w.out.Write(data)
+	return err
+}
+
+// setOutput replaces the underlying writer. Buffered entries are written to the previous writer first. A nil writer
+// discards the entries.
+func (w *asyncWriter) setOutput(out io.Writer) {
+	_ = w.Flush()
+
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+	w.out = out
+}
+
+// SetAsync enables asynchronous logging to the log file or custom output: entries are buffered in memory and written
+// in batches, see AsyncOptions. Passing nil flushes the buffered entries and disables asynchronous logging. Logging to
+// stderr is never asynchronous.
+func SetAsync(options *AsyncOptions) {
+	mu.Lock()
+	defer unlockAndPublish()
+	setAsync(options)
+}
+
+// setAsync enables or disables asynchronous logging. The caller must hold mu.
+func setAsync(options *AsyncOptions) {
+	_ = flushOutputs()
+	reportPressure(pressureAsync, 0, 0)
+	if asyncOutput != nil {
+		asyncOutput.stop()
+	}
+	if options == nil {
+		asyncOutput = nil
+		return
+	}
+
+	asyncOutput = newAsyncWriter(logWriter, options)
+}
@@ -0,0 +1,162 @@
+package logging
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+// syncBuffer is a bytes.Buffer which can be written and read concurrently.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// writerFunc is an io.Writer calling itself.
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
+
+// syncingSink records whether it was synced.
+type syncingSink struct {
+	synced bool
+}
+
+func (s *syncingSink) Write(Entry) error {
+	return nil
+}
+
+func (s *syncingSink) Sync() error {
+	s.synced = true
+	return nil
+}
+
+var _ = Describe("Asynchronous logging", func() {
+	var out *syncBuffer
+
+	BeforeEach(func() {
+		initLogger()
+		out = &syncBuffer{}
+		SetOutput(out)
+		SetLogStderr(false)
+	})
+
+	AfterEach(func() {
+		SetAsync(nil)
+	})
+
+	When("the batch size is not reached", func() {
+		It("writes the entry once it reaches the maximum age", func() {
+			SetAsync(&AsyncOptions{BatchSize: 1024 * 1024, MaxAge: 50 * time.Millisecond})
+			Infof(infoMsg)
+			Expect(out.String()).To(BeEmpty())
+			Eventually(out.String).Should(ContainSubstring(infoMsg))
+		})
+	})
+
+	When("the batch size is reached", func() {
+		It("writes the batch before the maximum age", func() {
+			SetAsync(&AsyncOptions{BatchSize: 1, MaxAge: time.Hour})
+			Infof(infoMsg)
+			Eventually(out.String).Should(ContainSubstring(infoMsg))
+		})
+	})
+
+	When("the output is slower than the logging", func() {
+		It("writes the full batches with a single goroutine", func() {
+			release := make(chan struct{})
+			SetOutput(writerFunc(func(p []byte) (int, error) {
+				<-release
+				return out.Write(p)
+			}))
+			SetAsync(&AsyncOptions{BatchSize: 1, MaxAge: time.Hour})
+			goroutines := runtime.NumGoroutine()
+			for i := 0; i < 100; i++ {
+				Infof(infoMsg)
+			}
+			running := runtime.NumGoroutine()
+			close(release)
+			Expect(running).To(BeNumerically("<=", goroutines+1))
+
+			Warningf(warningMsg)
+			Eventually(out.String).Should(ContainSubstring(warningMsg))
+			Expect(strings.Count(out.String(), infoMsg)).To(Equal(100))
+		})
+	})
+
+	When("asynchronous logging is disabled", func() {
+		It("writes the buffered entries", func() {
+			SetAsync(&AsyncOptions{MaxAge: time.Hour})
+			Infof(infoMsg)
+			Warningf(warningMsg)
+			SetAsync(nil)
+			Expect(out.String()).To(MatchRegexp(infoMsg + "\n.*" + warningMsg))
+		})
+	})
+
+	When("the entries are flushed", func() {
+		It("writes the buffered entries", func() {
+			SetAsync(&AsyncOptions{MaxAge: time.Hour})
+			Infof(infoMsg)
+			Flush()
+			Expect(out.String()).To(ContainSubstring(infoMsg))
+		})
+	})
+
+	When("a message is logged synchronously", func() {
+		It("returns once the buffered entries are written", func() {
+			SetAsync(&AsyncOptions{MaxAge: time.Hour})
+			Infof(infoMsg)
+			Expect(ErrorfSync(errorMsg)).To(MatchError(errorMsg))
+			Expect(out.String()).To(MatchRegexp(infoMsg + "\n.*" + errorMsg))
+		})
+
+		It("commits the log file to stable storage", func() {
+			logFile := path.Join(os.TempDir(), "test-sync.log")
+			defer os.Remove(logFile)
+			SetLogFile(logFile)
+			SetAsync(&AsyncOptions{MaxAge: time.Hour})
+			PanicfSync(panicMsg)
+			Expect(logFileContains(logFile, panicMsg)).To(BeTrue())
+		})
+
+		It("syncs outputs which support it", func() {
+			sink := &syncingSink{}
+			AddSink(sink)
+			Expect(Sync()).To(Succeed())
+			Expect(sink.synced).To(BeTrue())
+		})
+	})
+
+	When("the output is replaced", func() {
+		It("writes the buffered entries to the previous output", func() {
+			SetAsync(&AsyncOptions{MaxAge: time.Hour})
+			Infof(infoMsg)
+			newOut := &syncBuffer{}
+			SetOutput(newOut)
+			Expect(out.String()).To(ContainSubstring(infoMsg))
+			Expect(newOut.String()).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,110 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	callerKey     = "caller"
+	unknownCaller = "???"
+	maxCallDepth  = 32
+	logrPackage   = "github.com/go-logr/logr."
+	slogPackage   = "log/slog."
+)
+
+// packageDir is the directory of the source files of this package, which are skipped when looking for the call site.
+var packageDir = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Dir(file)
+}()
+
+// EnableCallerInfo adds the call site of the log statement, formatted as "file:line:function", to every message: after
+// the prefix of printf style messages, and in a "caller" field after the structured prefix of structured messages. The
+// frames of this package, of its klogbridge and logr packages and of logr are skipped, so the call site is found through
+// all logging functions, Loggers and the logr sink. skipFrames skips further frames above the call site, e.g. 1 for a helper function of the plugin which
+// wraps the logging functions.
+func EnableCallerInfo(skipFrames int) {
+	mu.Lock()
+	defer unlockAndPublish()
+	callerInfo = true
+	callerSkip = skipFrames
+}
+
+// DisableCallerInfo stops adding the call site to messages, which is the default.
+func DisableCallerInfo() {
+	mu.Lock()
+	defer unlockAndPublish()
+	callerInfo = false
+	callerSkip = 0
+}
+
+// callSite returns the call site of the log statement, skipping skip frames above it. The line number is 0 unless
+// withLine is set.
+func callSite(skip int, withLine bool) string {
+	pcs := make([]uintptr, maxCallDepth)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	external := false
+	for {
+		frame, more := frames.Next()
+		if external || !isInternalFrame(frame) {
+			if skip <= 0 {
+				line := 0
+				if withLine {
+					line = frame.Line
+				}
+				return fmt.Sprintf("%s:%d:%s", filepath.Base(frame.File), line, shortFunction(frame.Function))
+			}
+			external = true
+			skip--
+		}
+		if !more {
+			return unknownCaller
+		}
+	}
+}
+
+// bridgeDirs are the directories of the packages bridging other logging libraries to this one, relative to packageDir.
+var bridgeDirs = []string{"klogbridge", "logr"}
+
+// isInternalFrame returns true for frames of the logging functions, i.e. of the non-test files of this package and of
+// its bridge packages, of logr and of slog.
+func isInternalFrame(frame runtime.Frame) bool {
+	if strings.HasPrefix(frame.Function, logrPackage) || strings.HasPrefix(frame.Function, slogPackage) {
+		return true
+	}
+	if strings.HasSuffix(frame.File, "_test.go") {
+		return false
+	}
+	dir := filepath.Dir(frame.File)
+	for _, bridge := range bridgeDirs {
+		if dir == filepath.Join(packageDir, bridge) {
+			return true
+		}
+	}
+	return dir == packageDir
+}
+
+// shortFunction strips the import path from the name of a function, e.g. "main.cmdAdd".
+func shortFunction(function string) string {
+	if i := strings.LastIndex(function, "/"); i >= 0 {
+		return function[i+1:]
+	}
+	return function
+}
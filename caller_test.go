@@ -0,0 +1,64 @@
+package logging
+
+import (
+	"context"
+	"regexp"
+	"runtime"
+	"strconv"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+// currentLine returns the line of its caller.
+func currentLine() string {
+	_, _, line, _ := runtime.Caller(1)
+	return strconv.Itoa(line)
+}
+
+// logThroughHelper logs from a helper function, like a plugin wrapping the logging functions.
+func logThroughHelper(msg string, args ...interface{}) {
+	InfoStructured(msg, args...)
+}
+
+var _ = Describe("Caller information", func() {
+	var sink *captureSink
+
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+		sink = &captureSink{}
+		AddSink(sink)
+		EnableCallerInfo(0)
+	})
+
+	It("adds the call site to structured messages", func() {
+		InfoStructured(infoMsg, "line", currentLine())
+		With("pod", "pod-a").InfoFields(infoMsg, String("line", currentLine()))
+		InfoStructuredCtx(context.Background(), infoMsg, "line", currentLine())
+
+		Expect(sink.entries).To(HaveLen(3))
+		for _, entry := range sink.entries {
+			line := entry.Fields[len(entry.Fields)-1]
+			Expect(entry.String()).To(ContainSubstring(`caller="caller_test.go:%s:`, line))
+		}
+		Expect(sink.entries[0].String()).To(MatchRegexp(`msg=".*" caller="[^"]*" line="\d+"$`))
+	})
+
+	It("adds the call site to the prefix of printf style messages", func() {
+		Infof("%s", currentLine())
+		match := regexp.MustCompile(`\] caller_test.go:(\d+):\S+ (\d+)$`).FindStringSubmatch(sink.entries[0].String())
+		Expect(match).To(HaveLen(3))
+		Expect(match[1]).To(Equal(match[2]))
+	})
+
+	It("skips the frames of helper functions", func() {
+		EnableCallerInfo(1)
+		logThroughHelper(infoMsg, "line", currentLine())
+		Expect(sink.entries[0].String()).To(ContainSubstring(`caller="caller_test.go:%s:`, sink.entries[0].Fields[len(sink.entries[0].Fields)-1]))
+
+		DisableCallerInfo()
+		InfoStructured(infoMsg)
+		Expect(sink.entries[1].String()).NotTo(ContainSubstring("caller"))
+	})
+})
@@ -0,0 +1,93 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"fmt"
+	"sync"
+)
+
+const (
+	driftKey       = "drift"
+	findingsKey    = "findings"
+	driftFailMsg   = "%s: %d finding(s): %s"
+	findingsFormat = "%s: expected %+v, actual %+v"
+)
+
+// Finding is a difference between the desired and the actual state of a resource, found during a CNI CHECK.
+type Finding struct {
+	Resource string      `json:"resource"`
+	Expected interface{} `json:"expected"`
+	Actual   interface{} `json:"actual"`
+}
+
+// String returns a human-readable representation of the finding.
+func (f Finding) String() string {
+	return fmt.Sprintf(findingsFormat, f.Resource, f.Expected, f.Actual)
+}
+
+// CheckReport accumulates the findings of a CNI CHECK, so that the drift between the desired and the actual network
+// state is logged in a single machine-readable entry instead of one message per difference. It is safe for concurrent
+// use.
+type CheckReport struct {
+	mu       sync.Mutex
+	fields   []interface{}
+	findings []Finding
+}
+
+// NewCheckReport returns an empty report. The alternating keys and values of args, e.g. the container ID and the
+// interface name, are added to the entry emitted by Emit.
+func NewCheckReport(args ...interface{}) *CheckReport {
+	return &CheckReport{fields: args}
+}
+
+// Drift records that resource, e.g. "ip" or "route 10.0.0.0/8", is in the actual state instead of the expected one.
+func (r *CheckReport) Drift(resource string, expected, actual interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.findings = append(r.findings, Finding{Resource: resource, Expected: expected, Actual: actual})
+}
+
+// Findings returns the findings recorded so far.
+func (r *CheckReport) Findings() []Finding {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Finding(nil), r.findings...)
+}
+
+// Emit logs the report as a single structured entry with msg, the fields of the report, a "drift" field with the
+// number of findings and a "findings" field with the findings. Reports with findings are logged at warning level,
+// reports without at info level. It returns the findings.
+func (r *CheckReport) Emit(msg string) []Finding {
+	findings := r.Findings()
+	level := InfoLevel
+	if len(findings) > 0 {
+		level = WarningLevel
+	}
+
+	args := make([]interface{}, 0, len(r.fields)+4)
+	args = append(append(args, r.fields...), driftKey, len(findings), findingsKey, findings)
+	printStructured(level, msg, args...)
+	return findings
+}
+
+// Err returns an error listing the findings, which a CHECK can return to the runtime, or nil if there are none.
+func (r *CheckReport) Err(msg string) error {
+	findings := r.Findings()
+	if len(findings) == 0 {
+		return nil
+	}
+	return fmt.Errorf(driftFailMsg, msg, len(findings), findings)
+}
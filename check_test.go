@@ -0,0 +1,51 @@
+package logging
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CHECK reports", func() {
+	var sink *captureSink
+
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+		sink = &captureSink{}
+		AddSink(sink)
+	})
+
+	It("emits all findings in a single entry", func() {
+		out := &bytes.Buffer{}
+		AddOutput(out)
+		SetFileFormatter(JSONFormatter{})
+
+		report := NewCheckReport("containerID", "abc")
+		report.Drift("ip", "10.0.0.2/24", "10.0.0.3/24")
+		report.Drift("mtu", 1500, 9000)
+		findings := report.Emit("interface drifted")
+
+		Expect(findings).To(Equal([]Finding{
+			{Resource: "ip", Expected: "10.0.0.2/24", Actual: "10.0.0.3/24"},
+			{Resource: "mtu", Expected: 1500, Actual: 9000},
+		}))
+		Expect(sink.entries).To(HaveLen(1))
+		Expect(sink.entries[0].Level).To(Equal(WarningLevel))
+		Expect(sink.entries[0].String()).To(HaveSuffix(`containerID="abc" drift="2" ` +
+			`findings="[ip: expected 10.0.0.2/24, actual 10.0.0.3/24 mtu: expected 1500, actual 9000]"`))
+		Expect(out.String()).To(ContainSubstring(`"drift":2,"findings":[` +
+			`{"resource":"ip","expected":"10.0.0.2/24","actual":"10.0.0.3/24"},` +
+			`{"resource":"mtu","expected":1500,"actual":9000}]`))
+		Expect(report.Err("check failed")).To(MatchError(HavePrefix("check failed: 2 finding(s): [ip: expected")))
+	})
+
+	It("emits clean reports at info level", func() {
+		report := NewCheckReport()
+		Expect(report.Emit("no drift")).To(BeEmpty())
+		Expect(sink.entries[0].Level).To(Equal(InfoLevel))
+		Expect(sink.entries[0].String()).To(HaveSuffix(`drift="0" findings="[]"`))
+		Expect(report.Err("check failed")).To(Succeed())
+	})
+})
@@ -0,0 +1,135 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	chunkIDKey = "chunk_id"
+	chunkKey   = "chunk"
+)
+
+// splitEntry renders entry with render and, if the result exceeds maxSize bytes, splits it into chunks which do not.
+// The longest value of the entry, e.g. a stack trace, is split across the chunks: the message of printf style entries,
+// the longest field allowed by allowed of structured entries. All chunks carry the same chunk_id field and a chunk
+// field numbering them, e.g. "2/3". If the entry cannot be split small enough, it is returned in one piece.
+func splitEntry(entry Entry, maxSize int, allowed map[string]bool, render func(Entry) string) []string {
+	line := render(entry)
+	if maxSize <= 0 || len(line) <= maxSize {
+		return []string{line}
+	}
+
+	index, value := longestValue(entry, allowed)
+	id := nextChunkID()
+	// Estimate the number of chunks from the size of the line without the value and generous chunk fields, and
+	// increase it until all chunks fit, since quoting may make values longer.
+	overhead := len(line) - len(value) + len(chunkIDKey) + len(chunkKey) + len(id) + 32
+	if maxSize <= overhead {
+		return []string{line}
+	}
+	n := (len(value) + maxSize - overhead - 1) / (maxSize - overhead)
+	for ; n > 1 && n <= utf8.RuneCountInString(value); n += n/10 + 1 {
+		lines, ok := renderChunks(entry, index, splitString(value, n), id, maxSize, render)
+		if ok {
+			return lines
+		}
+	}
+	return []string{line}
+}
+
+// renderChunks renders the chunks of entry, with the value at index, -1 for the message, replaced by the pieces. It
+// returns false if a chunk exceeds maxSize.
+func renderChunks(entry Entry, index int, pieces []string, id string, maxSize int,
+	render func(Entry) string) ([]string, bool) {
+	lines := make([]string, 0, len(pieces))
+	for i, piece := range pieces {
+		chunk := entry.withFields(chunkIDKey, id, chunkKey, fmt.Sprintf("%d/%d", i+1, len(pieces)))
+		if index < 0 {
+			chunk.Message = piece
+		} else {
+			chunk.Fields[index] = piece
+		}
+
+		line := render(chunk)
+		if len(line) > maxSize {
+			return nil, false
+		}
+		lines = append(lines, line)
+	}
+	return lines, true
+}
+
+// longestValue returns the index of the longest allowed field value of a structured entry, or -1 and the message of a
+// printf style entry.
+func longestValue(entry Entry, allowed map[string]bool) (int, string) {
+	if !entry.structured {
+		return -1, entry.Message
+	}
+
+	index, value := -1, ""
+	for i := 0; i < len(entry.Fields)-1; i += 2 {
+		if allowed != nil && !allowed[argToString(entry.Fields[i])] {
+			continue
+		}
+		if v := argToString(entry.Fields[i+1]); index < 0 || len(v) > len(value) {
+			index, value = i+1, v
+		}
+	}
+	if index < 0 {
+		return -1, entry.Message
+	}
+	return index, value
+}
+
+// splitString splits s into n pieces of about the same size without splitting runes.
+func splitString(s string, n int) []string {
+	pieces := make([]string, 0, n)
+	for i := n; i > 0; i-- {
+		size := (len(s) + i - 1) / i
+		for size < len(s) && !utf8.RuneStart(s[size]) {
+			size++
+		}
+		pieces = append(pieces, s[:size])
+		s = s[size:]
+	}
+	return pieces
+}
+
+// newChunkID returns a random identifier for the chunks of an entry.
+func newChunkID() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
+
+// SetMaxEntrySize limits the size in bytes of the log lines written to stderr, the log file, the custom outputs and
+// syslog. Longer entries, notably Panic stack traces, are split into chunks rather than being truncated or dropped by a
+// collector: the longest value of the entry is spread across several lines which share a chunk_id field and are
+// numbered by a chunk field, e.g. chunk="2/3". A size <= 0, the default, does not limit the size. Independently of this
+// setting, messages sent to syslog via UDP or the local socket are split to fit into a datagram.
+func SetMaxEntrySize(size int) {
+	mu.Lock()
+	defer unlockAndPublish()
+	maxEntrySize = size
+}
@@ -0,0 +1,103 @@
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Splitting large entries", func() {
+	var out bytes.Buffer
+
+	BeforeEach(func() {
+		initLogger()
+		out = bytes.Buffer{}
+		SetOutput(&out)
+		SetLogStderr(false)
+		SetMaxEntrySize(200)
+	})
+
+	lines := func() []string {
+		return strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	}
+
+	It("does not split entries which fit", func() {
+		InfoStructured(infoMsg, "a", "b")
+		Expect(lines()).To(HaveLen(1))
+		Expect(out.String()).NotTo(ContainSubstring(chunkIDKey))
+	})
+
+	It("splits the longest field of structured entries into numbered chunks", func() {
+		value := strings.Repeat("0123456789", 50)
+		InfoStructured(infoMsg, "pod", "pod-a", "stack", value)
+
+		chunks := lines()
+		Expect(len(chunks)).To(BeNumerically(">", 2))
+		re := regexp.MustCompile(`pod="pod-a" stack="([0-9]+)" chunk_id="([0-9a-f]+)" chunk="(\d+)/(\d+)"$`)
+		var joined, id string
+		for i, line := range chunks {
+			Expect(len(line)).To(BeNumerically("<=", 200))
+			m := re.FindStringSubmatch(line)
+			Expect(m).NotTo(BeNil(), line)
+			if i == 0 {
+				id = m[2]
+			}
+			Expect(m[2]).To(Equal(id))
+			Expect(m[3]).To(Equal(fmt.Sprint(i + 1)))
+			Expect(m[4]).To(Equal(fmt.Sprint(len(chunks))))
+			joined += m[1]
+		}
+		Expect(joined).To(Equal(value))
+	})
+
+	It("splits the message of printf style entries", func() {
+		value := strings.Repeat("é", 300)
+		Infof("%s", value)
+
+		var joined string
+		for _, line := range lines() {
+			Expect(len(line)).To(BeNumerically("<=", 200))
+			m := regexp.MustCompile(`\[info\] (\S+) chunk_id="[0-9a-f]+" chunk="\d+/\d+"$`).FindStringSubmatch(line)
+			Expect(m).NotTo(BeNil(), line)
+			joined += m[1]
+		}
+		Expect(joined).To(Equal(value))
+	})
+
+	It("keeps chunks valid for other formatters", func() {
+		SetFileFormatter(JSONFormatter{})
+		InfoStructured(infoMsg, "stack", strings.Repeat("x", 500))
+		for _, line := range lines() {
+			Expect(line).To(MatchRegexp(`^\{.*"chunk_id":"[0-9a-f]+","chunk":"\d+/\d+"\}$`))
+		}
+	})
+
+	It("does not split entries if the size is not limited", func() {
+		SetMaxEntrySize(0)
+		InfoStructured(infoMsg, "stack", strings.Repeat("x", 500))
+		Expect(lines()).To(HaveLen(1))
+	})
+
+	It("splits syslog messages to fit into a UDP datagram", func() {
+		SetMaxEntrySize(0)
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		defer conn.Close()
+		Expect(SetSyslog("udp", conn.LocalAddr().String(), "cni-test")).To(Succeed())
+		defer DisableSyslog()
+
+		InfoStructured(infoMsg, "stack", strings.Repeat("x", 3*syslogMaxUDPSize))
+		buf := make([]byte, 65536)
+		for i := 1; i <= 4; i++ {
+			n, _, err := conn.ReadFrom(buf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(n).To(BeNumerically("<=", syslogMaxUDPSize))
+			Expect(string(buf[:n])).To(HaveSuffix(fmt.Sprintf(`chunk="%d/4"`, i)))
+		}
+	})
+})
@@ -0,0 +1,182 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+
+	logging "github.com/k8snetworkplumbingwg/cni-log"
+)
+
+// schema describes the log events of a package.
+type schema struct {
+	// Package is the name of the package of the generated file.
+	Package string `yaml:"package"`
+	// Imports are additional imports required by the field types.
+	Imports []string `yaml:"imports"`
+	Events  []event  `yaml:"events"`
+}
+
+// event describes a log event, for which a function Log<Name> is generated.
+type event struct {
+	Name    string  `yaml:"name"`
+	Level   string  `yaml:"level"`
+	Message string  `yaml:"message"`
+	Fields  []field `yaml:"fields"`
+}
+
+// field describes a field of a log event. It becomes a parameter of the generated function.
+type field struct {
+	// Name is the name of the parameter.
+	Name string `yaml:"name"`
+	// Key is the key of the field in the log message, defaults to Name.
+	Key string `yaml:"key"`
+	// Type is the Go type of the parameter, defaults to string.
+	Type string `yaml:"type"`
+}
+
+// structuredFuncs maps logging levels to the structured logging function of the level.
+var structuredFuncs = map[logging.Level]string{
+	logging.FatalLevel:   "FatalStructured",
+	logging.PanicLevel:   "PanicStructured",
+	logging.ErrorLevel:   "ErrorStructured",
+	logging.WarningLevel: "WarningStructured",
+	logging.InfoLevel:    "InfoStructured",
+	logging.DebugLevel:   "DebugStructured",
+	logging.TraceLevel:   "TraceStructured",
+}
+
+// parseSchema parses and validates a YAML or JSON schema and fills in the defaults.
+func parseSchema(data []byte) (*schema, error) {
+	s := &schema{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(s); err != nil {
+		return nil, err
+	}
+
+	if !token.IsIdentifier(s.Package) {
+		return nil, fmt.Errorf("invalid package name %q", s.Package)
+	}
+
+	names := map[string]bool{}
+	for i := range s.Events {
+		e := &s.Events[i]
+		if !token.IsIdentifier(e.Name) {
+			return nil, fmt.Errorf("invalid event name %q", e.Name)
+		}
+		if names[e.Name] {
+			return nil, fmt.Errorf("duplicate event %q", e.Name)
+		}
+		names[e.Name] = true
+
+		if e.Level == "" {
+			e.Level = logging.InfoLevel.String()
+		}
+		if _, ok := structuredFuncs[logging.StringToLevel(e.Level)]; !ok {
+			return nil, fmt.Errorf("event %q: invalid level %q", e.Name, e.Level)
+		}
+		if e.Message == "" {
+			return nil, fmt.Errorf("event %q: message is required", e.Name)
+		}
+
+		params := map[string]bool{}
+		for j := range e.Fields {
+			f := &e.Fields[j]
+			if !token.IsIdentifier(f.Name) {
+				return nil, fmt.Errorf("event %q: invalid field name %q", e.Name, f.Name)
+			}
+			if params[f.Name] {
+				return nil, fmt.Errorf("event %q: duplicate field %q", e.Name, f.Name)
+			}
+			params[f.Name] = true
+			if f.Key == "" {
+				f.Key = f.Name
+			}
+			if f.Type == "" {
+				f.Type = "string"
+			}
+		}
+	}
+	return s, nil
+}
+
+var fileTemplate = template.Must(template.New("file").Funcs(template.FuncMap{
+	"func":   func(level string) string { return structuredFuncs[logging.StringToLevel(level)] },
+	"isErr":  func(level string) bool { return logging.StringToLevel(level) == logging.ErrorLevel },
+	"params": params,
+	"args":   args,
+}).Parse(`// Code generated by cni-log-gen from {{.Source}}. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{- range .Imports}}
+	{{printf "%q" .}}
+{{- end}}
+
+	logging "github.com/k8snetworkplumbingwg/cni-log"
+)
+{{range .Events}}
+// Log{{.Name}} logs the {{printf "%q" .Message}} event at {{.Level}} level.
+{{- if isErr .Level}} It returns the log message as an error.{{end}}
+func Log{{.Name}}({{params .Fields}}) {{if isErr .Level}}error {{end}}{
+	{{if isErr .Level}}return {{end}}logging.{{func .Level}}({{printf "%q" .Message}}{{args .Fields}})
+}
+{{end}}`))
+
+// params returns the parameter list of the function of an event.
+func params(fields []field) string {
+	list := make([]string, 0, len(fields))
+	for _, f := range fields {
+		list = append(list, f.Name+" "+f.Type)
+	}
+	return strings.Join(list, ", ")
+}
+
+// args returns the key/value arguments of the structured logging call of an event.
+func args(fields []field) string {
+	var b strings.Builder
+	for _, f := range fields {
+		fmt.Fprintf(&b, ", %q, %s", f.Key, f.Name)
+	}
+	return b.String()
+}
+
+// generate generates the formatted Go source of the logging functions of s. source is mentioned in the header.
+func generate(s *schema, source string) ([]byte, error) {
+	var b bytes.Buffer
+	err := fileTemplate.Execute(&b, struct {
+		*schema
+		Source string
+	}{s, filepath.Base(source)})
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated code is invalid: %v", err)
+	}
+	return code, nil
+}
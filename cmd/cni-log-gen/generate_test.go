@@ -0,0 +1,81 @@
+package main
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestGenerate(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "cni-log-gen Suite")
+}
+
+var _ = Describe("cni-log-gen", func() {
+	const yamlSchema = `
+package: mylog
+imports:
+  - net
+events:
+  - name: IPAllocated
+    message: IP address allocated
+    fields:
+      - name: pod
+      - name: ip
+        type: net.IP
+  - name: AllocationFailed
+    level: error
+    message: IP address allocation failed
+    fields:
+      - name: pod
+      - name: err
+        key: error
+        type: error
+`
+
+	It("generates typed logging functions from a YAML schema", func() {
+		s, err := parseSchema([]byte(yamlSchema))
+		Expect(err).NotTo(HaveOccurred())
+		code, err := generate(s, "/tmp/events.yaml")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(string(code)).To(HavePrefix("// Code generated by cni-log-gen from events.yaml. DO NOT EDIT.\n\npackage mylog\n"))
+		Expect(string(code)).To(ContainSubstring(`	"net"`))
+		Expect(string(code)).To(ContainSubstring(`
+// LogIPAllocated logs the "IP address allocated" event at info level.
+func LogIPAllocated(pod string, ip net.IP) {
+	logging.InfoStructured("IP address allocated", "pod", pod, "ip", ip)
+}
+`))
+		Expect(string(code)).To(ContainSubstring(`
+// LogAllocationFailed logs the "IP address allocation failed" event at error level. It returns the log message as an error.
+func LogAllocationFailed(pod string, err error) error {
+	return logging.ErrorStructured("IP address allocation failed", "pod", pod, "error", err)
+}
+`))
+	})
+
+	It("accepts JSON schemas", func() {
+		s, err := parseSchema([]byte(`{"package": "mylog", "events": [{"name": "Started", "level": "debug", "message": "started"}]}`))
+		Expect(err).NotTo(HaveOccurred())
+		code, err := generate(s, "events.json")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(code)).To(ContainSubstring("func LogStarted() {\n\tlogging.DebugStructured(\"started\")\n}"))
+	})
+
+	DescribeTable("rejects invalid schemas",
+		func(schema, expected string) {
+			_, err := parseSchema([]byte(schema))
+			Expect(err).To(MatchError(ContainSubstring(expected)))
+		},
+		Entry("invalid package", `package: my-log`, `invalid package name "my-log"`),
+		Entry("invalid event name", "package: l\nevents: [{name: ip-allocated, message: m}]", `invalid event name`),
+		Entry("duplicate event", "package: l\nevents: [{name: A, message: m}, {name: A, message: m}]", `duplicate event "A"`),
+		Entry("invalid level", "package: l\nevents: [{name: A, level: verbose, message: m}]", `invalid level "verbose"`),
+		Entry("missing message", "package: l\nevents: [{name: A}]", `message is required`),
+		Entry("duplicate field", "package: l\nevents: [{name: A, message: m, fields: [{name: a}, {name: a}]}]",
+			`duplicate field "a"`),
+		Entry("unknown key", "package: l\nevents: [{name: A, msg: m}]", `field msg not found`),
+	)
+})
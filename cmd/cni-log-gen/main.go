@@ -0,0 +1,83 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command cni-log-gen generates typed logging functions from a YAML or JSON schema of log events, so that every event
+// of a plugin is logged with the same message and fields and missing or misspelled fields are compile errors:
+//
+//	package: mylog
+//	events:
+//	  - name: IPAllocated
+//	    level: info
+//	    message: IP address allocated
+//	    fields:
+//	      - name: pod
+//	      - name: ip
+//	        type: net.IP
+//	imports:
+//	  - net
+//
+// generates
+//
+//	// LogIPAllocated logs the "IP address allocated" event at info level.
+//	func LogIPAllocated(pod string, ip net.IP) {
+//		logging.InfoStructured("IP address allocated", "pod", pod, "ip", ip)
+//	}
+//
+// Usage:
+//
+//	cni-log-gen -schema events.yaml -o events_gen.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	schemaPath := flag.String("schema", "", "path of the YAML or JSON event schema")
+	output := flag.String("o", "", "path of the generated Go file, stdout if empty")
+	flag.Parse()
+
+	if err := run(*schemaPath, *output); err != nil {
+		fmt.Fprintf(os.Stderr, "cni-log-gen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run generates the logging functions of the schema at schemaPath and writes them to output.
+func run(schemaPath, output string) error {
+	if schemaPath == "" {
+		return fmt.Errorf("-schema is required")
+	}
+
+	data, err := os.ReadFile(schemaPath)
+	if err != nil {
+		return err
+	}
+	s, err := parseSchema(data)
+	if err != nil {
+		return fmt.Errorf("%s: %v", schemaPath, err)
+	}
+	code, err := generate(s, schemaPath)
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		_, err = os.Stdout.Write(code)
+		return err
+	}
+	return os.WriteFile(output, code, 0644)
+}
@@ -0,0 +1,236 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	lumberjack "gopkg.in/natefinch/lumberjack.v2"
+
+	logging "github.com/k8snetworkplumbingwg/cni-log"
+)
+
+// status is the outcome of a check.
+type status string
+
+const (
+	statusOK   status = "OK"
+	statusWarn status = "WARN"
+	statusFail status = "FAIL"
+)
+
+// result is the outcome of a check with an explanation.
+type result struct {
+	status  status
+	message string
+}
+
+// ok returns a result of a passed check.
+func ok(format string, a ...interface{}) result {
+	return result{statusOK, fmt.Sprintf(format, a...)}
+}
+
+// warn returns a result of a check which found a potential problem.
+func warn(format string, a ...interface{}) result {
+	return result{statusWarn, fmt.Sprintf(format, a...)}
+}
+
+// fail returns a result of a failed check.
+func fail(format string, a ...interface{}) result {
+	return result{statusFail, fmt.Sprintf(format, a...)}
+}
+
+// runChecks checks the logging configuration of the CNI network configuration netconf. If writeEntry is set, a test
+// entry is written to the log file through cni-log.
+func runChecks(netconf []byte, writeEntry bool) []result {
+	config, err := logging.ParseConfigStrict(netconf)
+	if err != nil {
+		return []result{fail("configuration is invalid: %v", err)}
+	}
+
+	level := config.LogLevel
+	if level == "" {
+		level = "info (default)"
+	}
+	toStderr := config.LogToStderr == nil || *config.LogToStderr
+	results := []result{ok("configuration parsed: logFile=%q logLevel=%s logToStderr=%t", config.LogFile, level, toStderr)}
+
+	if config.LogFile == "" {
+		if !toStderr {
+			return append(results, fail("logFile is empty and logToStderr is false: nothing will be logged"))
+		}
+		return append(results, warn("logFile is empty: messages are only logged to stderr, which the runtime may discard"))
+	}
+
+	fileResults := checkLogFile(config.LogFile)
+	results = append(results, fileResults...)
+	if failed(fileResults) {
+		return results
+	}
+
+	results = append(results, checkRotation(filepath.Dir(config.LogFile)))
+	if config.LogOptions != nil && config.LogOptions.RotationLock != nil && *config.LogOptions.RotationLock {
+		results = append(results, checkWritable(config.LogFile+".lock", "rotation lock file"))
+	}
+	if writeEntry {
+		results = append(results, checkEntry(config))
+	}
+	return results
+}
+
+// checkLogFile checks that the log file can be used: it is not a symbolic link, its directory exists or can be
+// created, it can be written to and its permissions do not allow everybody to write to it.
+func checkLogFile(path string) []result {
+	var results []result
+	if !filepath.IsAbs(path) {
+		results = append(results, warn("log file %q is relative to the working directory of the plugin", path))
+	}
+
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		return append(results, fail("log file %q is a symbolic link, which cni-log refuses to log to", path))
+	}
+
+	dir := filepath.Dir(path)
+	dirInfo, err := os.Stat(dir)
+	switch {
+	case os.IsNotExist(err):
+		results = append(results, warn("log directory %q does not exist, cni-log creates it on startup", dir))
+	case err != nil:
+		return append(results, fail("log directory %q cannot be accessed: %v", dir, err))
+	case !dirInfo.IsDir():
+		return append(results, fail("log directory %q is not a directory", dir))
+	default:
+		results = append(results, ok("log directory %q exists, mode %v", dir, dirInfo.Mode().Perm()))
+	}
+
+	results = append(results, checkWritable(path, "log file"))
+	if info, err := os.Stat(path); err == nil {
+		if info.Mode().Perm()&0002 != 0 {
+			results = append(results, warn("log file %q is writable by everybody, mode %v", path, info.Mode().Perm()))
+		} else {
+			results = append(results, ok("log file %q has mode %v, size %d bytes", path, info.Mode().Perm(), info.Size()))
+		}
+	}
+	return results
+}
+
+// checkWritable checks that path can be opened for appending, creating it and its directory if necessary. Files which
+// did not exist before are removed again.
+func checkWritable(path, name string) result {
+	_, statErr := os.Stat(path)
+	existed := statErr == nil
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fail("%s directory %q cannot be created: %v", name, filepath.Dir(path), err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fail("%s %q is not writable: %v", name, path, err)
+	}
+	f.Close()
+	if !existed {
+		_ = os.Remove(path)
+	}
+	return ok("%s %q is writable", name, path)
+}
+
+// checkRotation rotates a temporary log file in dir, the way the log file is rotated, and checks that the rotated
+// file is where it is expected.
+func checkRotation(dir string) result {
+	path := filepath.Join(dir, fmt.Sprintf(".cni-log-selftest-%d.log", os.Getpid()))
+	l := &lumberjack.Logger{Filename: path, MaxBackups: 1}
+	defer func() {
+		l.Close()
+		backups, _ := filepath.Glob(strings.TrimSuffix(path, ".log") + "-*.log")
+		for _, f := range append(backups, path) {
+			_ = os.Remove(f)
+		}
+	}()
+
+	if _, err := l.Write([]byte("rotation test\n")); err != nil {
+		return fail("a log file cannot be created in %q: %v", dir, err)
+	}
+	if err := l.Rotate(); err != nil {
+		return fail("log files in %q cannot be rotated: %v", dir, err)
+	}
+
+	// Lumberjack removes old backups asynchronously, give it a moment before looking at the directory.
+	time.Sleep(10 * time.Millisecond)
+	backups, err := filepath.Glob(strings.TrimSuffix(path, ".log") + "-*.log")
+	if err != nil || len(backups) == 0 {
+		return fail("rotated log file not found in %q", dir)
+	}
+	return ok("log files in %q can be rotated", dir)
+}
+
+// checkEntry writes a test entry through cni-log with the configuration applied and checks that it arrives in the log
+// file.
+func checkEntry(config *logging.Config) result {
+	logToStderr := false
+	entryConfig := *config
+	entryConfig.LogToStderr = &logToStderr
+	entryConfig.LogLevel = "info"
+	if err := logging.ApplyConfig(&entryConfig); err != nil {
+		return fail("cni-log rejects the configuration: %v", err)
+	}
+	defer logging.Close()
+
+	marker := fmt.Sprintf("cni-log self-test %d", time.Now().UnixNano())
+	logging.InfoStructured("cni-log self-test entry", "marker", marker)
+	logging.Flush()
+
+	f, err := os.Open(config.LogFile)
+	if err != nil {
+		return fail("log file %q cannot be read back: %v", config.LogFile, err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return fail("log file %q cannot be read back: %v", config.LogFile, err)
+	}
+	if !bytes.Contains(data, []byte(marker)) {
+		return fail("test entry was not written to %q", config.LogFile)
+	}
+	return ok("test entry written to %q", config.LogFile)
+}
+
+// failed returns true if one of the checks failed.
+func failed(results []result) bool {
+	for _, r := range results {
+		if r.status == statusFail {
+			return true
+		}
+	}
+	return false
+}
+
+// printReport prints the results of the checks.
+func printReport(w io.Writer, results []result) {
+	fmt.Fprintln(w, "cni-log self-test")
+	for _, r := range results {
+		fmt.Fprintf(w, "  [%-4s] %s\n", r.status, r.message)
+	}
+	if failed(results) {
+		fmt.Fprintln(w, "result: FAILED")
+		return
+	}
+	fmt.Fprintln(w, "result: passed")
+}
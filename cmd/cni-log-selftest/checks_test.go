@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestSelftest(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "cni-log-selftest Suite")
+}
+
+var _ = Describe("cni-log-selftest", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "cni-log-selftest")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	statuses := func(results []result) []status {
+		var s []status
+		for _, r := range results {
+			s = append(s, r.status)
+		}
+		return s
+	}
+
+	It("passes for a usable log file and leaves only the test entry behind", func() {
+		logFile := filepath.Join(dir, "plugin.log")
+		results := runChecks([]byte(fmt.Sprintf(`{"logging": {"logFile": %q}}`, logFile)), true)
+		Expect(failed(results)).To(BeFalse(), fmt.Sprint(results))
+		Expect(statuses(results)).NotTo(ContainElement(statusWarn))
+
+		data, err := os.ReadFile(logFile)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring("cni-log self-test entry"))
+		files, err := filepath.Glob(filepath.Join(dir, "*"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files).To(ConsistOf(logFile))
+	})
+
+	It("fails if nothing would be logged", func() {
+		results := runChecks([]byte(`{"logToStderr": false}`), true)
+		Expect(failed(results)).To(BeTrue())
+		Expect(results[len(results)-1].message).To(ContainSubstring("nothing will be logged"))
+	})
+
+	It("fails for invalid configurations", func() {
+		results := runChecks([]byte(`{"logging": {"logfile": "/tmp/x.log"}}`), true)
+		Expect(failed(results)).To(BeTrue())
+		Expect(results[0].message).To(ContainSubstring(`unknown field "logfile"`))
+	})
+
+	It("fails for symbolic links", func() {
+		link := filepath.Join(dir, "link.log")
+		Expect(os.Symlink(filepath.Join(dir, "target.log"), link)).To(Succeed())
+		results := runChecks([]byte(fmt.Sprintf(`{"logFile": %q}`, link)), true)
+		Expect(failed(results)).To(BeTrue())
+		Expect(results[len(results)-1].message).To(ContainSubstring("symbolic link"))
+	})
+
+	It("warns about log files writable by everybody", func() {
+		logFile := filepath.Join(dir, "plugin.log")
+		Expect(os.WriteFile(logFile, nil, 0644)).To(Succeed())
+		Expect(os.Chmod(logFile, 0666)).To(Succeed())
+		results := runChecks([]byte(fmt.Sprintf(`{"logFile": %q}`, logFile)), false)
+		Expect(failed(results)).To(BeFalse())
+		Expect(statuses(results)).To(ContainElement(statusWarn))
+	})
+
+	It("prints a report", func() {
+		var out bytes.Buffer
+		printReport(&out, []result{ok("fine"), fail("broken")})
+		Expect(out.String()).To(Equal("cni-log self-test\n  [OK  ] fine\n  [FAIL] broken\nresult: FAILED\n"))
+	})
+})
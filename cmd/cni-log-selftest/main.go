@@ -0,0 +1,53 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command cni-log-selftest diagnoses the logging configuration of a CNI plugin on a node. It reads a CNI network
+// configuration, or just its logging stanza, checks that the configuration is valid, that the log file can be written
+// and rotated and that its permissions are sane, and prints a report:
+//
+//	cni-log-selftest -config /etc/cni/net.d/10-mynet.conf
+//
+// The exit status is 1 if a check failed.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+func main() {
+	configPath := flag.String("config", "-", "path of the CNI network configuration, - for stdin")
+	writeEntry := flag.Bool("write-entry", true, "write a test entry to the log file")
+	flag.Parse()
+
+	var data []byte
+	var err error
+	if *configPath == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(*configPath)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cni-log-selftest: %v\n", err)
+		os.Exit(1)
+	}
+
+	results := runChecks(data, *writeEntry)
+	printReport(os.Stdout, results)
+	if failed(results) {
+		os.Exit(1)
+	}
+}
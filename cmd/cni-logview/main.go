@@ -0,0 +1,91 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command cni-logview prints the entries of cni-log log files and ring files, optionally filtered by level. Ring files
+// hold the last entries of a process even if it was killed, see logging.SetRingFile:
+//
+//	cni-logview -ring /run/mynet/log.ring
+//	cni-logview -level warning /var/log/mynet.log
+//
+// Without files, it reads a log file from stdin.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	logging "github.com/k8snetworkplumbingwg/cni-log"
+)
+
+func main() {
+	ring := flag.Bool("ring", false, "the files are ring files")
+	levelNames := make([]string, 0, len(logging.Levels()))
+	for _, level := range logging.Levels() {
+		levelNames = append(levelNames, level.String())
+	}
+	levelName := flag.String("level", logging.MaxLevel().String(),
+		"print the entries of this level and more severe ones: "+strings.Join(levelNames, ", "))
+	flag.Parse()
+
+	level := logging.StringToLevel(*levelName)
+	if level == logging.InvalidLevel {
+		fail(fmt.Errorf("invalid level %q", *levelName))
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	if flag.NArg() == 0 {
+		if *ring {
+			fail(fmt.Errorf("ring files have to be passed as arguments"))
+		}
+		if err := viewReader(out, os.Stdin, level); err != nil {
+			fail(err)
+		}
+		return
+	}
+	for _, path := range flag.Args() {
+		if err := viewFile(out, path, *ring, level); err != nil {
+			out.Flush()
+			fail(err)
+		}
+	}
+}
+
+// viewFile prints the entries of the log file or ring file at path.
+func viewFile(w io.Writer, path string, ring bool, level logging.Level) error {
+	if ring {
+		lines, err := logging.ReadRingFile(path)
+		if err != nil {
+			return err
+		}
+		return view(w, lines, level)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return viewReader(w, f, level)
+}
+
+// fail prints err and exits.
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "cni-logview: %v\n", err)
+	os.Exit(1)
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	logging "github.com/k8snetworkplumbingwg/cni-log"
+	"github.com/k8snetworkplumbingwg/cni-log/parse"
+)
+
+// maxLineSize is the size of the longest line viewReader reads.
+const maxLineSize = 16 << 20
+
+// levelFilter decides which lines are printed. Lines which cannot be parsed, e.g. the lines of a stack trace, follow
+// the decision for the last line which could.
+type levelFilter struct {
+	level logging.Level
+	hide  bool
+}
+
+// keep returns true if line is printed.
+func (f *levelFilter) keep(line string) bool {
+	if entry, err := parse.Line(line); err == nil && entry.Level != logging.InvalidLevel {
+		f.hide = entry.Level > f.level
+	}
+	return !f.hide
+}
+
+// view writes the lines whose entries are at least as severe as level to w.
+func view(w io.Writer, lines []string, level logging.Level) error {
+	filter := levelFilter{level: level}
+	for _, line := range lines {
+		if !filter.keep(line) {
+			continue
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// viewReader writes the lines of a log file read from r like view.
+func viewReader(w io.Writer, r io.Reader, level logging.Level) error {
+	filter := levelFilter{level: level}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxLineSize)
+	for scanner.Scan() {
+		if !filter.keep(scanner.Text()) {
+			continue
+		}
+		if _, err := fmt.Fprintln(w, scanner.Text()); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
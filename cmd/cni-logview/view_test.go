@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	logging "github.com/k8snetworkplumbingwg/cni-log"
+)
+
+func TestLogview(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "cni-logview Suite")
+}
+
+var _ = Describe("cni-logview", func() {
+	lines := []string{
+		`time="2024-01-02T03:04:05Z" level="info" msg="adding interface"`,
+		`2024-01-02T03:04:06Z [error] adding route failed`,
+		`goroutine 1 [running]:`,
+		`{"time":"2024-01-02T03:04:07Z","level":"debug","msg":"route"}`,
+		`  main.go:12`,
+	}
+
+	It("prints the lines of the entries up to a level", func() {
+		var out bytes.Buffer
+		Expect(view(&out, lines, logging.TraceLevel)).To(Succeed())
+		Expect(out.String()).To(Equal(strings.Join(lines, "\n") + "\n"))
+
+		out.Reset()
+		Expect(viewReader(&out, strings.NewReader(strings.Join(lines, "\n")), logging.WarningLevel)).To(Succeed())
+		Expect(out.String()).To(Equal(lines[1] + "\n" + lines[2] + "\n"))
+	})
+
+	It("prints the entries of ring files", func() {
+		dir, err := os.MkdirTemp("", "cni-logview")
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(os.RemoveAll, dir)
+		ring := filepath.Join(dir, "log.ring")
+
+		logging.SetLogStderr(false)
+		Expect(logging.SetRingFile(ring, &logging.RingOptions{Entries: 2})).To(Succeed())
+		DeferCleanup(logging.SetRingFile, "", (*logging.RingOptions)(nil))
+		for _, msg := range []string{"first", "second", "third"} {
+			logging.InfoStructured(msg)
+		}
+
+		var out bytes.Buffer
+		Expect(viewFile(&out, ring, true, logging.InfoLevel)).To(Succeed())
+		Expect(out.String()).To(MatchRegexp(`^time=".*" level="info" msg="second"\ntime=".*" level="info" msg="third"\n$`))
+	})
+})
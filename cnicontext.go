@@ -0,0 +1,53 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import "os"
+
+// cniEnvFields maps the environment variables of the CNI specification to the keys of the fields they are logged as.
+var cniEnvFields = []struct {
+	env string
+	key string
+}{
+	{"CNI_COMMAND", "cniCommand"},
+	{"CNI_CONTAINERID", "containerID"},
+	{"CNI_IFNAME", "ifname"},
+	{"CNI_NETNS", "netns"},
+}
+
+// EnableCNIContext reads the CNI_COMMAND, CNI_CONTAINERID, CNI_IFNAME and CNI_NETNS environment variables the runtime
+// passes to a plugin and adds them to every message as cniCommand, containerID, ifname and netns fields, which makes
+// correlating the messages of a container across plugins trivial. The fields follow the structured prefix of
+// structured messages and the prefix of printf style messages. Variables which are not set are skipped. Call it at the
+// start of the plugin.
+func EnableCNIContext() {
+	var fields []interface{}
+	for _, f := range cniEnvFields {
+		if value, ok := os.LookupEnv(f.env); ok {
+			fields = append(fields, f.key, value)
+		}
+	}
+
+	mu.Lock()
+	defer unlockAndPublish()
+	cniContext = fields
+}
+
+// DisableCNIContext stops adding the CNI environment to messages.
+func DisableCNIContext() {
+	mu.Lock()
+	defer unlockAndPublish()
+	cniContext = nil
+}
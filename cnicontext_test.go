@@ -0,0 +1,62 @@
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CNI context", func() {
+	var out bytes.Buffer
+
+	BeforeEach(func() {
+		initLogger()
+		out = bytes.Buffer{}
+		SetOutput(&out)
+		SetLogStderr(false)
+
+		for env, value := range map[string]string{"CNI_COMMAND": "ADD", "CNI_CONTAINERID": "abc", "CNI_IFNAME": "net1"} {
+			previous, ok := os.LookupEnv(env)
+			Expect(os.Setenv(env, value)).To(Succeed())
+			DeferCleanup(func(env string) {
+				if ok {
+					os.Setenv(env, previous)
+				} else {
+					os.Unsetenv(env)
+				}
+			}, env)
+		}
+		previous, ok := os.LookupEnv("CNI_NETNS")
+		Expect(os.Unsetenv("CNI_NETNS")).To(Succeed())
+		DeferCleanup(func() {
+			if ok {
+				os.Setenv("CNI_NETNS", previous)
+			}
+		})
+	})
+
+	It("adds the CNI environment to structured messages", func() {
+		EnableCNIContext()
+		InfoStructured(infoMsg, "a", "b")
+		Expect(out.String()).To(MatchRegexp(fmt.Sprintf(
+			`^time=".*" level="info" msg=%q cniCommand="ADD" containerID="abc" ifname="net1" a="b"\n$`, infoMsg)))
+	})
+
+	It("adds the CNI environment to the prefix of printf style messages", func() {
+		EnableCNIContext()
+		Infof(infoMsg)
+		Expect(out.String()).To(MatchRegexp(fmt.Sprintf(
+			`^.* \[info\] cniCommand="ADD" containerID="abc" ifname="net1" %s\n$`, infoMsg)))
+	})
+
+	It("is disabled by default and can be disabled", func() {
+		InfoStructured(infoMsg)
+		EnableCNIContext()
+		DisableCNIContext()
+		Infof(infoMsg)
+		Expect(out.String()).NotTo(ContainSubstring("containerID"))
+	})
+})
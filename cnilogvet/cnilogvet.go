@@ -0,0 +1,108 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cnilogvet defines an Analyzer which checks the key/value arguments of cni-log's structured logging calls.
+//
+// Structured logging functions such as logging.InfoStructured(msg string, args ...interface{}) take alternating keys
+// and values. Passing an odd number of arguments or a key which is not a string is only detected at runtime by
+// cni-log. The analyzer reports these mistakes at build time instead.
+package cnilogvet
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+const (
+	// loggingPkgPath is the import path of cni-log.
+	loggingPkgPath = "github.com/k8snetworkplumbingwg/cni-log"
+	// kvParamName is the name of the variadic parameter which cni-log uses for key/value pairs.
+	kvParamName = "args"
+)
+
+// Analyzer reports structured logging calls with an odd number of key/value arguments or non-string keys.
+var Analyzer = &analysis.Analyzer{
+	Name:     "cnilogvet",
+	Doc:      "check key/value arguments of cni-log structured logging calls",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	inspect.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		if call.Ellipsis.IsValid() {
+			// The arguments are passed as a slice, nothing to check.
+			return
+		}
+
+		first, name, ok := kvArguments(pass, call)
+		if !ok || first > len(call.Args) {
+			return
+		}
+
+		kvs := call.Args[first:]
+		if len(kvs)%2 != 0 {
+			pass.Reportf(kvs[len(kvs)-1].Pos(), "%s called with an odd number of key/value arguments", name)
+		}
+
+		for i := 0; i < len(kvs); i += 2 {
+			if !isString(pass.TypesInfo.TypeOf(kvs[i])) {
+				pass.Reportf(kvs[i].Pos(), "%s called with a non-string key", name)
+			}
+		}
+	})
+	return nil, nil
+}
+
+// kvArguments returns the index of the first key/value argument if call calls a function or method of cni-log which
+// takes key/value pairs, together with the name of the function.
+func kvArguments(pass *analysis.Pass, call *ast.CallExpr) (int, string, bool) {
+	var ident *ast.Ident
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		ident = fun
+	case *ast.SelectorExpr:
+		ident = fun.Sel
+	default:
+		return 0, "", false
+	}
+
+	fn, ok := pass.TypesInfo.Uses[ident].(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != loggingPkgPath {
+		return 0, "", false
+	}
+
+	sig := fn.Type().(*types.Signature)
+	params := sig.Params()
+	if !sig.Variadic() || params.Len() == 0 || params.At(params.Len()-1).Name() != kvParamName {
+		return 0, "", false
+	}
+	return params.Len() - 1, fn.Name(), true
+}
+
+// isString returns true if t is a string type.
+func isString(t types.Type) bool {
+	if t == nil {
+		return false
+	}
+	basic, ok := t.Underlying().(*types.Basic)
+	return ok && basic.Info()&types.IsString != 0
+}
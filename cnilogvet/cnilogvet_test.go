@@ -0,0 +1,13 @@
+package cnilogvet_test
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"github.com/k8snetworkplumbingwg/cni-log/cnilogvet"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), cnilogvet.Analyzer, "a")
+}
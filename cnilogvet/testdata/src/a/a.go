@@ -0,0 +1,20 @@
+package a
+
+import (
+	logging "github.com/k8snetworkplumbingwg/cni-log"
+)
+
+type key string
+
+func calls(ifname string, args []interface{}) {
+	logging.InfoStructured("msg")
+	logging.InfoStructured("msg", "ifname", ifname)
+	logging.InfoStructured("msg", key("ifname"), ifname)
+	logging.InfoStructured("msg", args...)
+	logging.Infof("%s %s %s", "a", "b", "c")
+
+	logging.InfoStructured("msg", "ifname")            // want `InfoStructured called with an odd number of key/value arguments`
+	_ = logging.ErrorStructured("msg", "a", 1, "b")    // want `ErrorStructured called with an odd number of key/value arguments`
+	logging.InfoStructured("msg", 1, ifname)           // want `InfoStructured called with a non-string key`
+	logging.InfoStructured("msg", "ifname", ifname, 2) // want `odd number of key/value arguments` `non-string key`
+}
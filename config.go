@@ -0,0 +1,264 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	parseConfigFailMsg  = "cni-log: unable to parse logging configuration: %v"
+	invalidLevelFailMsg = "cni-log: invalid logging level '%s'"
+	unwritableFailMsg   = "cni-log: log file '%s' is not writable"
+	unknownFieldFailMsg = "unknown field %q"
+)
+
+// Config defines the logging configuration of a CNI plugin. It is usually part of the CNI network configuration, see
+// ParseConfig.
+type Config struct {
+	// LogFile is the path of the log file. Logging to a file is disabled if it is empty.
+	LogFile string `json:"logFile,omitempty"`
+	// LogLevel is the string representation of the logging level, e.g. "debug". Defaults to "info".
+	LogLevel string `json:"logLevel,omitempty"`
+	// LogToStderr enables logging to stderr. Defaults to true.
+	LogToStderr *bool `json:"logToStderr,omitempty"`
+	// LogOptions configures the rotation of the log file.
+	LogOptions *LogOptions `json:"logOptions,omitempty"`
+	// Format is the name of the formatter of all outputs, e.g. "json", see RegisterFormatter.
+	Format string `json:"format,omitempty"`
+	// Prefix is the name of the prefixer of the printf style functions, e.g. "klog", see RegisterPrefixer.
+	Prefix string `json:"prefix,omitempty"`
+	// SecondaryLogFile is the path of a second log file which receives the messages of the log file in the format
+	// SecondaryFormat, see SetSecondaryLogFile. It is disabled if it is empty.
+	SecondaryLogFile string `json:"secondaryLogFile,omitempty"`
+	// SecondaryFormat is the name of the formatter of the secondary log file. Defaults to "json".
+	SecondaryFormat string `json:"secondaryFormat,omitempty"`
+	// Routes send the messages with a field value to log files of their own, see SetRoutes.
+	Routes []Route `json:"routes,omitempty"`
+	// Prefixer and StructuredPrefixer replace the prefixers of the printf style and the structured functions, see
+	// SetPrefixer and SetStructuredPrefixer. They take precedence over Prefix and cannot be set in a network
+	// configuration. nil keeps the prefixer selected by Prefix or set before.
+	Prefixer           Prefixer           `json:"-"`
+	StructuredPrefixer StructuredPrefixer `json:"-"`
+}
+
+// netConf is the part of the CNI network configuration ParseConfig is interested in. The logging stanza and the log
+// options are kept raw so that they can be decoded strictly.
+type netConf struct {
+	Config
+	LogOptions json.RawMessage `json:"logOptions,omitempty"`
+	Logging    json.RawMessage `json:"logging,omitempty"`
+}
+
+// ParseConfig extracts the logging configuration from a CNI network configuration. The configuration is read from the
+// "logging" stanza:
+//
+//	{
+//	  "cniVersion": "1.0.0",
+//	  "name": "mynet",
+//	  "type": "myplugin",
+//	  "logging": {
+//	    "logFile": "/var/log/myplugin.log",
+//	    "logLevel": "debug",
+//	    "logToStderr": false,
+//	    "logOptions": {"maxSize": 10},
+//	    "format": "json"
+//	  }
+//	}
+//
+// If there is no "logging" stanza, the same keys are read from the top level of the network configuration, which is
+// where many plugins historically define them.
+func ParseConfig(netconfBytes []byte) (*Config, error) {
+	return parseConfig(netconfBytes, false)
+}
+
+// ParseConfigStrict works like ParseConfig, but returns an error if the logging stanza or the log options contain
+// unknown fields, so that typos like "maxsize" instead of "maxSize" do not silently fall back to the defaults. Unknown
+// fields elsewhere in the network configuration are ignored.
+func ParseConfigStrict(netconfBytes []byte) (*Config, error) {
+	return parseConfig(netconfBytes, true)
+}
+
+// parseConfig extracts the logging configuration from a CNI network configuration. If strict is set, unknown fields in
+// the logging stanza or the log options are an error.
+func parseConfig(netconfBytes []byte, strict bool) (*Config, error) {
+	conf := &netConf{}
+	if err := json.Unmarshal(netconfBytes, conf); err != nil {
+		return nil, fmt.Errorf(parseConfigFailMsg, err)
+	}
+
+	config := &conf.Config
+	if len(conf.Logging) > 0 {
+		config = &Config{}
+		if err := decodeJSON(conf.Logging, config, strict); err != nil {
+			return nil, fmt.Errorf(parseConfigFailMsg, err)
+		}
+	} else if len(conf.LogOptions) > 0 {
+		if err := decodeJSON(conf.LogOptions, &config.LogOptions, strict); err != nil {
+			return nil, fmt.Errorf(parseConfigFailMsg, err)
+		}
+	}
+
+	if config.LogLevel != "" && StringToLevel(config.LogLevel) == InvalidLevel {
+		return nil, configErrorf(ErrInvalidLevel, invalidLevelFailMsg, config.LogLevel)
+	}
+	if err := checkRegistered(config.Format, config.Prefix); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// decodeJSON decodes data into v. If strict is set, unknown fields are an error.
+func decodeJSON(data []byte, v interface{}, strict bool) error {
+	if strict {
+		if err := checkKnownFields(data, reflect.TypeOf(v)); err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(data, v)
+}
+
+// checkKnownFields returns an error if the JSON object data, or any object nested in it, has a key which is not the
+// exact JSON name of a field of t. encoding/json matches keys case-insensitively, so json.Decoder's
+// DisallowUnknownFields would accept "maxsize" for "maxSize".
+func checkKnownFields(data []byte, t reflect.Type) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err != nil {
+		// Not an object, json.Unmarshal reports the type mismatch.
+		return nil
+	}
+
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fieldType, ok := fields[key]
+		if !ok {
+			return fmt.Errorf(unknownFieldFailMsg, key)
+		}
+		if err := checkKnownFields(object[key], fieldType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyConfig configures the logger according to config in a single step: concurrent log calls either use the previous
+// or the new configuration, never a mix of both. Settings missing from config fall back to the environment, the
+// configuration file or the default values, see ExplainConfig; a nil config removes the settings of the previous
+// ApplyConfig call. If config is invalid, an error is returned and nothing is changed, except that the messages of the
+// log file go to the emergency log file, see SetEmergencyLogFile, if the configured log file cannot be opened.
+func ApplyConfig(config *Config) error {
+	if config == nil {
+		config = &Config{}
+	}
+	return applyConfig(SourceNetConf, config)
+}
+
+// SetConfig configures the logger according to config in a single step, like ApplyConfig, but with the precedence of
+// the setters: it replaces all settings made with SetLogLevel, SetLogFile, SetLogStderr, SetLogOptions and previous
+// SetConfig calls at once, so concurrent log calls never see a mix of the old and the new settings and no warning is
+// printed for a transient state, e.g. while switching from stderr to a log file. Settings missing from config fall
+// back to the other sources, see ExplainConfig. If config is invalid, an error is returned and nothing is changed,
+// except for the switch to the emergency log file like with ApplyConfig.
+func SetConfig(config Config) error {
+	return applyConfig(SourceAPI, &config)
+}
+
+// GetConfig returns the configuration in effect, read in a single step. The log options have their defaults filled
+// in, the formatter and the prefix are reported by name if they were configured by name, and Prefixer and
+// StructuredPrefixer are the prefixers in use. Passing the result to SetConfig restores the configuration; outputs set
+// with SetOutput are not part of it.
+func GetConfig() Config {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	merged, _ := mergeConfigLayers(&configLayers)
+	stderr := logToStderr
+	config := Config{
+		LogLevel:           strings.ToLower(logLevel.String()),
+		LogToStderr:        &stderr,
+		LogOptions:         currentLogOptions(),
+		Prefixer:           prefixer,
+		StructuredPrefixer: structuredPrefixer,
+	}
+	if merged.logFile != nil && isFileLoggingEnabled() {
+		config.LogFile = *merged.logFile
+	}
+	if merged.format != nil {
+		config.Format = *merged.format
+	}
+	if merged.prefix != nil {
+		config.Prefix = *merged.prefix
+	}
+	if merged.secondaryLogFile != nil && secondaryLogWriter != nil {
+		config.SecondaryLogFile = *merged.secondaryLogFile
+	}
+	if merged.secondaryFormat != nil {
+		config.SecondaryFormat = *merged.secondaryFormat
+	}
+	if merged.routes != nil {
+		config.Routes = *copyRoutes(*merged.routes)
+	}
+	return config
+}
+
+// applyConfig replaces the settings of source with config and sets its prefixers in a single step.
+func applyConfig(source ConfigSource, config *Config) error {
+	if config.LogLevel != "" && StringToLevel(config.LogLevel) == InvalidLevel {
+		return configErrorf(ErrInvalidLevel, invalidLevelFailMsg, config.LogLevel)
+	}
+
+	mu.Lock()
+	defer unlockAndPublish()
+
+	if err := setConfigLayer(source, newConfigLayer(config)); err != nil {
+		return err
+	}
+	if config.Prefixer != nil {
+		prefixer = config.Prefixer
+	}
+	if config.StructuredPrefixer != nil {
+		structuredPrefixer = config.StructuredPrefixer
+	}
+	return nil
+}
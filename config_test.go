@@ -0,0 +1,210 @@
+package logging
+
+import (
+	"os"
+	"path"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Configuration from the network configuration", func() {
+	var logFile string
+
+	BeforeEach(func() {
+		initLogger()
+		logFile = path.Join(os.TempDir(), "test-config.log")
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(logFile)).To(Succeed())
+	})
+
+	Context("Parsing the configuration", func() {
+		When("the network configuration has a logging stanza", func() {
+			It("parses the logging stanza", func() {
+				config, err := ParseConfig([]byte(`{
+					"cniVersion": "1.0.0",
+					"name": "mynet",
+					"logLevel": "error",
+					"logging": {
+						"logFile": "/var/log/test.log",
+						"logLevel": "debug",
+						"logToStderr": false,
+						"logOptions": {"maxSize": 10}
+					}
+				}`))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config).To(Equal(&Config{
+					LogFile:     "/var/log/test.log",
+					LogLevel:    "debug",
+					LogToStderr: getPrimitivePointer(false),
+					LogOptions:  &LogOptions{MaxSize: getPrimitivePointer(10)},
+				}))
+			})
+		})
+
+		When("the network configuration has no logging stanza", func() {
+			It("parses the top level keys", func() {
+				config, err := ParseConfig([]byte(`{"name": "mynet", "logFile": "/var/log/test.log", "logLevel": "warning"}`))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config).To(Equal(&Config{LogFile: "/var/log/test.log", LogLevel: "warning"}))
+			})
+		})
+
+		When("the log options are at the top level", func() {
+			It("parses the log options", func() {
+				config, err := ParseConfig([]byte(`{"name": "mynet", "logOptions": {"maxAge": 3, "compress": false}}`))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.LogOptions).To(Equal(&LogOptions{
+					MaxAge:   getPrimitivePointer(3),
+					Compress: getPrimitivePointer(false),
+				}))
+			})
+		})
+
+		When("the logging configuration contains unknown fields", func() {
+			netconfs := []string{
+				`{"name": "mynet", "logging": {"logfile": "/var/log/test.log"}}`,
+				`{"name": "mynet", "logging": {"logOptions": {"maxsize": 10}}}`,
+				`{"name": "mynet", "logOptions": {"maxsize": 10}}`,
+				`{"name": "mynet", "logging": {"logLevl": "debug"}}`,
+			}
+
+			It("ignores them by default", func() {
+				for _, netconf := range netconfs {
+					_, err := ParseConfig([]byte(netconf))
+					Expect(err).NotTo(HaveOccurred())
+				}
+			})
+
+			It("returns an error in strict mode", func() {
+				for _, netconf := range netconfs {
+					_, err := ParseConfigStrict([]byte(netconf))
+					Expect(err).To(MatchError(ContainSubstring("unknown field")))
+				}
+			})
+
+			It("ignores unknown fields outside of the logging configuration in strict mode", func() {
+				_, err := ParseConfigStrict([]byte(`{"name": "mynet", "ipam": {}, "logging": {"logLevel": "debug"}}`))
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		When("the logging level is invalid", func() {
+			It("returns an error", func() {
+				_, err := ParseConfig([]byte(`{"logging": {"logLevel": "verbose"}}`))
+				Expect(err).To(MatchError(ContainSubstring("verbose")))
+			})
+		})
+
+		When("the network configuration is not valid JSON", func() {
+			It("returns an error", func() {
+				_, err := ParseConfig([]byte(`{"logging": `))
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Context("Applying the configuration", func() {
+		When("the configuration is valid", func() {
+			It("configures the logger", func() {
+				Expect(ApplyConfig(&Config{
+					LogFile:     logFile,
+					LogLevel:    "debug",
+					LogToStderr: getPrimitivePointer(false),
+					LogOptions:  &LogOptions{MaxSize: getPrimitivePointer(10)},
+				})).To(Succeed())
+
+				Expect(GetLogLevel()).To(Equal(DebugLevel))
+				Expect(logToStderr).To(BeFalse())
+				Expect(currentLumberjack().Filename).To(Equal(logFile))
+				Expect(currentLumberjack().MaxSize).To(Equal(10))
+
+				errStr := captureStdErrEvent(Debugf, debugMsg)
+				Expect(errStr).To(BeEmpty())
+				Expect(logFileContains(logFile, debugMsg)).To(BeTrue())
+			})
+
+			It("does not warn about transient states", func() {
+				Expect(ApplyConfig(&Config{LogFile: logFile, LogToStderr: getPrimitivePointer(false)})).To(Succeed())
+				errStr := captureStdErr(func(c *Config) { Expect(ApplyConfig(c)).To(Succeed()) }, &Config{
+					LogToStderr: getPrimitivePointer(true),
+				})
+				Expect(errStr).To(BeEmpty())
+				Expect(isFileLoggingEnabled()).To(BeFalse())
+			})
+		})
+
+		When("the configuration is invalid", func() {
+			It("returns an error and keeps the current configuration", func() {
+				SetLogLevel(WarningLevel)
+				Expect(ApplyConfig(&Config{LogFile: logFile, LogLevel: "verbose"})).NotTo(Succeed())
+				Expect(ApplyConfig(&Config{LogFile: "/proc/foobar.log", LogLevel: "debug"})).NotTo(Succeed())
+				Expect(GetLogLevel()).To(Equal(WarningLevel))
+				Expect(isFileLoggingEnabled()).To(BeFalse())
+			})
+		})
+
+		When("the configuration is nil", func() {
+			It("restores the defaults", func() {
+				Expect(ApplyConfig(&Config{LogFile: logFile, LogLevel: "debug"})).To(Succeed())
+				Expect(ApplyConfig(nil)).To(Succeed())
+				Expect(GetLogLevel()).To(Equal(defaultLogLevel))
+				Expect(logToStderr).To(BeTrue())
+				Expect(isFileLoggingEnabled()).To(BeFalse())
+			})
+		})
+	})
+
+	Context("Setting the whole configuration", func() {
+		It("replaces the settings of the setters at once without warnings", func() {
+			SetLogLevel(DebugLevel)
+			errStr := captureStdErr(func(c Config) { Expect(SetConfig(c)).To(Succeed()) }, Config{
+				LogFile:     logFile,
+				LogToStderr: getPrimitivePointer(false),
+				LogOptions:  &LogOptions{MaxSize: getPrimitivePointer(10)},
+			})
+			Expect(errStr).To(BeEmpty())
+			Expect(GetLogLevel()).To(Equal(defaultLogLevel))
+			Expect(currentLumberjack().Filename).To(Equal(logFile))
+			Expect(currentLumberjack().MaxSize).To(Equal(10))
+			Expect(logToStderr).To(BeFalse())
+		})
+
+		It("sets the prefixers", func() {
+			p := PrefixerFunc(func(Level) string { return "custom: " })
+			sp := StructuredPrefixerFunc(func(Level, string) []interface{} { return nil })
+			Expect(SetConfig(Config{Prefixer: p, StructuredPrefixer: sp})).To(Succeed())
+			config := GetConfig()
+			Expect(config.Prefixer).NotTo(BeNil())
+			Expect(config.Prefixer.CreatePrefix(InfoLevel)).To(Equal("custom: "))
+			Expect(config.StructuredPrefixer).NotTo(BeNil())
+		})
+
+		It("returns an error and keeps the current configuration if it is invalid", func() {
+			SetLogLevel(WarningLevel)
+			Expect(SetConfig(Config{LogFile: logFile, LogLevel: "verbose"})).NotTo(Succeed())
+			Expect(SetConfig(Config{Format: "unknown", LogLevel: "debug"})).NotTo(Succeed())
+			Expect(GetLogLevel()).To(Equal(WarningLevel))
+			Expect(isFileLoggingEnabled()).To(BeFalse())
+		})
+
+		It("restores the configuration returned by GetConfig", func() {
+			Expect(SetConfig(Config{LogFile: logFile, LogLevel: "debug", Format: FormatJSON,
+				LogToStderr: getPrimitivePointer(false)})).To(Succeed())
+			saved := GetConfig()
+			Expect(saved.LogFile).To(Equal(logFile))
+			Expect(saved.LogLevel).To(Equal("debug"))
+			Expect(*saved.LogToStderr).To(BeFalse())
+			Expect(saved.Format).To(Equal(FormatJSON))
+			Expect(*saved.LogOptions.MaxSize).To(Equal(defaultMaxSize))
+
+			Expect(SetConfig(Config{LogLevel: "error"})).To(Succeed())
+			Expect(isFileLoggingEnabled()).To(BeFalse())
+			Expect(SetConfig(saved)).To(Succeed())
+			Expect(GetConfig().LogFile).To(Equal(logFile))
+			Expect(GetLogLevel()).To(Equal(DebugLevel))
+		})
+	})
+})
@@ -0,0 +1,58 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Causes of the errors returned by the functions which configure the logger, e.g. SetConfig, SetErrorLogFile or
+// SetSymlinkPolicy. The returned errors describe the failure in detail and wrap one of them, so callers can test the
+// cause with errors.Is:
+//
+//	if err := logging.SetErrorLogFile(path, nil); errors.Is(err, logging.ErrUnwritableLogFile) {
+//		// fall back to stderr
+//	}
+var (
+	// ErrInvalidLevel is the cause of errors about a logging level which is not one of Levels.
+	ErrInvalidLevel = errors.New("cni-log: invalid logging level")
+	// ErrUnwritableLogFile is the cause of errors about a log file which cannot be created or opened for writing.
+	ErrUnwritableLogFile = errors.New("cni-log: log file is not writable")
+	// ErrSymlinkRejected is the cause of errors about a path whose symbolic links violate the symlink policy, see
+	// SetSymlinkPolicy, or cannot be resolved.
+	ErrSymlinkRejected = errors.New("cni-log: symbolic link rejected")
+)
+
+// configError is an error of the configuration with a detailed message, which wraps its cause.
+type configError struct {
+	cause error
+	msg   string
+}
+
+// configErrorf returns an error formatted according to format which wraps cause.
+func configErrorf(cause error, format string, a ...interface{}) error {
+	return &configError{cause: cause, msg: fmt.Sprintf(format, a...)}
+}
+
+// Error implements the error interface.
+func (e *configError) Error() string {
+	return e.msg
+}
+
+// Unwrap returns the cause of the error.
+func (e *configError) Unwrap() error {
+	return e.cause
+}
@@ -0,0 +1,59 @@
+package logging
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Configuration errors", func() {
+	var dir string
+
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+		var err error
+		dir, err = os.MkdirTemp("", "cni-log-configerror")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("wraps ErrInvalidLevel", func() {
+		err := SetConfig(Config{LogLevel: "chatty"})
+		Expect(errors.Is(err, ErrInvalidLevel)).To(BeTrue())
+		Expect(err).To(MatchError("cni-log: invalid logging level 'chatty'"))
+
+		err = SetLevelRules([]Rule{{Message: "a", Level: getPrimitivePointer(Level(42))}})
+		Expect(errors.Is(err, ErrInvalidLevel)).To(BeTrue())
+
+		os.Setenv(EnvLogLevel, "chatty")
+		defer os.Unsetenv(EnvLogLevel)
+		Expect(errors.Is(ConfigureFromEnv(), ErrInvalidLevel)).To(BeTrue())
+	})
+
+	It("wraps ErrUnwritableLogFile", func() {
+		file := filepath.Join(dir, "file")
+		Expect(os.WriteFile(file, nil, 0644)).To(Succeed())
+		err := SetErrorLogFile(filepath.Join(file, "error.log"), nil)
+		Expect(errors.Is(err, ErrUnwritableLogFile)).To(BeTrue())
+		Expect(errors.Is(err, ErrSymlinkRejected)).To(BeFalse())
+		Expect(err.Error()).To(ContainSubstring("is not writable"))
+
+		err = SetConfig(Config{LogFile: filepath.Join(file, "plugin.log")})
+		Expect(errors.Is(err, ErrUnwritableLogFile)).To(BeTrue())
+	})
+
+	It("wraps ErrSymlinkRejected", func() {
+		link := filepath.Join(dir, "plugin.log")
+		Expect(os.Symlink(filepath.Join(dir, "target.log"), link)).To(Succeed())
+		err := SetSecondaryLogFile(link, nil)
+		Expect(errors.Is(err, ErrSymlinkRejected)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("unable to evaluate symbolic links"))
+	})
+})
@@ -0,0 +1,86 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import "context"
+
+// contextKey is the key of the Logger stored in a context.
+type contextKey struct{}
+
+// NewContext returns a copy of ctx which carries the alternating keys and values of args, in addition to the fields
+// ctx carries already. The *StructuredCtx functions add these fields to every message logged with the context, e.g.
+// the container ID and network name of the CNI operation being handled.
+func NewContext(ctx context.Context, args ...interface{}) context.Context {
+	return context.WithValue(ctx, contextKey{}, FromContext(ctx).With(args...))
+}
+
+// FromContext returns a Logger which adds the fields carried by ctx to every message. It returns a Logger without
+// context if ctx does not carry any fields.
+func FromContext(ctx context.Context) *Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(contextKey{}).(*Logger); ok {
+			return l
+		}
+	}
+	return &Logger{}
+}
+
+// FatalStructuredCtx works like FatalStructured and adds the fields carried by ctx.
+func FatalStructuredCtx(ctx context.Context, msg string, args ...interface{}) {
+	FromContext(ctx).FatalStructured(msg, args...)
+}
+
+// PanicStructuredCtx works like PanicStructured and adds the fields carried by ctx.
+func PanicStructuredCtx(ctx context.Context, msg string, args ...interface{}) {
+	FromContext(ctx).PanicStructured(msg, args...)
+}
+
+// ErrorStructuredCtx works like ErrorStructured and adds the fields carried by ctx.
+func ErrorStructuredCtx(ctx context.Context, msg string, args ...interface{}) error {
+	return FromContext(ctx).ErrorStructured(msg, args...)
+}
+
+// WarningStructuredCtx works like WarningStructured and adds the fields carried by ctx.
+func WarningStructuredCtx(ctx context.Context, msg string, args ...interface{}) {
+	FromContext(ctx).WarningStructured(msg, args...)
+}
+
+// InfoStructuredCtx works like InfoStructured and adds the fields carried by ctx.
+func InfoStructuredCtx(ctx context.Context, msg string, args ...interface{}) {
+	FromContext(ctx).InfoStructured(msg, args...)
+}
+
+// DebugStructuredCtx works like DebugStructured and adds the fields carried by ctx.
+func DebugStructuredCtx(ctx context.Context, msg string, args ...interface{}) {
+	FromContext(ctx).DebugStructured(msg, args...)
+}
+
+// TraceStructuredCtx works like TraceStructured and adds the fields carried by ctx.
+func TraceStructuredCtx(ctx context.Context, msg string, args ...interface{}) {
+	FromContext(ctx).TraceStructured(msg, args...)
+}
+
+// WithTemporaryLevel calls fn with a copy of ctx whose Logger logs the messages up to level, see Logger.WithLevel, e.g.
+// to log a single suspicious operation in detail. Only the messages logged with the context passed to fn are affected,
+// e.g. through the *StructuredCtx functions or FromContext, so concurrent operations keep their level.
+func WithTemporaryLevel(ctx context.Context, level Level, fn func(ctx context.Context)) {
+	fn(context.WithValue(ctx, contextKey{}, FromContext(ctx).WithLevel(level)))
+}
+
+// WithTemporarySink calls fn with a copy of ctx whose Logger writes its messages to sink in addition to the outputs, see
+// Logger.WithSink. Only the messages logged with the context passed to fn are affected.
+func WithTemporarySink(ctx context.Context, sink Sink, fn func(ctx context.Context)) {
+	fn(context.WithValue(ctx, contextKey{}, FromContext(ctx).WithSink(sink)))
+}
@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Context-aware logging", func() {
+	var out bytes.Buffer
+
+	BeforeEach(func() {
+		initLogger()
+		out = bytes.Buffer{}
+		SetOutput(&out)
+		SetLogStderr(false)
+		SetLogLevel(TraceLevel)
+		SetFileFields("msg", "containerID", "network", "a")
+	})
+
+	It("adds the fields carried by the context", func() {
+		ctx := NewContext(context.Background(), "containerID", "abc")
+		ctx = NewContext(ctx, "network", "net1")
+		InfoStructuredCtx(ctx, infoMsg, "a", "b")
+		DebugStructuredCtx(ctx, debugMsg)
+		Expect(out.String()).To(Equal(fmt.Sprintf(
+			"msg=%q containerID=\"abc\" network=\"net1\" a=\"b\"\nmsg=%q containerID=\"abc\" network=\"net1\"\n",
+			infoMsg, debugMsg)))
+	})
+
+	It("does not change the parent context", func() {
+		parent := NewContext(context.Background(), "containerID", "abc")
+		_ = NewContext(parent, "network", "net1")
+		WarningStructuredCtx(parent, warningMsg)
+		Expect(out.String()).To(Equal(fmt.Sprintf("msg=%q containerID=\"abc\"\n", warningMsg)))
+	})
+
+	It("logs without fields if the context carries none", func() {
+		TraceStructuredCtx(context.Background(), traceMsg, "a", "b")
+		Expect(out.String()).To(Equal(fmt.Sprintf("msg=%q a=\"b\"\n", traceMsg)))
+	})
+
+	It("returns the message from ErrorStructuredCtx", func() {
+		ctx := NewContext(context.Background(), "containerID", "abc")
+		Expect(ErrorStructuredCtx(ctx, errorMsg)).To(MatchError(ContainSubstring(`containerID="abc"`)))
+	})
+
+	It("returns a Logger with the fields of the context", func() {
+		ctx := NewContext(context.Background(), "containerID", "abc")
+		FromContext(ctx).With("a", "b").InfoStructured(infoMsg)
+		Expect(out.String()).To(Equal(fmt.Sprintf("msg=%q containerID=\"abc\" a=\"b\"\n", infoMsg)))
+	})
+
+	It("changes the level of the messages logged with the context within a function", func() {
+		SetLogLevel(InfoLevel)
+		ctx := NewContext(context.Background(), "containerID", "abc")
+		WithTemporaryLevel(ctx, DebugLevel, func(ctx context.Context) {
+			DebugStructuredCtx(ctx, debugMsg)
+			DebugStructured("global")
+		})
+		DebugStructuredCtx(ctx, debugMsg)
+		Expect(out.String()).To(Equal(fmt.Sprintf("msg=%q containerID=\"abc\"\n", debugMsg)))
+	})
+
+	It("adds a sink for the messages logged with the context within a function", func() {
+		sink := &captureSink{}
+		WithTemporarySink(context.Background(), sink, func(ctx context.Context) {
+			InfoStructuredCtx(ctx, infoMsg)
+			InfoStructured("global")
+		})
+		InfoStructuredCtx(context.Background(), infoMsg)
+		Expect(sink.entries).To(HaveLen(1))
+		Expect(sink.entries[0].Message).To(Equal(infoMsg))
+	})
+})
@@ -0,0 +1,159 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+const crashRecordFailMsg = "cni-log: unable to write crash record '%s': %v\n"
+
+// CrashRecord is the machine-readable record of a crash written by the Fatal functions, see SetCrashRecordFile.
+type CrashRecord struct {
+	// Time is the time the fatal message was logged at.
+	Time time.Time `json:"time"`
+	// Message is the fatal message, without prefix.
+	Message string `json:"msg"`
+	// Fields are the fields of the fatal message, without the time, level and msg fields of the structured prefix.
+	Fields map[string]json.RawMessage `json:"fields,omitempty"`
+	// Stack is the stack trace of the goroutine which logged the fatal message.
+	Stack string `json:"stack"`
+	// PID is the process ID of the crashed process.
+	PID int `json:"pid"`
+}
+
+// crashRecorder keeps the fatal message until the process exits and writes it to the crash record file. It outlives
+// the snapshots and is safe for concurrent use.
+type crashRecorder struct {
+	filename string
+
+	mu    sync.Mutex
+	entry *Entry
+}
+
+// SetCrashRecordFile makes the Fatal functions write a CrashRecord as JSON to filename before they exit the process,
+// so that node controllers can detect and react to plugin crashes without parsing the logs, like kubelet checkpoints.
+// The file is replaced atomically and only written on a crash, so its presence, or a change of its modification time,
+// signals a crash. If the fatal message is not logged, e.g. because there is no output, the record carries only the
+// stack. The directory of filename is created if needed; it is resolved like the log file, see SetRootDir. An empty
+// filename disables the crash record, which is the default.
+func SetCrashRecordFile(filename string) error {
+	mu.Lock()
+	defer unlockAndPublish()
+
+	if filename == "" {
+		crashRecordWriter = nil
+		return nil
+	}
+	fp, err := resolvePath(filename)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(fp), 0755); err != nil {
+		return err
+	}
+	crashRecordWriter = &crashRecorder{filename: fp}
+	return nil
+}
+
+// capture keeps the first fatal entry until the crash record is written. The stack trace which follows a printf style
+// fatal message is logged at fatal level as well and is not mistaken for the message.
+func (c *crashRecorder) capture(entry Entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entry == nil {
+		c.entry = &entry
+	}
+}
+
+// write writes the crash record of the captured entry, or an empty message if none was captured, and resets the
+// captured entry.
+func (c *crashRecorder) write(now time.Time) error {
+	c.mu.Lock()
+	entry := c.entry
+	c.entry = nil
+	c.mu.Unlock()
+
+	record := CrashRecord{Time: now, PID: os.Getpid()}
+	if entry != nil {
+		record.Time, record.Message = entry.Time, entry.Message
+		fields := entry.Fields
+		if !entry.structured {
+			fields = entry.extra
+		}
+		for i := 0; i < len(fields)-1; i += 2 {
+			key := argToString(fields[i])
+			switch {
+			case entry.structured && (key == "time" || key == "level" || key == "msg"):
+			case key == stackTraceKey:
+				record.Stack = argToString(fields[i+1])
+			default:
+				if record.Fields == nil {
+					record.Fields = map[string]json.RawMessage{}
+				}
+				record.Fields[key] = jsonValue(fields[i+1])
+			}
+		}
+	}
+	if record.Stack == "" {
+		record.Stack = string(debug.Stack())
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(c.filename, data)
+}
+
+// writeFileAtomic replaces filename with data through a temporary file in the same directory, so that readers never
+// see a partially written file.
+func writeFileAtomic(filename string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filename)
+}
+
+// writeCrashRecord writes the crash record of s, if enabled. Failures are reported on stderr, since the process is
+// about to exit.
+func (s *snapshot) writeCrashRecord() {
+	if s.crashRecord == nil {
+		return
+	}
+	if err := s.crashRecord.write(s.now()); err != nil {
+		fmt.Fprintf(os.Stderr, crashRecordFailMsg, s.crashRecord.filename, err)
+	}
+}
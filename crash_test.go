@@ -0,0 +1,82 @@
+package logging
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Crash record", func() {
+	var dir, recordFile string
+	var exitCode int
+
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+		AddSink(&captureSink{})
+		var err error
+		dir, err = os.MkdirTemp("", "cni-log-crash")
+		Expect(err).NotTo(HaveOccurred())
+		recordFile = filepath.Join(dir, "crash", "plugin.json")
+		Expect(SetCrashRecordFile(recordFile)).To(Succeed())
+		exitCode = 0
+		SetExitFunc(func(code int) { exitCode = code })
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	readRecord := func() CrashRecord {
+		data, err := os.ReadFile(recordFile)
+		Expect(err).NotTo(HaveOccurred())
+		record := CrashRecord{}
+		Expect(json.Unmarshal(data, &record)).To(Succeed())
+		return record
+	}
+
+	It("is only written on a crash", func() {
+		_ = ErrorStructured(errorMsg)
+		Expect(recordFile).NotTo(BeAnExistingFile())
+	})
+
+	It("records the message, the fields and the stack of a structured fatal message", func() {
+		FatalStructured(fatalMsg, "ifname", "net1", "mtu", 1400)
+		Expect(exitCode).To(Equal(1))
+
+		record := readRecord()
+		Expect(record.Message).To(Equal(fatalMsg))
+		Expect(record.Fields).To(Equal(map[string]json.RawMessage{
+			"ifname": json.RawMessage(`"net1"`),
+			"mtu":    json.RawMessage(`1400`),
+		}))
+		Expect(record.Stack).To(HavePrefix("goroutine "))
+		Expect(record.PID).To(Equal(os.Getpid()))
+		Expect(record.Time).NotTo(BeZero())
+
+		entries, err := os.ReadDir(filepath.Dir(recordFile))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+	})
+
+	It("records printf style fatal messages without their stack trace lines", func() {
+		Annotate("ifname", "net1")
+		Fatalf("cannot continue: %s", "no IPAM")
+		record := readRecord()
+		Expect(record.Message).To(Equal("cannot continue: no IPAM"))
+		Expect(record.Fields).To(HaveKeyWithValue("ifname", json.RawMessage(`"net1"`)))
+		Expect(record.Stack).To(ContainSubstring("crash_test.go"))
+
+		Fatalf(fatalMsg)
+		Expect(readRecord().Message).To(Equal(fatalMsg))
+	})
+
+	It("can be disabled", func() {
+		Expect(SetCrashRecordFile("")).To(Succeed())
+		FatalStructured(fatalMsg)
+		Expect(recordFile).NotTo(BeAnExistingFile())
+	})
+})
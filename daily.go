@@ -0,0 +1,165 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	dailyDateFormat = "20060102"
+	dailySuffix     = ".log"
+
+	dailyNameFailMsg = "cni-log: invalid log file name '%s'"
+)
+
+// dailyWriter writes to one log file per day, <dir>/<name>.<YYYYMMDD>.log, and removes the files of days which are
+// older than the retention.
+type dailyWriter struct {
+	mu      sync.Mutex
+	dir     string
+	name    string
+	maxDays int
+	now     func() time.Time
+	day     string
+	file    *os.File
+}
+
+// newDailyWriter returns a dailyWriter which writes to the files of name in dir.
+func newDailyWriter(dir, name string, maxDays int) *dailyWriter {
+	return &dailyWriter{dir: dir, name: name, maxDays: maxDays, now: time.Now}
+}
+
+// path returns the path of the log file of day.
+func (w *dailyWriter) path(day string) string {
+	return filepath.Join(w.dir, w.name+"."+day+dailySuffix)
+}
+
+// Write implements io.Writer.
+func (w *dailyWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if day := w.now().Format(dailyDateFormat); day != w.day || w.file == nil {
+		rotating := w.day != "" && day != w.day
+		if err := w.open(day); err != nil {
+			return 0, err
+		}
+		if rotating {
+			recordRotation()
+		}
+	}
+	return w.file.Write(p)
+}
+
+// open switches to the log file of day and removes expired log files.
+func (w *dailyWriter) open(day string) error {
+	if w.file != nil {
+		_ = w.file.Close()
+		w.file = nil
+	}
+
+	path := w.path(day)
+	if !isLogFileWritable(path) {
+		return configErrorf(ErrUnwritableLogFile, unwritableFailMsg, path)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	w.file = f
+	w.day = day
+	w.prune()
+	return nil
+}
+
+// prune removes the log files of the days before the retention. A retention <= 0 keeps all files.
+func (w *dailyWriter) prune() {
+	if w.maxDays <= 0 {
+		return
+	}
+
+	now := w.now()
+	oldest := time.Date(now.Year(), now.Month(), now.Day()-w.maxDays+1, 0, 0, 0, 0, now.Location())
+	files, err := filepath.Glob(filepath.Join(w.dir, w.name+".*"+dailySuffix))
+	if err != nil {
+		return
+	}
+	for _, f := range files {
+		day := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(f), w.name+"."), dailySuffix)
+		date, err := time.ParseInLocation(dailyDateFormat, day, now.Location())
+		if err != nil || len(day) != len(dailyDateFormat) {
+			// Not one of our files.
+			continue
+		}
+		if date.Before(oldest) {
+			_ = os.Remove(f)
+		}
+	}
+}
+
+// Sync commits the current log file to stable storage.
+func (w *dailyWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	return w.file.Sync()
+}
+
+// Close closes the current log file. It is reopened on the next write.
+func (w *dailyWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// SetDailyLogFiles logs to one file per day in dir instead of a single, size rotated, log file, the layout some
+// platforms expect for node component logs: dir/name.20240101.log. The files of days older than maxDays days, counting
+// today, are removed when a new file is opened; maxDays <= 0 keeps all files. Like SetOutput, the setting is replaced by
+// subsequent calls to SetLogFile or SetLogOptions.
+func SetDailyLogFiles(dir, name string, maxDays int) error {
+	if name == "" || strings.ContainsRune(name, os.PathSeparator) || strings.ContainsAny(name, "*?[") {
+		return fmt.Errorf(dailyNameFailMsg, name)
+	}
+
+	mu.Lock()
+	defer unlockAndPublish()
+
+	fp, err := resolvePath(dir)
+	if err != nil {
+		return err
+	}
+
+	w := newDailyWriter(fp, name, maxDays)
+	if !isLogFileWritable(w.path(w.now().Format(dailyDateFormat))) {
+		return configErrorf(ErrUnwritableLogFile, unwritableFailMsg, dir)
+	}
+	setLogWriter(w)
+	return nil
+}
@@ -0,0 +1,89 @@
+package logging
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Daily log files", func() {
+	var dir string
+
+	BeforeEach(func() {
+		initLogger()
+		var err error
+		dir, err = os.MkdirTemp("", "cni-log-daily")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		initLogger()
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	readDay := func(day string) string {
+		data, err := os.ReadFile(path.Join(dir, "plugin."+day+".log"))
+		Expect(err).NotTo(HaveOccurred())
+		return string(data)
+	}
+
+	It("switches to a new file every day and removes expired files", func() {
+		Expect(os.WriteFile(path.Join(dir, "plugin.20240101.log"), nil, 0644)).To(Succeed())
+		Expect(os.WriteFile(path.Join(dir, "plugin.20240102.log"), nil, 0644)).To(Succeed())
+		Expect(os.WriteFile(path.Join(dir, "plugin.backup.log"), nil, 0644)).To(Succeed())
+		Expect(os.WriteFile(path.Join(dir, "other.20240101.log"), nil, 0644)).To(Succeed())
+
+		now := time.Date(2024, 1, 3, 23, 59, 0, 0, time.Local)
+		w := newDailyWriter(dir, "plugin", 2)
+		w.now = func() time.Time { return now }
+		defer w.Close()
+
+		_, err := w.Write([]byte("first\n"))
+		Expect(err).NotTo(HaveOccurred())
+		now = now.Add(2 * time.Minute)
+		_, err = w.Write([]byte("second\n"))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(readDay("20240103")).To(Equal("first\n"))
+		Expect(readDay("20240104")).To(Equal("second\n"))
+
+		files, err := filepath.Glob(path.Join(dir, "*"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files).To(ConsistOf(
+			path.Join(dir, "plugin.20240103.log"),
+			path.Join(dir, "plugin.20240104.log"),
+			path.Join(dir, "plugin.backup.log"),
+			path.Join(dir, "other.20240101.log"),
+		))
+	})
+
+	It("keeps all files without retention", func() {
+		Expect(os.WriteFile(path.Join(dir, "plugin.20000101.log"), nil, 0644)).To(Succeed())
+		w := newDailyWriter(dir, "plugin", 0)
+		defer w.Close()
+
+		_, err := w.Write([]byte("msg\n"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(path.Join(dir, "plugin.20000101.log")).To(BeAnExistingFile())
+	})
+
+	It("logs to the file of the current day", func() {
+		logDir := path.Join(dir, "cni")
+		Expect(SetDailyLogFiles(logDir, "plugin", 7)).To(Succeed())
+		SetLogStderr(false)
+		Infof(infoMsg)
+
+		data, err := os.ReadFile(path.Join(logDir, "plugin."+time.Now().Format(dailyDateFormat)+".log"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring(infoMsg))
+	})
+
+	It("rejects invalid names", func() {
+		Expect(SetDailyLogFiles(dir, "", 7)).To(MatchError(ContainSubstring("invalid log file name")))
+		Expect(SetDailyLogFiles(dir, "a/b", 7)).To(MatchError(ContainSubstring("invalid log file name")))
+	})
+})
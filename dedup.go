@@ -0,0 +1,142 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"sync"
+	"time"
+)
+
+const repeatedSummaryMsg = "last message repeated"
+
+// repeatSummary describes the repetitions of a message which were suppressed.
+type repeatSummary struct {
+	level Level
+	count int
+	first time.Time
+	last  time.Time
+}
+
+// write logs the summary at the level of the repeated message. It is not subject to suppression.
+func (s *repeatSummary) write() {
+	writeStructured(loadSnapshot(), s.level, repeatedSummaryMsg, false, "repeated", s.count,
+		"window", s.last.Sub(s.first).String())
+}
+
+// deduplicator collapses consecutive identical messages.
+type deduplicator struct {
+	mu     sync.Mutex
+	now    func() time.Time
+	window time.Duration
+	level  Level
+	key    string
+	// pending holds the repetitions of the last message which were suppressed, nil if there are none.
+	pending *repeatSummary
+	timer   *time.Timer
+}
+
+// newDeduplicator returns a deduplicator which reports repetitions at least every window.
+func newDeduplicator(window time.Duration) *deduplicator {
+	return &deduplicator{now: time.Now, window: window}
+}
+
+// check returns false if the message is a repetition of the previous message and is suppressed. If the message ends a
+// series of repetitions, their summary is returned; the caller must write it before the message.
+func (d *deduplicator) check(level Level, key string) (bool, *repeatSummary) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	if level == d.level && key == d.key {
+		if d.pending == nil {
+			d.pending = &repeatSummary{level: level, first: now}
+			d.timer = time.AfterFunc(d.window, d.flush)
+		}
+		d.pending.count++
+		d.pending.last = now
+		return false, nil
+	}
+
+	d.level, d.key = level, key
+	return true, d.takePending()
+}
+
+// takePending returns and resets the pending summary. The caller must hold d.mu.
+func (d *deduplicator) takePending() *repeatSummary {
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	s := d.pending
+	d.pending = nil
+	return s
+}
+
+// flush writes the summary of the repetitions suppressed so far. Further repetitions of the message are suppressed
+// again.
+func (d *deduplicator) flush() {
+	d.mu.Lock()
+	s := d.takePending()
+	d.mu.Unlock()
+
+	if s != nil {
+		s.write()
+	}
+}
+
+// stop stops the timer without writing the pending summary.
+func (d *deduplicator) stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.takePending()
+}
+
+// suppressDuplicate returns true if a message is a repetition of the previous message and must not be written. It
+// writes the summary of the previous repetitions if the message ends them.
+func suppressDuplicate(d *deduplicator, level Level, key string) bool {
+	if d == nil {
+		return false
+	}
+
+	write, summary := d.check(level, key)
+	if summary != nil {
+		summary.write()
+	}
+	return !write
+}
+
+// flushDuplicates writes the summary of the currently suppressed repetitions.
+func flushDuplicates() {
+	if d := loadSnapshot().dedup; d != nil {
+		d.flush()
+	}
+}
+
+// SetDuplicateSuppression collapses consecutive identical messages, e.g. of retry loops, into a single "last message
+// repeated" line which carries the number of repetitions and the time window they occurred in. Messages are identical
+// if they have the same level, message and arguments. The summary is written when a different message is logged, by
+// Flush, or once window has passed since the first repetition. A window <= 0, the default, disables the suppression.
+func SetDuplicateSuppression(window time.Duration) {
+	mu.Lock()
+	defer unlockAndPublish()
+
+	if logDedup != nil {
+		logDedup.stop()
+		logDedup = nil
+	}
+	if window > 0 {
+		logDedup = newDeduplicator(window)
+	}
+}
@@ -0,0 +1,80 @@
+package logging
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Duplicate suppression", func() {
+	var out *syncBuffer
+
+	BeforeEach(func() {
+		initLogger()
+		out = &syncBuffer{}
+		SetOutput(out)
+		SetLogStderr(false)
+		SetFileFields("level", "msg", "a", "repeated")
+		SetDuplicateSuppression(time.Hour)
+	})
+
+	AfterEach(func() {
+		initLogger()
+	})
+
+	It("collapses consecutive identical messages", func() {
+		for i := 0; i < 4; i++ {
+			InfoStructured(infoMsg, "a", "b")
+		}
+		InfoStructured(infoMsg, "a", "c")
+		Expect(out.String()).To(Equal(fmt.Sprintf(
+			"level=\"info\" msg=%q a=\"b\"\n"+
+				"level=\"info\" msg=%q repeated=\"3\"\n"+
+				"level=\"info\" msg=%q a=\"c\"\n", infoMsg, repeatedSummaryMsg, infoMsg)))
+	})
+
+	It("collapses printf style messages", func() {
+		Warningf("retrying %d", 1)
+		Warningf("retrying %d", 1)
+		Warningf("retrying %d", 2)
+		Expect(strings.Count(out.String(), "retrying 1")).To(Equal(1))
+		Expect(out.String()).To(ContainSubstring(fmt.Sprintf("level=\"warning\" msg=%q repeated=\"1\"", repeatedSummaryMsg)))
+		Expect(out.String()).To(HaveSuffix("retrying 2\n"))
+	})
+
+	It("does not collapse messages of different levels", func() {
+		InfoStructured(infoMsg)
+		WarningStructured(infoMsg)
+		Expect(strings.Count(out.String(), infoMsg)).To(Equal(2))
+		Expect(out.String()).NotTo(ContainSubstring(repeatedSummaryMsg))
+	})
+
+	It("writes the summary on Flush", func() {
+		InfoStructured(infoMsg)
+		InfoStructured(infoMsg)
+		Flush()
+		Expect(out.String()).To(HaveSuffix(fmt.Sprintf("msg=%q repeated=\"1\"\n", repeatedSummaryMsg)))
+
+		// Further repetitions are suppressed again.
+		InfoStructured(infoMsg)
+		Expect(strings.Count(out.String(), infoMsg)).To(Equal(1))
+	})
+
+	It("writes the summary once the window has passed", func() {
+		SetDuplicateSuppression(20 * time.Millisecond)
+		InfoStructured(infoMsg)
+		InfoStructured(infoMsg)
+		InfoStructured(infoMsg)
+		Eventually(out.String).Should(HaveSuffix(fmt.Sprintf("msg=%q repeated=\"2\"\n", repeatedSummaryMsg)))
+	})
+
+	It("is disabled with a window <= 0", func() {
+		SetDuplicateSuppression(0)
+		InfoStructured(infoMsg)
+		InfoStructured(infoMsg)
+		Expect(strings.Count(out.String(), infoMsg)).To(Equal(2))
+	})
+})
@@ -0,0 +1,97 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// DeterministicOptions configures EnableDeterministicOutput.
+type DeterministicOptions struct {
+	// Time is the time of every message, the Unix epoch in UTC if zero.
+	Time time.Time
+	// Pid is the process ID written by the klog prefix and syslog.
+	Pid int
+	// StripCallerLines sets the line numbers of the call sites added by EnableCallerInfo to 0, so that the output does
+	// not change when lines are added to the source file.
+	StripCallerLines bool
+}
+
+// deterministic is the state of the deterministic output.
+type deterministic struct {
+	options  DeterministicOptions
+	chunkIDs uint32
+}
+
+// EnableDeterministicOutput makes the output reproducible for tests which compare complete log lines: every message
+// is logged at a fixed time, the klog prefix and syslog write a fixed process ID and the chunks of split entries are
+// numbered sequentially instead of having random IDs. nil enables it with the default options. Custom prefixers and
+// stack traces are not affected.
+func EnableDeterministicOutput(options *DeterministicOptions) {
+	mu.Lock()
+	defer unlockAndPublish()
+	d := &deterministic{}
+	if options != nil {
+		d.options = *options
+	}
+	if d.options.Time.IsZero() {
+		d.options.Time = time.Unix(0, 0).UTC()
+	}
+	deterministicOutput = d
+}
+
+// DisableDeterministicOutput restores the variable fields of the output, which is the default.
+func DisableDeterministicOutput() {
+	mu.Lock()
+	defer unlockAndPublish()
+	deterministicOutput = nil
+}
+
+// now returns the time of a message.
+func (s *snapshot) now() time.Time {
+	if s.deterministic != nil {
+		return s.deterministic.options.Time
+	}
+	return time.Now()
+}
+
+// callSite returns the call site of the log statement.
+func (s *snapshot) callSite() string {
+	return callSite(s.callerSkip, s.deterministic == nil || !s.deterministic.options.StripCallerLines)
+}
+
+// logTime returns the time of a message for the code which has no snapshot at hand, e.g. prefixers.
+func logTime() time.Time {
+	return loadSnapshot().now()
+}
+
+// logPid returns the process ID written to the output.
+func logPid() int {
+	if d := loadSnapshot().deterministic; d != nil {
+		return d.options.Pid
+	}
+	return os.Getpid()
+}
+
+// nextChunkID returns the identifier of the chunks of an entry, see newChunkID.
+func nextChunkID() string {
+	if d := loadSnapshot().deterministic; d != nil {
+		return fmt.Sprintf("%08x", atomic.AddUint32(&d.chunkIDs, 1))
+	}
+	return newChunkID()
+}
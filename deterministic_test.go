@@ -0,0 +1,71 @@
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Deterministic output", func() {
+	var out bytes.Buffer
+
+	BeforeEach(func() {
+		initLogger()
+		out = bytes.Buffer{}
+		SetOutput(&out)
+		SetLogStderr(false)
+		EnableDeterministicOutput(nil)
+	})
+
+	It("logs every message at the Unix epoch", func() {
+		Infof(infoMsg)
+		InfoStructured(infoMsg, "pod", "pod-a")
+		Expect(out.String()).To(Equal(fmt.Sprintf("1970-01-01T00:00:00Z [info] %s\n"+
+			`time="1970-01-01T00:00:00Z" level="info" msg=%q pod="pod-a"`+"\n", infoMsg, infoMsg)))
+	})
+
+	It("uses the configured time and process ID", func() {
+		EnableDeterministicOutput(&DeterministicOptions{Time: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), Pid: 42})
+		SetPrefixer(klogPrefixer{})
+		Warningf(warningMsg)
+		Expect(out.String()).To(Equal(fmt.Sprintf("W0102 03:04:05.000000      42] %s\n", warningMsg)))
+
+		out.Reset()
+		SetFormatter(JSONFormatter{})
+		Warningf(warningMsg)
+		Expect(out.String()).To(Equal(fmt.Sprintf(`{"time":"2024-01-02T03:04:05Z","level":"warning","msg":%q}`+"\n",
+			warningMsg)))
+	})
+
+	It("optionally strips the line numbers of call sites", func() {
+		EnableCallerInfo(0)
+		InfoStructured(infoMsg)
+		Expect(out.String()).To(MatchRegexp(`caller="deterministic_test.go:[1-9]\d*:`))
+
+		out.Reset()
+		EnableDeterministicOutput(&DeterministicOptions{StripCallerLines: true})
+		InfoStructured(infoMsg)
+		Infof(infoMsg)
+		Expect(out.String()).To(MatchRegexp(fmt.Sprintf(`^time="1970-01-01T00:00:00Z" level="info" msg=%q`+
+			` caller="deterministic_test.go:0:\S+"\n1970-01-01T00:00:00Z \[info\] deterministic_test.go:0:\S+ %s\n$`,
+			infoMsg, infoMsg)))
+	})
+
+	It("numbers the chunks of split entries", func() {
+		SetMaxEntrySize(200)
+		InfoStructured(infoMsg, "value", strings.Repeat("x", 500))
+		InfoStructured(infoMsg, "value", strings.Repeat("x", 500))
+		Expect(out.String()).To(ContainSubstring(`chunk_id="00000001" chunk="1/`))
+		Expect(out.String()).To(ContainSubstring(`chunk_id="00000002" chunk="1/`))
+	})
+
+	It("is disabled again", func() {
+		DisableDeterministicOutput()
+		Infof(infoMsg)
+		Expect(out.String()).NotTo(HavePrefix("1970"))
+	})
+})
@@ -0,0 +1,90 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"fmt"
+	"os"
+)
+
+const (
+	emergencyNoticeMsg = "cni-log: failed to set log file '%s', logging to the emergency log file\n"
+	notRegularFailMsg  = "cni-log: '%s' is not a regular file"
+)
+
+// SetEmergencyLogFile sets a fallback log file, e.g. in /tmp, which is only used if the log file cannot be opened when
+// it is configured with SetLogFile, ApplyConfig or ConfigureFromEnv. This preserves the evidence of an early
+// misconfiguration, e.g. of a CNI plugin whose stderr is not captured. The emergency log file is never rotated, and a
+// notice naming the failed log file is written to it before the messages. Once a valid log file is configured, it is
+// used instead. The emergency log file is not used if it is a symbolic link or not a regular file. An empty filename
+// disables the emergency log file, which is the default.
+func SetEmergencyLogFile(filename string) {
+	mu.Lock()
+	defer unlockAndPublish()
+
+	closeEmergencyLogFile()
+	emergencyLogFile = filename
+}
+
+// useEmergencyLogFile makes the emergency log file the output of the log file after failed could not be opened. It
+// returns false if there is no emergency log file or it cannot be opened either. The caller must hold mu.
+func useEmergencyLogFile(failed string) bool {
+	if emergencyLogFile == "" {
+		return false
+	}
+	if emergencyOutput == nil {
+		f, err := openEmergencyLogFile(emergencyLogFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, logFileFailMsg, emergencyLogFile)
+			return false
+		}
+		emergencyOutput = f
+	}
+
+	fmt.Fprintf(emergencyOutput, emergencyNoticeMsg, failed)
+	setLogWriter(emergencyOutput)
+	return true
+}
+
+// openEmergencyLogFile opens the emergency log file filename. It usually lives in a world-writable directory like
+// /tmp, so it is neither followed if it is a symbolic link nor used if it is not a regular file, which keeps other
+// users of the node from redirecting the messages into an arbitrary file.
+func openEmergencyLogFile(filename string) (*os.File, error) {
+	f, err := openNoFollow(filename)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err == nil && !info.Mode().IsRegular() {
+		err = fmt.Errorf(notRegularFailMsg, filename)
+	}
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// closeEmergencyLogFile closes the emergency log file, disabling file logging if it is in use. The caller must hold mu.
+func closeEmergencyLogFile() {
+	if emergencyOutput == nil {
+		return
+	}
+	if logWriter == emergencyOutput {
+		setLogWriter(nil)
+	}
+	_ = emergencyOutput.Close()
+	emergencyOutput = nil
+}
@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Emergency log file", func() {
+	const invalidLogFile = "/proc/foobar.log"
+	var dir, emergencyFile string
+
+	BeforeEach(func() {
+		initLogger()
+		var err error
+		dir, err = os.MkdirTemp("", "cni-log-emergency")
+		Expect(err).NotTo(HaveOccurred())
+		emergencyFile = path.Join(dir, "emergency.log")
+		SetEmergencyLogFile(emergencyFile)
+	})
+
+	AfterEach(func() {
+		initLogger()
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("is used if the log file cannot be opened", func() {
+		Expect(captureStdErr(SetLogFile, invalidLogFile)).To(Equal(fmt.Sprintf(logFileFailMsg, invalidLogFile)))
+		_ = captureStdErrEvent(Infof, infoMsg)
+		Expect(logFileContains(emergencyFile, fmt.Sprintf(emergencyNoticeMsg, invalidLogFile))).To(BeTrue())
+		Expect(logFileContains(emergencyFile, infoMsg)).To(BeTrue())
+	})
+
+	It("is used if the configured log file cannot be opened", func() {
+		Expect(ApplyConfig(&Config{LogFile: invalidLogFile, LogLevel: "debug"})).NotTo(Succeed())
+		Expect(GetLogLevel()).To(Equal(InfoLevel))
+		_ = captureStdErrEvent(Infof, infoMsg)
+		Expect(logFileContains(emergencyFile, infoMsg)).To(BeTrue())
+	})
+
+	It("is replaced by a valid log file", func() {
+		_ = captureStdErr(SetLogFile, invalidLogFile)
+		logFile := path.Join(dir, "plugin.log")
+		SetLogFile(logFile)
+		_ = captureStdErrEvent(Infof, infoMsg)
+		Expect(logFileContains(logFile, infoMsg)).To(BeTrue())
+		Expect(logFileContains(emergencyFile, infoMsg)).To(BeFalse())
+	})
+
+	It("does not follow a symbolic link", func() {
+		target := path.Join(dir, "target")
+		Expect(os.WriteFile(target, nil, 0600)).To(Succeed())
+		Expect(os.Symlink(target, emergencyFile)).To(Succeed())
+
+		Expect(captureStdErr(SetLogFile, invalidLogFile)).To(ContainSubstring(fmt.Sprintf(logFileFailMsg, emergencyFile)))
+		_ = captureStdErrEvent(Infof, infoMsg)
+		Expect(logFileContains(target, infoMsg)).To(BeFalse())
+		Expect(logFileContains(target, invalidLogFile)).To(BeFalse())
+	})
+
+	It("is not used if it is not a regular file", func() {
+		if err := mkfifo(emergencyFile); err != nil {
+			Skip(err.Error())
+		}
+		Expect(captureStdErr(SetLogFile, invalidLogFile)).To(ContainSubstring(fmt.Sprintf(logFileFailMsg, emergencyFile)))
+		Expect(emergencyOutput).To(BeNil())
+	})
+
+	It("is not used if the log file can be opened", func() {
+		SetLogFile(path.Join(dir, "plugin.log"))
+		Expect(emergencyFile).NotTo(BeAnExistingFile())
+	})
+})
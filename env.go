@@ -0,0 +1,152 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// Environment variables read by ConfigureFromEnv.
+const (
+	EnvLogLevel      = "CNI_LOG_LEVEL"
+	EnvLogFile       = "CNI_LOG_FILE"
+	EnvLogStderr     = "CNI_LOG_STDERR"
+	EnvLogMaxSize    = "CNI_LOG_MAX_SIZE"
+	EnvLogMaxAge     = "CNI_LOG_MAX_AGE"
+	EnvLogMaxBackups = "CNI_LOG_MAX_BACKUPS"
+	EnvLogCompress   = "CNI_LOG_COMPRESS"
+	EnvLogLocalTime  = "CNI_LOG_LOCAL_TIME"
+	EnvLogFormat     = "CNI_LOG_FORMAT"
+	EnvLogPrefix     = "CNI_LOG_PREFIX"
+)
+
+const invalidEnvFailMsg = "cni-log: invalid value '%s' of environment variable %s: %w"
+
+// ConfigureFromEnv configures the logger from environment variables, which lets operators tune the logging of deployed
+// binaries, e.g. through the environment of a DaemonSet, without changing the network configuration. Only the settings
+// whose environment variable is set are changed:
+//
+//	CNI_LOG_LEVEL        logging level, e.g. "debug"
+//	CNI_LOG_FILE         path of the log file, "" disables logging to a file
+//	CNI_LOG_STDERR       "true" or "false", enables logging to stderr
+//	CNI_LOG_MAX_SIZE     LogOptions.MaxSize
+//	CNI_LOG_MAX_AGE      LogOptions.MaxAge
+//	CNI_LOG_MAX_BACKUPS  LogOptions.MaxBackups
+//	CNI_LOG_COMPRESS     LogOptions.Compress
+//	CNI_LOG_LOCAL_TIME   LogOptions.LocalTime
+//	CNI_LOG_FORMAT       name of the formatter, e.g. "json", see RegisterFormatter
+//	CNI_LOG_PREFIX       name of the prefixer, e.g. "klog", see RegisterPrefixer
+//
+// The settings take precedence over the configuration file, but not over ApplyConfig or the setters, see
+// ExplainConfig. Calling it again replaces the settings of the previous call. If any of the values is invalid, an error
+// is returned and nothing is changed, except that the emergency log file, see SetEmergencyLogFile, is used if the log
+// file cannot be opened.
+func ConfigureFromEnv() error {
+	env := &envConfig{}
+	env.level = env.lookupLevel(EnvLogLevel)
+	filename, fileSet := os.LookupEnv(EnvLogFile)
+	toStderr := env.lookupBool(EnvLogStderr)
+	maxSize := env.lookupInt(EnvLogMaxSize)
+	maxAge := env.lookupInt(EnvLogMaxAge)
+	maxBackups := env.lookupInt(EnvLogMaxBackups)
+	compress := env.lookupBool(EnvLogCompress)
+	localTime := env.lookupBool(EnvLogLocalTime)
+	if env.err != nil {
+		return env.err
+	}
+
+	layer := configLayer{
+		logToStderr: toStderr,
+		logOptions: LogOptions{
+			MaxSize:    maxSize,
+			MaxAge:     maxAge,
+			MaxBackups: maxBackups,
+			Compress:   compress,
+			LocalTime:  localTime,
+		},
+	}
+	if env.level != InvalidLevel {
+		layer.logLevel = &env.level
+	}
+	if fileSet {
+		layer.logFile = &filename
+	}
+	if format := os.Getenv(EnvLogFormat); format != "" {
+		layer.format = &format
+	}
+	if prefix := os.Getenv(EnvLogPrefix); prefix != "" {
+		layer.prefix = &prefix
+	}
+	return applyConfigLayer(SourceEnv, layer)
+}
+
+// envConfig reads configuration values from the environment. It remembers the first invalid value.
+type envConfig struct {
+	level Level
+	err   error
+}
+
+// lookupLevel returns the logging level set in the environment variable key, InvalidLevel if it is not set.
+func (e *envConfig) lookupLevel(key string) Level {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return InvalidLevel
+	}
+
+	level := StringToLevel(value)
+	if level == InvalidLevel {
+		e.fail(key, value, configErrorf(ErrInvalidLevel, invalidLevelFailMsg, value))
+	}
+	return level
+}
+
+// lookupBool returns the boolean set in the environment variable key, nil if it is not set.
+func (e *envConfig) lookupBool(key string) *bool {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		e.fail(key, value, err)
+		return nil
+	}
+	return &b
+}
+
+// lookupInt returns the integer set in the environment variable key, nil if it is not set.
+func (e *envConfig) lookupInt(key string) *int {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		e.fail(key, value, err)
+		return nil
+	}
+	return &i
+}
+
+// fail records an invalid value unless an invalid value was found before.
+func (e *envConfig) fail(key, value string, err error) {
+	if e.err == nil {
+		e.err = fmt.Errorf(invalidEnvFailMsg, value, key, err)
+	}
+}
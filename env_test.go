@@ -0,0 +1,71 @@
+package logging
+
+import (
+	"os"
+	"path"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Configuration from the environment", func() {
+	var logFile string
+
+	setEnv := func(key, value string) {
+		Expect(os.Setenv(key, value)).To(Succeed())
+		DeferCleanup(os.Unsetenv, key)
+	}
+
+	BeforeEach(func() {
+		initLogger()
+		logFile = path.Join(os.TempDir(), "test-env.log")
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(logFile)).To(Succeed())
+	})
+
+	When("no environment variables are set", func() {
+		It("keeps the current configuration", func() {
+			SetLogLevel(WarningLevel)
+			SetLogOptions(&LogOptions{MaxSize: getPrimitivePointer(7)})
+			Expect(ConfigureFromEnv()).To(Succeed())
+			Expect(GetLogLevel()).To(Equal(WarningLevel))
+			Expect(currentLumberjack().MaxSize).To(Equal(7))
+			Expect(logToStderr).To(BeTrue())
+		})
+	})
+
+	When("environment variables are set", func() {
+		It("configures the logger", func() {
+			SetLogOptions(&LogOptions{MaxAge: getPrimitivePointer(2)})
+			setEnv(EnvLogLevel, "debug")
+			setEnv(EnvLogFile, logFile)
+			setEnv(EnvLogStderr, "false")
+			setEnv(EnvLogMaxSize, "10")
+			setEnv(EnvLogMaxBackups, "1")
+			setEnv(EnvLogCompress, "false")
+			setEnv(EnvLogLocalTime, "true")
+
+			Expect(ConfigureFromEnv()).To(Succeed())
+			Expect(GetLogLevel()).To(Equal(DebugLevel))
+			Expect(logToStderr).To(BeFalse())
+			Expect(currentLumberjack().Filename).To(Equal(logFile))
+			Expect(currentLumberjack().MaxSize).To(Equal(10))
+			Expect(currentLumberjack().MaxAge).To(Equal(2))
+			Expect(currentLumberjack().MaxBackups).To(Equal(1))
+			Expect(currentLumberjack().Compress).To(BeFalse())
+			Expect(currentLumberjack().LocalTime).To(BeTrue())
+		})
+	})
+
+	When("an environment variable is invalid", func() {
+		It("returns an error and keeps the current configuration", func() {
+			setEnv(EnvLogLevel, "debug")
+			setEnv(EnvLogMaxSize, "ten")
+			Expect(ConfigureFromEnv()).To(MatchError(ContainSubstring(EnvLogMaxSize)))
+			Expect(GetLogLevel()).To(Equal(defaultLogLevel))
+			Expect(currentLumberjack().MaxSize).To(Equal(100))
+		})
+	})
+})
@@ -0,0 +1,71 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+// errorLogLevel is the least severe level written to the error log file.
+const errorLogLevel = WarningLevel
+
+// SetErrorLogFile writes warnings and more severe messages to filename in addition to the other outputs, so that
+// operators can tail a small high-signal file on busy nodes while the log file keeps all messages. The file is rotated
+// according to options like the log file, see SetLogOptions; nil options use the default values. The error log file
+// uses the formatter and the fields of the log file and follows the level set with SetFileLogLevel, so it never
+// receives messages the log file would not receive. An empty filename disables the error log file. If filename is not
+// writable, an error is returned and nothing is changed.
+func SetErrorLogFile(filename string, options *LogOptions) error {
+	mu.Lock()
+	defer unlockAndPublish()
+
+	if filename == "" {
+		return closeErrorLogFile()
+	}
+	fp, err := resolvePath(filename)
+	if err != nil {
+		return err
+	}
+	if !isLogFileWritable(fp) {
+		return configErrorf(ErrUnwritableLogFile, unwritableFailMsg, filename)
+	}
+
+	if err := closeErrorLogFile(); err != nil {
+		return err
+	}
+	errorLogWriter = newFileWriter(newRotatingWriter(), fp)
+	errorLogWriter.setOptions(options)
+	return nil
+}
+
+// closeErrorLogFile closes and disables the error log file. The caller must hold mu.
+func closeErrorLogFile() error {
+	if errorLogWriter == nil {
+		return nil
+	}
+	err := errorLogWriter.close()
+	errorLogWriter = nil
+	return err
+}
+
+// errorLogSink returns the sink of the error log file, nil if it is disabled. verbose is the most verbose level of all
+// outputs. The caller must hold mu.
+func errorLogSink(verbose Level) Sink {
+	if errorLogWriter == nil {
+		return nil
+	}
+	level := errorLogLevel
+	if fileLevel := outputLevel(fileLogLevel); fileLevel < level {
+		level = fileLevel
+	}
+	return withLevel(&writerSink{out: errorLogWriter, formatter: fileFormatter, fields: fileFields, ascii: asciiOnly,
+		maxSize: maxEntrySize, name: statsErrorLog}, level, verbose)
+}
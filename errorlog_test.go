@@ -0,0 +1,64 @@
+package logging
+
+import (
+	"os"
+	"path"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Error log file", func() {
+	var dir, logFile, errorLogFile string
+
+	BeforeEach(func() {
+		initLogger()
+		var err error
+		dir, err = os.MkdirTemp("", "cni-log-errorlog")
+		Expect(err).NotTo(HaveOccurred())
+		logFile = path.Join(dir, "plugin.log")
+		errorLogFile = path.Join(dir, "plugin.error.log")
+		SetLogFile(logFile)
+		SetLogStderr(false)
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("receives warnings and more severe messages in addition to the log file", func() {
+		Expect(SetErrorLogFile(errorLogFile, &LogOptions{MaxSize: getPrimitivePointer(1)})).To(Succeed())
+		Infof(infoMsg)
+		Warningf(warningMsg)
+		_ = ErrorStructured(errorMsg)
+
+		Expect(logFileContains(logFile, infoMsg)).To(BeTrue())
+		Expect(logFileContains(logFile, warningMsg)).To(BeTrue())
+		Expect(logFileContains(errorLogFile, infoMsg)).To(BeFalse())
+		Expect(logFileContains(errorLogFile, warningMsg)).To(BeTrue())
+		Expect(logFileContains(errorLogFile, errorMsg)).To(BeTrue())
+		Expect(errorLogWriter.backend.(*lumberjackWriter).logger.MaxSize).To(Equal(1))
+	})
+
+	It("follows the level of the log file", func() {
+		Expect(SetErrorLogFile(errorLogFile, nil)).To(Succeed())
+		SetFileLogLevel(ErrorLevel)
+		Warningf(warningMsg)
+		Expect(logFileContains(errorLogFile, warningMsg)).To(BeFalse())
+	})
+
+	It("can be disabled", func() {
+		Expect(SetErrorLogFile(errorLogFile, nil)).To(Succeed())
+		Expect(SetErrorLogFile("", nil)).To(Succeed())
+		Warningf(warningMsg)
+		Expect(logFileContains(logFile, warningMsg)).To(BeTrue())
+		Expect(logFileContains(errorLogFile, warningMsg)).To(BeFalse())
+	})
+
+	It("rejects unwritable files", func() {
+		Expect(SetErrorLogFile(errorLogFile, nil)).To(Succeed())
+		Expect(SetErrorLogFile("/proc/cni-log/error.log", nil)).To(MatchError(ContainSubstring("not writable")))
+		Warningf(warningMsg)
+		Expect(logFileContains(errorLogFile, warningMsg)).To(BeTrue())
+	})
+})
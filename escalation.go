@@ -0,0 +1,194 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultEscalationWindow   = time.Minute
+	defaultEscalationDuration = 5 * time.Minute
+	// maxEscalationCounters bounds the number of distinct field values escalation keeps track of.
+	maxEscalationCounters = 4096
+
+	escalatedMsg    = "logging level escalated"
+	escalatedForKey = "escalatedFor"
+)
+
+// EscalationOptions configures the automatic escalation of the logging level to debug for the messages with a field
+// value which logged repeated errors, e.g. of a component or a container.
+type EscalationOptions struct {
+	// Key is the field whose values are escalated separately, e.g. "component" or "containerID".
+	Key string
+	// Errors is the number of error messages within Window which escalate a value, at least 1.
+	Errors int
+	// Window is the interval the errors are counted in, one minute by default.
+	Window time.Duration
+	// Duration is the time a value stays escalated after its last error, five minutes by default.
+	Duration time.Duration
+}
+
+// escalationCounter counts the errors of a field value.
+type escalationCounter struct {
+	start time.Time
+	count int
+	until time.Time
+}
+
+// escalator keeps track of the errors per field value and of the escalated values. It is safe for concurrent use.
+type escalator struct {
+	mu       sync.Mutex
+	now      func() time.Time
+	options  EscalationOptions
+	counters map[string]*escalationCounter
+	// latest is the time in Unix nanoseconds when the last escalation ends, so that log calls do not take mu while no
+	// value is escalated.
+	latest int64
+}
+
+// newEscalator returns an escalator for options.
+func newEscalator(options EscalationOptions) *escalator {
+	return &escalator{now: time.Now, options: options, counters: map[string]*escalationCounter{}}
+}
+
+// SetEscalation enables the automatic escalation of the logging level: once options.Errors error messages with the same
+// value of the field options.Key were logged within options.Window, the debug messages with that value are logged as
+// well for options.Duration, e.g.
+//
+//	SetEscalation(&EscalationOptions{Key: "containerID", Errors: 3, Window: time.Minute, Duration: 10 * time.Minute})
+//
+// This captures the verbose context of a failing container or component without reproducing the failure at a higher
+// level. The field is looked up in the arguments and the context of structured messages, including the CNI context,
+// see EnableCNIContext; printf style messages only carry the CNI context. Escalated messages are written to the outputs
+// which follow the level set with SetLogLevel, outputs with a level of their own keep it. A "logging level escalated"
+// warning reports every escalation. Passing nil disables escalation, which is the default.
+func SetEscalation(options *EscalationOptions) {
+	mu.Lock()
+	defer unlockAndPublish()
+
+	if options == nil {
+		logEscalator = nil
+		return
+	}
+	o := *options
+	if o.Errors < 1 {
+		o.Errors = 1
+	}
+	if o.Window <= 0 {
+		o.Window = defaultEscalationWindow
+	}
+	if o.Duration <= 0 {
+		o.Duration = defaultEscalationDuration
+	}
+	logEscalator = newEscalator(o)
+}
+
+// escalated returns true if a message of the given level is only logged because a field value of args or the CNI
+// context is escalated, or because it is logged by a Logger with a more verbose level, see Logger.WithLevel.
+func (s *snapshot) escalated(level Level, args []interface{}) bool {
+	return level > s.level && level <= s.levelCap && (level <= s.scopedLevel ||
+		s.escalator != nil && s.escalator.escalated(level, args, s.cniContext))
+}
+
+// recordError counts an error message for escalation and reports the escalation of its field value. Log calls defer
+// it, so that the report follows the error which caused it.
+func (s *snapshot) recordError(level Level, args []interface{}) {
+	if s.escalator == nil || level > ErrorLevel {
+		return
+	}
+	if value, ok := s.escalator.record(args, s.cniContext); ok {
+		writeStructured(s, WarningLevel, escalatedMsg, false, s.escalator.options.Key, value,
+			escalatedForKey, s.escalator.options.Duration.String())
+	}
+}
+
+// value returns the value of the escalated field, looked up in args before context.
+func (e *escalator) value(args, context []interface{}) (string, bool) {
+	if value, ok := fieldValue(e.options.Key, args); ok {
+		return value, true
+	}
+	return fieldValue(e.options.Key, context)
+}
+
+// escalated returns true if a message of the given level is logged because its field value is escalated.
+func (e *escalator) escalated(level Level, args, context []interface{}) bool {
+	if level > DebugLevel {
+		return false
+	}
+	now := e.now()
+	if now.UnixNano() >= atomic.LoadInt64(&e.latest) {
+		return false
+	}
+	value, ok := e.value(args, context)
+	if !ok {
+		return false
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	c, ok := e.counters[value]
+	return ok && now.Before(c.until)
+}
+
+// record counts an error message of the field value of args or context. It returns the value if the error escalated
+// it, which it was not before.
+func (e *escalator) record(args, context []interface{}) (string, bool) {
+	value, ok := e.value(args, context)
+	if !ok {
+		return "", false
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := e.now()
+	c, ok := e.counters[value]
+	if !ok {
+		if len(e.counters) >= maxEscalationCounters {
+			e.expire(now)
+		}
+		c = &escalationCounter{start: now}
+		e.counters[value] = c
+	}
+	if now.Sub(c.start) >= e.options.Window {
+		c.start, c.count = now, 0
+	}
+	c.count++
+	escalated := now.Before(c.until)
+	if !escalated && c.count < e.options.Errors {
+		return "", false
+	}
+
+	c.until = now.Add(e.options.Duration)
+	if until := c.until.UnixNano(); until > atomic.LoadInt64(&e.latest) {
+		atomic.StoreInt64(&e.latest, until)
+	}
+	return value, !escalated
+}
+
+// expire discards the counters which are neither escalated nor counting errors within the window, or all counters if
+// that is not enough. The caller must hold e.mu.
+func (e *escalator) expire(now time.Time) {
+	for value, c := range e.counters {
+		if !now.Before(c.until) && now.Sub(c.start) >= e.options.Window {
+			delete(e.counters, value)
+		}
+	}
+	if len(e.counters) >= maxEscalationCounters {
+		e.counters = map[string]*escalationCounter{}
+	}
+}
@@ -0,0 +1,106 @@
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Escalation", func() {
+	var sink *captureSink
+	var now time.Time
+
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+		sink = &captureSink{}
+		AddSink(sink)
+		SetEscalation(&EscalationOptions{Key: "containerID", Errors: 2, Window: time.Minute, Duration: time.Hour})
+
+		now = time.Now()
+		loadSnapshot().escalator.now = func() time.Time { return now }
+	})
+
+	messages := func() []string {
+		var msgs []string
+		for _, entry := range sink.entries {
+			msgs = append(msgs, entry.Message)
+		}
+		return msgs
+	}
+
+	It("logs debug messages of a value after repeated errors", func() {
+		DebugStructured("before", "containerID", "abc")
+		_ = ErrorStructured(errorMsg, "containerID", "abc")
+		_ = ErrorStructured(errorMsg, "containerID", "def")
+		_ = ErrorStructured(errorMsg, "containerID", "abc")
+		DebugStructured("escalated", "containerID", "abc")
+		DebugStructured("other", "containerID", "def")
+		TraceStructured("trace", "containerID", "abc")
+
+		Expect(messages()).To(Equal([]string{errorMsg, errorMsg, errorMsg, escalatedMsg, "escalated"}))
+		Expect(sink.entries[3].String()).To(HaveSuffix(`containerID="abc" escalatedFor="1h0m0s"`))
+	})
+
+	It("reverts after the duration", func() {
+		for i := 0; i < 2; i++ {
+			_ = ErrorStructured(errorMsg, "containerID", "abc")
+		}
+		now = now.Add(59 * time.Minute)
+		DebugStructured("escalated", "containerID", "abc")
+		now = now.Add(time.Minute)
+		DebugStructured("reverted", "containerID", "abc")
+
+		Expect(messages()).To(ContainElement("escalated"))
+		Expect(messages()).NotTo(ContainElement("reverted"))
+	})
+
+	It("does not escalate errors spread over several windows", func() {
+		_ = ErrorStructured(errorMsg, "containerID", "abc")
+		now = now.Add(time.Minute)
+		_ = ErrorStructured(errorMsg, "containerID", "abc")
+		DebugStructured(debugMsg, "containerID", "abc")
+
+		Expect(messages()).To(Equal([]string{errorMsg, errorMsg}))
+	})
+
+	It("escalates printf style messages with the CNI context", func() {
+		Expect(os.Setenv("CNI_CONTAINERID", "abc")).To(Succeed())
+		DeferCleanup(os.Unsetenv, "CNI_CONTAINERID")
+		EnableCNIContext()
+		loadSnapshot().escalator.now = func() time.Time { return now }
+
+		_ = Errorf(errorMsg)
+		_ = Errorf(errorMsg)
+		Debugf(debugMsg)
+		Expect(messages()).To(Equal([]string{errorMsg, errorMsg, escalatedMsg, debugMsg}))
+	})
+
+	It("keeps the level of outputs with a level of their own", func() {
+		var file bytes.Buffer
+		SetOutput(&file)
+		SetFileLogLevel(InfoLevel)
+		loadSnapshot().escalator.now = func() time.Time { return now }
+
+		for i := 0; i < 2; i++ {
+			_ = ErrorStructured(errorMsg, "containerID", "abc")
+		}
+		DebugStructured(debugMsg, "containerID", "abc")
+		Expect(messages()).To(ContainElement(debugMsg))
+		Expect(file.String()).To(ContainSubstring(fmt.Sprintf("msg=%q", escalatedMsg)))
+		Expect(file.String()).NotTo(ContainSubstring(debugMsg))
+	})
+
+	It("is disabled by nil", func() {
+		SetEscalation(nil)
+		for i := 0; i < 2; i++ {
+			_ = ErrorStructured(errorMsg, "containerID", "abc")
+		}
+		DebugStructured(debugMsg, "containerID", "abc")
+		Expect(messages()).To(Equal([]string{errorMsg, errorMsg}))
+	})
+})
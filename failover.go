@@ -0,0 +1,233 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	defaultRetryInterval = 10 * time.Second
+
+	lostMessagesMsg = "cni-log: messages were lost while the log file could not be written"
+)
+
+// FallbackOptions configures the fallback of the log file, see SetFileFallback.
+type FallbackOptions struct {
+	// Sink receives the messages which cannot be written to the log file, stderr if nil.
+	Sink Sink
+	// RetryInterval is the time after a failure of the log file during which messages are only written to Sink, before
+	// the log file is tried again, ten seconds by default.
+	RetryInterval time.Duration
+	// BufferSize is the number of messages which are kept in memory while the log file fails, e.g. while its
+	// filesystem is remounted read-only. They are written to the log file once it works again, followed by a message
+	// with the number of messages which were lost because the buffer was full. The messages are written to Sink as
+	// well. 0 disables the buffer, which is the default.
+	BufferSize int
+}
+
+// fallback keeps track of the failures of the log file. It outlives the sinks of a snapshot, so that the log file is
+// not retried early whenever the configuration changes. It is safe for concurrent use.
+type fallback struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	options FallbackOptions
+	// failed is the time of the last failure of the log file, zero while it works.
+	failed time.Time
+	// buffered are the entries kept while the log file fails, dropped the number of entries which did not fit.
+	buffered []Entry
+	dropped  int
+}
+
+// fallbackSink writes entries to primary, or to secondary while primary fails. A nil secondary drops the entries,
+// because they reach stderr anyway.
+type fallbackSink struct {
+	primary   Sink
+	secondary Sink
+	state     *fallback
+}
+
+// failoverSink writes entries to the first of its sinks which accepts them.
+type failoverSink struct {
+	sinks []Sink
+}
+
+// FailoverSink returns a sink which writes entries to primary only. The secondary sinks receive an entry only if all
+// sinks before them failed to write it, e.g. a remote collector which receives messages only while the log file cannot
+// be written. The returned sink fails if all sinks failed, with the error of the last one.
+func FailoverSink(primary Sink, secondaries ...Sink) Sink {
+	sinks := make([]Sink, 0, 1+len(secondaries))
+	for _, s := range append([]Sink{primary}, secondaries...) {
+		if s != nil {
+			sinks = append(sinks, s)
+		}
+	}
+	return &failoverSink{sinks: sinks}
+}
+
+// Write implements the Sink interface.
+func (s *failoverSink) Write(entry Entry) error {
+	var err error
+	for _, sink := range s.sinks {
+		if err = sink.Write(entry); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// Flush flushes all sinks which buffer messages.
+func (s *failoverSink) Flush() error {
+	var err error
+	for _, sink := range s.sinks {
+		if flushErr := flushWriter(sink); flushErr != nil {
+			err = flushErr
+		}
+	}
+	return err
+}
+
+// SetStderrFailover makes stderr a secondary output of the log file: while enabled, messages are only written to stderr
+// if writing them to the log file or the output set with SetOutput fails, e.g. because the disk is full. Without a log
+// file, messages are written to stderr as usual. Failures are only detected with synchronous logging, see SetAsync.
+func SetStderrFailover(enable bool) {
+	mu.Lock()
+	defer unlockAndPublish()
+	stderrFailover = enable
+}
+
+// SetFileFallback writes the messages which cannot be written to the log file or the output set with SetOutput, e.g.
+// because the filesystem is read-only or the disk is full, to stderr or to options.Sink instead. After a failure, the
+// log file is only tried again once options.RetryInterval passed, messages are written to the fallback in the meantime.
+// With options.BufferSize, the messages are also kept in memory and written to the log file once it works again.
+// If stderr is an output anyway, the default fallback does not write messages to it twice. Passing nil disables the
+// fallback, which is the default, and discards the buffered messages. Failures are only detected with synchronous
+// logging, see SetAsync.
+func SetFileFallback(options *FallbackOptions) {
+	mu.Lock()
+	defer unlockAndPublish()
+
+	reportPressure(pressureFallback, 0, 0)
+	if options == nil {
+		fileFallback = nil
+		return
+	}
+	o := *options
+	if o.RetryInterval <= 0 {
+		o.RetryInterval = defaultRetryInterval
+	}
+	fileFallback = &fallback{now: time.Now, options: o}
+}
+
+// withFallback returns file with the fallback set with SetFileFallback, if any. The caller must hold mu.
+func withFallback(file Sink) Sink {
+	if fileFallback == nil {
+		return file
+	}
+	secondary := fileFallback.options.Sink
+	if secondary == nil && (!logToStderr || stderrFailover) {
+		secondary = &writerSink{out: stderrWriter{}, formatter: stderrFormatter, fields: stderrFields, ascii: asciiOnly,
+			maxSize: maxEntrySize, name: statsStderr}
+	}
+	return &fallbackSink{primary: file, secondary: secondary, state: fileFallback}
+}
+
+// Write implements the Sink interface.
+func (s *fallbackSink) Write(entry Entry) error {
+	if s.state.retry() {
+		if s.state.writeBuffered(s.primary) == nil && s.primary.Write(entry) == nil {
+			s.state.setFailed(time.Time{})
+			return nil
+		}
+		s.state.setFailed(s.state.now())
+	}
+	s.state.buffer(entry)
+	if s.secondary == nil {
+		return nil
+	}
+	return s.secondary.Write(entry)
+}
+
+// Flush flushes both sinks if they buffer messages.
+func (s *fallbackSink) Flush() error {
+	err := flushWriter(s.primary)
+	if s.secondary != nil {
+		if flushErr := flushWriter(s.secondary); flushErr != nil {
+			err = flushErr
+		}
+	}
+	return err
+}
+
+// retry returns true if the primary sink should be written to: while it works, and once the retry interval passed
+// after a failure.
+func (f *fallback) retry() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.failed.IsZero() || f.now().Sub(f.failed) >= f.options.RetryInterval
+}
+
+// setFailed records the time of a failure of the primary sink, zero if it worked.
+func (f *fallback) setFailed(failed time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failed = failed
+}
+
+// buffer keeps entry until the primary sink works again, or counts it as lost if the buffer is full.
+func (f *fallback) buffer(entry Entry) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.options.BufferSize <= 0 {
+		return
+	}
+	if len(f.buffered) >= f.options.BufferSize {
+		f.dropped++
+		return
+	}
+	f.buffered = append(f.buffered, entry)
+	reportPressure(pressureFallback, len(f.buffered), f.options.BufferSize)
+}
+
+// writeBuffered writes the buffered entries to primary, followed by a warning with the number of lost entries if the
+// buffer was full. Entries which could not be written stay buffered.
+func (f *fallback) writeBuffered(primary Sink) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.options.BufferSize > 0 {
+		defer func() { reportPressure(pressureFallback, len(f.buffered), f.options.BufferSize) }()
+	}
+
+	for len(f.buffered) > 0 {
+		if err := primary.Write(f.buffered[0]); err != nil {
+			return err
+		}
+		f.buffered[0] = Entry{}
+		f.buffered = f.buffered[1:]
+	}
+	f.buffered = nil
+	if f.dropped > 0 {
+		t := f.now()
+		marker := NewEntry(t, WarningLevel, lostMessagesMsg, "time", t.Format(defaultTimestampFormat),
+			"level", WarningLevel, "msg", lostMessagesMsg, "lost", f.dropped)
+		if err := primary.Write(marker); err != nil {
+			return err
+		}
+		f.dropped = 0
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+// failingWriter fails every write while failing is set.
+type failingWriter struct {
+	buf     bytes.Buffer
+	failing bool
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.failing {
+		return 0, errors.New("no space left on device")
+	}
+	return w.buf.Write(p)
+}
+
+var _ = Describe("Failover", func() {
+	BeforeEach(func() {
+		initLogger()
+	})
+
+	It("writes to secondary sinks only while the primary sink fails", func() {
+		primary, secondary := &captureSink{}, &captureSink{}
+		failing := true
+		AddSink(FailoverSink(SinkFunc(func(entry Entry) error {
+			if failing {
+				return errors.New("unavailable")
+			}
+			return primary.Write(entry)
+		}), secondary))
+		SetLogStderr(false)
+
+		InfoStructured(infoMsg)
+		failing = false
+		WarningStructured(warningMsg)
+		Expect(secondary.entries).To(HaveLen(1))
+		Expect(secondary.entries[0].Message).To(Equal(infoMsg))
+		Expect(primary.entries).To(HaveLen(1))
+		Expect(primary.entries[0].Message).To(Equal(warningMsg))
+	})
+
+	It("reports the error of the last sink if all sinks fail", func() {
+		sink := FailoverSink(SinkFunc(func(Entry) error { return errors.New("first") }),
+			SinkFunc(func(Entry) error { return errors.New("second") }))
+		Expect(sink.Write(Entry{Message: infoMsg})).To(MatchError("second"))
+	})
+
+	It("writes to stderr only while the log file fails", func() {
+		out := &failingWriter{}
+		SetOutput(out)
+		SetStderrFailover(true)
+
+		Expect(captureStdErrEvent(Infof, infoMsg)).To(BeEmpty())
+		Expect(out.buf.String()).To(ContainSubstring(infoMsg))
+
+		out.failing = true
+		Expect(captureStdErrEvent(Warningf, warningMsg)).To(ContainSubstring(warningMsg))
+	})
+
+	It("writes to stderr and the log file if failover is disabled", func() {
+		out := &failingWriter{}
+		SetOutput(out)
+		Expect(captureStdErrEvent(Infof, infoMsg)).To(ContainSubstring(infoMsg))
+		Expect(out.buf.String()).To(ContainSubstring(infoMsg))
+	})
+	Describe("file fallback", func() {
+		var out *failingWriter
+		var now time.Time
+
+		BeforeEach(func() {
+			out = &failingWriter{failing: true}
+			SetOutput(out)
+			SetLogStderr(false)
+			SetFileFallback(&FallbackOptions{RetryInterval: time.Minute})
+			now = time.Now()
+			fileFallback.now = func() time.Time { return now }
+		})
+
+		It("writes to stderr while the log file fails", func() {
+			Expect(captureStdErrEvent(Infof, infoMsg)).To(ContainSubstring(infoMsg))
+			out.failing = false
+			Expect(captureStdErrEvent(Warningf, warningMsg)).To(ContainSubstring(warningMsg))
+			Expect(out.buf.String()).To(BeEmpty())
+
+			now = now.Add(time.Minute)
+			Expect(captureStdErrEvent(Warningf, warningMsg)).To(BeEmpty())
+			Expect(out.buf.String()).To(ContainSubstring(warningMsg))
+		})
+
+		It("writes to the fallback sink", func() {
+			sink := &captureSink{}
+			SetFileFallback(&FallbackOptions{Sink: sink})
+			var errs []error
+			SetErrorHandler(func(err error) { errs = append(errs, err) })
+
+			InfoStructured(infoMsg)
+			Expect(sink.entries).To(HaveLen(1))
+			Expect(sink.entries[0].Message).To(Equal(infoMsg))
+			Expect(errs).To(BeEmpty())
+		})
+
+		It("writes the buffered messages to the log file once it works again", func() {
+			SetFileFallback(&FallbackOptions{RetryInterval: time.Minute, BufferSize: 2})
+			fileFallback.now = func() time.Time { return now }
+
+			_ = captureStdErrEvent(Infof, "first")
+			_ = captureStdErrEvent(Infof, "second")
+			_ = captureStdErrEvent(Infof, "third")
+			out.failing = false
+			now = now.Add(time.Minute)
+			Expect(captureStdErrEvent(Warningf, warningMsg)).To(BeEmpty())
+
+			lines := strings.Split(strings.TrimSpace(out.buf.String()), "\n")
+			Expect(lines).To(HaveLen(4))
+			Expect(lines[0]).To(ContainSubstring("first"))
+			Expect(lines[1]).To(ContainSubstring("second"))
+			Expect(lines[2]).To(ContainSubstring(lostMessagesMsg))
+			Expect(lines[2]).To(ContainSubstring(`lost="1"`))
+			Expect(lines[3]).To(ContainSubstring(warningMsg))
+		})
+
+		It("keeps the buffered messages while the log file fails", func() {
+			SetFileFallback(&FallbackOptions{RetryInterval: time.Minute, BufferSize: 10})
+			fileFallback.now = func() time.Time { return now }
+
+			_ = captureStdErrEvent(Infof, "first")
+			now = now.Add(time.Minute)
+			_ = captureStdErrEvent(Infof, "second")
+			Expect(fileFallback.buffered).To(HaveLen(2))
+
+			out.failing = false
+			now = now.Add(time.Minute)
+			Expect(captureStdErrEvent(Warningf, warningMsg)).To(BeEmpty())
+			Expect(out.buf.String()).To(ContainSubstring("first"))
+			Expect(out.buf.String()).To(ContainSubstring("second"))
+			Expect(out.buf.String()).To(ContainSubstring(warningMsg))
+			Expect(out.buf.String()).NotTo(ContainSubstring(lostMessagesMsg))
+			Expect(fileFallback.buffered).To(BeEmpty())
+		})
+
+		It("does not write to stderr twice", func() {
+			SetLogStderr(true)
+			Expect(strings.Count(captureStdErrEvent(Infof, infoMsg), infoMsg)).To(Equal(1))
+		})
+
+		It("keeps the level of the log file", func() {
+			SetLogLevel(DebugLevel)
+			SetFileLogLevel(InfoLevel)
+			Expect(captureStdErrEvent(Debugf, debugMsg)).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,169 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"reflect"
+	"time"
+)
+
+const (
+	errorKey = "error"
+	causeKey = "cause"
+)
+
+// Field is a typed key/value pair of a structured message, created with String, Int, Bool, Float64, Duration, Time, Err
+// or Any. The *Fields logging functions take fields instead of alternating keys and values, so that a missing key or
+// value is a compile time error instead of a malformed message.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// String returns a field with a string value.
+func String(key, value string) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Int returns a field with an integer value.
+func Int(key string, value int) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Int64 returns a field with a 64 bit integer value.
+func Int64(key string, value int64) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Bool returns a field with a boolean value.
+func Bool(key string, value bool) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Float64 returns a field with a floating point value.
+func Float64(key string, value float64) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Duration returns a field with a duration value, which is rendered like "1.5s" by all formatters.
+func Duration(key string, value time.Duration) Field {
+	return Field{Key: key, Value: value.String()}
+}
+
+// Time returns a field with a timestamp value, which is rendered in the same format as the time of the message.
+func Time(key string, value time.Time) Field {
+	return Field{Key: key, Value: value.Format(defaultTimestampFormat)}
+}
+
+// Err returns an "error" field with the message of err. A nil err, including a nil pointer stored in the error
+// interface, is rendered as "<nil>" instead of panicking when the message is formatted.
+func Err(err error) Field {
+	if err == nil {
+		return Field{Key: errorKey, Value: "<nil>"}
+	}
+	if v := reflect.ValueOf(err); v.Kind() == reflect.Ptr && v.IsNil() {
+		return Field{Key: errorKey, Value: "<nil>"}
+	}
+	return Field{Key: errorKey, Value: err.Error()}
+}
+
+// Any returns a field with an arbitrary value, e.g. a struct or a LazyValue.
+func Any(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// fieldArgs returns fields as alternating keys and values.
+func fieldArgs(fields []Field) []interface{} {
+	args := make([]interface{}, 0, 2*len(fields))
+	for _, f := range fields {
+		args = append(args, f.Key, f.Value)
+	}
+	return args
+}
+
+// FatalFields works like FatalStructured, but takes typed fields.
+func FatalFields(msg string, fields ...Field) {
+	FatalStructured(msg, fieldArgs(fields)...)
+}
+
+// PanicFields works like PanicStructured, but takes typed fields.
+func PanicFields(msg string, fields ...Field) {
+	PanicStructured(msg, fieldArgs(fields)...)
+}
+
+// ErrorFields works like ErrorStructured, but takes typed fields.
+func ErrorFields(msg string, fields ...Field) error {
+	return errorStructured(loadSnapshot(), msg, fieldArgs(fields)...)
+}
+
+// WarningFields works like WarningStructured, but takes typed fields.
+func WarningFields(msg string, fields ...Field) {
+	printStructured(WarningLevel, msg, fieldArgs(fields)...)
+}
+
+// InfoFields works like InfoStructured, but takes typed fields.
+func InfoFields(msg string, fields ...Field) {
+	printStructured(InfoLevel, msg, fieldArgs(fields)...)
+}
+
+// DebugFields works like DebugStructured, but takes typed fields.
+func DebugFields(msg string, fields ...Field) {
+	printStructured(DebugLevel, msg, fieldArgs(fields)...)
+}
+
+// TraceFields works like TraceStructured, but takes typed fields.
+func TraceFields(msg string, fields ...Field) {
+	printStructured(TraceLevel, msg, fieldArgs(fields)...)
+}
+
+// WithTyped returns a Logger which adds fields to every structured message, in addition to the context of l.
+func (l *Logger) WithTyped(fields ...Field) *Logger {
+	return l.With(fieldArgs(fields)...)
+}
+
+// FatalFields works like FatalStructured, but takes typed fields.
+func (l *Logger) FatalFields(msg string, fields ...Field) {
+	l.FatalStructured(msg, fieldArgs(fields)...)
+}
+
+// PanicFields works like PanicStructured, but takes typed fields.
+func (l *Logger) PanicFields(msg string, fields ...Field) {
+	l.PanicStructured(msg, fieldArgs(fields)...)
+}
+
+// ErrorFields works like ErrorStructured, but takes typed fields.
+func (l *Logger) ErrorFields(msg string, fields ...Field) error {
+	return l.ErrorStructured(msg, fieldArgs(fields)...)
+}
+
+// WarningFields works like WarningStructured, but takes typed fields.
+func (l *Logger) WarningFields(msg string, fields ...Field) {
+	l.WarningStructured(msg, fieldArgs(fields)...)
+}
+
+// InfoFields works like InfoStructured, but takes typed fields.
+func (l *Logger) InfoFields(msg string, fields ...Field) {
+	l.InfoStructured(msg, fieldArgs(fields)...)
+}
+
+// DebugFields works like DebugStructured, but takes typed fields.
+func (l *Logger) DebugFields(msg string, fields ...Field) {
+	l.DebugStructured(msg, fieldArgs(fields)...)
+}
+
+// TraceFields works like TraceStructured, but takes typed fields.
+func (l *Logger) TraceFields(msg string, fields ...Field) {
+	l.TraceStructured(msg, fieldArgs(fields)...)
+}
@@ -0,0 +1,62 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+type pointerError struct{}
+
+func (e *pointerError) Error() string { return "pointer error" }
+
+var _ = Describe("Typed fields", func() {
+	var sink *captureSink
+
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+		SetLogLevel(TraceLevel)
+		sink = &captureSink{}
+		AddSink(sink)
+	})
+
+	It("logs typed fields like alternating keys and values", func() {
+		InfoFields(infoMsg, String("ifname", "net1"), Int("mtu", 1500), Bool("up", true), Float64("ratio", 0.5))
+		InfoStructured(infoMsg, "ifname", "net1", "mtu", 1500, "up", true, "ratio", 0.5)
+		Expect(sink.entries[0].Fields[6:]).To(Equal(sink.entries[1].Fields[6:]))
+		Expect(sink.entries[0].String()).To(HaveSuffix(`ifname="net1" mtu="1500" up="true" ratio="0.5"`))
+	})
+
+	It("renders durations, timestamps and errors as strings", func() {
+		out := &bytes.Buffer{}
+		AddOutput(out)
+		SetFileFormatter(JSONFormatter{})
+		ts := time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC)
+
+		WarningFields(warningMsg, Duration("elapsed", 1500*time.Millisecond), Time("since", ts), Err(errors.New("timeout")))
+		Expect(out.String()).To(ContainSubstring(`"elapsed":"1.5s","since":"2022-05-01T12:00:00Z","error":"timeout"`))
+	})
+
+	It("does not panic on nil errors", func() {
+		var typedNil *pointerError
+		var err error = typedNil
+		Expect(Err(nil)).To(Equal(Field{Key: "error", Value: "<nil>"}))
+		Expect(Err(err)).To(Equal(Field{Key: "error", Value: "<nil>"}))
+	})
+
+	It("returns the message from ErrorFields", func() {
+		err := ErrorFields(errorMsg, String("pod", "pod-a"))
+		Expect(err).To(MatchError(MatchRegexp(fmt.Sprintf(`msg=%q pod="pod-a"$`, errorMsg))))
+	})
+
+	It("adds typed fields to loggers", func() {
+		l := With("pod", "pod-a").WithTyped(String("containerID", "abc"))
+		l.DebugFields(debugMsg, Any("lazy", Lazy(func() interface{} { return 42 })))
+		Expect(sink.entries[0].String()).To(HaveSuffix(`pod="pod-a" containerID="abc" lazy="42"`))
+	})
+})
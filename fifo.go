@@ -0,0 +1,117 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	fifoRetryInterval = time.Second
+	fifoWriteTimeout  = 100 * time.Millisecond
+
+	notFIFOFailMsg = "cni-log: '%s' exists and is not a named pipe"
+)
+
+// errFIFONotConnected is returned by writes while no reader has the named pipe open.
+var errFIFONotConnected = errors.New("cni-log: named pipe has no reader")
+
+// FIFOWriter writes to a named pipe without ever blocking the plugin on a missing or slow reader, see OpenFIFO.
+type FIFOWriter struct {
+	path          string
+	retryInterval time.Duration
+
+	mu          sync.Mutex
+	f           *os.File
+	lastAttempt time.Time
+}
+
+// OpenFIFO returns a writer for the named pipe at path, creating the pipe if it does not exist, e.g. for a sidecar
+// which reads the logs of the plugin through a pipe in a shared emptyDir volume. Add it with AddOutput. The pipe is
+// opened without blocking: while there is no reader, writes fail and messages are dropped, and the writer reconnects
+// at most once per second. When the reader goes away, the writer reconnects once a new reader opens the pipe. Writes
+// which cannot be completed within 100ms because the reader is too slow fail as well. Named pipes are only supported
+// on Unix.
+func OpenFIFO(path string) (*FIFOWriter, error) {
+	info, err := os.Stat(path)
+	switch {
+	case os.IsNotExist(err):
+		if err := mkfifo(path); err != nil && !os.IsExist(err) {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	case info.Mode()&os.ModeNamedPipe == 0:
+		return nil, fmt.Errorf(notFIFOFailMsg, path)
+	}
+
+	w := &FIFOWriter{path: path, retryInterval: fifoRetryInterval}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_ = w.connect()
+	return w, nil
+}
+
+// Write implements io.Writer.
+func (w *FIFOWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.f == nil {
+		if time.Since(w.lastAttempt) < w.retryInterval {
+			return 0, errFIFONotConnected
+		}
+		if err := w.connect(); err != nil {
+			return 0, err
+		}
+	}
+
+	_ = w.f.SetWriteDeadline(time.Now().Add(fifoWriteTimeout))
+	n, err := w.f.Write(p)
+	if err != nil && !errors.Is(err, os.ErrDeadlineExceeded) {
+		// The reader went away.
+		w.f.Close()
+		w.f = nil
+	}
+	return n, err
+}
+
+// Close closes the pipe.
+func (w *FIFOWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.f == nil {
+		return nil
+	}
+	err := w.f.Close()
+	w.f = nil
+	return err
+}
+
+// connect opens the pipe for writing. It fails if there is no reader. The caller must hold w.mu.
+func (w *FIFOWriter) connect() error {
+	w.lastAttempt = time.Now()
+	f, err := openFIFO(w.path)
+	if err != nil {
+		return errFIFONotConnected
+	}
+	w.f = f
+	return nil
+}
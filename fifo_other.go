@@ -0,0 +1,35 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package logging
+
+import (
+	"errors"
+	"os"
+)
+
+// errFIFOUnsupported is returned by OpenFIFO on platforms without named pipes.
+var errFIFOUnsupported = errors.New("cni-log: named pipes are not supported on this platform")
+
+// mkfifo is not supported on this platform.
+func mkfifo(string) error {
+	return errFIFOUnsupported
+}
+
+// openFIFO is not supported on this platform.
+func openFIFO(string) (*os.File, error) {
+	return nil, errFIFOUnsupported
+}
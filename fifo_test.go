@@ -0,0 +1,73 @@
+//go:build linux
+
+package logging
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"syscall"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Named pipe output", func() {
+	var dir, fifo string
+
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+		var err error
+		dir, err = os.MkdirTemp("", "cni-log-fifo")
+		Expect(err).NotTo(HaveOccurred())
+		fifo = path.Join(dir, "plugin.log")
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	openReader := func() *os.File {
+		r, err := os.OpenFile(fifo, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+		Expect(err).NotTo(HaveOccurred())
+		return r
+	}
+
+	It("drops messages while there is no reader and reconnects", func() {
+		w, err := OpenFIFO(fifo)
+		Expect(err).NotTo(HaveOccurred())
+		defer w.Close()
+		w.retryInterval = 0
+		info, err := os.Stat(fifo)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode() & os.ModeNamedPipe).NotTo(BeZero())
+
+		AddOutput(w)
+		_, err = w.Write([]byte("dropped\n"))
+		Expect(err).To(MatchError(errFIFONotConnected))
+
+		r := openReader()
+		Infof(infoMsg)
+		line, err := bufio.NewReader(r).ReadString('\n')
+		Expect(err).NotTo(HaveOccurred())
+		Expect(line).To(HaveSuffix(infoMsg + "\n"))
+
+		r.Close()
+		_, err = w.Write([]byte("reader gone\n"))
+		Expect(err).To(HaveOccurred())
+
+		r = openReader()
+		defer r.Close()
+		Warningf(warningMsg)
+		line, err = bufio.NewReader(r).ReadString('\n')
+		Expect(err).NotTo(HaveOccurred())
+		Expect(line).To(HaveSuffix(warningMsg + "\n"))
+	})
+
+	It("rejects paths which are not named pipes", func() {
+		Expect(os.WriteFile(fifo, nil, 0600)).To(Succeed())
+		_, err := OpenFIFO(fifo)
+		Expect(err).To(MatchError(ContainSubstring("not a named pipe")))
+	})
+})
@@ -0,0 +1,77 @@
+package logging
+
+import (
+	"bytes"
+	"os"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Output formatting", func() {
+	var out bytes.Buffer
+
+	BeforeEach(func() {
+		initLogger()
+		out = bytes.Buffer{}
+		SetOutput(&out)
+		SetLogStderr(false)
+	})
+
+	When("a locale with different number and time formats is configured", func() {
+		BeforeEach(func() {
+			for _, key := range []string{"LANG", "LC_ALL", "LC_NUMERIC", "LC_TIME"} {
+				value, ok := os.LookupEnv(key)
+				Expect(os.Setenv(key, "de_DE.UTF-8")).To(Succeed())
+				if ok {
+					DeferCleanup(os.Setenv, key, value)
+				} else {
+					DeferCleanup(os.Unsetenv, key)
+				}
+			}
+		})
+
+		It("formats numbers independently of the locale", func() {
+			Infof("%d %.2f %v", 1234567, 1234.5, 0.25)
+			InfoStructured(infoMsg, "count", 1234567, "ratio", 1234.5)
+			Expect(out.String()).To(ContainSubstring("1234567 1234.50 0.25\n"))
+			Expect(out.String()).To(ContainSubstring(`count="1234567" ratio="1234.5"`))
+		})
+
+		It("formats the time independently of the locale", func() {
+			Infof(infoMsg)
+			InfoStructured(infoMsg)
+			timestamp := `\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})`
+			Expect(out.String()).To(MatchRegexp(`^` + timestamp + ` \[info\] ` + infoMsg + "\n"))
+			Expect(out.String()).To(MatchRegexp(`time="` + timestamp + `" level="info"`))
+		})
+	})
+
+	When("ASCII only output is enabled", func() {
+		BeforeEach(func() {
+			SetASCIIOnly(true)
+		})
+
+		It("escapes non-ASCII characters in messages", func() {
+			Infof("café \U0001F600 %s", string([]byte{0xff}))
+			Expect(out.String()).To(ContainSubstring(`caf\u00e9 \U0001f600 \xff` + "\n"))
+		})
+
+		It("escapes non-ASCII characters in structured values", func() {
+			InfoStructured("café", "ifname", "nét1")
+			Expect(out.String()).To(ContainSubstring(`msg="caf\u00e9" ifname="n\u00e9t1"`))
+		})
+
+		It("leaves ASCII output unchanged", func() {
+			Infof(infoMsg)
+			Expect(out.String()).To(HaveSuffix("[info] " + infoMsg + "\n"))
+		})
+	})
+
+	When("ASCII only output is disabled", func() {
+		It("writes UTF-8", func() {
+			Infof("café")
+			Expect(out.String()).To(ContainSubstring("café\n"))
+		})
+	})
+})
@@ -0,0 +1,213 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Formatter renders log entries. The rendered entry must not end with a newline, the outputs add it.
+type Formatter interface {
+	Format(entry Entry) []byte
+}
+
+// FormatterFunc is an adapter which allows the use of an ordinary function as a Formatter.
+type FormatterFunc func(Entry) []byte
+
+// Format implements the Formatter interface.
+func (f FormatterFunc) Format(entry Entry) []byte {
+	return f(entry)
+}
+
+// TextFormatter is the default formatter: messages of the printf style functions are prefixed by the configured
+// Prefixer, structured messages are rendered as key="value" pairs.
+type TextFormatter struct{}
+
+// Format implements the Formatter interface.
+func (TextFormatter) Format(entry Entry) []byte {
+	return []byte(entry.render(nil))
+}
+
+// LogfmtFormatter renders entries in logfmt: key=value pairs, values are only quoted if necessary. Messages of the
+// printf style functions are rendered with time, level and msg keys. Quoted values use the escape sequences of JSON
+// strings, and characters which are not allowed in keys are replaced, so that strict logfmt parsers accept the output.
+type LogfmtFormatter struct{}
+
+// Format implements the Formatter interface.
+func (LogfmtFormatter) Format(entry Entry) []byte {
+	var b bytes.Buffer
+	fields := formatterFields(entry)
+	for i := 0; i < len(fields)-1; i += 2 {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(logfmtKey(argToString(fields[i])))
+		b.WriteByte('=')
+		b.WriteString(logfmtValue(argToString(fields[i+1])))
+	}
+	return b.Bytes()
+}
+
+// invalidKeyRune returns true for the characters which are not allowed in logfmt keys: spaces, control characters,
+// equal signs, quotes and invalid UTF-8.
+func invalidKeyRune(r rune) bool {
+	return r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || !unicode.IsPrint(r)
+}
+
+// logfmtKey replaces the characters of key which are not allowed in logfmt keys with underscores. An empty key is
+// rendered as a single underscore.
+func logfmtKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+	if strings.IndexFunc(key, invalidKeyRune) < 0 {
+		return key
+	}
+
+	var b strings.Builder
+	for _, r := range key {
+		if invalidKeyRune(r) {
+			r = '_'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// logfmtValue quotes v if it is empty or contains characters which are not allowed in keys, see invalidKeyRune.
+func logfmtValue(v string) string {
+	if v != "" && strings.IndexFunc(v, invalidKeyRune) < 0 {
+		return v
+	}
+
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(v)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// JSONFormatter renders entries as JSON objects, keeping the order of the fields. Values are encoded as JSON if
+// possible, errors and values implementing fmt.Stringer as their string representation. Messages of the printf style
+// functions are rendered with time, level and msg keys.
+type JSONFormatter struct{}
+
+// Format implements the Formatter interface.
+func (JSONFormatter) Format(entry Entry) []byte {
+	var b bytes.Buffer
+	fields := formatterFields(entry)
+	b.WriteByte('{')
+	for i := 0; i < len(fields)-1; i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(argToString(fields[i]))
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(jsonValue(fields[i+1]))
+	}
+	b.WriteByte('}')
+	return b.Bytes()
+}
+
+// jsonValue encodes v as JSON. Values implementing json.Marshaler encode themselves, a json.Number is encoded as a
+// number.
+func jsonValue(v interface{}) []byte {
+	switch value := v.(type) {
+	case json.Marshaler, json.Number:
+	case error:
+		v = value.Error()
+	case fmt.Stringer:
+		v = value.String()
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(argToString(v))
+	}
+	return data
+}
+
+// formatterFields returns the fields of entry. Messages of the printf style functions get time, level and msg fields.
+func formatterFields(entry Entry) []interface{} {
+	if entry.structured {
+		return entry.Fields
+	}
+	fields := []interface{}{
+		"time", entry.Time.Format(defaultTimestampFormat),
+		"level", entry.Level,
+		"msg", entry.Message,
+	}
+	return append(fields, entry.extra...)
+}
+
+// formatEntry renders entry with f, restricted to the allowed fields unless allowed is nil. A nil formatter renders
+// the entry as text.
+func formatEntry(f Formatter, entry Entry, allowed map[string]bool) string {
+	if allowed != nil && entry.structured {
+		fields := make([]interface{}, 0, len(entry.Fields))
+		for i := 0; i < len(entry.Fields)-1; i += 2 {
+			if allowed[argToString(entry.Fields[i])] {
+				fields = append(fields, entry.Fields[i], entry.Fields[i+1])
+			}
+		}
+		entry.Fields = fields
+	}
+	if f == nil {
+		return entry.render(nil)
+	}
+	return string(f.Format(entry))
+}
+
+// NewWriterSink returns a sink which writes the entries rendered by f to out, one entry per line. A nil formatter
+// renders entries as text. It allows custom outputs to use the built-in formatters.
+func NewWriterSink(out io.Writer, f Formatter) Sink {
+	return &writerSink{out: out, formatter: f}
+}
+
+// SetFormatter sets the formatter of all outputs which write text: stderr, the log file, the custom outputs and
+// syslog. Passing nil restores the default TextFormatter.
+func SetFormatter(f Formatter) {
+	mu.Lock()
+	defer unlockAndPublish()
+	stderrFormatter, fileFormatter, syslogFormatter = f, f, f
+}
+
+// SetStderrFormatter sets the formatter of stderr. Passing nil restores the default TextFormatter.
+func SetStderrFormatter(f Formatter) {
+	mu.Lock()
+	defer unlockAndPublish()
+	stderrFormatter = f
+}
+
+// SetFileFormatter sets the formatter of the log file and the custom outputs. Passing nil restores the default
+// TextFormatter.
+func SetFileFormatter(f Formatter) {
+	mu.Lock()
+	defer unlockAndPublish()
+	fileFormatter = f
+}
+
+// SetSyslogFormatter sets the formatter of syslog. Passing nil restores the default TextFormatter.
+func SetSyslogFormatter(f Formatter) {
+	mu.Lock()
+	defer unlockAndPublish()
+	syslogFormatter = f
+}
@@ -0,0 +1,96 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Formatters", func() {
+	var out bytes.Buffer
+
+	BeforeEach(func() {
+		initLogger()
+		out = bytes.Buffer{}
+		SetOutput(&out)
+		SetLogStderr(false)
+	})
+
+	structured := Entry{
+		Level:      InfoLevel,
+		Message:    "hello world",
+		Fields:     []interface{}{"level", InfoLevel, "msg", "hello world", "count", 3, "empty", "", "err", errors.New("a=b")},
+		structured: true,
+	}
+	printf := Entry{
+		Time:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Level:   WarningLevel,
+		Message: `say "hi"`,
+		prefix:  "2024-01-02T03:04:05Z [warning] ",
+	}
+
+	It("renders entries as text", func() {
+		Expect(string(TextFormatter{}.Format(structured))).To(Equal(
+			`level="info" msg="hello world" count="3" empty="" err="a=b"`))
+		Expect(string(TextFormatter{}.Format(printf))).To(Equal(`2024-01-02T03:04:05Z [warning] say "hi"`))
+	})
+
+	It("renders entries as logfmt", func() {
+		Expect(string(LogfmtFormatter{}.Format(structured))).To(Equal(
+			`level=info msg="hello world" count=3 empty="" err="a=b"`))
+		Expect(string(LogfmtFormatter{}.Format(printf))).To(Equal(
+			`time=2024-01-02T03:04:05Z level=warning msg="say \"hi\""`))
+	})
+
+	It("quotes and escapes logfmt values only if necessary", func() {
+		entry := Entry{structured: true, Fields: []interface{}{
+			"path", "/var/run/netns/cni-1", "url", "http://x/?a&b", "line", "a\nb\tc", "ctl", "\x1b[31m", "bad", "\xff",
+		}}
+		Expect(string(LogfmtFormatter{}.Format(entry))).To(Equal(
+			`path=/var/run/netns/cni-1 url=http://x/?a&b line="a\nb\tc" ctl="\u001b[31m" bad="�"`))
+	})
+
+	It("replaces the characters which are not allowed in keys", func() {
+		entry := Entry{structured: true, Fields: []interface{}{"pod name", "a", "k=v", "b", `"q"`, "c", "", "d"}}
+		Expect(string(LogfmtFormatter{}.Format(entry))).To(Equal(`pod_name=a k_v=b _q_=c _=d`))
+		Expect(string(TextFormatter{}.Format(entry))).To(Equal(`pod_name="a" k_v="b" _q_="c" _="d"`))
+	})
+
+	It("renders entries as JSON", func() {
+		Expect(string(JSONFormatter{}.Format(structured))).To(Equal(
+			`{"level":"info","msg":"hello world","count":3,"empty":"","err":"a=b"}`))
+		Expect(string(JSONFormatter{}.Format(printf))).To(Equal(
+			`{"time":"2024-01-02T03:04:05Z","level":"warning","msg":"say \"hi\""}`))
+	})
+
+	It("uses the formatter of each output", func() {
+		SetFormatter(JSONFormatter{})
+		SetStderrFormatter(LogfmtFormatter{})
+		SetLogStderr(true)
+		SetFileFields("msg", "pod")
+
+		errStr := captureStdErrEvent(InfoStructured, infoMsg, "pod", "pod-a")
+		Expect(errStr).To(MatchRegexp(fmt.Sprintf(`^time=\S+ level=info msg=%q pod=pod-a\n$`, infoMsg)))
+		Expect(out.String()).To(Equal(fmt.Sprintf(`{"msg":%q,"pod":"pod-a"}`+"\n", infoMsg)))
+	})
+
+	It("restores the text formatter", func() {
+		SetFileFormatter(JSONFormatter{})
+		SetFileFormatter(nil)
+		InfoStructured(infoMsg)
+		Expect(out.String()).To(MatchRegexp(fmt.Sprintf(`^time=".*" level="info" msg=%q\n$`, infoMsg)))
+	})
+
+	It("formats custom writer sinks", func() {
+		var custom bytes.Buffer
+		AddSink(NewWriterSink(&custom, FormatterFunc(func(e Entry) []byte {
+			return []byte(e.Level.String() + ": " + e.Message)
+		})))
+		Warningf(warningMsg)
+		Expect(custom.String()).To(Equal(fmt.Sprintf("%s: %s\n", warningStr, warningMsg)))
+	})
+})
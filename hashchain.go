@@ -0,0 +1,189 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	hashChainKey = "prev_hash"
+	// hashChainLength is the length of the truncated hashes: 16 hex digits, 64 bits.
+	hashChainLength = 16
+
+	hashChainBrokenFailMsg = "cni-log: hash chain broken at line %d"
+)
+
+// hashChainStart is the prev_hash of the first line of a chain which does not continue an existing log file.
+var hashChainStart = strings.Repeat("0", hashChainLength)
+
+// hashChainPattern matches the prev_hash field in the text, logfmt and JSON formats.
+var hashChainPattern = regexp.MustCompile(`"?` + hashChainKey + `"?[=:]\s*"?([0-9a-f]{` +
+	fmt.Sprint(hashChainLength) + `})`)
+
+// hashChain is the state of the hash chain of the log file, see SetHashChain. It outlives the sinks, which are
+// recreated whenever the configuration changes.
+type hashChain struct {
+	mu sync.Mutex
+	// prev is the truncated hash of the last line written, empty until the chain was seeded.
+	prev string
+	// placeholder is rendered in place of the hash, which is only known once the previous line was written, so that
+	// entries can be split into chunks before the hashes are inserted.
+	placeholder string
+}
+
+// newHashChain returns a new hash chain.
+func newHashChain() *hashChain {
+	b := make([]byte, hashChainLength/2)
+	if _, err := rand.Read(b); err != nil {
+		return &hashChain{placeholder: strings.Repeat("x", hashChainLength)}
+	}
+	return &hashChain{placeholder: hex.EncodeToString(b)}
+}
+
+// write writes entry to the output of s with the hash of the line written before it. Lines are written one after
+// another so that the chain follows the order of the lines in the output. seed is the log file the chain continues, if
+// any.
+func (c *hashChain) write(s *writerSink, entry Entry, seed string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.prev == "" {
+		c.prev = lastLineHash(seed)
+	}
+	var err error
+	for _, line := range splitEntry(entry.withFields(hashChainKey, c.placeholder), s.maxSize, s.fields, s.render) {
+		if i := strings.LastIndex(line, c.placeholder); i >= 0 {
+			line = line[:i] + c.prev + line[i+len(c.placeholder):]
+		}
+		if writeErr := s.writeLine(line); writeErr != nil {
+			err = writeErr
+			continue
+		}
+		c.prev = chainHash([]byte(line + "\n"))
+	}
+	return err
+}
+
+// chainHash returns the truncated hash of a line.
+func chainHash(line []byte) string {
+	sum := sha256.Sum256(line)
+	return hex.EncodeToString(sum[:])[:hashChainLength]
+}
+
+// lastLineHash returns the truncated hash of the last line of filename, so that the chain continues across restarts,
+// or hashChainStart if there is none.
+func lastLineHash(filename string) string {
+	if filename == "" {
+		return hashChainStart
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return hashChainStart
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || info.Size() == 0 {
+		return hashChainStart
+	}
+
+	offset := info.Size() - megabyte
+	if offset < 0 {
+		offset = 0
+	}
+	tail := make([]byte, info.Size()-offset)
+	if _, err := f.ReadAt(tail, offset); err != nil {
+		return hashChainStart
+	}
+	if tail[len(tail)-1] != '\n' {
+		return hashChainStart
+	}
+	i := bytes.LastIndexByte(tail[:len(tail)-1], '\n')
+	if i < 0 && offset > 0 {
+		return hashChainStart
+	}
+	return chainHash(tail[i+1:])
+}
+
+// allowHashChain returns the field allow-list fields with the prev_hash field added.
+func allowHashChain(fields map[string]bool) map[string]bool {
+	if fields == nil {
+		return nil
+	}
+	allowed := make(map[string]bool, len(fields)+1)
+	for key := range fields {
+		allowed[key] = true
+	}
+	allowed[hashChainKey] = true
+	return allowed
+}
+
+// SetHashChain chains the lines of the log file, or of the custom output set with SetOutput, so that truncated or
+// altered logs can be detected after an incident: every line carries a prev_hash field with the first 16 hex digits of
+// the SHA-256 hash of the line before it, including its newline. The chain continues the last line of an existing log
+// file and continues across rotations, so rotated files can be verified in order by concatenating them. Verify logs
+// with VerifyHashChain. Entries are written one after another while the chain is enabled.
+func SetHashChain(enable bool) {
+	mu.Lock()
+	defer unlockAndPublish()
+	switch {
+	case !enable:
+		logHashChain = nil
+	case logHashChain == nil:
+		logHashChain = newHashChain()
+	}
+}
+
+// VerifyHashChain verifies the prev_hash fields of a log written with SetHashChain and returns an error with the number
+// of the first line which does not match the line before it. Lines before the first line with a prev_hash field are
+// skipped, as is the hash of that first line, which refers to a line of another file. Lines without a prev_hash field
+// after it, e.g. the continuation lines of a custom formatter, belong to the line before them. Note that lines removed
+// from the end of the log cannot be detected from the log alone.
+func VerifyHashChain(r io.Reader) error {
+	reader := bufio.NewReader(r)
+	var record hash.Hash
+	for n := 1; ; n++ {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			if m := hashChainPattern.FindAllSubmatch(line, -1); len(m) > 0 {
+				prev := string(m[len(m)-1][1])
+				if record != nil && prev != hex.EncodeToString(record.Sum(nil))[:hashChainLength] {
+					return fmt.Errorf(hashChainBrokenFailMsg, n)
+				}
+				record = sha256.New()
+			}
+			if record != nil {
+				record.Write(line)
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package logging
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Hash chain", func() {
+	var out bytes.Buffer
+
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+		out.Reset()
+		SetOutput(&out)
+		SetHashChain(true)
+	})
+
+	It("chains the lines", func() {
+		Infof(infoMsg)
+		WarningStructured(warningMsg, "a", "b")
+		Errorf(errorMsg)
+
+		lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+		Expect(lines).To(HaveLen(3))
+		Expect(lines[0]).To(HaveSuffix(`prev_hash="` + hashChainStart + `"`))
+		Expect(lines[1]).To(ContainSubstring(`prev_hash="` + chainHash([]byte(lines[0]+"\n")) + `"`))
+		Expect(lines[2]).To(ContainSubstring(`prev_hash="` + chainHash([]byte(lines[1]+"\n")) + `"`))
+		Expect(VerifyHashChain(&out)).To(Succeed())
+	})
+
+	It("detects altered and removed lines", func() {
+		for i := 0; i < 4; i++ {
+			Infof(infoMsg)
+		}
+		lines := strings.SplitAfter(out.String(), "\n")
+
+		altered := strings.Join(lines[:1], "") + strings.Replace(lines[1], infoMsg, warningMsg, 1) +
+			strings.Join(lines[2:], "")
+		Expect(VerifyHashChain(strings.NewReader(altered))).To(MatchError(ContainSubstring("line 3")))
+
+		removed := strings.Join(lines[:1], "") + strings.Join(lines[2:], "")
+		Expect(VerifyHashChain(strings.NewReader(removed))).To(MatchError(ContainSubstring("line 2")))
+	})
+
+	It("chains JSON lines and chunks", func() {
+		SetFileFormatter(JSONFormatter{})
+		SetMaxEntrySize(200)
+		InfoStructured(infoMsg, "long", strings.Repeat("x", 500))
+		Infof(infoMsg)
+
+		Expect(strings.Count(out.String(), `"prev_hash":`)).To(BeNumerically(">", 3))
+		Expect(VerifyHashChain(&out)).To(Succeed())
+	})
+
+	It("keeps the hash if the fields are restricted", func() {
+		SetFileFields("msg")
+		InfoStructured(infoMsg, "a", "b")
+		Expect(out.String()).To(ContainSubstring(hashChainKey))
+		Expect(out.String()).NotTo(ContainSubstring(`a="b"`))
+	})
+
+	It("skips the lines before the chain", func() {
+		out.WriteString("written before the chain\n")
+		Infof(infoMsg)
+		Infof(infoMsg)
+		Expect(VerifyHashChain(&out)).To(Succeed())
+	})
+
+	It("stops chaining when disabled", func() {
+		SetHashChain(false)
+		Infof(infoMsg)
+		Expect(out.String()).NotTo(ContainSubstring(hashChainKey))
+	})
+
+	When("logging to an existing log file", func() {
+		var logFile string
+
+		BeforeEach(func() {
+			logDir, err := os.MkdirTemp("", "cni-log-hashchain")
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(os.RemoveAll, logDir)
+			logFile = path.Join(logDir, "chain.log")
+			Expect(os.WriteFile(logFile, []byte("before\nlast\n"), 0644)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(logFileWriter.close()).To(Succeed())
+		})
+
+		It("continues the chain of the last line", func() {
+			SetLogFile(logFile)
+			Infof(infoMsg)
+			Infof(infoMsg)
+
+			Expect(logFileContains(logFile, `prev_hash="`+chainHash([]byte("last\n"))+`"`)).To(BeTrue())
+			f, err := os.Open(logFile)
+			Expect(err).NotTo(HaveOccurred())
+			defer f.Close()
+			Expect(VerifyHashChain(f)).To(Succeed())
+		})
+	})
+})
@@ -0,0 +1,80 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import "reflect"
+
+// Hook processes the entries of all log messages before they are written to the outputs and sinks, e.g. to enrich or
+// redact fields, to drop entries or to fan them out elsewhere, e.g. to metrics or Kubernetes events. Hooks receive the
+// entries of the printf style functions as well, with nil Fields. They must be safe for concurrent use.
+type Hook interface {
+	// Process returns the entry to write, or false to drop it. The Fields of entry may be shared with other hooks and
+	// the caller, so they must be copied before they are changed.
+	Process(entry Entry) (Entry, bool)
+}
+
+// HookFunc is an adapter which allows the use of an ordinary function as a Hook.
+type HookFunc func(Entry) (Entry, bool)
+
+// Process implements the Hook interface.
+func (f HookFunc) Process(entry Entry) (Entry, bool) {
+	return f(entry)
+}
+
+// AddHook registers a hook. Hooks run in the order they were added, after redaction, and each receives the entry
+// returned by the previous one. Once a hook drops an entry, the remaining hooks do not see it.
+func AddHook(hook Hook) {
+	if hook == nil {
+		return
+	}
+
+	mu.Lock()
+	defer unlockAndPublish()
+
+	// Log calls use the slice after releasing mu, so it is never modified in place.
+	h := make([]Hook, 0, len(hooks)+1)
+	hooks = append(append(h, hooks...), hook)
+}
+
+// RemoveHook removes a hook added with AddHook. Hooks are compared with ==, hooks of types which are not comparable,
+// e.g. a HookFunc, cannot be removed.
+func RemoveHook(hook Hook) {
+	if hook == nil || !reflect.TypeOf(hook).Comparable() {
+		return
+	}
+
+	mu.Lock()
+	defer unlockAndPublish()
+
+	h := make([]Hook, 0, len(hooks))
+	for _, existing := range hooks {
+		if reflect.TypeOf(existing).Comparable() && existing == hook {
+			continue
+		}
+		h = append(h, existing)
+	}
+	hooks = h
+}
+
+// runHooks passes entry through hooks. It returns false if a hook dropped the entry.
+func runHooks(hooks []Hook, entry Entry) (Entry, bool) {
+	for _, hook := range hooks {
+		var ok bool
+		if entry, ok = hook.Process(entry); !ok {
+			return entry, false
+		}
+	}
+	return entry, true
+}
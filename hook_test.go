@@ -0,0 +1,76 @@
+package logging
+
+import (
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+// levelDropHook drops the entries of a level.
+type levelDropHook struct {
+	level Level
+}
+
+func (h *levelDropHook) Process(entry Entry) (Entry, bool) {
+	return entry, entry.Level != h.level
+}
+
+var _ = Describe("Hooks", func() {
+	var sink *captureSink
+
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+		sink = &captureSink{}
+		AddSink(sink)
+	})
+
+	It("can change the fields of entries", func() {
+		AddHook(HookFunc(func(entry Entry) (Entry, bool) {
+			if entry.Structured() {
+				entry.Fields = append(entry.Fields[:len(entry.Fields):len(entry.Fields)], "node", "node-a")
+			}
+			return entry, true
+		}))
+		InfoStructured(infoMsg, "pod", "pod-a")
+		Expect(sink.entries[0].String()).To(HaveSuffix(`pod="pod-a" node="node-a"`))
+	})
+
+	It("runs the hooks in order and stops once an entry is dropped", func() {
+		var fanOut []string
+		AddHook(HookFunc(func(entry Entry) (Entry, bool) {
+			fanOut = append(fanOut, entry.Message)
+			return entry, true
+		}))
+		AddHook(&levelDropHook{level: WarningLevel})
+		AddHook(HookFunc(func(entry Entry) (Entry, bool) {
+			entry.Message += "!"
+			return entry, true
+		}))
+
+		Infof(infoMsg)
+		Warningf(warningMsg)
+		Expect(fanOut).To(Equal([]string{infoMsg, warningMsg}))
+		Expect(sink.entries).To(HaveLen(1))
+		Expect(sink.entries[0].Message).To(Equal(infoMsg + "!"))
+	})
+
+	It("receives redacted entries", func() {
+		Expect(SetRedaction(&RedactionOptions{Keys: []string{"password"}})).To(Succeed())
+		var seen Entry
+		AddHook(HookFunc(func(entry Entry) (Entry, bool) {
+			seen = entry
+			return entry, true
+		}))
+		InfoStructured(infoMsg, "password", "hunter2")
+		Expect(seen.String()).NotTo(ContainSubstring("hunter2"))
+	})
+
+	It("removes hooks", func() {
+		hook := &levelDropHook{level: InfoLevel}
+		AddHook(hook)
+		Infof(infoMsg)
+		RemoveHook(hook)
+		Infof(infoMsg)
+		Expect(sink.entries).To(HaveLen(1))
+	})
+})
@@ -0,0 +1,56 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+// SetJournald enables logging to the systemd journal in addition to, or, by disabling the other outputs, instead of
+// the log file and stderr. It is only supported on Linux. The PRIORITY of entries is mapped from the logging level
+// like the syslog severity, and the fields of structured log messages are forwarded as journal fields with upper case
+// names. identifier is used as SYSLOG_IDENTIFIER and defaults to the name of the executable.
+func SetJournald(identifier string) error {
+	w, err := newJournaldWriter(identifier)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer unlockAndPublish()
+
+	if journaldOutput != nil {
+		_ = journaldOutput.close()
+	}
+	journaldOutput = w
+	return nil
+}
+
+// DisableJournald disables logging to the systemd journal.
+func DisableJournald() error {
+	mu.Lock()
+	defer unlockAndPublish()
+
+	if journaldOutput == nil {
+		return nil
+	}
+	err := journaldOutput.close()
+	journaldOutput = nil
+	return err
+}
+
+// SetJournaldFields restricts the fields of structured log messages which are forwarded to the systemd journal to the
+// provided keys. Calling it without any keys forwards all fields again.
+func SetJournaldFields(keys ...string) {
+	mu.Lock()
+	defer unlockAndPublish()
+	journaldFields = fieldSet(keys)
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+
+package logging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const journaldConnectFailMsg = "cni-log: unable to connect to journald: %v"
+
+// journaldSocket is the socket of the native journald protocol.
+var journaldSocket = "/run/systemd/journal/socket"
+
+// journaldWriter writes log messages to the systemd journal using the native journald protocol.
+type journaldWriter struct {
+	mu         sync.Mutex
+	identifier string
+	conn       *net.UnixConn
+}
+
+// newJournaldWriter connects to journald.
+func newJournaldWriter(identifier string) (*journaldWriter, error) {
+	if identifier == "" {
+		identifier = filepath.Base(os.Args[0])
+	}
+
+	w := &journaldWriter{identifier: identifier}
+	if err := w.connect(); err != nil {
+		return nil, fmt.Errorf(journaldConnectFailMsg, err)
+	}
+	return w, nil
+}
+
+// connect (re)connects to journald. The caller must hold w.mu unless w is not shared yet.
+func (w *journaldWriter) connect() error {
+	if w.conn != nil {
+		w.conn.Close()
+		w.conn = nil
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: journaldSocket, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	w.conn = conn
+	return nil
+}
+
+// write sends a log message of the given level to journald. fields are forwarded as journal fields, restricted to
+// the keys in allowed unless it is nil. If the connection is broken, journald is reconnected once.
+func (w *journaldWriter) write(level Level, msg string, fields []interface{}, allowed map[string]bool) error {
+	var b bytes.Buffer
+	appendJournalField(&b, "MESSAGE", msg)
+	appendJournalField(&b, "PRIORITY", fmt.Sprint(syslogSeverity(level)))
+	appendJournalField(&b, "SYSLOG_IDENTIFIER", w.identifier)
+	for i := 0; i < len(fields)-1; i += 2 {
+		key := argToString(fields[i])
+		if allowed != nil && !allowed[key] {
+			continue
+		}
+		appendJournalField(&b, journalFieldName(key), argToString(fields[i+1]))
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn != nil {
+		if _, err := w.conn.Write(b.Bytes()); err == nil {
+			return nil
+		}
+	}
+
+	if err := w.connect(); err != nil {
+		return err
+	}
+	_, err := w.conn.Write(b.Bytes())
+	return err
+}
+
+// close closes the connection to journald.
+func (w *journaldWriter) close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+// appendJournalField appends a field in the native journald format. Values containing newlines are length-prefixed.
+func appendJournalField(b *bytes.Buffer, name, value string) {
+	if !strings.Contains(value, "\n") {
+		fmt.Fprintf(b, "%s=%s\n", name, value)
+		return
+	}
+
+	b.WriteString(name)
+	b.WriteByte('\n')
+	_ = binary.Write(b, binary.LittleEndian, uint64(len(value)))
+	b.WriteString(value)
+	b.WriteByte('\n')
+}
+
+// journalFieldName converts a key of a structured log message into a valid journal field name: upper case letters,
+// digits and underscores, not starting with an underscore or a digit.
+func journalFieldName(key string) string {
+	name := []byte(strings.ToUpper(key))
+	for i, c := range name {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			name[i] = '_'
+		}
+	}
+	if len(name) == 0 || name[0] == '_' || (name[0] >= '0' && name[0] <= '9') {
+		return "F" + string(name)
+	}
+	return string(name)
+}
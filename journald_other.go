@@ -0,0 +1,35 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !linux
+
+package logging
+
+import "errors"
+
+// journaldWriter is not supported on this platform.
+type journaldWriter struct{}
+
+// newJournaldWriter returns an error, journald is only available on Linux.
+func newJournaldWriter(identifier string) (*journaldWriter, error) {
+	return nil, errors.New("cni-log: journald is only supported on Linux")
+}
+
+func (w *journaldWriter) write(level Level, msg string, fields []interface{}, allowed map[string]bool) error {
+	return nil
+}
+
+func (w *journaldWriter) close() error {
+	return nil
+}
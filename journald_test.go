@@ -0,0 +1,114 @@
+//go:build linux
+
+package logging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"os"
+	"path"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Journald output", func() {
+	var conn net.PacketConn
+	var socket, originalSocket string
+
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+		SetLogLevel(DebugLevel)
+
+		var err error
+		socket = path.Join(os.TempDir(), "cni-log-test-journald.sock")
+		_ = os.Remove(socket)
+		conn, err = net.ListenPacket("unixgram", socket)
+		Expect(err).NotTo(HaveOccurred())
+		originalSocket = journaldSocket
+		journaldSocket = socket
+	})
+
+	AfterEach(func() {
+		Expect(DisableJournald()).To(Succeed())
+		journaldSocket = originalSocket
+		conn.Close()
+		_ = os.Remove(socket)
+	})
+
+	// readEntry reads one journal entry and parses its fields.
+	readEntry := func() map[string]string {
+		buf := make([]byte, 4096)
+		n, _, err := conn.ReadFrom(buf)
+		Expect(err).NotTo(HaveOccurred())
+
+		fields := map[string]string{}
+		data := buf[:n]
+		for len(data) > 0 {
+			nl := bytes.IndexByte(data, '\n')
+			Expect(nl).To(BeNumerically(">", 0))
+			line := string(data[:nl])
+			data = data[nl+1:]
+			if eq := strings.IndexByte(line, '='); eq >= 0 {
+				fields[line[:eq]] = line[eq+1:]
+				continue
+			}
+			length := binary.LittleEndian.Uint64(data[:8])
+			fields[line] = string(data[8 : 8+length])
+			data = data[8+length+1:]
+		}
+		return fields
+	}
+
+	It("sends entries with the mapped priority", func() {
+		Expect(SetJournald("cni-test")).To(Succeed())
+
+		Warningf(warningMsg)
+		entry := readEntry()
+		Expect(entry).To(HaveKeyWithValue("PRIORITY", "4"))
+		Expect(entry).To(HaveKeyWithValue("SYSLOG_IDENTIFIER", "cni-test"))
+		Expect(entry["MESSAGE"]).To(HaveSuffix("[warning] " + warningMsg))
+
+		Debugf(debugMsg)
+		Expect(readEntry()).To(HaveKeyWithValue("PRIORITY", "7"))
+	})
+
+	It("forwards structured arguments as journal fields", func() {
+		Expect(SetJournald("cni-test")).To(Succeed())
+
+		InfoStructured(infoMsg, "pod", "default/nginx", "if-name", "eth0", "multi", "a\nb")
+		entry := readEntry()
+		Expect(entry).To(HaveKeyWithValue("PRIORITY", "6"))
+		Expect(entry).To(HaveKeyWithValue("MESSAGE", infoMsg))
+		Expect(entry).To(HaveKeyWithValue("LEVEL", infoStr))
+		Expect(entry).To(HaveKeyWithValue("POD", "default/nginx"))
+		Expect(entry).To(HaveKeyWithValue("IF_NAME", "eth0"))
+		Expect(entry).To(HaveKeyWithValue("MULTI", "a\nb"))
+	})
+
+	It("forwards only the selected fields", func() {
+		Expect(SetJournald("cni-test")).To(Succeed())
+		SetJournaldFields("pod")
+
+		InfoStructured(infoMsg, "pod", "default/nginx", "a", "b")
+		entry := readEntry()
+		Expect(entry).To(HaveKey("POD"))
+		Expect(entry).NotTo(HaveKey("A"))
+		Expect(entry).NotTo(HaveKey("LEVEL"))
+	})
+
+	It("converts keys into valid journal field names", func() {
+		Expect(journalFieldName("pod")).To(Equal("POD"))
+		Expect(journalFieldName("k8s.pod-name")).To(Equal("K8S_POD_NAME"))
+		Expect(journalFieldName("_cursor")).To(Equal("F_CURSOR"))
+		Expect(journalFieldName("1st")).To(Equal("F1ST"))
+	})
+
+	It("fails if journald is not available", func() {
+		journaldSocket = path.Join(os.TempDir(), "cni-log-test-missing.sock")
+		Expect(SetJournald("cni-test")).NotTo(Succeed())
+	})
+})
@@ -0,0 +1,126 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package klogbridge routes the messages of klog to the cni-log outputs and provides klog style logging functions. It
+// is a package of its own, so that only the users of the bridge link klog.
+package klogbridge
+
+import (
+	"flag"
+	"regexp"
+	"strings"
+
+	"k8s.io/klog/v2"
+
+	logging "github.com/k8snetworkplumbingwg/cni-log"
+)
+
+// klogHeader matches the header klog writes in front of its messages, e.g. "I1017 15:04:05.123456   12345 main.go:42] ".
+var klogHeader = regexp.MustCompile(`^[IWEF]\d{4} \d{2}:\d{2}:\d{2}\.\d{6}\s+\d+ [^\]]*\] `)
+
+// klogSeverities maps the klog severities to the cni-log levels.
+var klogSeverities = map[string]logging.Level{
+	"INFO":    logging.InfoLevel,
+	"WARNING": logging.WarningLevel,
+	"ERROR":   logging.ErrorLevel,
+	"FATAL":   logging.FatalLevel,
+}
+
+// klogWriter writes the messages klog writes for a severity to the cni-log outputs, see RedirectKlog.
+type klogWriter struct {
+	level logging.Level
+}
+
+// RedirectKlog installs cni-log as the output of klog, so that the messages of vendored Kubernetes libraries which log
+// through klog directly land in the same rotated log file as the messages of the plugin or daemon, instead of stderr.
+// The klog severities INFO, WARNING, ERROR and FATAL are logged at info, warning, error and fatal level, without the
+// klog header. It configures klog to write each message to the output of its own severity only and never to stderr
+// itself, see SetOutputBySeverity in klog; a fatal message is followed by the goroutine stacks klog dumps before it
+// exits the process. klog filters its verbosity itself, with -v. Loggers set with klog.SetLogger take precedence over
+// the outputs of klog, use NewLogr of the logr package of cni-log there instead.
+func RedirectKlog() {
+	flags := flag.NewFlagSet("klog", flag.ContinueOnError)
+	klog.InitFlags(flags)
+	for name, value := range map[string]string{
+		"logtostderr":     "false",
+		"alsologtostderr": "false",
+		"log_file":        "",
+		"log_dir":         "",
+		"one_output":      "true",
+		"skip_headers":    "false",
+		// Above FATAL, so that klog writes no severity to stderr.
+		"stderrthreshold": "4",
+	} {
+		_ = flags.Set(name, value)
+	}
+	for severity, level := range klogSeverities {
+		klog.SetOutputBySeverity(severity, klogWriter{level: level})
+	}
+}
+
+// Write implements io.Writer. klog writes every message in a single call.
+func (w klogWriter) Write(p []byte) (int, error) {
+	msg := string(p)
+	header := klogHeader.FindString(msg)
+	// klog writes the goroutine stacks after a fatal message to the outputs of all severities.
+	if header == "" && w.level != logging.FatalLevel {
+		return len(p), nil
+	}
+	logging.Logf(w.level, "%s", strings.TrimSuffix(msg[len(header):], "\n"))
+	if w.level == logging.FatalLevel {
+		// klog exits the process once the stacks are written.
+		logging.Flush()
+	}
+	return len(p), nil
+}
+
+// Verbose logs messages of a klog verbosity, see V.
+type Verbose struct {
+	level logging.Level
+}
+
+// V returns a Verbose which logs messages of the given klog verbosity at the level of logging.LevelFromVerbosity.
+// Together with InfoS and ErrorS it allows migrating klog call sites by replacing the package name:
+//
+//	klog.V(4).InfoS("adding route", "dst", dst)        // before
+//	klogbridge.V(4).InfoS("adding route", "dst", dst)  // after
+func V(verbosity int) Verbose {
+	return Verbose{level: logging.LevelFromVerbosity(verbosity)}
+}
+
+// Enabled returns true if messages of the verbosity of v are logged.
+func (v Verbose) Enabled() bool {
+	return logging.Enabled(v.level)
+}
+
+// InfoS provides structured logging at the verbosity of v.
+func (v Verbose) InfoS(msg string, keysAndValues ...interface{}) {
+	logging.LogStructured(v.level, msg, keysAndValues...)
+}
+
+// Infof provides printf style logging at the verbosity of v.
+func (v Verbose) Infof(format string, a ...interface{}) {
+	logging.Logf(v.level, format, a...)
+}
+
+// InfoS provides structured logging for log level >= info, like klog.InfoS.
+func InfoS(msg string, keysAndValues ...interface{}) {
+	logging.LogStructured(logging.InfoLevel, msg, keysAndValues...)
+}
+
+// ErrorS logs err with structured logging for log level >= error, like klog.ErrorS. A nil err is not logged in the
+// "error" field.
+func ErrorS(err error, msg string, keysAndValues ...interface{}) {
+	_ = logging.ErrorStructuredErr(err, msg, keysAndValues...)
+}
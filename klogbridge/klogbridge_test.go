@@ -0,0 +1,110 @@
+package klogbridge
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/klog/v2"
+
+	logging "github.com/k8snetworkplumbingwg/cni-log"
+)
+
+const (
+	infoMsg    = "Info message"
+	warningMsg = "Warning message"
+	errorMsg   = "Error message"
+	debugMsg   = "Debug message"
+	traceMsg   = "Trace message"
+)
+
+func TestKlogbridge(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "klogbridge Suite")
+}
+
+// captureStderr returns what f writes to stderr.
+func captureStderr(f func()) string {
+	r, w, err := os.Pipe()
+	Expect(err).NotTo(HaveOccurred())
+	stderr := os.Stderr
+	os.Stderr = w
+	f()
+	os.Stderr = stderr
+	Expect(w.Close()).To(Succeed())
+	out, err := io.ReadAll(r)
+	Expect(err).NotTo(HaveOccurred())
+	return string(out)
+}
+
+var _ = Describe("klog bridge", func() {
+	var out bytes.Buffer
+
+	lines := func() []string {
+		return strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	}
+
+	BeforeEach(func() {
+		out = bytes.Buffer{}
+		logging.SetOutput(&out)
+		logging.SetLogStderr(false)
+		logging.SetLogLevel(logging.InfoLevel)
+		RedirectKlog()
+		DeferCleanup(func() { logging.SetOutput(nil) })
+	})
+
+	It("maps the klog severities to levels without the klog header", func() {
+		errStr := captureStderr(func() {
+			klog.Info(infoMsg)
+			klog.Warningf("%s", warningMsg)
+			klog.ErrorS(nil, errorMsg, "dst", "10.0.0.0/8")
+		})
+		Expect(errStr).To(BeEmpty())
+
+		Expect(lines()).To(HaveLen(3))
+		Expect(lines()[0]).To(HaveSuffix(" [info] " + infoMsg))
+		Expect(lines()[1]).To(HaveSuffix(" [warning] " + warningMsg))
+		Expect(lines()[2]).To(HaveSuffix(` [error] "` + errorMsg + `" dst="10.0.0.0/8"`))
+	})
+
+	It("follows the cni-log level", func() {
+		logging.SetLogLevel(logging.WarningLevel)
+		klog.Info(infoMsg)
+		Expect(out.String()).To(BeEmpty())
+	})
+
+	It("ignores writes without a klog header below fatal", func() {
+		_, err := klogWriter{level: logging.InfoLevel}.Write([]byte("goroutine 1 [running]:\n"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.String()).To(BeEmpty())
+	})
+
+	It("provides klog style functions", func() {
+		logging.SetLogLevel(logging.TraceLevel)
+		InfoS(infoMsg, "a", "b")
+		V(4).InfoS(debugMsg)
+		V(6).Infof("%s", traceMsg)
+		ErrorS(errors.New("timeout"), errorMsg)
+
+		Expect(lines()).To(HaveLen(4))
+		Expect(lines()[0]).To(HaveSuffix(`level="info" msg="` + infoMsg + `" a="b"`))
+		Expect(lines()[1]).To(HaveSuffix(`level="debug" msg="` + debugMsg + `"`))
+		Expect(lines()[2]).To(HaveSuffix(" [trace] " + traceMsg))
+		Expect(lines()[3]).To(HaveSuffix(`level="error" msg="` + errorMsg + `" error="timeout"`))
+		Expect(V(6).Enabled()).To(BeTrue())
+		logging.SetLogLevel(logging.InfoLevel)
+		Expect(V(4).Enabled()).To(BeFalse())
+	})
+
+	It("reports the call site outside of the bridge", func() {
+		logging.EnableCallerInfo(0)
+		DeferCleanup(logging.DisableCallerInfo)
+		InfoS(infoMsg)
+		Expect(out.String()).To(ContainSubstring(`caller="klogbridge_test.go:`))
+	})
+})
@@ -0,0 +1,63 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import "fmt"
+
+// LazyValue is a value which is only computed when the log message it belongs to is written, see Lazy.
+type LazyValue struct {
+	f func() interface{}
+}
+
+// Lazy wraps an expensive value, e.g. a marshaled network configuration or a dump of the routes, so that it is only
+// computed if the message passes the level filter and the sampling and rate limits:
+//
+//	logging.DebugStructured("added routes", "routes", logging.Lazy(func() interface{} { return dumpRoutes() }))
+//
+// Structured messages compute the value once and pass the result to all outputs. In printf style messages the value is
+// formatted with %v. Values implementing fmt.Stringer are converted lazily as well, without a wrapper.
+func Lazy(f func() interface{}) LazyValue {
+	return LazyValue{f: f}
+}
+
+// Value computes the value.
+func (v LazyValue) Value() interface{} {
+	if v.f == nil {
+		return nil
+	}
+	return v.f()
+}
+
+// String implements fmt.Stringer, so that lazy values can be used in printf style messages as well.
+func (v LazyValue) String() string {
+	return fmt.Sprintf("%+v", v.Value())
+}
+
+// evaluateLazy returns args with all lazy values computed. args is only copied if it contains lazy values.
+func evaluateLazy(args []interface{}) []interface{} {
+	var evaluated []interface{}
+	for i, arg := range args {
+		if lazy, ok := arg.(LazyValue); ok {
+			if evaluated == nil {
+				evaluated = append(make([]interface{}, 0, len(args)), args...)
+			}
+			evaluated[i] = lazy.Value()
+		}
+	}
+	if evaluated == nil {
+		return args
+	}
+	return evaluated
+}
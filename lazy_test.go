@@ -0,0 +1,55 @@
+package logging
+
+import (
+	"bytes"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Lazy values", func() {
+	var sink *captureSink
+	var calls int
+
+	routes := Lazy(func() interface{} {
+		calls++
+		return []string{"10.0.0.0/8", "192.168.0.0/16"}
+	})
+
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+		sink = &captureSink{}
+		AddSink(sink)
+		calls = 0
+	})
+
+	It("does not compute values of filtered messages", func() {
+		DebugStructured(debugMsg, "routes", routes)
+		Debugf("%v", routes)
+		Expect(calls).To(BeZero())
+
+		SetRateLimit(&RateLimitOptions{Rate: 1, Burst: 1})
+		InfoStructured(infoMsg, "routes", routes)
+		InfoStructured(infoMsg, "routes", routes)
+		Expect(calls).To(Equal(1))
+	})
+
+	It("computes the value once for all outputs", func() {
+		out := &bytes.Buffer{}
+		AddOutput(out)
+		SetFileFormatter(JSONFormatter{})
+		SetDuplicateSuppression(time.Minute)
+
+		InfoStructured(infoMsg, "routes", routes)
+		Expect(calls).To(Equal(1))
+		Expect(sink.entries[0].Fields).To(ContainElement([]string{"10.0.0.0/8", "192.168.0.0/16"}))
+		Expect(out.String()).To(ContainSubstring(`"routes":["10.0.0.0/8","192.168.0.0/16"]`))
+	})
+
+	It("formats lazy values in printf style messages", func() {
+		Infof("routes: %v", routes)
+		Expect(sink.entries[0].Message).To(Equal("routes: [10.0.0.0/8 192.168.0.0/16]"))
+	})
+})
@@ -0,0 +1,169 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const invalidLevelRuleFailMsg = "cni-log: invalid level rule %d: %w"
+
+var (
+	errRuleWithoutPattern = errors.New("logger or message pattern required")
+	errRuleWithoutLevel   = errors.New("level required")
+)
+
+// Rule overrides the logging level of the messages of a module, see SetLevelRules. A rule matches a message if both of
+// its patterns match; an empty pattern matches every message.
+type Rule struct {
+	// Logger is a pattern for the name of the Logger the message is logged with, see GetLogger, e.g. "cni.netlink.*".
+	// Messages which are not logged with a named Logger never match it.
+	Logger string
+	// Message is a pattern for the beginning of the message, e.g. "netlink:" or "route * added".
+	Message string
+	// Regexp makes the patterns regular expressions instead of glob patterns, where "*" matches any sequence of
+	// characters and "?" any single character.
+	Regexp bool
+	// Level is the logging level of the matching messages. It is required, so that a rule which does not set it cannot
+	// silently turn the matching messages down to FatalLevel, the zero value of Level.
+	Level *Level
+}
+
+// levelRule is a compiled Rule.
+type levelRule struct {
+	logger  *regexp.Regexp
+	message *regexp.Regexp
+	level   Level
+}
+
+// SetLevelRules replaces the level rules: the level of a message is the level of the first rule which matches it,
+// regardless of the level set with SetLogLevel, SetLevelFor or Logger.WithLevel, e.g. to turn a noisy component down
+// to error without losing the debug messages of the others:
+//
+//	logging.SetLogLevel(logging.DebugLevel)
+//	errorLevel := logging.ErrorLevel
+//	err := logging.SetLevelRules([]logging.Rule{
+//		{Logger: "cni.netlink*", Level: &errorLevel},
+//		{Message: "sriov:", Level: &errorLevel},
+//	})
+//
+// Logger patterns match the whole name, message patterns the beginning of the message: the message of structured
+// messages, the formatted message of printf style ones. A more verbose rule level works like Logger.WithLevel, a less
+// verbose one restricts all outputs. Messages of printf style functions are formatted before the rules are applied, so
+// rules make log calls more expensive. nil removes the rules. If a rule is invalid, an error is returned and nothing is
+// changed.
+func SetLevelRules(rules []Rule) error {
+	compiled := make([]levelRule, 0, len(rules))
+	for i, r := range rules {
+		rule, err := compileRule(r)
+		if err != nil {
+			return fmt.Errorf(invalidLevelRuleFailMsg, i, err)
+		}
+		compiled = append(compiled, rule)
+	}
+
+	mu.Lock()
+	defer unlockAndPublish()
+
+	levelRules = nil
+	if len(compiled) > 0 {
+		levelRules = compiled
+	}
+	return nil
+}
+
+// compileRule compiles the patterns of r.
+func compileRule(r Rule) (levelRule, error) {
+	rule := levelRule{}
+	if r.Logger == "" && r.Message == "" {
+		return rule, errRuleWithoutPattern
+	}
+	if r.Level == nil {
+		return rule, errRuleWithoutLevel
+	}
+	if !validateLogLevel(*r.Level) {
+		return rule, ErrInvalidLevel
+	}
+	rule.level = *r.Level
+	var err error
+	if r.Logger != "" {
+		if rule.logger, err = compilePattern(r.Logger, r.Regexp, true); err != nil {
+			return rule, err
+		}
+	}
+	if r.Message != "" {
+		if rule.message, err = compilePattern(r.Message, r.Regexp, false); err != nil {
+			return rule, err
+		}
+	}
+	return rule, nil
+}
+
+// compilePattern compiles a glob pattern, or a regular expression, anchored at the beginning and, if whole is set, at
+// the end.
+func compilePattern(pattern string, isRegexp, whole bool) (*regexp.Regexp, error) {
+	if !isRegexp {
+		var b strings.Builder
+		for _, part := range strings.SplitAfter(pattern, "") {
+			switch part {
+			case "*":
+				b.WriteString(".*")
+			case "?":
+				b.WriteString(".")
+			default:
+				b.WriteString(regexp.QuoteMeta(part))
+			}
+		}
+		pattern = b.String()
+	}
+	pattern = "^(?:" + pattern + ")"
+	if whole {
+		pattern += "$"
+	}
+	return regexp.Compile(pattern)
+}
+
+// matches returns true if the rule matches a message logged by the Logger named name.
+func (r *levelRule) matches(name, msg string) bool {
+	return (r.logger == nil || name != "" && r.logger.MatchString(name)) &&
+		(r.message == nil || r.message.MatchString(msg))
+}
+
+// withRules returns s changed by the level of the first rule which matches msg, s itself if none does.
+func (s *snapshot) withRules(msg string) *snapshot {
+	for i := range s.levelRules {
+		if s.levelRules[i].matches(s.name, msg) {
+			scoped := *s
+			scoped.setLevel(s.levelRules[i].level)
+			return &scoped
+		}
+	}
+	return s
+}
+
+// mostVerboseRuleLevel returns the most verbose level of the level rules, InvalidLevel if there are none. The caller
+// must hold mu.
+func mostVerboseRuleLevel() Level {
+	level := InvalidLevel
+	for _, r := range levelRules {
+		if r.level > level {
+			level = r.level
+		}
+	}
+	return level
+}
@@ -0,0 +1,96 @@
+package logging
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Level rules", func() {
+	var out bytes.Buffer
+
+	BeforeEach(func() {
+		initLogger()
+		out = bytes.Buffer{}
+		SetOutput(&out)
+		SetLogStderr(false)
+		SetFileFields("msg", "logger")
+	})
+
+	It("turns a noisy logger down without losing debug messages elsewhere", func() {
+		SetLogLevel(DebugLevel)
+		Expect(SetLevelRules([]Rule{{Logger: "cni.netlink*", Level: getPrimitivePointer(ErrorLevel)}})).To(Succeed())
+
+		GetLogger("cni.netlink.route").DebugStructured(debugMsg)
+		GetLogger("cni.netlink").WarningStructured(warningMsg)
+		GetLogger("cni.ipam").DebugStructured(debugMsg)
+		Expect(out.String()).To(Equal(`msg="` + debugMsg + `" logger="cni.ipam"` + "\n"))
+	})
+
+	It("matches the beginning of printf style and structured messages", func() {
+		SetLogLevel(DebugLevel)
+		Expect(SetLevelRules([]Rule{{Message: "sriov: ?f *", Level: getPrimitivePointer(ErrorLevel)}})).To(Succeed())
+
+		Debugf("sriov: vf %d configured", 3)
+		DebugStructured("sriov: pf configured")
+		Debugf("macvlan: configured %s", "eth0")
+		Expect(out.String()).To(HaveSuffix("[debug] macvlan: configured eth0\n"))
+		Expect(out.String()).NotTo(ContainSubstring("sriov"))
+	})
+
+	It("raises the level of matching messages", func() {
+		Expect(SetLevelRules([]Rule{{Logger: "ipam", Message: "allocated", Level: getPrimitivePointer(DebugLevel)}})).
+			To(Succeed())
+		Expect(Enabled(DebugLevel)).To(BeTrue())
+
+		GetLogger("ipam").DebugStructured("allocated address")
+		GetLogger("ipam").DebugStructured("released address")
+		DebugStructured("allocated address")
+		Expect(out.String()).To(Equal(`msg="allocated address" logger="ipam"` + "\n"))
+	})
+
+	It("applies the first matching rule over SetLevelFor", func() {
+		SetLevelFor("ipam", TraceLevel)
+		Expect(SetLevelRules([]Rule{
+			{Message: "lease", Level: getPrimitivePointer(WarningLevel)},
+			{Logger: "ipam", Level: getPrimitivePointer(DebugLevel)},
+		})).To(Succeed())
+
+		GetLogger("ipam").InfoStructured("lease renewed")
+		GetLogger("ipam").TraceStructured(traceMsg)
+		GetLogger("ipam").DebugStructured(debugMsg)
+		Expect(out.String()).To(Equal(`msg="` + debugMsg + `" logger="ipam"` + "\n"))
+	})
+
+	It("supports regular expressions", func() {
+		Expect(SetLevelRules([]Rule{{Logger: "cni\\.(ipam|netlink)", Regexp: true, Level: getPrimitivePointer(ErrorLevel)}})).
+			To(Succeed())
+		GetLogger("cni.ipam").InfoStructured(infoMsg)
+		GetLogger("cni.ipam.store").InfoStructured(infoMsg)
+		Expect(out.String()).To(Equal(`msg="` + infoMsg + `" logger="cni.ipam.store"` + "\n"))
+	})
+
+	It("removes the rules with nil", func() {
+		Expect(SetLevelRules([]Rule{{Message: "a", Level: getPrimitivePointer(ErrorLevel)}})).To(Succeed())
+		Expect(SetLevelRules(nil)).To(Succeed())
+		InfoStructured("a")
+		Expect(out.String()).To(ContainSubstring(`msg="a"`))
+	})
+
+	It("rejects invalid rules without changing the rules", func() {
+		Expect(SetLevelRules([]Rule{{Message: "a", Level: getPrimitivePointer(ErrorLevel)}})).To(Succeed())
+		Expect(SetLevelRules([]Rule{{Level: getPrimitivePointer(ErrorLevel)}})).
+			To(MatchError(ContainSubstring("invalid level rule 0")))
+		Expect(SetLevelRules([]Rule{{Logger: "ipam"}})).To(MatchError("cni-log: invalid level rule 0: level required"))
+		Expect(SetLevelRules([]Rule{{Message: "a", Level: getPrimitivePointer(Level(42))}})).To(HaveOccurred())
+		Expect(SetLevelRules([]Rule{
+			{Message: "a", Level: getPrimitivePointer(InfoLevel)},
+			{Message: "(", Regexp: true, Level: getPrimitivePointer(InfoLevel)},
+		})).
+			To(MatchError(ContainSubstring("invalid level rule 1")))
+
+		InfoStructured("a")
+		Expect(out.String()).To(BeEmpty())
+	})
+})
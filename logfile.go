@@ -0,0 +1,385 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"os"
+	"reflect"
+	"sync"
+	"time"
+)
+
+const (
+	megabyte       = 1024 * 1024
+	defaultMaxSize = 100
+	lockFileSuffix = ".lock"
+)
+
+// fileCheckInterval is the time for which the observed state of the log file is trusted before the file is checked
+// again, see recoverAppendPosition.
+var fileCheckInterval = time.Second
+
+// fileWriter wraps the RotatingWriter of a log file, by default lumberjack. Lumberjack opens the log file in append mode
+// and keeps track of its size itself, which goes wrong when the file is removed, replaced or truncated by somebody else:
+// lumberjack then keeps writing to a stale file handle and rotates based on a stale size. fileWriter detects these cases
+// before writing, at most once per fileCheckInterval, and makes the backend reopen the file. It can also preallocate
+// disk space for every newly opened log file and serialize rotation with other processes sharing the same log file.
+// Optionally, the log file is closed after it has not been written to for a while.
+type fileWriter struct {
+	mu           sync.Mutex
+	backend      RotatingWriter
+	filename     string
+	logOptions   LogOptions // the options with the defaults filled in
+	options      RotationOptions
+	preallocate  bool
+	rotationLock bool
+	idleTimeout  time.Duration
+	idleTimer    *time.Timer
+	info         os.FileInfo // last observed state of the log file, nil if not observed yet
+	checked      time.Time   // time info was observed, zero to check the log file before the next write
+	size         int64       // minimum size the log file is expected to have
+	reopen       bool        // the backend may have opened the log file without append mode, e.g. when rotating it
+}
+
+// newFileWriter returns a fileWriter which writes filename through the provided backend.
+func newFileWriter(backend RotatingWriter, filename string) *fileWriter {
+	backend.Configure(filename, RotationOptions{})
+	return &fileWriter{backend: backend, filename: filename}
+}
+
+// Write implements io.Writer.
+func (w *fileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.recoverAppendPosition()
+	if w.rotationLock && w.rotates() && w.info != nil && w.size+int64(len(p)) >= w.maxSize() {
+		w.rotate(int64(len(p)))
+	}
+	// The backend rotates the log file itself if the write would exceed the maximum size.
+	rotating := w.rotates() && w.info != nil && w.size+int64(len(p)) > w.maxSize()
+	n, err := w.backend.Write(p)
+	if rotating && err == nil {
+		recordRotation()
+		w.reopen = true
+	}
+	if err != nil || n != len(p) {
+		w.checked = time.Time{}
+	}
+	w.size += int64(n)
+	w.resetIdleTimer()
+	return n, err
+}
+
+// resetIdleTimer restarts the countdown after which an idle log file is closed.
+func (w *fileWriter) resetIdleTimer() {
+	if w.idleTimeout <= 0 {
+		return
+	}
+
+	if w.idleTimer == nil {
+		w.idleTimer = time.AfterFunc(w.idleTimeout, w.closeIdle)
+		return
+	}
+	w.idleTimer.Reset(w.idleTimeout)
+}
+
+// setIdleTimeout sets the time after which an idle log file is closed. A timeout <= 0 keeps the log file open.
+func (w *fileWriter) setIdleTimeout(timeout time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.idleTimer != nil {
+		w.idleTimer.Stop()
+		w.idleTimer = nil
+	}
+	w.idleTimeout = timeout
+}
+
+// closeIdle closes the log file. The backend reopens it on the next write.
+func (w *fileWriter) closeIdle() {
+	_ = w.close()
+}
+
+// close closes the log file. The backend reopens it on the next write.
+func (w *fileWriter) close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	err := w.backend.Close()
+	w.info = nil
+	w.size = 0
+	return err
+}
+
+// setOptions applies the rotation options. Unset options are set to their default values.
+func (w *fileWriter) setOptions(options *LogOptions) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.logOptions = withDefaultLogOptions(options)
+	w.preallocate = *w.logOptions.Preallocate
+	w.rotationLock = *w.logOptions.RotationLock
+
+	rotationOptions := RotationOptions{
+		MaxSize:    *w.logOptions.MaxSize,
+		MaxAge:     *w.logOptions.MaxAge,
+		MaxBackups: *w.logOptions.MaxBackups,
+		Compress:   *w.logOptions.Compress,
+		LocalTime:  *w.logOptions.LocalTime,
+	}
+	if w.options != rotationOptions {
+		w.options = rotationOptions
+		w.backend.Configure(w.filename, w.options)
+	}
+}
+
+// defaultLogOptions returns the default value of every log option. A new option must get its default here.
+func defaultLogOptions() LogOptions {
+	maxSize, maxAge, maxBackups := defaultMaxSize, 5, 5
+	compress, preallocate, rotationLock, localTime := true, false, false, false
+	return LogOptions{
+		MaxAge:       &maxAge,
+		MaxSize:      &maxSize,
+		MaxBackups:   &maxBackups,
+		Compress:     &compress,
+		Preallocate:  &preallocate,
+		RotationLock: &rotationLock,
+		LocalTime:    &localTime,
+	}
+}
+
+// withDefaultLogOptions returns a copy of options in which every unset option is set to its default value.
+func withDefaultLogOptions(options *LogOptions) LogOptions {
+	merged := defaultLogOptions()
+	if options == nil {
+		return merged
+	}
+	from := reflect.ValueOf(copyLogOptions(*options))
+	to := reflect.ValueOf(&merged).Elem()
+	for i := 0; i < from.NumField(); i++ {
+		if !from.Field(i).IsNil() {
+			to.Field(i).Set(from.Field(i))
+		}
+	}
+	return merged
+}
+
+// copyLogOptions returns a copy of options which shares no pointers with it.
+func copyLogOptions(options LogOptions) LogOptions {
+	copied := LogOptions{}
+	from := reflect.ValueOf(options)
+	to := reflect.ValueOf(&copied).Elem()
+	for i := 0; i < from.NumField(); i++ {
+		if !from.Field(i).IsNil() {
+			value := reflect.New(from.Field(i).Type().Elem())
+			value.Elem().Set(from.Field(i).Elem())
+			to.Field(i).Set(value)
+		}
+	}
+	return copied
+}
+
+// setFilename makes the writer write to filename. The current log file is closed if filename is a different file.
+func (w *fileWriter) setFilename(filename string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.filename != filename {
+		_ = w.backend.Close()
+		w.info = nil
+		w.size = 0
+		w.filename = filename
+		w.backend.Configure(w.filename, w.options)
+	}
+}
+
+// setBackend replaces the backend of the writer. The log file is closed, the new backend reopens it on the next write.
+func (w *fileWriter) setBackend(backend RotatingWriter) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	_ = w.backend.Close()
+	w.info = nil
+	w.size = 0
+	backend.Configure(w.filename, w.options)
+	w.backend = backend
+}
+
+// rotates returns false if the backend does not rotate the log file.
+func (w *fileWriter) rotates() bool {
+	_, ok := w.backend.(*nonRotatingWriter)
+	return !ok
+}
+
+// sync commits the log file to stable storage. fsync applies to the file, not to the file descriptor, so the log file
+// is opened again instead of reaching into lumberjack.
+func (w *fileWriter) sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.filename == "" {
+		return nil
+	}
+	f, err := os.OpenFile(w.filename, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Sync()
+}
+
+// recoverAppendPosition compares the log file on disk with the one which was observed last. If the file disappeared,
+// was replaced by a different file or is shorter than what was written to it, the backend's file handle is closed so
+// that the next write reopens the file and appends at its actual end. The observed state is trusted for
+// fileCheckInterval, unless a write failed or the log file is shared with other processes, see RotationLock, whose
+// writes must be accounted for before every write.
+//
+// Lumberjack only opens existing files in append mode, new files are opened at offset 0. The log file is therefore
+// created before lumberjack gets to open it, and lumberjack is made to reopen every file it did not open itself in
+// append mode, e.g. after a rotation.
+func (w *fileWriter) recoverAppendPosition() {
+	now := time.Now()
+	if w.info != nil && !w.reopen && !w.rotationLock && now.Sub(w.checked) < fileCheckInterval {
+		return
+	}
+
+	current, err := os.Stat(w.filename)
+	if os.IsNotExist(err) && isLogFileWritable(w.filename) {
+		current, err = os.Stat(w.filename)
+	}
+	if err != nil {
+		// Nothing can be done about it here. The backend will report the error on write.
+		_ = w.backend.Close()
+		w.info = nil
+		w.size = 0
+		w.reopen = false
+		return
+	}
+
+	previous := w.info
+	w.info, w.checked = current, now
+	if previous != nil && !w.reopen && os.SameFile(previous, current) && current.Size() >= w.size {
+		// Other processes may append to the same file, so the size on disk is the one that counts.
+		w.size = current.Size()
+		return
+	}
+
+	// Without a previously observed file, the backend has not opened the log file since it was last closed.
+	if previous != nil || w.reopen {
+		_ = w.backend.Close()
+	}
+	w.reopen = false
+	w.size = current.Size()
+
+	if w.preallocate {
+		// Preallocation is an optimization only, filesystems which do not support it are not an error.
+		_ = preallocate(w.filename, w.maxSize())
+	}
+}
+
+// rotate rotates the log file while holding an advisory lock on a lock file next to it, so that processes sharing
+// the log file do not rotate it concurrently. The size of the file is checked again once the lock is held: if another
+// process rotated the file in the meantime, the new file is reopened instead of being rotated a second time.
+func (w *fileWriter) rotate(writeLen int64) {
+	unlock, err := lockFile(w.filename + lockFileSuffix)
+	if err != nil {
+		// Fall back to the backend's own, unserialized, rotation.
+		return
+	}
+	defer unlock()
+
+	// Another process may have rotated the log file while the lock was not held.
+	w.checked = time.Time{}
+	w.recoverAppendPosition()
+	if w.info == nil || w.size+writeLen < w.maxSize() {
+		return
+	}
+	_ = w.rotateFile()
+}
+
+// rotateFile has the backend rotate the log file. The caller must hold w.mu.
+func (w *fileWriter) rotateFile() error {
+	if err := w.backend.Rotate(); err != nil {
+		return err
+	}
+	recordRotation()
+	// Lumberjack did not open the new file in append mode, so have it reopened.
+	w.reopen = true
+	w.recoverAppendPosition()
+	return nil
+}
+
+// forceRotate rotates the log file regardless of its size, see Rotate.
+func (w *fileWriter) forceRotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.filename == "" || !w.rotates() {
+		return nil
+	}
+	if w.rotationLock {
+		if unlock, err := lockFile(w.filename + lockFileSuffix); err == nil {
+			defer unlock()
+		}
+	}
+	return w.rotateFile()
+}
+
+// Rotate rotates the log file, the error log file and the secondary log file right away instead of waiting for them to
+// reach their maximum size, e.g. on request of an operator. The rotated files are renamed and compressed according to
+// the log options, see SetLogOptions, and new files are started. Daily log files, see SetDailyLogFiles, are not
+// rotated. The last error is returned.
+func Rotate() error {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	var err error
+	writers := append([]*fileWriter{errorLogWriter, secondaryLogWriter}, routeWriters()...)
+	if logWriter == logFileWriter {
+		writers = append(writers, logFileWriter)
+	}
+	for _, w := range writers {
+		if w == nil {
+			continue
+		}
+		if rotateErr := w.forceRotate(); rotateErr != nil {
+			err = rotateErr
+		}
+	}
+	return err
+}
+
+// Rotate rotates the log files, see the Rotate function. Loggers share the log files of the package.
+func (l *Logger) Rotate() error {
+	return Rotate()
+}
+
+// SetIdleTimeout closes the log file after no message has been written to it for the given duration; it is reopened
+// on the next write. This frees the file descriptor of rarely logging daemons and lets unmounts of the log directory
+// succeed. A timeout <= 0, the default, keeps the log file open.
+func SetIdleTimeout(timeout time.Duration) {
+	mu.RLock()
+	defer mu.RUnlock()
+	logFileWriter.setIdleTimeout(timeout)
+}
+
+// maxSize returns the size in bytes at which the backend rotates the log file.
+func (w *fileWriter) maxSize() int64 {
+	if w.options.MaxSize == 0 {
+		return int64(defaultMaxSize) * megabyte
+	}
+	return int64(w.options.MaxSize) * megabyte
+}
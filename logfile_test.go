@@ -0,0 +1,256 @@
+package logging
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+// closeCountingWriter counts how often the log file is closed.
+type closeCountingWriter struct {
+	RotatingWriter
+	closed int
+}
+
+func (w *closeCountingWriter) Close() error {
+	w.closed++
+	return w.RotatingWriter.Close()
+}
+
+var _ = Describe("Log file handling", func() {
+	var logFile string
+
+	BeforeEach(func() {
+		initLogger()
+		logFile = path.Join(os.TempDir(), "test-logfile.log")
+		SetLogFile(logFile)
+		SetLogStderr(false)
+		// Check the log file before every write.
+		interval := fileCheckInterval
+		fileCheckInterval = 0
+		DeferCleanup(func() { fileCheckInterval = interval })
+	})
+
+	AfterEach(func() {
+		Expect(logFileWriter.close()).To(Succeed())
+		Expect(os.RemoveAll(logFile)).To(Succeed())
+	})
+
+	When("the log file did not change", func() {
+		It("keeps the log file open", func() {
+			backend := &closeCountingWriter{RotatingWriter: newRotatingWriter()}
+			logFileWriter.setBackend(backend)
+			for i := 0; i < 3; i++ {
+				Infof(infoMsg)
+			}
+			Expect(backend.closed).To(BeZero())
+			Expect(logFileContains(logFile, infoMsg)).To(BeTrue())
+		})
+	})
+
+	When("the log file was checked recently", func() {
+		It("checks it again once the check interval passed", func() {
+			fileCheckInterval = 50 * time.Millisecond
+			Infof(infoMsg)
+			Expect(os.Remove(logFile)).To(Succeed())
+			time.Sleep(2 * fileCheckInterval)
+			Infof(warningMsg)
+			Expect(logFileContains(logFile, warningMsg)).To(BeTrue())
+		})
+	})
+
+	When("the log file is removed while logging", func() {
+		It("recreates the log file", func() {
+			Infof(infoMsg)
+			Expect(os.Remove(logFile)).To(Succeed())
+			Infof(warningMsg)
+			Expect(logFileContains(logFile, warningMsg)).To(BeTrue())
+		})
+	})
+
+	When("the log file is replaced by a shorter file", func() {
+		It("appends to the new file", func() {
+			Infof(infoMsg)
+			Infof(infoMsg)
+			Expect(os.Remove(logFile)).To(Succeed())
+			Expect(os.WriteFile(logFile, []byte("replaced\n"), 0644)).To(Succeed())
+			Infof(warningMsg)
+
+			contents, err := os.ReadFile(logFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(HavePrefix("replaced\n"))
+			Expect(string(contents)).To(ContainSubstring(warningMsg))
+			Expect(string(contents)).NotTo(ContainSubstring(infoMsg))
+		})
+	})
+
+	When("the log file is truncated", func() {
+		It("appends at the new end of the file", func() {
+			Infof(infoMsg)
+			Expect(os.Truncate(logFile, 0)).To(Succeed())
+			Infof(warningMsg)
+
+			contents, err := os.ReadFile(logFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring(warningMsg))
+			Expect(contents).NotTo(ContainElement(byte(0)))
+		})
+	})
+
+	When("the log file is closed", func() {
+		It("writes pending entries and reopens the log file on the next write", func() {
+			SetAsync(&AsyncOptions{MaxAge: time.Hour})
+			defer SetAsync(nil)
+			Infof(infoMsg)
+			Expect(Close()).To(Succeed())
+			Expect(logFileContains(logFile, infoMsg)).To(BeTrue())
+
+			Expect(os.Remove(logFile)).To(Succeed())
+			Infof(warningMsg)
+			Flush()
+			Expect(logFileContains(logFile, warningMsg)).To(BeTrue())
+		})
+	})
+
+	When("an idle timeout is set", func() {
+		It("closes the log file when idle and reopens it on the next write", func() {
+			SetIdleTimeout(20 * time.Millisecond)
+			defer SetIdleTimeout(0)
+
+			Infof(infoMsg)
+			Eventually(func() os.FileInfo {
+				logFileWriter.mu.Lock()
+				defer logFileWriter.mu.Unlock()
+				return logFileWriter.info
+			}).Should(BeNil())
+
+			Expect(os.Remove(logFile)).To(Succeed())
+			Infof(warningMsg)
+			Expect(logFileContains(logFile, warningMsg)).To(BeTrue())
+		})
+	})
+
+	When("preallocation is enabled", func() {
+		It("does not change the apparent size of the log file", func() {
+			SetLogOptions(&LogOptions{
+				MaxSize:     getPrimitivePointer(1),
+				Preallocate: getPrimitivePointer(true),
+			})
+			Expect(logFileWriter.preallocate).To(BeTrue())
+
+			Infof(infoMsg)
+			info, err := os.Stat(logFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Size()).To(BeNumerically("<", megabyte))
+			Expect(logFileContains(logFile, infoMsg)).To(BeTrue())
+		})
+	})
+})
+
+var _ = Describe("Log file rotation lock", func() {
+	var logDir string
+
+	BeforeEach(func() {
+		var err error
+		logDir, err = os.MkdirTemp("", "cni-log-rotation")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(logDir)).To(Succeed())
+	})
+
+	When("two writers share a log file", func() {
+		It("rotates the file once and loses no data", func() {
+			logFile := path.Join(logDir, "shared.log")
+			var writers []*fileWriter
+			for i := 0; i < 2; i++ {
+				w := newFileWriter(newRotatingWriter(), logFile)
+				w.setOptions(&LogOptions{MaxSize: getPrimitivePointer(1), Compress: getPrimitivePointer(false),
+					RotationLock: getPrimitivePointer(true)})
+				writers = append(writers, w)
+			}
+			defer func() {
+				for _, w := range writers {
+					Expect(w.close()).To(Succeed())
+				}
+			}()
+
+			chunk := bytes.Repeat([]byte("x"), 64*1024)
+			written := 0
+			for i := 0; i < 24; i++ {
+				n, err := writers[i%2].Write(chunk)
+				Expect(err).NotTo(HaveOccurred())
+				written += n
+			}
+
+			entries, err := os.ReadDir(logDir)
+			Expect(err).NotTo(HaveOccurred())
+			total := 0
+			logFiles := 0
+			for _, e := range entries {
+				if strings.HasSuffix(e.Name(), lockFileSuffix) {
+					continue
+				}
+				logFiles++
+				info, err := e.Info()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(info.Size()).To(BeNumerically("<=", megabyte))
+				total += int(info.Size())
+			}
+			Expect(logFiles).To(Equal(2))
+			Expect(total).To(Equal(written))
+		})
+	})
+})
+
+var _ = Describe("Rotating the log file on demand", func() {
+	var logDir string
+	var logFile string
+
+	BeforeEach(func() {
+		var err error
+		logDir, err = os.MkdirTemp("", "cni-log-rotate")
+		Expect(err).NotTo(HaveOccurred())
+		initLogger()
+		logFile = path.Join(logDir, "rotate.log")
+		SetLogFile(logFile)
+		SetLogStderr(false)
+	})
+
+	AfterEach(func() {
+		Expect(logFileWriter.close()).To(Succeed())
+		Expect(os.RemoveAll(logDir)).To(Succeed())
+	})
+
+	It("moves the log file aside and starts a new one", func() {
+		Infof(infoMsg)
+		Expect(Rotate()).To(Succeed())
+		Infof(warningMsg)
+
+		// the rotated file is compressed in the background
+		Eventually(logDirEntries(logDir)).Should(HaveLen(2))
+		Expect(logFileContains(logFile, warningMsg)).To(BeTrue())
+		Expect(logFileContains(logFile, infoMsg)).To(BeFalse())
+		Expect(Stats().LastRotation).NotTo(BeZero())
+	})
+
+	It("is available on Loggers", func() {
+		Infof(infoMsg)
+		Expect(With("component", "test").Rotate()).To(Succeed())
+
+		Eventually(logDirEntries(logDir)).Should(HaveLen(2))
+	})
+})
+
+// logDirEntries returns a function which lists the entries of dir, for use with Eventually.
+func logDirEntries(dir string) func() ([]os.DirEntry, error) {
+	return func() ([]os.DirEntry, error) {
+		return os.ReadDir(dir)
+	}
+}
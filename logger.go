@@ -0,0 +1,203 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"sort"
+)
+
+// Logger logs structured messages with a persistent context: its key/value pairs are added to every message, after
+// the structured prefix and before the arguments of the call. Loggers use the global configuration and are safe for
+// concurrent use.
+type Logger struct {
+	// name is the name of a Logger returned by GetLogger, whose level can be set with SetLevelFor.
+	name   string
+	fields []interface{}
+	// level is the logging level of the Logger, if it is more verbose than the global one, see WithLevel.
+	level *Level
+	// sinks receive the messages of the Logger in addition to the outputs, see WithSink.
+	sinks []Sink
+}
+
+// With returns a Logger which adds the alternating keys and values of args to every structured message, e.g. the pod,
+// container ID and interface name a plugin invocation works on.
+func With(args ...interface{}) *Logger {
+	return (&Logger{}).With(args...)
+}
+
+// WithFields works like With, but takes the context as a map. The fields are added in the order of their keys.
+func WithFields(fields map[string]interface{}) *Logger {
+	return (&Logger{}).WithFields(fields)
+}
+
+// With returns a Logger which adds the alternating keys and values of args to every structured message, in addition to
+// the context of l.
+func (l *Logger) With(args ...interface{}) *Logger {
+	args = loadSnapshot().evenArgs("", structuredLoggingOddArguments, args)
+	fields := make([]interface{}, 0, len(l.fields)+len(args))
+	fields = append(fields, l.fields...)
+	return &Logger{name: l.name, fields: append(fields, args...), level: l.level, sinks: l.sinks}
+}
+
+// WithLevel returns a Logger which logs the messages up to level even if the level set with SetLogLevel is less
+// verbose, e.g. to log a single suspicious operation in detail. The messages are written to the outputs which follow
+// the level set with SetLogLevel, outputs with a level of their own keep it. A level less verbose than the global one
+// has no effect. Only the messages logged through the returned Logger are affected, see also WithTemporaryLevel.
+func (l *Logger) WithLevel(level Level) *Logger {
+	return &Logger{name: l.name, fields: l.fields, level: &level, sinks: l.sinks}
+}
+
+// WithSink returns a Logger which writes its messages to sink in addition to the outputs, e.g. to capture the messages
+// of a single operation. Only the messages logged through the returned Logger are affected, see also
+// WithTemporarySink.
+func (l *Logger) WithSink(sink Sink) *Logger {
+	sinks := make([]Sink, 0, len(l.sinks)+1)
+	return &Logger{name: l.name, fields: l.fields, level: l.level, sinks: append(append(sinks, l.sinks...), sink)}
+}
+
+// snapshot returns the configuration used by the log calls of l: the published one, changed by the level and the sinks
+// of its name, see SetLevelFor and SetSinksFor, and the level and the sinks of l.
+func (l *Logger) snapshot() *snapshot {
+	s := loadSnapshot().withName(l.name)
+	if (l.level == nil || *l.level <= s.level) && len(l.sinks) == 0 {
+		return s
+	}
+
+	scoped := *s
+	if l.level != nil && *l.level > s.level && *l.level > s.scopedLevel {
+		scoped.scopedLevel = *l.level
+		if *l.level > scoped.levelCap {
+			scoped.levelCap = *l.level
+		}
+	}
+	if len(l.sinks) > 0 {
+		sinks := make([]Sink, 0, len(s.sinks)+len(l.sinks))
+		scoped.sinks = append(append(sinks, s.sinks...), l.sinks...)
+	}
+	return &scoped
+}
+
+// WithFields works like With, but takes the context as a map. The fields are added in the order of their keys.
+func (l *Logger) WithFields(fields map[string]interface{}) *Logger {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	args := make([]interface{}, 0, 2*len(keys))
+	for _, k := range keys {
+		args = append(args, k, fields[k])
+	}
+	return l.With(args...)
+}
+
+// args returns the context of l followed by args.
+func (l *Logger) args(args []interface{}) []interface{} {
+	if len(l.fields) == 0 {
+		return args
+	}
+
+	all := make([]interface{}, 0, len(l.fields)+len(args))
+	all = append(all, l.fields...)
+	return append(all, args...)
+}
+
+// FatalStructured provides structured logging for log level fatal. It flushes the outputs and exits the process with
+// status 1.
+func (l *Logger) FatalStructured(msg string, args ...interface{}) {
+	writeStructured(l.snapshot(), FatalLevel, msg, true, l.args(args)...)
+	Flush()
+	exit(1)
+}
+
+// PanicStructured provides structured logging for log level >= panic.
+func (l *Logger) PanicStructured(msg string, args ...interface{}) {
+	writeStructured(l.snapshot(), PanicLevel, msg, true, l.args(args)...)
+}
+
+// ErrorStructured provides structured logging for log level >= error.
+func (l *Logger) ErrorStructured(msg string, args ...interface{}) error {
+	return errorStructured(l.snapshot(), msg, l.args(args)...)
+}
+
+// ErrorStructuredErr works like ErrorStructured, but logs and wraps err, see the ErrorStructuredErr function.
+func (l *Logger) ErrorStructuredErr(err error, msg string, args ...interface{}) error {
+	return errorStructuredErr(l.snapshot(), err, msg, l.args(args)...)
+}
+
+// WarningStructured provides structured logging for log level >= warning.
+func (l *Logger) WarningStructured(msg string, args ...interface{}) {
+	writeStructured(l.snapshot(), WarningLevel, msg, true, l.args(args)...)
+}
+
+// InfoStructured provides structured logging for log level >= info.
+func (l *Logger) InfoStructured(msg string, args ...interface{}) {
+	writeStructured(l.snapshot(), InfoLevel, msg, true, l.args(args)...)
+}
+
+// DebugStructured provides structured logging for log level >= debug.
+func (l *Logger) DebugStructured(msg string, args ...interface{}) {
+	writeStructured(l.snapshot(), DebugLevel, msg, true, l.args(args)...)
+}
+
+// TraceStructured provides structured logging for log level >= trace.
+func (l *Logger) TraceStructured(msg string, args ...interface{}) {
+	writeStructured(l.snapshot(), TraceLevel, msg, true, l.args(args)...)
+}
+
+// Fatalf prints logging like Fatalf, followed by the context of l, flushes the outputs and exits the process with
+// status 1.
+func (l *Logger) Fatalf(format string, a ...interface{}) {
+	writef(l.snapshot(), FatalLevel, true, format, a, l.fields)
+	Flush()
+	exit(1)
+}
+
+// Panicf prints logging like Panicf, followed by the context of l.
+func (l *Logger) Panicf(format string, a ...interface{}) {
+	writef(l.snapshot(), PanicLevel, true, format, a, l.fields)
+}
+
+// Errorf prints logging like Errorf, followed by the context of l. The returned error wraps the arguments of %w verbs.
+func (l *Logger) Errorf(format string, a ...interface{}) error {
+	return errorf(l.snapshot(), format, a, l.fields)
+}
+
+// Warningf prints logging if logging level >= warning, followed by the context of l.
+func (l *Logger) Warningf(format string, a ...interface{}) {
+	writef(l.snapshot(), WarningLevel, true, format, a, l.fields)
+}
+
+// Infof prints logging if logging level >= info, followed by the context of l, e.g. the "logger" field of a Logger
+// returned by GetLogger. Its prefix is created by the prefixer of its name, see SetPrefixerFor.
+func (l *Logger) Infof(format string, a ...interface{}) {
+	writef(l.snapshot(), InfoLevel, true, format, a, l.fields)
+}
+
+// Debugf prints logging if logging level >= debug, followed by the context of l.
+func (l *Logger) Debugf(format string, a ...interface{}) {
+	writef(l.snapshot(), DebugLevel, true, format, a, l.fields)
+}
+
+// Tracef prints logging if logging level >= trace, followed by the context of l.
+func (l *Logger) Tracef(format string, a ...interface{}) {
+	writef(l.snapshot(), TraceLevel, true, format, a, l.fields)
+}
+
+// LogStructured provides structured logging at the given level, see the LogStructured function.
+func (l *Logger) LogStructured(level Level, msg string, args ...interface{}) {
+	writeStructured(l.snapshot(), level, msg, true, l.args(args)...)
+}
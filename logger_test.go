@@ -0,0 +1,87 @@
+package logging
+
+import (
+	"bytes"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Loggers with context", func() {
+	var out bytes.Buffer
+
+	BeforeEach(func() {
+		initLogger()
+		out = bytes.Buffer{}
+		SetOutput(&out)
+		SetLogStderr(false)
+		SetLogLevel(TraceLevel)
+		SetFileFields("msg", "pod", "containerID", "ifname", "a")
+	})
+
+	It("adds the context to every structured message", func() {
+		l := With("pod", "pod-a", "containerID", "abc")
+		l.InfoStructured(infoMsg, "a", "b")
+		l.TraceStructured(traceMsg)
+		Expect(out.String()).To(Equal(fmt.Sprintf(
+			"msg=%q pod=\"pod-a\" containerID=\"abc\" a=\"b\"\nmsg=%q pod=\"pod-a\" containerID=\"abc\"\n", infoMsg, traceMsg)))
+	})
+
+	It("derives loggers without changing the parent", func() {
+		parent := With("pod", "pod-a")
+		child := parent.WithFields(map[string]interface{}{"ifname": "net1", "containerID": "abc"})
+		child.WarningStructured(warningMsg)
+		parent.DebugStructured(debugMsg)
+		Expect(out.String()).To(Equal(fmt.Sprintf(
+			"msg=%q pod=\"pod-a\" containerID=\"abc\" ifname=\"net1\"\nmsg=%q pod=\"pod-a\"\n", warningMsg, debugMsg)))
+	})
+
+	It("returns the message with the context from ErrorStructured", func() {
+		err := WithFields(map[string]interface{}{"pod": "pod-a"}).ErrorStructured(errorMsg)
+		Expect(err).To(MatchError(MatchRegexp(fmt.Sprintf(`msg=%q pod="pod-a"$`, errorMsg))))
+	})
+
+	It("respects the logging level", func() {
+		SetLogLevel(InfoLevel)
+		With("pod", "pod-a").DebugStructured(debugMsg)
+		Expect(out.String()).To(BeEmpty())
+	})
+
+	It("logs the messages up to its own level", func() {
+		SetLogLevel(InfoLevel)
+		l := With("pod", "pod-a").WithLevel(DebugLevel)
+		l.DebugStructured(debugMsg)
+		l.With("a", "b").DebugStructured(debugMsg)
+		l.TraceStructured("trace")
+		With("pod", "pod-b").DebugStructured(debugMsg)
+		Expect(out.String()).To(Equal(fmt.Sprintf("msg=%q pod=\"pod-a\"\nmsg=%q pod=\"pod-a\" a=\"b\"\n",
+			debugMsg, debugMsg)))
+	})
+
+	It("keeps its messages from outputs with a level of their own", func() {
+		SetLogLevel(InfoLevel)
+		SetFileLogLevel(InfoLevel)
+		sink := &captureSink{}
+		AddSink(sink)
+		With().WithLevel(DebugLevel).DebugStructured(debugMsg)
+		Expect(out.String()).To(BeEmpty())
+		Expect(sink.entries).To(HaveLen(1))
+	})
+
+	It("writes its messages to its own sinks", func() {
+		sink := &captureSink{}
+		l := With("pod", "pod-a").WithSink(sink)
+		l.InfoStructured(infoMsg)
+		Expect(l.ErrorStructured(errorMsg)).To(HaveOccurred())
+		InfoStructured(infoMsg)
+		Expect(sink.entries).To(HaveLen(2))
+		Expect(sink.entries[1].Message).To(Equal(errorMsg))
+		Expect(bytes.Count(out.Bytes(), []byte("\n"))).To(Equal(3))
+	})
+
+	It("panics on an odd number of arguments in strict mode", func() {
+		SetStrictMode(true)
+		Expect(func() { With("pod") }).To(Panic())
+	})
+})
@@ -0,0 +1,180 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// loggerNameKey is the key of the field carrying the name of a Logger returned by GetLogger.
+const loggerNameKey = "logger"
+
+// namedLoggers holds the Loggers returned by GetLogger by name. It is guarded by mu.
+var namedLoggers = map[string]*Logger{}
+
+// GetLogger returns the Logger of a subsystem, e.g. "ipam", "netlink" or "webhook", from a process-wide registry: every
+// call with the same name returns the same Logger. Its messages carry the name in a "logger" field, and their level
+// and sinks can be tuned independently of the other subsystems with SetLevelFor and SetSinksFor, also after the Logger
+// was created. Loggers derived from it, e.g. with With, keep the name and its settings.
+//
+// Names form a hierarchy separated by dots, e.g. "cni.ipam.store" is a descendant of "cni.ipam" and "cni". A Logger
+// without a setting of its own inherits it from its nearest ancestor which has one, so that a whole subtree can be
+// tuned or silenced at once.
+func GetLogger(name string) *Logger {
+	mu.Lock()
+	defer mu.Unlock()
+
+	l, ok := namedLoggers[name]
+	if !ok {
+		l = &Logger{name: name, fields: []interface{}{loggerNameKey, name}}
+		namedLoggers[name] = l
+	}
+	return l
+}
+
+// Name returns the name of a Logger returned by GetLogger, "" for other Loggers.
+func (l *Logger) Name() string {
+	return l.name
+}
+
+// SetLevelFor sets the logging level of the Loggers named name and of their descendants without a level of their own,
+// see GetLogger, e.g. to debug just the IPAM subsystem at runtime:
+//
+//	logging.SetLevelFor("ipam", logging.DebugLevel)
+//
+// The level replaces the level set with SetLogLevel for their messages, in both directions: a less verbose level
+// silences a chatty subsystem. Outputs with a level of their own keep it for the messages which are more verbose than
+// the level set with SetLogLevel, and never receive messages more verbose than it. Passing InvalidLevel makes the
+// Loggers inherit their level again, or follow the level set with SetLogLevel if no ancestor has one.
+func SetLevelFor(name string, level Level) {
+	mu.Lock()
+	defer unlockAndPublish()
+
+	if level != InvalidLevel && !validateLogLevel(level) {
+		fmt.Fprintf(os.Stderr, setLevelFailMsg, level)
+		return
+	}
+
+	loggerLevels = withSetting(loggerLevels, name, level, level != InvalidLevel)
+}
+
+// LevelFor returns the logging level of the Loggers named name, set for them or inherited from their nearest ancestor,
+// InvalidLevel if they follow the level set with SetLogLevel.
+func LevelFor(name string) Level {
+	if level, ok := inherited(loadSnapshot().loggerLevels, name); ok {
+		return level
+	}
+	return InvalidLevel
+}
+
+// SetSinksFor makes the Loggers named name and their descendants without sinks of their own, see GetLogger, write
+// their messages to sinks in addition to the outputs, e.g. to capture the messages of a whole subsystem like
+// Logger.WithSink does for a single Logger. Sinks which buffer messages are flushed by Flush and Close. Passing no sinks
+// removes the setting, so that the Loggers inherit the sinks of their ancestors again.
+func SetSinksFor(name string, sinks ...Sink) {
+	mu.Lock()
+	defer unlockAndPublish()
+
+	var set []Sink
+	for _, sink := range sinks {
+		if sink != nil {
+			set = append(set, sink)
+		}
+	}
+	loggerSinks = withSetting(loggerSinks, name, set, len(set) > 0)
+}
+
+// SetPrefixerFor sets the prefixer of the printf style messages of the Loggers named name and of their descendants
+// without a prefixer of their own, see GetLogger and Logger.Infof, e.g. to tag the messages of a subsystem in a
+// human-readable log file. The prefixer replaces the one set with SetPrefixer for their messages. Passing nil makes the
+// Loggers inherit their prefixer again, or use the one set with SetPrefixer if no ancestor has one.
+func SetPrefixerFor(name string, p Prefixer) {
+	mu.Lock()
+	defer unlockAndPublish()
+	loggerPrefixers = withSetting(loggerPrefixers, name, p, p != nil)
+}
+
+// SetStructuredPrefixerFor sets the structured prefixer of the structured messages of the Loggers named name and of
+// their descendants without a structured prefixer of their own, like SetPrefixerFor does for printf style messages.
+// Passing nil makes the Loggers inherit their structured prefixer again.
+func SetStructuredPrefixerFor(name string, p StructuredPrefixer) {
+	mu.Lock()
+	defer unlockAndPublish()
+	loggerStructuredPrefixers = withSetting(loggerStructuredPrefixers, name, p, p != nil)
+}
+
+// withSetting returns a copy of settings with the setting of name replaced by setting, or removed if set is false. Log
+// calls use the maps after releasing mu, so they are never modified in place.
+func withSetting[T any](settings map[string]T, name string, setting T, set bool) map[string]T {
+	updated := make(map[string]T, len(settings)+1)
+	for n, s := range settings {
+		updated[n] = s
+	}
+	if set {
+		updated[name] = setting
+	} else {
+		delete(updated, name)
+	}
+	return updated
+}
+
+// inherited returns the setting of name, or of its nearest ancestor in the hierarchy of dotted names which has one.
+func inherited[T any](settings map[string]T, name string) (T, bool) {
+	for {
+		if setting, ok := settings[name]; ok {
+			return setting, true
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			var none T
+			return none, false
+		}
+		name = name[:i]
+	}
+}
+
+// withName returns s changed by the level, the sinks and the prefixers of the Loggers named name, s itself if they have
+// none.
+func (s *snapshot) withName(name string) *snapshot {
+	if name == "" {
+		return s
+	}
+	level, hasLevel := inherited(s.loggerLevels, name)
+	sinks, hasSinks := inherited(s.loggerSinks, name)
+	prefixer, hasPrefixer := inherited(s.loggerPrefixers, name)
+	structuredPrefixer, hasStructuredPrefixer := inherited(s.loggerStructuredPrefixers, name)
+	if (!hasLevel || level == s.level) && !hasSinks && !hasPrefixer && !hasStructuredPrefixer && len(s.levelRules) == 0 {
+		return s
+	}
+
+	scoped := *s
+	scoped.name = name
+	if hasLevel {
+		scoped.setLevel(level)
+	}
+	if hasSinks {
+		all := make([]Sink, 0, len(s.sinks)+len(sinks))
+		scoped.sinks = append(append(all, s.sinks...), sinks...)
+	}
+	if hasPrefixer {
+		scoped.prefixer = prefixer
+	}
+	if hasStructuredPrefixer {
+		scoped.structuredPrefixer = structuredPrefixer
+	}
+	return &scoped
+}
@@ -0,0 +1,125 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Named loggers", func() {
+	var out bytes.Buffer
+
+	BeforeEach(func() {
+		initLogger()
+		out = bytes.Buffer{}
+		SetOutput(&out)
+		SetLogStderr(false)
+		SetFileFields("msg", "logger", "a")
+	})
+
+	It("returns the same Logger for a name and logs the name", func() {
+		Expect(GetLogger("ipam")).To(BeIdenticalTo(GetLogger("ipam")))
+		Expect(GetLogger("ipam").Name()).To(Equal("ipam"))
+		GetLogger("ipam").InfoStructured(infoMsg, "a", "b")
+		Expect(out.String()).To(Equal(`msg="` + infoMsg + `" logger="ipam" a="b"` + "\n"))
+	})
+
+	It("tunes the level of one subsystem at runtime", func() {
+		ipam, netlink := GetLogger("ipam"), GetLogger("netlink")
+		SetLevelFor("ipam", DebugLevel)
+		Expect(LevelFor("ipam")).To(Equal(DebugLevel))
+		Expect(LevelFor("netlink")).To(Equal(InvalidLevel))
+
+		ipam.With("a", "b").DebugStructured(debugMsg)
+		netlink.DebugStructured(debugMsg)
+		Expect(out.String()).To(Equal(`msg="` + debugMsg + `" logger="ipam" a="b"` + "\n"))
+	})
+
+	It("silences a subsystem with a less verbose level", func() {
+		SetLevelFor("webhook", ErrorLevel)
+		GetLogger("webhook").WarningStructured(warningMsg)
+		Expect(out.String()).To(BeEmpty())
+		_ = GetLogger("webhook").ErrorStructured(errorMsg)
+		Expect(out.String()).To(ContainSubstring(errorMsg))
+	})
+
+	It("follows the global level again with InvalidLevel", func() {
+		SetLevelFor("ipam", TraceLevel)
+		SetLevelFor("ipam", InvalidLevel)
+		GetLogger("ipam").DebugStructured(debugMsg)
+		Expect(out.String()).To(BeEmpty())
+	})
+
+	It("rejects invalid levels", func() {
+		errStr := captureStdErr(func(level Level) { SetLevelFor("ipam", level) }, Level(42))
+		Expect(errStr).To(ContainSubstring("cannot set logging level"))
+		Expect(LevelFor("ipam")).To(Equal(InvalidLevel))
+	})
+
+	It("inherits the level from the nearest configured ancestor", func() {
+		SetLevelFor("cni", ErrorLevel)
+		SetLevelFor("cni.ipam", DebugLevel)
+		Expect(LevelFor("cni.ipam.store")).To(Equal(DebugLevel))
+		Expect(LevelFor("cni.netlink")).To(Equal(ErrorLevel))
+		Expect(LevelFor("cnix")).To(Equal(InvalidLevel))
+
+		GetLogger("cni.ipam.store").DebugStructured(debugMsg)
+		GetLogger("cni.netlink").WarningStructured(warningMsg)
+		Expect(out.String()).To(Equal(`msg="` + debugMsg + `" logger="cni.ipam.store"` + "\n"))
+
+		SetLevelFor("cni.ipam", InvalidLevel)
+		Expect(LevelFor("cni.ipam.store")).To(Equal(ErrorLevel))
+	})
+
+	It("inherits the sinks from the nearest configured ancestor", func() {
+		cni, ipam := &captureSink{}, &captureSink{}
+		SetSinksFor("cni", cni)
+		SetSinksFor("cni.ipam", ipam)
+
+		GetLogger("cni.ipam.store").InfoStructured(infoMsg)
+		GetLogger("cni.netlink").InfoStructured(warningMsg)
+		Expect(ipam.entries).To(HaveLen(1))
+		Expect(ipam.entries[0].Message).To(Equal(infoMsg))
+		Expect(cni.entries).To(HaveLen(1))
+		Expect(cni.entries[0].Message).To(Equal(warningMsg))
+		Expect(out.String()).To(ContainSubstring(infoMsg))
+
+		SetSinksFor("cni.ipam")
+		GetLogger("cni.ipam").InfoStructured(traceMsg)
+		Expect(cni.entries).To(HaveLen(2))
+	})
+
+	It("logs printf style messages with the context of the Logger", func() {
+		SetPrefixer(PrefixerFunc(func(Level) string { return "" }))
+		GetLogger("ipam").With("a", "b").Infof("allocated %s", "10.0.0.2")
+		err := GetLogger("ipam").Errorf("failed: %w", errors.New("exhausted"))
+		Expect(errors.Unwrap(err)).To(MatchError("exhausted"))
+		Expect(out.String()).To(Equal(`allocated 10.0.0.2 logger="ipam" a="b"` + "\n" +
+			`failed: exhausted logger="ipam"` + "\n"))
+	})
+
+	It("inherits the prefixers from the nearest configured ancestor", func() {
+		SetFileFields()
+		SetPrefixerFor("cni", PrefixerFunc(func(level Level) string { return "[cni " + level.String() + "] " }))
+		SetStructuredPrefixerFor("cni.ipam", NewStructuredPrefixer(StaticField("subsystem", "ipam"), MessageField()))
+
+		GetLogger("cni.ipam.store").Warningf("%s", warningMsg)
+		GetLogger("cni.ipam.store").InfoStructured(infoMsg)
+		GetLogger("cni.netlink").InfoStructured(infoMsg)
+		Infof(infoMsg)
+		lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+		Expect(lines).To(HaveLen(4))
+		Expect(lines[0]).To(Equal(`[cni warning] ` + warningMsg + ` logger="cni.ipam.store"`))
+		Expect(lines[1]).To(Equal(`subsystem="ipam" msg="` + infoMsg + `" logger="cni.ipam.store"`))
+		Expect(lines[2]).To(HaveSuffix(`level="info" msg="` + infoMsg + `" logger="cni.netlink"`))
+		Expect(lines[3]).To(HaveSuffix(`[info] ` + infoMsg))
+
+		SetPrefixerFor("cni", nil)
+		out.Reset()
+		GetLogger("cni.ipam").Infof("%s", infoMsg)
+		Expect(out.String()).To(HaveSuffix(`[info] ` + infoMsg + ` logger="cni.ipam"` + "\n"))
+	})
+})
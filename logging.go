@@ -15,15 +15,17 @@
 package logging
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
-	"runtime/debug"
+	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
-
-	lumberjack "gopkg.in/natefinch/lumberjack.v2"
+	"unicode/utf8"
 )
 
 // Level type
@@ -32,27 +34,36 @@ type Level int
 /*
 Common use of different level:
 
+"fatal":   Unrecoverable error, the process exits
 "panic":   Code crash
 "error":   Unusual event occurred (invalid input or system issue), so exiting code prematurely
 "warning": Unusual event occurred (invalid input or system issue), but continuing
 "info":    Basic information, indication of major code paths
 "debug":   Additional information, indication of minor code branches
+"trace":   Very verbose information, e.g. packet or netlink level details
 */
 
+// FatalLevel is the zero value of Level. Before FatalLevel was added, SetLogLevel(0) was rejected; it now selects fatal
+// messages only, so APIs taking an optional level use a *Level, e.g. Rule.Level.
 const (
 	InvalidLevel Level = -1
+	FatalLevel   Level = 0
 	PanicLevel   Level = 1
 	ErrorLevel   Level = 2
 	WarningLevel Level = 3
 	InfoLevel    Level = 4
 	DebugLevel   Level = 5
-	maximumLevel Level = DebugLevel
+	TraceLevel   Level = 6
+	minimumLevel Level = FatalLevel
+	maximumLevel Level = TraceLevel
 
+	fatalStr   = "fatal"
 	panicStr   = "panic"
 	errorStr   = "error"
 	warningStr = "warning"
 	infoStr    = "info"
 	debugStr   = "debug"
+	traceStr   = "trace"
 	invalidStr = "invalid"
 )
 
@@ -70,19 +81,82 @@ const (
 )
 
 var levelMap = map[string]Level{
+	fatalStr:   FatalLevel,
 	panicStr:   PanicLevel,
 	errorStr:   ErrorLevel,
 	warningStr: WarningLevel,
 	infoStr:    InfoLevel,
 	debugStr:   DebugLevel,
+	traceStr:   TraceLevel,
 }
 
-var logger *lumberjack.Logger
+// mu guards the configuration of the logger below.
+var mu sync.RWMutex
+var logFileWriter *fileWriter
+var errorLogWriter *fileWriter
+var secondaryLogWriter *fileWriter
+var logRoutes []*route
+var secondaryFormatter Formatter
+var ringFile *ringBuffer
 var logWriter io.Writer
+var extraOutputs []io.Writer
+var hooks []Hook
+var panicHooks []PanicHook
+var customSinks []Sink
+var resolvers map[string]*fieldResolver
+var stderrFormatter, fileFormatter, syslogFormatter Formatter
+var maxEntrySize int
+var cniContext []interface{}
+var logLimiter *limiter
+var logDedup *deduplicator
+var logEscalator *escalator
 var logLevel Level
+var stderrLogLevel, fileLogLevel Level
+var quietLevel Level
 var logToStderr bool
+var logToStdout bool
+var stderrFailover bool
+var fileFallback *fallback
 var prefixer Prefixer
 var structuredPrefixer StructuredPrefixer
+var exitFunc func(int)
+var strictMode bool
+var callerInfo bool
+var callerSkip int
+var stackTraceLevel Level
+var stackTraceOptions StackTraceOptions
+var logRedactor *redactor
+var emergencyLogFile string
+var rootDir string
+var symlinkPolicy SymlinkPolicy
+var emergencyOutput *os.File
+var sanitize bool
+var errorHandler func(error)
+var stderrFields map[string]bool
+var fileFields map[string]bool
+var asyncOutput *asyncWriter
+var asciiOnly bool
+var syslogOutput *syslogWriter
+var syslogFields map[string]bool
+var syslogSendTime bool
+var syslogPayloadHook PayloadHook
+var networkProxy proxyFunc
+var schemaField bool
+var journaldOutput *journaldWriter
+var journaldFields map[string]bool
+var deterministicOutput *deterministic
+var levelToggle *signalToggle
+var reopenHandler *signalHandler
+var configWatcher *configWatch
+var logHashChain *hashChain
+var crashRecordWriter *crashRecorder
+var consoleTheme Theme
+var loggerLevels map[string]Level
+var loggerSinks map[string][]Sink
+var loggerPrefixers map[string]Prefixer
+var loggerStructuredPrefixers map[string]StructuredPrefixer
+var levelRules []levelRule
+var rotatingWriterFactory func() RotatingWriter
 
 // Prefixer creator interface. Implement this interface if you wish to create a custom prefix.
 type Prefixer interface {
@@ -127,14 +201,76 @@ func (f StructuredPrefixerFunc) CreateStructuredPrefix(loggingLevel Level, msg s
 type defaultPrefixer struct {
 	prefixFormat string
 	timeFormat   string
+	fields       []PrefixField
+}
+
+// PrefixField defines a field of the structured prefix created by NewStructuredPrefixer.
+type PrefixField struct {
+	// Key is the key of the field.
+	Key string
+	// Value produces the value of the field for a log message of the given level.
+	Value func(loggingLevel Level, message string) interface{}
+}
+
+// TimeField returns the "time" field of the default structured prefix.
+func TimeField() PrefixField {
+	return PrefixField{Key: "time", Value: func(Level, string) interface{} {
+		return logTime().Format(defaultTimestampFormat)
+	}}
+}
+
+// LevelField returns the "level" field of the default structured prefix.
+func LevelField() PrefixField {
+	return PrefixField{Key: "level", Value: func(loggingLevel Level, _ string) interface{} {
+		return loggingLevel
+	}}
+}
+
+// MessageField returns the "msg" field of the default structured prefix.
+func MessageField() PrefixField {
+	return PrefixField{Key: "msg", Value: func(_ Level, message string) interface{} {
+		return message
+	}}
+}
+
+// StaticField returns a field with a fixed value, e.g. the name of the component.
+func StaticField(key string, value interface{}) PrefixField {
+	return PrefixField{Key: key, Value: func(Level, string) interface{} {
+		return value
+	}}
+}
+
+// DefaultPrefixFields returns the fields of the default structured prefix: time, level and msg.
+func DefaultPrefixFields() []PrefixField {
+	return []PrefixField{TimeField(), LevelField(), MessageField()}
+}
+
+// NewStructuredPrefixer returns a StructuredPrefixer which produces the given fields in the given order. This allows
+// reordering or extending the default structured prefix without implementing a StructuredPrefixer:
+//
+//	SetStructuredPrefixer(NewStructuredPrefixer(LevelField(), TimeField(), StaticField("component", "ipam"), MessageField()))
+func NewStructuredPrefixer(fields ...PrefixField) StructuredPrefixer {
+	return &defaultPrefixer{
+		timeFormat: defaultTimestampFormat,
+		fields:     fields,
+	}
 }
 
-// LogOptions defines the configuration of the lumberjack logger
+// LogOptions defines the configuration of the lumberjack logger. Every option is a pointer which is omitted from JSON
+// when unset; unset options fall back to the other configuration sources and finally to their default value, so
+// netconf stanzas written for older versions keep their meaning when options are added.
 type LogOptions struct {
 	MaxAge     *int  `json:"maxAge,omitempty"`
 	MaxSize    *int  `json:"maxSize,omitempty"`
 	MaxBackups *int  `json:"maxBackups,omitempty"`
 	Compress   *bool `json:"compress,omitempty"`
+	// Preallocate reserves MaxSize of disk space whenever a log file is opened to avoid fragmentation (Linux only).
+	Preallocate *bool `json:"preallocate,omitempty"`
+	// RotationLock serializes rotation between processes sharing the log file through an advisory lock on a
+	// "<filename>.lock" file.
+	RotationLock *bool `json:"rotationLock,omitempty"`
+	// LocalTime stamps the rotated log files with the local time instead of UTC.
+	LocalTime *bool `json:"localTime,omitempty"`
 }
 
 func init() {
@@ -142,119 +278,256 @@ func init() {
 }
 
 func initLogger() {
-	logger = &lumberjack.Logger{}
+	mu.Lock()
+	defer unlockAndPublish()
+
+	rotatingWriterFactory = nil
+	logFileWriter = newFileWriter(newLumberjackWriter(), "")
 
 	// Set default options.
-	SetLogOptions(nil)
-	SetLogStderr(true)
-	SetLogFile("")
-	SetLogLevel(defaultLogLevel)
+	setLogOptions(nil)
+	logToStdout = false
+	setLogStderr(true)
+	setLogFile("")
+	setLogLevel(defaultLogLevel)
+	stderrLogLevel, fileLogLevel = InvalidLevel, InvalidLevel
+	quietLevel = InvalidLevel
+	rootDir = ""
+	symlinkPolicy = SymlinkPolicyDeny
+	resetStats()
+	resetAnnotations()
+	setExitFunc(nil)
+	strictMode = false
+	callerInfo = false
+	callerSkip = 0
+	stackTraceLevel = defaultStackTraceLevel
+	stackTraceOptions = StackTraceOptions{}
+	logRedactor = nil
+	sanitize = true
+	closeEmergencyLogFile()
+	emergencyLogFile = ""
+	errorHandler = nil
+	atomic.StoreInt64(&lastSelfLog, 0)
+	stderrFields = nil
+	fileFields = nil
+	syslogFields = nil
+	stderrFailover = false
+	fileFallback = nil
+	syslogSendTime = false
+	syslogPayloadHook = nil
+	networkProxy = nil
+	schemaField = false
+	deterministicOutput = nil
+	logHashChain = nil
+	crashRecordWriter = nil
+	consoleTheme = nil
+	loggerLevels = nil
+	loggerSinks = nil
+	loggerPrefixers = nil
+	loggerStructuredPrefixers = nil
+	levelRules = nil
+	resetPressure()
+	stopSignalToggle()
+	stopReopenOnSignal()
+	stopConfigWatch()
+	_ = closeErrorLogFile()
+	_ = closeSecondaryLogFile()
+	secondaryFormatter = nil
+	_ = setRoutes(nil, nil)
+	_ = closeRingFile()
+	if syslogOutput != nil {
+		_ = syslogOutput.close()
+		syslogOutput = nil
+	}
+	journaldFields = nil
+	if journaldOutput != nil {
+		_ = journaldOutput.close()
+		journaldOutput = nil
+	}
+	asciiOnly = false
+	setAsync(nil)
+	extraOutputs = nil
+	customSinks = nil
+	hooks = nil
+	panicHooks = nil
+	resolvers = nil
+	stderrFormatter, fileFormatter, syslogFormatter = nil, nil, nil
+	maxEntrySize = 0
+	cniContext = nil
+	if logLimiter != nil {
+		logLimiter.stop()
+		logLimiter = nil
+	}
+	logEscalator = nil
+	if logDedup != nil {
+		logDedup.stop()
+		logDedup = nil
+	}
 
 	// Create the default prefixer
-	SetDefaultPrefixer()
-	SetDefaultStructuredPrefixer()
+	prefixer = newDefaultPrefixer()
+	structuredPrefixer = newDefaultStructuredPrefixer()
+	configLayers = [numConfigSources]configLayer{}
 }
 
 // CreatePrefix implements the Prefixer interface for the defaultPrefixer.
 func (p *defaultPrefixer) CreatePrefix(loggingLevel Level) string {
-	return fmt.Sprintf(p.prefixFormat, time.Now().Format(p.timeFormat), loggingLevel)
+	return fmt.Sprintf(p.prefixFormat, logTime().Format(p.timeFormat), loggingLevel)
 }
 
 // CreateStructuredPrefix implements the StructuredPrefixer interface for the defaultPrefixer.
 func (p *defaultPrefixer) CreateStructuredPrefix(loggingLevel Level, message string) []interface{} {
-	return []interface{}{
-		"time", time.Now().Format(p.timeFormat),
-		"level", loggingLevel,
-		"msg", message,
+	prefix := make([]interface{}, 0, 2*len(p.fields))
+	for _, f := range p.fields {
+		prefix = append(prefix, f.Key, f.Value(loggingLevel, message))
 	}
+	return prefix
 }
 
 // SetPrefixer allows overwriting the Prefixer with a custom one.
 func SetPrefixer(p Prefixer) {
+	mu.Lock()
+	defer unlockAndPublish()
 	prefixer = p
 }
 
 // SetStructuredPrefixer allows overwriting the StructuredPrefixer with a custom one.
 func SetStructuredPrefixer(p StructuredPrefixer) {
+	mu.Lock()
+	defer unlockAndPublish()
 	structuredPrefixer = p
 }
 
 // SetDefaultPrefixer sets the default Prefixer.
 func SetDefaultPrefixer() {
-	defaultPrefix := &defaultPrefixer{
-		prefixFormat: "%s [%s] ",
-		timeFormat:   defaultTimestampFormat,
-	}
-	SetPrefixer(defaultPrefix)
+	SetPrefixer(newDefaultPrefixer())
 }
 
 // SetDefaultStructuredPrefixer sets the default StructuredPrefixer.
 func SetDefaultStructuredPrefixer() {
-	defaultStructuredPrefix := &defaultPrefixer{
-		timeFormat: defaultTimestampFormat,
+	SetStructuredPrefixer(newDefaultStructuredPrefixer())
+}
+
+// newDefaultPrefixer returns the default Prefixer.
+func newDefaultPrefixer() Prefixer {
+	return &defaultPrefixer{
+		prefixFormat: "%s [%s] ",
+		timeFormat:   defaultTimestampFormat,
 	}
-	SetStructuredPrefixer(defaultStructuredPrefix)
 }
 
-// Set the logging options (LogOptions)
+// newDefaultStructuredPrefixer returns the default StructuredPrefixer.
+func newDefaultStructuredPrefixer() StructuredPrefixer {
+	return NewStructuredPrefixer(DefaultPrefixFields()...)
+}
+
+// Set the logging options (LogOptions). Options which are not set fall back to the other configuration sources, see
+// ExplainConfig.
 func SetLogOptions(options *LogOptions) {
-	// give some default value
-	logger.MaxSize = 100
-	logger.MaxAge = 5
-	logger.MaxBackups = 5
-	logger.Compress = true
+	mu.Lock()
+	defer unlockAndPublish()
+	configLayers[SourceAPI].logOptions = LogOptions{}
 	if options != nil {
-		if options.MaxAge != nil {
-			logger.MaxAge = *options.MaxAge
-		}
-		if options.MaxSize != nil {
-			logger.MaxSize = *options.MaxSize
-		}
-		if options.MaxBackups != nil {
-			logger.MaxBackups = *options.MaxBackups
-		}
-		if options.Compress != nil {
-			logger.Compress = *options.Compress
-		}
+		configLayers[SourceAPI].logOptions = *options
 	}
+	setLogOptions(effectiveLogOptions())
+}
+
+// setLogOptions sets the logging options and makes the log file the output if file logging is enabled. The caller
+// must hold mu.
+func setLogOptions(options *LogOptions) {
+	setFileOptions(options)
 
 	// Update the logWriter if necessary.
-	if isFileLoggingEnabled() {
-		logWriter = logger
+	if isFileLoggingEnabled() && !isStreamLoggingEnabled() {
+		setLogWriter(logFileWriter)
 	}
 }
 
+// setFileOptions sets the logging options of the log files. The caller must hold mu.
+func setFileOptions(options *LogOptions) {
+	logFileWriter.setOptions(options)
+	if secondaryLogWriter != nil {
+		secondaryLogWriter.setOptions(options)
+	}
+	for _, w := range routeWriters() {
+		w.setOptions(options)
+	}
+}
+
+// currentLogOptions returns the logging options in effect. The caller must hold mu.
+func currentLogOptions() *LogOptions {
+	options := copyLogOptions(logFileWriter.logOptions)
+	return &options
+}
+
 // SetLogFile sets logging file.
 func SetLogFile(filename string) {
+	mu.Lock()
+	defer unlockAndPublish()
+	if setLogFile(filename) {
+		configLayers[SourceAPI].logFile = &filename
+	}
+}
+
+// setLogFile sets the logging file and reports whether it succeeded. The caller must hold mu.
+func setLogFile(filename string) bool {
 	// Allow logging to stderr only. Print an error a single time when this is set to the empty string but stderr
 	// logging is off.
 	if filename == "" {
-		if !logToStderr {
+		disableFileLogging()
+		if !isLoggingEnabled(minimumLevel) {
 			fmt.Fprint(os.Stderr, logFileReqFailMsg)
 		}
-		disableFileLogging()
-		return
+		return true
+	}
+
+	if stream, ok, err := openStream(filename); ok {
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return false
+		}
+		enableStreamLogging(stream)
+		return true
 	}
 
 	fp, err := resolvePath(filename)
 	if err != nil {
 		fmt.Fprint(os.Stderr, err)
-		return
+		useEmergencyLogFile(filename)
+		return false
 	}
 
 	if !isLogFileWritable(fp) {
 		fmt.Fprintf(os.Stderr, logFileFailMsg, filename)
-		return
+		useEmergencyLogFile(filename)
+		return false
 	}
 
-	logger.Filename = filename
-	logWriter = logger
+	enableFileLogging(fp)
+	return true
+}
+
+// enableFileLogging makes the logger write to filename, which must have been validated already.
+func enableFileLogging(filename string) {
+	logFileWriter.setFilename(filename)
+	setLogWriter(logFileWriter)
 }
 
 // disableFileLogging disables file logging.
 func disableFileLogging() {
-	logger.Filename = ""
-	logWriter = nil
+	logFileWriter.setFilename("")
+	setLogWriter(nil)
+}
+
+// configOwnsOutput returns false if the output of the log file was set with SetOutput or SetDailyLogFiles, which the
+// log file settings of the configuration sources do not replace unless the log file changes. The caller must hold mu.
+func configOwnsOutput() bool {
+	switch logWriter.(type) {
+	case *customOutput, *dailyWriter:
+		return false
+	}
+	return true
 }
 
 // isFileLoggingEnabled returns true if file logging is enabled.
@@ -264,16 +537,44 @@ func isFileLoggingEnabled() bool {
 
 // GetLogLevel gets current logging level
 func GetLogLevel() Level {
-	return logLevel
+	return loadSnapshot().level
+}
+
+// Enabled returns true if messages of the given level are logged to at least one output. Callers can use it to skip
+// building expensive arguments, e.g. marshaling the network configuration, for messages which would be filtered anyway.
+func Enabled(level Level) bool {
+	s := loadSnapshot()
+	return s.enabled(level) || level <= s.ruleLevel
 }
 
-// SetLogLevel sets logging level
+// IsDebugEnabled returns true if debug messages are logged, see Enabled.
+func IsDebugEnabled() bool {
+	return Enabled(DebugLevel)
+}
+
+// IsTraceEnabled returns true if trace messages are logged, see Enabled.
+func IsTraceEnabled() bool {
+	return Enabled(TraceLevel)
+}
+
+// SetLogLevel sets logging level. Note that 0 is FatalLevel: SetLogLevel(0), e.g. with a Level variable which was never
+// set, logs fatal messages only, while it used to be rejected before FatalLevel was added.
 func SetLogLevel(level Level) {
-	if validateLogLevel(level) {
-		logLevel = level
-	} else {
+	mu.Lock()
+	defer unlockAndPublish()
+	if setLogLevel(level) {
+		configLayers[SourceAPI].logLevel = &level
+	}
+}
+
+// setLogLevel sets the logging level and reports whether it succeeded. The caller must hold mu.
+func setLogLevel(level Level) bool {
+	if !validateLogLevel(level) {
 		fmt.Fprintf(os.Stderr, setLevelFailMsg, level)
+		return false
 	}
+	logLevel = level
+	return true
 }
 
 func StringToLevel(level string) Level {
@@ -283,17 +584,72 @@ func StringToLevel(level string) Level {
 	return InvalidLevel
 }
 
+// Levels returns the valid logging levels from the most severe to the most verbose, e.g. to list them in the help of a
+// flag or to validate a configuration without hardcoding them.
+func Levels() []Level {
+	levels := make([]Level, 0, maximumLevel-minimumLevel+1)
+	for level := minimumLevel; level <= maximumLevel; level++ {
+		levels = append(levels, level)
+	}
+	return levels
+}
+
+// MinLevel returns the most severe valid logging level, FatalLevel.
+func MinLevel() Level {
+	return minimumLevel
+}
+
+// MaxLevel returns the most verbose valid logging level, TraceLevel.
+func MaxLevel() Level {
+	return maximumLevel
+}
+
+// LevelFromVerbosity returns the cni-log level of a logr or klog verbosity.
+func LevelFromVerbosity(verbosity int) Level {
+	switch {
+	case verbosity <= 0:
+		return InfoLevel
+	case verbosity <= 4:
+		return DebugLevel
+	default:
+		return TraceLevel
+	}
+}
+
 // SetLogStderr sets flag for logging stderr output
 func SetLogStderr(enable bool) {
-	if !enable && !isFileLoggingEnabled() {
+	mu.Lock()
+	defer unlockAndPublish()
+	configLayers[SourceAPI].logToStderr = &enable
+	setLogStderr(enable)
+}
+
+// setLogStderr sets the flag for logging to stderr. The caller must hold mu.
+func setLogStderr(enable bool) {
+	logToStderr = enable
+	if !isLoggingEnabled(minimumLevel) {
+		fmt.Fprint(os.Stderr, logFileReqFailMsg)
+	}
+}
+
+// SetLogStdout enables logging to stdout, in addition to the other outputs, e.g. for plugins which keep stderr clean
+// and let the container runtime capture their stdout. The stdout output uses the settings of stderr: the formatter, see
+// SetStderrFormatter, the fields, see SetStderrFields, and the level, see SetStderrLogLevel. It is disabled by default.
+// Note that CNI plugins return their result on stdout, so stdout must only be used by processes which do not.
+func SetLogStdout(enable bool) {
+	mu.Lock()
+	defer unlockAndPublish()
+	logToStdout = enable
+	if !isLoggingEnabled(minimumLevel) {
 		fmt.Fprint(os.Stderr, logFileReqFailMsg)
 	}
-	logToStderr = enable
 }
 
 // String converts a Level into its string representation.
 func (l Level) String() string {
 	switch l {
+	case FatalLevel:
+		return fatalStr
 	case PanicLevel:
 		return panicStr
 	case WarningLevel:
@@ -304,6 +660,8 @@ func (l Level) String() string {
 		return errorStr
 	case DebugLevel:
 		return debugStr
+	case TraceLevel:
+		return traceStr
 	case InvalidLevel:
 		return invalidStr
 	default:
@@ -311,38 +669,262 @@ func (l Level) String() string {
 	}
 }
 
-// SetOutput set custom output WARNING subsequent call to SetLogFile or SetLogOptions invalidates this setting
+// SetStderrFields restricts the fields of structured log messages which are written to stderr to the provided keys,
+// e.g. when a collector already adds the time. Calling it without any keys writes all fields again.
+func SetStderrFields(keys ...string) {
+	mu.Lock()
+	defer unlockAndPublish()
+	stderrFields = fieldSet(keys)
+}
+
+// SetFileFields restricts the fields of structured log messages which are written to the log file or the custom output
+// to the provided keys. Calling it without any keys writes all fields again.
+func SetFileFields(keys ...string) {
+	mu.Lock()
+	defer unlockAndPublish()
+	fileFields = fieldSet(keys)
+}
+
+// fieldSet converts a list of keys into a set. It returns nil, meaning all fields, if no keys are provided.
+func fieldSet(keys []string) map[string]bool {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	set := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		set[k] = true
+	}
+	return set
+}
+
+// SetASCIIOnly enables escaping of all non-ASCII characters in log messages, for log parsers which cannot handle
+// UTF-8. Characters are escaped as \uXXXX, invalid UTF-8 bytes as \xXX.
+func SetASCIIOnly(enable bool) {
+	mu.Lock()
+	defer unlockAndPublish()
+	asciiOnly = enable
+}
+
+// SetOutput set custom output WARNING subsequent call to SetLogFile or SetLogOptions invalidates this setting. Use
+// AddOutput to log to a custom output in addition to the log file. The output is never written to concurrently, and
+// it is flushed by Flush if it buffers data, e.g. a *bufio.Writer. When the output is replaced or Close is called, it
+// is flushed and closed as well if it implements both Flush() error and Close() error, e.g. a *gzip.Writer, so no tail
+// data is lost; other outputs, e.g. an *os.File, are left open.
 func SetOutput(out io.Writer) {
-	logWriter = out
+	mu.Lock()
+	defer unlockAndPublish()
+
+	if o, ok := logWriter.(*customOutput); ok && o.is(out) {
+		return
+	}
+	setLogWriter(newCustomOutput(out))
+}
+
+// AddOutput adds an output which receives all log messages in addition to the log file, stderr and the other outputs.
+// Outputs added this way are not affected by SetLogFile, SetLogOptions or SetOutput, and they are written to
+// synchronously even if asynchronous logging is enabled. Structured log messages are restricted to the fields set with
+// SetFileFields. Outputs which buffer data, e.g. a *bufio.Writer, are flushed by Flush and Close, and Close closes
+// them like SetOutput does.
+func AddOutput(out io.Writer) {
+	if out == nil {
+		return
+	}
+
+	mu.Lock()
+	defer unlockAndPublish()
+
+	// Log calls use the slice after releasing mu, so it is never modified in place.
+	outputs := make([]io.Writer, 0, len(extraOutputs)+1)
+	extraOutputs = append(append(outputs, extraOutputs...), newCustomOutput(out))
+}
+
+// RemoveOutput removes an output added with AddOutput. Data buffered by the output is flushed first, and the output is
+// closed like by SetOutput. Outputs are compared with ==, outputs of types which are not comparable, e.g. slices,
+// cannot be removed.
+func RemoveOutput(out io.Writer) {
+	if out == nil || !reflect.TypeOf(out).Comparable() {
+		return
+	}
+
+	mu.Lock()
+	defer unlockAndPublish()
+
+	outputs := make([]io.Writer, 0, len(extraOutputs))
+	for _, o := range extraOutputs {
+		if o.(*customOutput).is(out) {
+			_ = closeOutput(o)
+			continue
+		}
+		outputs = append(outputs, o)
+	}
+	extraOutputs = outputs
+}
+
+// setLogWriter replaces the writer of the log file or custom output. Entries buffered for the previous writer are
+// flushed first, and a replaced custom output is closed, see SetOutput. The caller must hold mu.
+func setLogWriter(w io.Writer) {
+	_ = flushOutputs()
+	if d, ok := logWriter.(*dailyWriter); ok && logWriter != w {
+		_ = d.Close()
+	}
+	if logWriter != w {
+		_ = closeOutput(logWriter)
+	}
+	logWriter = w
+	if asyncOutput != nil {
+		asyncOutput.setOutput(w)
+	}
+}
+
+// fileOutput returns the writer log messages for the log file or custom output are written to, nil if there is none.
+// The caller must hold mu.
+func fileOutput() io.Writer {
+	if asyncOutput != nil && logWriter != nil {
+		return asyncOutput
+	}
+	return logWriter
+}
+
+// SetExitFunc sets the function which is called by Fatalf and FatalStructured to exit the process. Passing nil restores
+// the default, os.Exit. Tests can use this to intercept the exit.
+func SetExitFunc(f func(int)) {
+	mu.Lock()
+	defer unlockAndPublish()
+	setExitFunc(f)
+}
+
+// setExitFunc sets the exit function. The caller must hold mu.
+func setExitFunc(f func(int)) {
+	if f == nil {
+		f = os.Exit
+	}
+	exitFunc = f
+}
+
+// Fatalf prints logging, flushes the outputs and exits the process with status 1.
+func Fatalf(format string, a ...interface{}) {
+	printf(FatalLevel, format, a...)
+	Flush()
+	exit(1)
+}
+
+// FatalStructured provides structured logging for log level fatal. It flushes the outputs and exits the process with
+// status 1.
+func FatalStructured(msg string, args ...interface{}) {
+	printStructured(FatalLevel, msg, args...)
+	Flush()
+	exit(1)
+}
+
+// exit writes the crash record, if enabled, and calls the configured exit function.
+func exit(code int) {
+	s := loadSnapshot()
+	s.writeCrashRecord()
+	s.exitFunc(code)
+}
+
+// PanicfSync works like Panicf, but only returns once the message has been written to stable storage, see Sync.
+func PanicfSync(format string, a ...interface{}) {
+	Panicf(format, a...)
+	_ = Sync()
 }
 
 // Panicf prints logging plus stack trace. This should be used only for unrecoverable error
 func Panicf(format string, a ...interface{}) {
 	printf(PanicLevel, format, a...)
-	printf(PanicLevel, "========= Stack trace output ========")
-	printf(PanicLevel, "%+v", string(debug.Stack()))
-	printf(PanicLevel, "========= Stack trace output end ========")
 }
 
-// PanicStructured provides structured logging for log level >= panic.
+// PanicStructured provides structured logging for log level >= panic. The message carries a stack trace in the
+// "stacktrace" field.
 func PanicStructured(msg string, args ...interface{}) {
-	stackTrace := string(debug.Stack())
-	args = append(args, "stacktrace", stackTrace)
-	m := structuredMessage(PanicLevel, msg, args...)
-	printWithPrefixf(PanicLevel, false, m)
+	printStructured(PanicLevel, msg, args...)
 }
 
-// Errorf prints logging if logging level >= error
+// ErrorfSync works like Errorf, but only returns once the message has been written to stable storage, see Sync. Use it
+// on code paths which exit or return a fatal CNI error right afterwards.
+func ErrorfSync(format string, a ...interface{}) error {
+	err := Errorf(format, a...)
+	_ = Sync()
+	return err
+}
+
+// Errorf prints logging if logging level >= error. The returned error wraps the arguments of %w verbs, like the one
+// returned by fmt.Errorf.
 func Errorf(format string, a ...interface{}) error {
-	printf(ErrorLevel, format, a...)
-	return fmt.Errorf(format, a...)
+	return errorf(loadSnapshot(), format, a, nil)
+}
+
+// errorf prints a printf style error message like Errorf with the configuration s, followed by context, and returns it
+// as an error.
+func errorf(s *snapshot, format string, a []interface{}, context []interface{}) error {
+	err := fmt.Errorf(format, a...)
+	if s = s.withRules(err.Error()); s.enabled(ErrorLevel) && !s.quiet(ErrorLevel, nil) {
+		writeMessage(s, ErrorLevel, true, format, err.Error(), context...)
+	}
+	return err
 }
 
 // ErrorStructured provides structured logging for log level >= error.
 func ErrorStructured(msg string, args ...interface{}) error {
-	m := structuredMessage(ErrorLevel, msg, args...)
-	printWithPrefixf(ErrorLevel, false, m)
-	return fmt.Errorf("%s", m)
+	return errorStructured(loadSnapshot(), msg, args...)
+}
+
+// ErrorStructuredErr works like ErrorStructured, but logs err with an "error" field and, if err wraps other errors, a
+// "cause" field with the innermost one. The returned error wraps err, so errors.Is and errors.As see the error chain.
+func ErrorStructuredErr(err error, msg string, args ...interface{}) error {
+	return errorStructuredErr(loadSnapshot(), err, msg, args...)
+}
+
+// errorStructured prints a structured error message with the configuration s and returns it as an error. The error
+// carries the fields of the message even if the message itself is filtered.
+func errorStructured(s *snapshot, msg string, args ...interface{}) error {
+	fields := writeStructured(s, ErrorLevel, msg, true, args...)
+	if fields == nil {
+		fields = s.redactor.fields(s.fields(ErrorLevel, msg, args...))
+	}
+	return fmt.Errorf("%s", renderStructured(fields, nil))
+}
+
+// errorStructuredErr prints a structured error message with the fields of err and returns it as an error wrapping err.
+func errorStructuredErr(s *snapshot, err error, msg string, args ...interface{}) error {
+	if err == nil {
+		return errorStructured(s, msg, args...)
+	}
+
+	errArgs := make([]interface{}, 0, len(args)+4)
+	errArgs = append(append(errArgs, args...), errorKey, err.Error())
+	if cause := rootCause(err); cause != err {
+		errArgs = append(errArgs, causeKey, cause.Error())
+	}
+	return &structuredError{msg: errorStructured(s, msg, errArgs...).Error(), err: err}
+}
+
+// structuredError is a rendered structured error message which wraps the error it was logged for.
+type structuredError struct {
+	msg string
+	err error
+}
+
+// Error implements the error interface.
+func (e *structuredError) Error() string {
+	return e.msg
+}
+
+// Unwrap returns the wrapped error.
+func (e *structuredError) Unwrap() error {
+	return e.err
+}
+
+// rootCause returns the innermost error of the chain of err.
+func rootCause(err error) error {
+	for {
+		next := errors.Unwrap(err)
+		if next == nil {
+			return err
+		}
+		err = next
+	}
 }
 
 // Warningf prints logging if logging level >= warning
@@ -352,8 +934,7 @@ func Warningf(format string, a ...interface{}) {
 
 // WarningStructured provides structured logging for log level >= warning.
 func WarningStructured(msg string, args ...interface{}) {
-	m := structuredMessage(WarningLevel, msg, args...)
-	printWithPrefixf(WarningLevel, false, m)
+	printStructured(WarningLevel, msg, args...)
 }
 
 // Infof prints logging if logging level >= info
@@ -363,8 +944,7 @@ func Infof(format string, a ...interface{}) {
 
 // InfoStructured provides structured logging for log level >= info.
 func InfoStructured(msg string, args ...interface{}) {
-	m := structuredMessage(InfoLevel, msg, args...)
-	printWithPrefixf(InfoLevel, false, m)
+	printStructured(InfoLevel, msg, args...)
 }
 
 // Debugf prints logging if logging level >= debug
@@ -374,29 +954,61 @@ func Debugf(format string, a ...interface{}) {
 
 // DebugStructured provides structured logging for log level >= debug.
 func DebugStructured(msg string, args ...interface{}) {
-	m := structuredMessage(DebugLevel, msg, args...)
-	printWithPrefixf(DebugLevel, false, m)
+	printStructured(DebugLevel, msg, args...)
+}
+
+// Tracef prints logging if logging level >= trace
+func Tracef(format string, a ...interface{}) {
+	printf(TraceLevel, format, a...)
+}
+
+// TraceStructured provides structured logging for log level >= trace.
+func TraceStructured(msg string, args ...interface{}) {
+	printStructured(TraceLevel, msg, args...)
+}
+
+// Logf prints logging at the given level, for bridges from other logging libraries, e.g. the klogbridge package.
+// Unlike Fatalf and Panicf, it neither exits nor panics.
+func Logf(level Level, format string, a ...interface{}) {
+	printf(level, format, a...)
+}
+
+// LogStructured provides structured logging at the given level, for bridges from other logging libraries. Unlike
+// FatalStructured and PanicStructured, it neither exits nor panics.
+func LogStructured(level Level, msg string, args ...interface{}) {
+	printStructured(level, msg, args...)
 }
 
 // structuredMessage takes msg and an even list of args and returns a structured message.
 func structuredMessage(loggingLevel Level, msg string, args ...interface{}) string {
-	prefixArgs := structuredPrefixer.CreateStructuredPrefix(loggingLevel, msg)
-	if len(prefixArgs)%2 != 0 {
-		panic(fmt.Sprintf("msg=%q logging_failure=%q", msg, structuredPrefixerOddArguments))
-	}
-
-	var output []string
-	for i := 0; i < len(prefixArgs)-1; i += 2 {
-		output = append(output, fmt.Sprintf("%s=%q", argToString(prefixArgs[i]), argToString(prefixArgs[i+1])))
-	}
+	s := loadSnapshot()
+	return renderStructured(structuredFields(s, loggingLevel, msg, args...), nil)
+}
 
-	if len(args)%2 != 0 {
-		output = append(output, fmt.Sprintf("logging_failure=%q", structuredLoggingOddArguments))
-		panic(strings.Join(output, " "))
-	}
+// structuredFields takes msg and an even list of args and returns the key/value pairs of the structured message,
+// starting with the ones produced by the structured prefixer of s. Malformed prefixes and args are reported as
+// configured in s, see SetStrictMode.
+func structuredFields(s *snapshot, loggingLevel Level, msg string, args ...interface{}) []interface{} {
+	prefixArgs := s.structuredPrefixer.CreateStructuredPrefix(loggingLevel, msg)
+	prefixArgs = s.evenArgs(fmt.Sprintf("msg=%q", msg), structuredPrefixerOddArguments, prefixArgs)
+	args = s.evenArgs(renderStructured(prefixArgs, nil), structuredLoggingOddArguments, args)
+
+	fields := make([]interface{}, 0, len(prefixArgs)+len(args))
+	fields = append(fields, prefixArgs...)
+	return append(fields, args...)
+}
 
-	for i := 0; i < len(args)-1; i += 2 {
-		output = append(output, fmt.Sprintf("%s=%q", argToString(args[i]), argToString(args[i+1])))
+// renderStructured renders an even list of key/value pairs. If allowed is not nil, only the keys contained in it are
+// rendered. Keys are written unquoted, so the characters which are not allowed in logfmt keys are replaced, see
+// logfmtKey.
+func renderStructured(fields []interface{}, allowed map[string]bool) string {
+	var output []string
+	for i := 0; i < len(fields)-1; i += 2 {
+		key := argToString(fields[i])
+		if allowed != nil && !allowed[key] {
+			continue
+		}
+		output = append(output, fmt.Sprintf("%s=%q", logfmtKey(key), argToString(fields[i+1])))
 	}
 
 	return strings.Join(output, " ")
@@ -407,10 +1019,40 @@ func argToString(arg interface{}) string {
 	return fmt.Sprintf("%+v", arg)
 }
 
-// doWritef takes care of the low level writing to the output io.Writer.
-func doWritef(writer io.Writer, format string, a ...interface{}) {
-	fmt.Fprintf(writer, format, a...)
-	fmt.Fprintf(writer, "\n")
+// doWrite takes care of the low level writing of a log line to the output io.Writer. The line is written with a
+// single write so that lines of concurrent writers do not interleave.
+func doWrite(writer io.Writer, line string) error {
+	_, err := io.WriteString(writer, line+"\n")
+	return err
+}
+
+// formatLine renders a log line. If asciiOnly is set, all non-ASCII characters are escaped.
+func formatLine(asciiOnly bool, format string, a ...interface{}) string {
+	line := fmt.Sprintf(format, a...)
+	if asciiOnly {
+		line = toASCII(line)
+	}
+	return line
+}
+
+// toASCII escapes all non-ASCII characters of s: runes as \uXXXX or \UXXXXXXXX, invalid UTF-8 bytes as \xXX.
+func toASCII(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r < utf8.RuneSelf:
+			b.WriteByte(s[i])
+		case r == utf8.RuneError && size == 1:
+			fmt.Fprintf(&b, `\x%02x`, s[i])
+		case r > 0xffff:
+			fmt.Fprintf(&b, `\U%08x`, r)
+		default:
+			fmt.Fprintf(&b, `\u%04x`, r)
+		}
+		i += size
+	}
+	return b.String()
 }
 
 // printf prints log messages if they match the configured log level. A configured prefix is prepended to messages.
@@ -421,25 +1063,287 @@ func printf(level Level, format string, a ...interface{}) {
 // printWithPrefixf prints log messages if they match the configured log level. Messages are optionally prepended by a
 // configured prefix.
 func printWithPrefixf(level Level, printPrefix bool, format string, a ...interface{}) {
-	if level > logLevel {
+	writef(loadSnapshot(), level, printPrefix, format, a, nil)
+}
+
+// writef prints a printf style message like printWithPrefixf with the configuration s. context, the alternating keys
+// and values of a Logger, is written after the message.
+func writef(s *snapshot, level Level, printPrefix bool, format string, a []interface{}, context []interface{}) {
+	if len(s.levelRules) > 0 {
+		message := fmt.Sprintf(format, a...)
+		if s = s.withRules(message); (s.enabled(level) || s.escalated(level, nil)) && !s.quiet(level, nil) {
+			writeMessage(s, level, printPrefix, format, message, context...)
+		}
 		return
 	}
+	if (s.enabled(level) || s.escalated(level, nil)) && !s.quiet(level, nil) {
+		writeMessage(s, level, printPrefix, format, fmt.Sprintf(format, a...), context...)
+	}
+}
 
-	if !isFileLoggingEnabled() && !logToStderr {
+// writeMessage writes a formatted printf style message to the sinks of s, followed by the alternating keys and values
+// of context and a stack trace if the level requires one. format identifies the message for rate limiting.
+func writeMessage(s *snapshot, level Level, printPrefix bool, format, message string, context ...interface{}) {
+	message = s.redactor.message(message)
+	if s.sanitize {
+		message = sanitizeString(message)
+	}
+	defer s.recordError(level, nil)
+	if suppressDuplicate(s.dedup, level, message) || (s.limiter != nil && !s.limiter.allow(level, format, nil, nil)) {
 		return
 	}
+	extra := s.annotations()
+	if len(context) > 0 {
+		if s.sanitize {
+			context = sanitizeKeys(context)
+		}
+		extra = append(context[:len(context):len(context)], extra...)
+	}
+	if diagnostics := s.panicDiagnostics(level, message); len(diagnostics) > 0 {
+		extra = append(extra[:len(extra):len(extra)], diagnostics...)
+	}
+	writeLine(s, level, printPrefix, message, s.redactor.fields(extra)...)
+	if s.stackTraceEnabled(level) {
+		writeLine(s, level, printPrefix, stackTraceHeader)
+		writeLine(s, level, printPrefix, stackTrace(s.stackTraceOptions))
+		writeLine(s, level, printPrefix, stackTraceFooter)
+	}
+}
 
+// writeLine writes a printf style line, followed by the alternating keys and values of extra, to the sinks of s.
+func writeLine(s *snapshot, level Level, printPrefix bool, message string, extra ...interface{}) {
+	entry := Entry{Time: s.now(), Level: level, Message: message, escalated: s.escalated(level, nil)}
 	if printPrefix {
-		format = prefixer.CreatePrefix(level) + format
+		entry.prefix = s.prefixer.CreatePrefix(level)
+		if s.callerInfo {
+			entry.prefix += s.callSite() + " "
+		}
+		if len(s.cniContext) > 0 {
+			entry.prefix += renderStructured(s.cniContext, nil) + " "
+		}
+	}
+	if len(extra) > 0 {
+		entry = entry.withFields(extra...)
+	}
+	if s.schema {
+		entry = entry.withFields(schemaKey, SchemaVersion)
+	}
+	writeSinks(s, entry)
+}
+
+// printStructured prints structured log messages if they match the configured log level. Every output only receives
+// the fields which it is configured to receive. It returns all fields of the message, or nil if the message is filtered;
+// the level is checked before any field is rendered, so filtered messages are cheap.
+func printStructured(level Level, msg string, args ...interface{}) []interface{} {
+	return writeStructured(loadSnapshot(), level, msg, true, args...)
+}
+
+// writeStructured prints structured log messages like printStructured with the configuration s. Messages are subject to
+// sampling and rate limiting if limit is set.
+func writeStructured(s *snapshot, level Level, msg string, limit bool, args ...interface{}) []interface{} {
+	s = s.withRules(msg)
+	escalated := s.escalated(level, args)
+	if !(s.enabled(level) || escalated) || s.quiet(level, args) {
+		return nil
+	}
+	defer s.recordError(level, args)
+	if limit {
+		if s.dedup != nil {
+			// Duplicates are detected by their values, so lazy values have to be computed first.
+			args = evaluateLazy(args)
+			if suppressDuplicate(s.dedup, level, msg+" "+renderStructured(args, nil)) {
+				return nil
+			}
+		}
+		if s.limiter != nil && !s.limiter.allow(level, msg, args, s.cniContext) {
+			return nil
+		}
+	}
+
+	args = evaluateLazy(args)
+	if s.sanitize {
+		args = sanitizeKeys(args)
 	}
+	if diagnostics := s.panicDiagnostics(level, msg); len(diagnostics) > 0 {
+		args = append(args[:len(args):len(args)], diagnostics...)
+	}
+	if s.stackTraceEnabled(level) {
+		args = append(args[:len(args):len(args)], stackTraceKey, stackTrace(s.stackTraceOptions))
+	}
+	fields := s.fields(level, msg, args...)
+	if annotations := s.annotations(); len(annotations) > 0 {
+		fields = append(fields, annotations...)
+	}
+	fields = s.redactor.fields(enrich(s.resolvers, fields))
+	msg = s.redactor.message(msg)
+	writeSinks(s, Entry{Time: s.now(), Level: level, Message: msg, Fields: fields, structured: true,
+		escalated: escalated})
+	return fields
+}
 
-	if logToStderr {
-		doWritef(os.Stderr, format, a...)
+// writeSinks passes entry through the hooks of s and writes it to the sinks of s unless a hook dropped it.
+func writeSinks(s *snapshot, entry Entry) {
+	if entry.Level == FatalLevel && s.crashRecord != nil {
+		s.crashRecord.capture(entry)
 	}
+	entry, ok := runHooks(s.hooks, entry)
+	if !ok {
+		return
+	}
+	countMessage(entry.Level)
+	for _, sink := range s.sinks {
+		if err := sink.Write(entry); err != nil {
+			s.reportWriteError(err)
+		}
+	}
+}
 
-	if isFileLoggingEnabled() {
-		doWritef(logWriter, format, a...)
+// isLoggingEnabled returns true if messages of the given level are logged to at least one output. The caller must hold
+// mu.
+func isLoggingEnabled(level Level) bool {
+	if level > logLevel {
+		return false
 	}
+
+	return isFileLoggingEnabled() || logToStderr || logToStdout || errorLogWriter != nil || secondaryLogWriter != nil ||
+		ringFile != nil || syslogOutput != nil || journaldOutput != nil || len(extraOutputs) > 0 || len(customSinks) > 0
+}
+
+// Flush writes all pending log messages to their outputs. Callers should defer it, or Close, in main() when
+// asynchronous logging or a buffering custom output is used.
+func Flush() {
+	flushDuplicates()
+
+	mu.RLock()
+	defer mu.RUnlock()
+	_ = flushOutputs()
+}
+
+// Close flushes all pending log messages and closes the log file. Logging to the log file after Close reopens it.
+// Custom outputs which buffer data and implement Close() error are closed as well, see SetOutput; they are not
+// written to anymore.
+func Close() error {
+	flushDuplicates()
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	flushErr := flushOutputs()
+	for _, o := range append([]io.Writer{logWriter}, extraOutputs...) {
+		if err := closeOutput(o); err != nil {
+			flushErr = err
+		}
+	}
+	if d, ok := logWriter.(*dailyWriter); ok {
+		if err := d.Close(); err != nil {
+			return err
+		}
+	}
+	if err := logFileWriter.close(); err != nil {
+		return err
+	}
+	if errorLogWriter != nil {
+		if err := errorLogWriter.close(); err != nil {
+			return err
+		}
+	}
+	if secondaryLogWriter != nil {
+		if err := secondaryLogWriter.close(); err != nil {
+			return err
+		}
+	}
+	for _, w := range routeWriters() {
+		if err := w.close(); err != nil {
+			return err
+		}
+	}
+	return flushErr
+}
+
+// Sync flushes all pending log messages, like Flush, and commits the log file and all outputs and sinks which implement
+// Sync() error, e.g. an *os.File, to stable storage. It returns the last error, if any.
+func Sync() error {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	err := flushOutputs()
+	if logWriter == logFileWriter {
+		if syncErr := logFileWriter.sync(); syncErr != nil {
+			err = syncErr
+		}
+	}
+	if errorLogWriter != nil {
+		if syncErr := errorLogWriter.sync(); syncErr != nil {
+			err = syncErr
+		}
+	}
+	if secondaryLogWriter != nil {
+		if syncErr := secondaryLogWriter.sync(); syncErr != nil {
+			err = syncErr
+		}
+	}
+	for _, w := range routeWriters() {
+		if syncErr := w.sync(); syncErr != nil {
+			err = syncErr
+		}
+	}
+	if ringFile != nil {
+		if syncErr := ringFile.Sync(); syncErr != nil {
+			err = syncErr
+		}
+	}
+
+	outputs := []interface{}{logWriter}
+	for _, w := range extraOutputs {
+		outputs = append(outputs, w)
+	}
+	for _, s := range customSinks {
+		outputs = append(outputs, s)
+	}
+	for _, o := range outputs {
+		if s, ok := o.(interface{ Sync() error }); ok {
+			if syncErr := s.Sync(); syncErr != nil {
+				err = syncErr
+			}
+		}
+	}
+	return err
+}
+
+// flushOutputs writes entries buffered for asynchronous logging and flushes the custom outputs set with SetOutput or
+// AddOutput and the sinks added with AddSink or SetSinksFor if they buffer data, e.g. a *bufio.Writer. The caller must
+// hold mu.
+func flushOutputs() error {
+	var err error
+	if asyncOutput != nil {
+		err = asyncOutput.Flush()
+	}
+	outputs := []interface{}{logWriter}
+	for _, w := range extraOutputs {
+		outputs = append(outputs, w)
+	}
+	for _, s := range customSinks {
+		outputs = append(outputs, s)
+	}
+	for _, sinks := range loggerSinks {
+		for _, s := range sinks {
+			outputs = append(outputs, s)
+		}
+	}
+	for _, o := range outputs {
+		if flushErr := flushWriter(o); flushErr != nil {
+			err = flushErr
+		}
+	}
+	return err
+}
+
+// flushWriter flushes w, an output or a sink, if it buffers data.
+func flushWriter(w interface{}) error {
+	if f, ok := w.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
 }
 
 // isLogFileWritable checks if the path can be written to. If the file does not exist yet, the entire path including
@@ -479,19 +1383,16 @@ func isSymLink(path string) bool {
 	return false
 }
 
-// resolvePath will try to resolve the provided path. If path is empty or is a symlink, return an error.
+// resolvePath will try to resolve the provided path, relative to the root directory if one is set. If path is empty or
+// violates the symlink policy, see SetSymlinkPolicy, return an error. The caller must hold mu.
 func resolvePath(path string) (string, error) {
 	if path == "" {
 		return "", fmt.Errorf(emptyStringFailMsg)
 	}
 
-	if isSymLink(path) {
-		return "", fmt.Errorf(symlinkEvalFailMsg, path)
-	}
-
-	return filepath.Clean(path), nil
+	return resolveSymlinks(path)
 }
 
 func validateLogLevel(level Level) bool {
-	return level > 0 && level <= maximumLevel
+	return level >= minimumLevel && level <= maximumLevel
 }
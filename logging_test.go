@@ -1,26 +1,32 @@
 package logging
 
 import (
+	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path"
+	"reflect"
 	"regexp"
 	"strings"
 	"testing"
 
-	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/ginkgo/v2/dsl/decorators"
 	. "github.com/onsi/gomega"
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 const (
+	fatalMsg   = "This is a FATAL message"
 	panicMsg   = "This is a PANIC message"
 	errorMsg   = "This is an ERROR message"
 	warningMsg = "This is a WARNING message"
 	infoMsg    = "This is an INFO message"
 	debugMsg   = "This is a DEBUG message"
+	traceMsg   = "This is a TRACE message"
 )
 
 type customPrefix struct {
@@ -72,6 +78,33 @@ var _ = Describe("CNI Logging Operations", func() {
 		})
 	})
 
+	Context("Enumerating the levels", func() {
+		It("returns the valid levels in order of verbosity", func() {
+			Expect(Levels()).To(Equal([]Level{FatalLevel, PanicLevel, ErrorLevel, WarningLevel, InfoLevel, DebugLevel,
+				TraceLevel}))
+			Expect(MinLevel()).To(Equal(FatalLevel))
+			Expect(MaxLevel()).To(Equal(TraceLevel))
+			for _, level := range Levels() {
+				Expect(StringToLevel(level.String())).To(Equal(level))
+			}
+		})
+
+		It("maps logr and klog verbosities to levels", func() {
+			Expect(LevelFromVerbosity(0)).To(Equal(InfoLevel))
+			Expect(LevelFromVerbosity(2)).To(Equal(DebugLevel))
+			Expect(LevelFromVerbosity(4)).To(Equal(DebugLevel))
+			Expect(LevelFromVerbosity(5)).To(Equal(TraceLevel))
+		})
+
+		It("treats the zero value as FatalLevel", func() {
+			var level Level
+			SetLogLevel(level)
+			Expect(GetLogLevel()).To(Equal(FatalLevel))
+			SetLogLevel(InvalidLevel)
+			Expect(GetLogLevel()).To(Equal(FatalLevel))
+		})
+	})
+
 	Context("Setting error logging", func() {
 		Context("File logging is disabled", func() {
 			When("error logging is enabled first and file logging is disabled later", func() {
@@ -134,7 +167,7 @@ var _ = Describe("CNI Logging Operations", func() {
 		When("the log file name is valid", func() {
 			It("prepares the logger's writer and creates the log file", func() {
 				SetLogFile(logFile)
-				Expect(logWriter).To(Equal(logger))
+				Expect(logWriter).To(Equal(logFileWriter))
 				Expect(logFile).To(BeAnExistingFile())
 			})
 		})
@@ -153,7 +186,7 @@ var _ = Describe("CNI Logging Operations", func() {
 
 			It("should be created", func() {
 				SetLogFile(logFile)
-				Expect(logWriter).To(Equal(logger))
+				Expect(logWriter).To(Equal(logFileWriter))
 				Expect(logFile).To(BeAnExistingFile())
 			})
 		})
@@ -221,7 +254,7 @@ var _ = Describe("CNI Logging Operations", func() {
 					Compress:   getPrimitivePointer(true),
 				}
 				SetLogOptions(logOpts)
-				Expect(logger).To(Equal(expectedLogger))
+				Expect(currentLumberjack()).To(Equal(expectedLogger))
 			})
 		})
 
@@ -240,7 +273,36 @@ var _ = Describe("CNI Logging Operations", func() {
 					Compress:   getPrimitivePointer(true),
 				}
 				SetLogOptions(logOpts)
-				Expect(logger).To(Equal(expectedLogger))
+				Expect(currentLumberjack()).To(Equal(expectedLogger))
+			})
+		})
+
+		When("localTime is set", func() {
+			It("should stamp the rotated log files with the local time", func() {
+				SetLogFile(logFile)
+				SetLogOptions(&LogOptions{LocalTime: getPrimitivePointer(true)})
+				Expect(currentLumberjack().LocalTime).To(BeTrue())
+				Expect(*currentLogOptions().LocalTime).To(BeTrue())
+
+				SetLogOptions(nil)
+				Expect(currentLumberjack().LocalTime).To(BeFalse())
+			})
+		})
+
+		When("options are added to LogOptions", func() {
+			It("should keep them optional in JSON and give them a default", func() {
+				defaults := reflect.ValueOf(defaultLogOptions())
+				optionsType := reflect.TypeOf(LogOptions{})
+				for i := 0; i < optionsType.NumField(); i++ {
+					field := optionsType.Field(i)
+					Expect(field.Type.Kind()).To(Equal(reflect.Ptr), field.Name)
+					Expect(field.Tag.Get("json")).To(HaveSuffix(",omitempty"), field.Name)
+					Expect(defaults.Field(i).IsNil()).To(BeFalse(), field.Name)
+				}
+
+				options := &LogOptions{}
+				Expect(decodeJSON([]byte(`{"maxSize": 10, "compress": false}`), &options, true)).To(Succeed())
+				Expect(options).To(Equal(&LogOptions{MaxSize: getPrimitivePointer(10), Compress: getPrimitivePointer(false)}))
 			})
 		})
 
@@ -256,7 +318,7 @@ var _ = Describe("CNI Logging Operations", func() {
 				}
 
 				SetLogOptions(nil)
-				Expect(logger).To(Equal(expectedLogger))
+				Expect(currentLumberjack()).To(Equal(expectedLogger))
 			})
 		})
 	})
@@ -370,8 +432,47 @@ var _ = Describe("CNI Logging Operations", func() {
 			})
 		})
 
-		When("stucturedMessage is called with an odd number of arguments", func() {
+		When("log level is set to TRACE and messages are logged", func() {
+			It("should print trace messages to log file", func() {
+				SetLogFile(logFile)
+				SetLogStderr(false)
+
+				SetLogLevel(DebugLevel)
+				Tracef(traceMsg)
+				Expect(logFileContains(logFile, traceMsg)).To(BeFalse())
+				TraceStructured(traceMsg)
+				Expect(logFileContainsRegex(logFile, fmt.Sprintf(`level=%q msg=%q`, traceStr, traceMsg))).To(BeFalse())
+
+				SetLogLevel(StringToLevel(traceStr))
+				Tracef(traceMsg)
+				Expect(logFileContains(logFile, fmt.Sprintf("[%s] %s", traceStr, traceMsg))).To(BeTrue())
+				TraceStructured(traceMsg)
+				Expect(logFileContainsRegex(logFile, fmt.Sprintf(`time=".*" level=%q msg=%q`, traceStr, traceMsg))).To(BeTrue())
+			})
+		})
+
+		When("a structured message does not match the log level", func() {
+			It("does not compute the structured prefix", func() {
+				calls := 0
+				SetStructuredPrefixer(StructuredPrefixerFunc(func(Level, string) []interface{} {
+					calls++
+					return nil
+				}))
+				SetLogLevel(InfoLevel)
+				DebugStructured(debugMsg, "a", "b")
+				Expect(calls).To(BeZero())
+
+				SetLogLevel(PanicLevel)
+				Expect(ErrorStructured(errorMsg, "a", "b")).To(MatchError(`a="b"`))
+				Expect(calls).To(Equal(1))
+				SetDefaultStructuredPrefixer()
+			})
+		})
+
+		When("stucturedMessage is called with an odd number of arguments in strict mode", func() {
 			It("should panic", func() {
+				SetStrictMode(true)
+				defer SetStrictMode(false)
 				Expect(func() { structuredMessage(InfoLevel, infoMsg, "a", "b", "c") }).Should(PanicWith(MatchRegexp( //nolint:staticcheck
 					fmt.Sprintf(`^time=".*" msg=%q logging_failure=%q$`, infoMsg, structuredLoggingOddArguments))))
 			})
@@ -404,6 +505,116 @@ var _ = Describe("CNI Logging Operations", func() {
 			})
 		})
 
+		When("additional outputs are added", func() {
+			var out, other bytes.Buffer
+
+			BeforeEach(func() {
+				out, other = bytes.Buffer{}, bytes.Buffer{}
+				SetLogStderr(false)
+				SetLogFile(logFile)
+				AddOutput(&out)
+				AddOutput(&other)
+			})
+
+			It("logs to the log file and all outputs", func() {
+				Infof(infoMsg)
+				InfoStructured(debugMsg, "a", "b")
+				Expect(logFileContains(logFile, infoMsg)).To(BeTrue())
+				for _, o := range []*bytes.Buffer{&out, &other} {
+					Expect(o.String()).To(ContainSubstring(infoMsg))
+					Expect(o.String()).To(MatchRegexp(fmt.Sprintf(`msg=%q a="b"`, debugMsg)))
+				}
+			})
+
+			It("keeps the outputs after a call to SetLogFile, SetLogOptions or SetOutput", func() {
+				SetLogFile("")
+				SetLogOptions(nil)
+				SetOutput(nil)
+				Infof(infoMsg)
+				Expect(out.String()).To(ContainSubstring(infoMsg))
+			})
+
+			It("stops logging to a removed output and flushes it", func() {
+				var buf bytes.Buffer
+				buffered := bufio.NewWriter(&buf)
+				AddOutput(buffered)
+				Infof(infoMsg)
+				RemoveOutput(buffered)
+				RemoveOutput(&out)
+				Expect(buf.String()).To(ContainSubstring(infoMsg))
+
+				Infof(warningMsg)
+				Expect(buf.String()).NotTo(ContainSubstring(warningMsg))
+				Expect(out.String()).NotTo(ContainSubstring(warningMsg))
+				Expect(other.String()).To(ContainSubstring(warningMsg))
+			})
+
+			It("logs to the outputs if file and stderr logging are off", func() {
+				SetLogFile("")
+				Infof(infoMsg)
+				Expect(out.String()).To(ContainSubstring(infoMsg))
+			})
+		})
+
+		When("field allow-lists are configured", func() {
+			BeforeEach(func() {
+				SetLogStderr(true)
+				SetLogFile(logFile)
+				SetStderrFields("level", "msg", "pod")
+			})
+
+			It("writes only the allowed fields to stderr", func() {
+				errStr := captureStdErrEvent(InfoStructured, infoMsg, "pod", "pod-a", "ifname", "net1")
+				Expect(errStr).To(Equal(fmt.Sprintf("level=%q msg=%q pod=\"pod-a\"\n", infoStr, infoMsg)))
+				Expect(logFileContainsRegex(logFile,
+					fmt.Sprintf(`time=".*" level=%q msg=%q pod="pod-a" ifname="net1"`, infoStr, infoMsg))).To(BeTrue())
+			})
+
+			It("writes only the allowed fields to the log file", func() {
+				SetStderrFields()
+				SetFileFields("msg")
+				errStr := captureStdErrEvent(InfoStructured, infoMsg, "pod", "pod-a")
+				Expect(errStr).To(MatchRegexp(fmt.Sprintf(`time=".*" level=%q msg=%q pod="pod-a"`, infoStr, infoMsg)))
+				Expect(logFileContains(logFile, fmt.Sprintf("msg=%q\n", infoMsg))).To(BeTrue())
+				Expect(logFileContains(logFile, "pod-a")).To(BeFalse())
+			})
+
+			It("returns all fields from ErrorStructured", func() {
+				var err error
+				_ = captureStdErrEvent(func(msg string, args ...interface{}) { err = ErrorStructured(msg, args...) },
+					errorMsg, "pod", "pod-a", "ifname", "net1")
+				Expect(err).To(MatchError(ContainSubstring(`ifname="net1"`)))
+			})
+		})
+
+		When("a fatal message is logged", func() {
+			var exitCode int
+			var out bytes.Buffer
+			var buffered *bufio.Writer
+
+			BeforeEach(func() {
+				exitCode = -1
+				SetExitFunc(func(code int) { exitCode = code })
+				out = bytes.Buffer{}
+				buffered = bufio.NewWriter(&out)
+				SetOutput(buffered)
+				SetLogStderr(false)
+				SetLogLevel(PanicLevel)
+			})
+
+			It("logs the message, flushes the output and exits with status 1", func() {
+				Fatalf(fatalMsg)
+				Expect(out.String()).To(ContainSubstring(fmt.Sprintf("[%s] %s", fatalStr, fatalMsg)))
+				Expect(exitCode).To(Equal(1))
+			})
+
+			It("logs the structured message, flushes the output and exits with status 1", func() {
+				FatalStructured(fatalMsg, "a", "b")
+				Expect(out.String()).To(MatchRegexp(fmt.Sprintf(`time=".*" level=%q msg=%q a="b"`, fatalStr, fatalMsg)))
+				Expect(exitCode).To(Equal(1))
+			})
+		})
+
 		When("error logging is on and file logging is off", func() {
 			BeforeEach(func() {
 				errStr := captureStdErr(SetLogStderr, true)
@@ -543,8 +754,22 @@ var _ = Describe("CNI Logging Operations", func() {
 			})
 		})
 
-		When("an invalid custom structured prefix is provided", func() {
+		When("the fields of the default structured prefix are reordered and extended", func() {
+			It("uses the configured fields", func() {
+				SetStructuredPrefixer(NewStructuredPrefixer(
+					LevelField(), TimeField(), StaticField("component", "ipam"), MessageField()))
+
+				expected := fmt.Sprintf(`level=%q time=".*" component="ipam" msg=%q a="b"`, infoStr, infoMsg)
+				errStr := captureStdErrEvent(InfoStructured, infoMsg, "a", "b")
+				Expect(errStr).To(MatchRegexp(expected))
+				Expect(logFileContainsRegex(logFile, expected)).To(BeTrue())
+			})
+		})
+
+		When("an invalid custom structured prefix is provided in strict mode", func() {
 			It("should panic", func() {
+				SetStrictMode(true)
+				defer SetStrictMode(false)
 				var invalidPrefix StructuredPrefixerFunc = func(loggingLevel Level, message string) []interface{} {
 					return []interface{}{
 						"custom-level", loggingLevel,
@@ -562,6 +787,40 @@ var _ = Describe("CNI Logging Operations", func() {
 	})
 })
 
+var _ = Describe("Error wrapping", func() {
+	var sink *captureSink
+	errNotFound := errors.New("not found")
+
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+		sink = &captureSink{}
+		AddSink(sink)
+	})
+
+	It("preserves %w wrapping in Errorf", func() {
+		err := Errorf("cannot allocate address: %w", errNotFound)
+		Expect(errors.Is(err, errNotFound)).To(BeTrue())
+		Expect(sink.entries[0].Message).To(Equal("cannot allocate address: not found"))
+	})
+
+	It("logs the error chain and wraps the error in ErrorStructuredErr", func() {
+		wrapped := fmt.Errorf("lookup pod: %w", errNotFound)
+		err := ErrorStructuredErr(wrapped, errorMsg, "pod", "pod-a")
+		Expect(errors.Is(err, errNotFound)).To(BeTrue())
+		Expect(err.Error()).To(HaveSuffix(`pod="pod-a" error="lookup pod: not found" cause="not found"`))
+		Expect(sink.entries[0].String()).To(Equal(err.Error()))
+
+		err = With("pod", "pod-b").ErrorStructuredErr(errNotFound, errorMsg)
+		Expect(errors.Is(err, errNotFound)).To(BeTrue())
+		Expect(err.Error()).To(HaveSuffix(`pod="pod-b" error="not found"`))
+	})
+
+	It("works like ErrorStructured without an error", func() {
+		Expect(ErrorStructuredErr(nil, errorMsg, "a", "b")).To(MatchError(HaveSuffix(`a="b"`)))
+	})
+})
+
 var _ = Describe("CNI Log Level Operations", func() {
 	BeforeEach(func() {
 		initLogger()
@@ -574,6 +833,8 @@ var _ = Describe("CNI Log Level Operations", func() {
 					Expect(StringToLevel(warningStr)).To(Equal(WarningLevel))
 					Expect(StringToLevel("ERROR")).To(Equal(ErrorLevel))
 					Expect(StringToLevel("dEbUg")).To(Equal(DebugLevel))
+					Expect(StringToLevel(fatalStr)).To(Equal(FatalLevel))
+					Expect(StringToLevel("Trace")).To(Equal(TraceLevel))
 				})
 			})
 
@@ -598,8 +859,10 @@ var _ = Describe("CNI Log Level Operations", func() {
 					Expect(logLevel).To(Equal(ErrorLevel))
 					SetLogLevel(StringToLevel(panicStr))
 					Expect(logLevel).To(Equal(PanicLevel))
+					SetLogLevel(StringToLevel(traceStr))
+					Expect(logLevel).To(Equal(TraceLevel))
 					// by int
-					for i := 1; i <= 5; i++ {
+					for i := 1; i <= 6; i++ {
 						l := Level(i)
 						SetLogLevel(l)
 						Expect(logLevel).To(Equal(l))
@@ -696,6 +959,6 @@ func captureStdErrEvent(f func(string, ...interface{}), s string, a ...interface
 	return closePipes(pipeWriter, pipeReader, origWriter)
 }
 
-func getPrimitivePointer[P int | bool](param P) *P {
+func getPrimitivePointer[P int | bool | Level](param P) *P {
 	return &param
 }
@@ -0,0 +1,89 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package logr provides a logr.LogSink writing to the cni-log outputs. It is a package of its own, so that only the
+// users of the sink link logr.
+package logr
+
+import (
+	gologr "github.com/go-logr/logr"
+
+	logging "github.com/k8snetworkplumbingwg/cni-log"
+)
+
+const loggerNameKey = "logger"
+
+// logrSink is a logr.LogSink writing to the cni-log outputs.
+type logrSink struct {
+	name   string
+	logger *logging.Logger
+}
+
+// NewLogr returns a logr.Logger which writes to the cni-log outputs with the global configuration. It can be passed to
+// klog.SetLogger, so that libraries logging through klog, e.g. vendored kubelet libraries, write to the log file of
+// the CNI plugin or daemon as well:
+//
+//	klog.SetLogger(logr.NewLogr())
+//
+// The verbosity of logr and klog is mapped to the cni-log levels by logging.LevelFromVerbosity: V(0) is info, V(1) to
+// V(4) are debug, and V(5) and above are trace. Names added with WithName are logged in a "logger" field, separated by
+// slashes.
+func NewLogr() gologr.Logger {
+	return gologr.New(NewLogSink())
+}
+
+// NewLogSink returns the logr.LogSink used by NewLogr, for use with logr.New.
+func NewLogSink() gologr.LogSink {
+	return &logrSink{logger: logging.With()}
+}
+
+// Init implements logr.LogSink.
+func (s *logrSink) Init(gologr.RuntimeInfo) {}
+
+// Enabled implements logr.LogSink.
+func (s *logrSink) Enabled(level int) bool {
+	return logging.Enabled(logging.LevelFromVerbosity(level))
+}
+
+// Info implements logr.LogSink.
+func (s *logrSink) Info(level int, msg string, keysAndValues ...interface{}) {
+	s.logger.LogStructured(logging.LevelFromVerbosity(level), msg, s.args(keysAndValues)...)
+}
+
+// Error implements logr.LogSink.
+func (s *logrSink) Error(err error, msg string, keysAndValues ...interface{}) {
+	_ = s.logger.ErrorStructuredErr(err, msg, s.args(keysAndValues)...)
+}
+
+// WithValues implements logr.LogSink.
+func (s *logrSink) WithValues(keysAndValues ...interface{}) gologr.LogSink {
+	return &logrSink{name: s.name, logger: s.logger.With(keysAndValues...)}
+}
+
+// WithName implements logr.LogSink.
+func (s *logrSink) WithName(name string) gologr.LogSink {
+	if s.name != "" {
+		name = s.name + "/" + name
+	}
+	return &logrSink{name: name, logger: s.logger}
+}
+
+// args returns the name of the sink and keysAndValues as alternating keys and values. The values of the sink are added
+// by its Logger.
+func (s *logrSink) args(keysAndValues []interface{}) []interface{} {
+	if s.name != "" {
+		keysAndValues = append([]interface{}{loggerNameKey, s.name}, keysAndValues...)
+	}
+	return keysAndValues
+}
@@ -0,0 +1,64 @@
+package logr_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	logging "github.com/k8snetworkplumbingwg/cni-log"
+	"github.com/k8snetworkplumbingwg/cni-log/logr"
+)
+
+const (
+	infoMsg  = "Info message"
+	errorMsg = "Error message"
+	debugMsg = "Debug message"
+)
+
+func TestLogr(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "logr Suite")
+}
+
+var _ = Describe("logr sink", func() {
+	var out bytes.Buffer
+
+	lines := func() []string {
+		return strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	}
+
+	BeforeEach(func() {
+		out = bytes.Buffer{}
+		logging.SetOutput(&out)
+		logging.SetLogStderr(false)
+		logging.SetLogLevel(logging.InfoLevel)
+		DeferCleanup(func() { logging.SetOutput(nil) })
+	})
+
+	It("writes logr messages to the outputs", func() {
+		log := logr.NewLogr().WithName("kubelet").WithName("cm").WithValues("pod", "pod-a")
+		log.Info(infoMsg, "a", "b")
+		log.V(2).Info(debugMsg)
+		Expect(log.V(2).Enabled()).To(BeFalse())
+		log.Error(errors.New("timeout"), errorMsg)
+
+		Expect(lines()).To(HaveLen(2))
+		Expect(lines()[0]).To(HaveSuffix(`level="info" msg="` + infoMsg + `" pod="pod-a" logger="kubelet/cm" a="b"`))
+		Expect(lines()[1]).To(HaveSuffix(`level="error" msg="` + errorMsg + `" pod="pod-a" logger="kubelet/cm" error="timeout"`))
+
+		logging.SetLogLevel(logging.DebugLevel)
+		log.V(2).Info(debugMsg)
+		Expect(lines()[2]).To(HaveSuffix(`level="debug" msg="` + debugMsg + `" pod="pod-a" logger="kubelet/cm"`))
+	})
+
+	It("reports the call site outside of logr and the sink", func() {
+		logging.EnableCallerInfo(0)
+		DeferCleanup(logging.DisableCallerInfo)
+		logr.NewLogr().Info(infoMsg)
+		Expect(out.String()).To(ContainSubstring(`caller="logr_test.go:`))
+	})
+})
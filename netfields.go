@@ -0,0 +1,73 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// PCIAddress is a PCI address, e.g. of an SR-IOV device, which is rendered by all formatters in the canonical form of
+// sysfs, "0000:3b:00.1": lower case hex digits, zero-padded, with the domain. Addresses without the domain, like
+// "3B:00.1", get domain 0000. Strings which are no PCI address are rendered in lower case without surrounding spaces.
+type PCIAddress string
+
+// String implements fmt.Stringer.
+func (a PCIAddress) String() string {
+	s := strings.ToLower(strings.TrimSpace(string(a)))
+	parts := strings.Split(s, ":")
+	if len(parts) == 2 {
+		parts = append([]string{"0"}, parts...)
+	}
+	if len(parts) != 3 {
+		return s
+	}
+	slot := strings.Split(parts[2], ".")
+	if len(slot) != 2 {
+		return s
+	}
+
+	domain, err1 := strconv.ParseUint(parts[0], 16, 32)
+	bus, err2 := strconv.ParseUint(parts[1], 16, 8)
+	device, err3 := strconv.ParseUint(slot[0], 16, 5)
+	function, err4 := strconv.ParseUint(slot[1], 16, 3)
+	if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+		return s
+	}
+	return fmt.Sprintf("%04x:%02x:%02x.%x", domain, bus, device, function)
+}
+
+// MAC returns a field with a hardware address, which is rendered in lower case with colons, e.g. "0a:58:0a:f4:00:05".
+// A nil address is rendered as an empty string.
+func MAC(key string, addr net.HardwareAddr) Field {
+	return Field{Key: key, Value: addr.String()}
+}
+
+// PCI returns a field with a PCI address in its canonical form, see PCIAddress.
+func PCI(key, address string) Field {
+	return Field{Key: key, Value: PCIAddress(address).String()}
+}
+
+// VLAN returns a field with a VLAN ID, which is rendered as a decimal number.
+func VLAN(key string, id int) Field {
+	return Field{Key: key, Value: id}
+}
+
+// VFIndex returns a field with the index of an SR-IOV virtual function, which is rendered as a decimal number.
+func VFIndex(key string, index int) Field {
+	return Field{Key: key, Value: index}
+}
@@ -0,0 +1,42 @@
+package logging
+
+import (
+	"bytes"
+	"net"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CNI value fields", func() {
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+	})
+
+	It("renders PCI addresses in their canonical form", func() {
+		Expect(PCIAddress("0000:3b:00.1").String()).To(Equal("0000:3b:00.1"))
+		Expect(PCIAddress("0000:3B:00.1").String()).To(Equal("0000:3b:00.1"))
+		Expect(PCIAddress("3b:0.7").String()).To(Equal("0000:3b:00.7"))
+		Expect(PCIAddress(" 10000:0:1f.0 ").String()).To(Equal("10000:00:1f.0"))
+		Expect(PCIAddress("0000:3b:20.1").String()).To(Equal("0000:3b:20.1"))
+		Expect(PCIAddress("0000:3b:00.8").String()).To(Equal("0000:3b:00.8"))
+		Expect(PCIAddress("Unknown").String()).To(Equal("unknown"))
+	})
+
+	It("logs the values in the same form with every formatter", func() {
+		mac, err := net.ParseMAC("0A:58:0A:F4:00:05")
+		Expect(err).NotTo(HaveOccurred())
+
+		text, json := &bytes.Buffer{}, &bytes.Buffer{}
+		AddOutput(text)
+		AddSink(NewWriterSink(json, JSONFormatter{}))
+		InfoFields(infoMsg, MAC("mac", mac), PCI("pciAddress", "3B:00.1"), VLAN("vlan", 100), VFIndex("vf", 3))
+		InfoStructured(infoMsg, "mac", mac, "pciAddress", PCIAddress("3B:00.1"))
+
+		Expect(text.String()).To(ContainSubstring(`mac="0a:58:0a:f4:00:05" pciAddress="0000:3b:00.1" vlan="100" vf="3"`))
+		Expect(json.String()).To(ContainSubstring(`"mac":"0a:58:0a:f4:00:05","pciAddress":"0000:3b:00.1","vlan":100,"vf":3`))
+		Expect(bytes.Count(text.Bytes(), []byte(`mac="0a:58:0a:f4:00:05" pciAddress="0000:3b:00.1"`))).To(Equal(2))
+		Expect(bytes.Count(json.Bytes(), []byte(`"mac":"0a:58:0a:f4:00:05","pciAddress":"0000:3b:00.1"`))).To(Equal(2))
+	})
+})
@@ -0,0 +1,112 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+)
+
+// errOutputClosed is returned when writing to an output which was closed by the logger.
+var errOutputClosed = errors.New("cni-log: output is closed")
+
+// customOutput wraps an output set with SetOutput or added with AddOutput. It serializes writing, flushing and closing
+// the output, since buffering writers such as a *bufio.Writer or a *gzip.Writer are not safe for concurrent use, and
+// messages are written while Flush or Close runs.
+type customOutput struct {
+	mu     sync.Mutex
+	out    io.Writer
+	closed bool
+}
+
+// newCustomOutput wraps out, nil if out is nil.
+func newCustomOutput(out io.Writer) io.Writer {
+	if out == nil {
+		return nil
+	}
+	return &customOutput{out: out}
+}
+
+// Write implements io.Writer.
+func (o *customOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.closed {
+		return 0, errOutputClosed
+	}
+	return o.out.Write(p)
+}
+
+// Flush flushes the output if it buffers data.
+func (o *customOutput) Flush() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.closed {
+		return nil
+	}
+	return flushWriter(o.out)
+}
+
+// Sync commits the output to stable storage if it implements Sync() error.
+func (o *customOutput) Sync() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if s, ok := o.out.(interface{ Sync() error }); ok && !o.closed {
+		return s.Sync()
+	}
+	return nil
+}
+
+// close flushes the output and closes it if it buffers data, i.e. implements both Flush() error and Close() error like
+// a *gzip.Writer, since closing writes the tail of the data. Other outputs, e.g. an *os.File such as os.Stdout, are
+// left open for the caller. The output is not written to after it was closed.
+func (o *customOutput) close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.closed {
+		return nil
+	}
+	if err := flushWriter(o.out); err != nil {
+		return err
+	}
+	c, ok := o.out.(interface {
+		Flush() error
+		Close() error
+	})
+	if !ok {
+		return nil
+	}
+	o.closed = true
+	return c.Close()
+}
+
+// is returns true if o wraps out. Outputs are compared with ==, outputs of types which are not comparable never match.
+func (o *customOutput) is(out io.Writer) bool {
+	return out != nil && reflect.TypeOf(out).Comparable() && o.out == out
+}
+
+// closeOutput closes w if it is a custom output, see customOutput.close.
+func closeOutput(w io.Writer) error {
+	if o, ok := w.(*customOutput); ok {
+		return o.close()
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package logging
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+// readGzip returns the decompressed content of data.
+func readGzip(data []byte) string {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	Expect(err).NotTo(HaveOccurred())
+	content, err := io.ReadAll(r)
+	Expect(err).NotTo(HaveOccurred())
+	return string(content)
+}
+
+var _ = Describe("Custom outputs", func() {
+	var out bytes.Buffer
+
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+		out = bytes.Buffer{}
+	})
+
+	It("closes a gzip output when it is replaced", func() {
+		SetOutput(gzip.NewWriter(&out))
+		Infof(infoMsg)
+		SetOutput(nil)
+		Expect(readGzip(out.Bytes())).To(ContainSubstring(infoMsg))
+	})
+
+	It("closes gzip outputs on Close and stops writing to them", func() {
+		var extra bytes.Buffer
+		SetOutput(gzip.NewWriter(&out))
+		AddOutput(gzip.NewWriter(&extra))
+		Infof(infoMsg)
+		Expect(Close()).To(Succeed())
+		Expect(readGzip(out.Bytes())).To(ContainSubstring(infoMsg))
+		Expect(readGzip(extra.Bytes())).To(ContainSubstring(infoMsg))
+
+		size := out.Len()
+		Infof(warningMsg)
+		Expect(out.Len()).To(Equal(size))
+	})
+
+	It("closes a gzip output when it is removed", func() {
+		gz := gzip.NewWriter(&out)
+		AddOutput(gz)
+		Infof(infoMsg)
+		RemoveOutput(gz)
+		Expect(readGzip(out.Bytes())).To(ContainSubstring(infoMsg))
+	})
+
+	It("keeps the output when it is set again", func() {
+		buffered := bufio.NewWriter(&out)
+		SetOutput(buffered)
+		Infof(infoMsg)
+		SetOutput(buffered)
+		Infof(warningMsg)
+		Flush()
+		Expect(out.String()).To(ContainSubstring(infoMsg))
+		Expect(out.String()).To(ContainSubstring(warningMsg))
+	})
+
+	It("does not close outputs which do not buffer data", func() {
+		f, err := os.CreateTemp("", "cni-log-output")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.Remove(f.Name())
+		defer f.Close()
+
+		SetOutput(f)
+		Expect(Close()).To(Succeed())
+		_, err = f.WriteString("still open\n")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("serializes writes with flushing a buffered output", func() {
+		SetOutput(bufio.NewWriterSize(&out, 64))
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				for j := 0; j < 50; j++ {
+					Infof(infoMsg)
+					Flush()
+				}
+			}()
+		}
+		wg.Wait()
+		Flush()
+		Expect(bytes.Count(out.Bytes(), []byte(infoMsg))).To(Equal(200))
+	})
+})
@@ -0,0 +1,73 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import "fmt"
+
+const (
+	panicHookFailedKey      = "panic_hook_error"
+	panicHookOddArguments   = "panic hook must return an even number of arguments"
+	panicHookPanickedFormat = "panic hook panicked: %v"
+)
+
+// PanicHook collects last-gasp diagnostics for a Panic level message, e.g. the state of the network interfaces. It
+// receives the message and returns alternating keys and values which are added to the same entry.
+type PanicHook func(msg string) []interface{}
+
+// AddPanicHook registers a hook which is called for every Panic level message which is logged, before its stack trace
+// is taken. The returned fields are appended to the fields of a structured message and after a printf style message,
+// so the diagnostics are found next to the message instead of in separate entries. Hooks run in the order they were
+// added. A hook which panics does not prevent the message from being logged, its panic is logged in a
+// "panic_hook_error" field instead.
+func AddPanicHook(hook PanicHook) {
+	if hook == nil {
+		return
+	}
+
+	mu.Lock()
+	defer unlockAndPublish()
+
+	// Log calls use the slice after releasing mu, so it is never modified in place.
+	h := make([]PanicHook, 0, len(panicHooks)+1)
+	panicHooks = append(append(h, panicHooks...), hook)
+}
+
+// panicDiagnostics returns the fields of the panic hooks for a message of the given level, prepared like the arguments
+// of a structured message. It returns nil for all levels but PanicLevel.
+func (s *snapshot) panicDiagnostics(level Level, msg string) []interface{} {
+	if level != PanicLevel || len(s.panicHooks) == 0 {
+		return nil
+	}
+
+	var kv []interface{}
+	for _, hook := range s.panicHooks {
+		kv = append(kv, s.evenArgs("", panicHookOddArguments, runPanicHook(hook, msg))...)
+	}
+	kv = evaluateLazy(kv)
+	if s.sanitize {
+		kv = sanitizeKeys(kv)
+	}
+	return kv
+}
+
+// runPanicHook calls hook and turns a panic of the hook into a field.
+func runPanicHook(hook PanicHook, msg string) (kv []interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			kv = []interface{}{panicHookFailedKey, fmt.Sprintf(panicHookPanickedFormat, r)}
+		}
+	}()
+	return hook(msg)
+}
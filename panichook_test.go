@@ -0,0 +1,63 @@
+package logging
+
+import (
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Panic hooks", func() {
+	var sink *captureSink
+
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+		sink = &captureSink{}
+		AddSink(sink)
+	})
+
+	It("attaches the diagnostics to structured panic messages before the stack trace", func() {
+		var msgs []string
+		AddPanicHook(func(msg string) []interface{} {
+			msgs = append(msgs, msg)
+			return []interface{}{"links", "eth0,net1"}
+		})
+		AddPanicHook(func(string) []interface{} { return []interface{}{"routes", 3} })
+
+		PanicStructured(panicMsg, "ifname", "net1")
+		_ = ErrorStructured(errorMsg)
+		Expect(msgs).To(Equal([]string{panicMsg}))
+
+		fields := sink.entries[0].Fields
+		Expect(fields[len(fields)-8 : len(fields)-2]).To(Equal([]interface{}{"ifname", "net1", "links", "eth0,net1",
+			"routes", 3}))
+		Expect(fields[len(fields)-2]).To(Equal(stackTraceKey))
+		Expect(sink.entries[1].String()).NotTo(ContainSubstring("links"))
+	})
+
+	It("appends the diagnostics after printf style panic messages", func() {
+		AddPanicHook(func(string) []interface{} { return []interface{}{"links", "eth0,net1"} })
+		Panicf(panicMsg)
+		Expect(sink.entries[0].String()).To(HaveSuffix(panicMsg + ` links="eth0,net1"`))
+		Expect(sink.entries[1].Message).To(Equal(stackTraceHeader))
+	})
+
+	It("logs the message if a hook panics or returns an odd number of arguments", func() {
+		AddPanicHook(func(string) []interface{} { panic("netlink unavailable") })
+		AddPanicHook(func(string) []interface{} { return []interface{}{"links"} })
+		PanicStructured(panicMsg)
+		Expect(sink.entries).To(HaveLen(1))
+		Expect(sink.entries[0].String()).To(ContainSubstring(`panic_hook_error="panic hook panicked: netlink unavailable"`))
+		Expect(sink.entries[0].String()).To(ContainSubstring(panicHookOddArguments))
+	})
+
+	It("does not call the hooks for filtered messages", func() {
+		called := false
+		AddPanicHook(func(string) []interface{} {
+			called = true
+			return nil
+		})
+		SetLogLevel(FatalLevel)
+		PanicStructured(panicMsg)
+		Expect(called).To(BeFalse())
+	})
+})
@@ -0,0 +1,253 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package parse reads the log lines written by cni-log back into logging.Entry values, e.g. to inspect log files or
+// to make assertions on the output of a plugin in tests.
+//
+// Lines of all built-in formats are supported: JSON, logfmt, and the key="value" pairs of structured messages in the
+// text format. A parsed line is a structured entry whose fields are the keys and values of the line in their order;
+// its time, level and message are taken from the "time", "level" and "msg" fields. Formatting it with the formatter
+// which wrote the line yields the line again. Printf style messages in the text format are only parsed with the
+// default prefix, into an entry with time, level and message but without fields.
+package parse
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	logging "github.com/k8snetworkplumbingwg/cni-log"
+)
+
+const (
+	// maxLineSize is the size of the longest line a Scanner reads.
+	maxLineSize = 16 << 20
+
+	lineFailMsg = "parse: line %d: %w"
+)
+
+var (
+	// ErrUnknownFormat is returned for lines which were not written by one of the built-in formats.
+	ErrUnknownFormat = errors.New("parse: unknown log line format")
+
+	// printfLine matches printf style messages with the default prefix.
+	printfLine = regexp.MustCompile(`^(\S+) \[([a-z]+)\] (.*)$`)
+)
+
+// Line parses a log line written by cni-log.
+func Line(line string) (logging.Entry, error) {
+	line = strings.TrimRight(line, "\r\n")
+	if fields, ok := jsonFields(line); ok {
+		return entry(fields), nil
+	}
+	if fields, ok := pairs(line); ok {
+		return entry(fields), nil
+	}
+	if m := printfLine.FindStringSubmatch(line); m != nil {
+		t, err := time.Parse(time.RFC3339Nano, m[1])
+		level := logging.StringToLevel(m[2])
+		if err == nil && level != logging.InvalidLevel {
+			return logging.Entry{Time: t, Level: level, Message: m[3]}, nil
+		}
+	}
+	return logging.Entry{}, fmt.Errorf("%w: %q", ErrUnknownFormat, line)
+}
+
+// entry returns the structured entry with fields.
+func entry(fields []interface{}) logging.Entry {
+	var t time.Time
+	level := logging.InvalidLevel
+	var msg string
+	for i := 0; i < len(fields)-1; i += 2 {
+		value, ok := fields[i+1].(string)
+		if !ok {
+			continue
+		}
+		switch fields[i] {
+		case "time":
+			t, _ = time.Parse(time.RFC3339Nano, value)
+		case "level":
+			level = logging.StringToLevel(value)
+		case "msg":
+			msg = value
+		}
+	}
+	return logging.NewEntry(t, level, msg, fields...)
+}
+
+// pairs parses the key=value pairs of logfmt and of structured messages in the text format. Quoted values are
+// unquoted, the escape sequences of both formats are a subset of those of Go string literals.
+func pairs(line string) ([]interface{}, bool) {
+	var fields []interface{}
+	for line != "" {
+		eq := strings.IndexByte(line, '=')
+		if eq <= 0 || strings.ContainsAny(line[:eq], ` "`) {
+			return nil, false
+		}
+		key := line[:eq]
+		line = line[eq+1:]
+
+		var value string
+		if strings.HasPrefix(line, `"`) {
+			end := closingQuote(line)
+			if end < 0 {
+				return nil, false
+			}
+			var err error
+			if value, err = strconv.Unquote(line[:end+1]); err != nil {
+				return nil, false
+			}
+			line = line[end+1:]
+		} else {
+			end := strings.IndexByte(line, ' ')
+			if end < 0 {
+				end = len(line)
+			}
+			value = line[:end]
+			if strings.ContainsRune(value, '"') {
+				return nil, false
+			}
+			line = line[end:]
+		}
+		fields = append(fields, key, value)
+
+		if line != "" {
+			if line[0] != ' ' {
+				return nil, false
+			}
+			line = line[1:]
+		}
+	}
+	return fields, len(fields) > 0
+}
+
+// closingQuote returns the index of the quote which closes the quoted string s starts with, -1 if there is none.
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// jsonFields parses a JSON object, keeping the order of its keys. Strings, booleans and null are decoded, numbers are
+// returned as json.Number and nested objects and arrays as json.RawMessage, so that they are encoded unchanged.
+func jsonFields(line string) ([]interface{}, bool) {
+	if !strings.HasPrefix(line, "{") {
+		return nil, false
+	}
+	dec := json.NewDecoder(strings.NewReader(line))
+	if _, err := dec.Token(); err != nil {
+		return nil, false
+	}
+
+	var fields []interface{}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, false
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, false
+		}
+		value, err := jsonValue(raw)
+		if err != nil {
+			return nil, false
+		}
+		fields = append(fields, key, value)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, false
+	}
+	_, err := dec.Token()
+	return fields, err == io.EOF
+}
+
+// jsonValue decodes a JSON value, see jsonFields.
+func jsonValue(raw json.RawMessage) (interface{}, error) {
+	switch raw[0] {
+	case '{', '[':
+		return raw, nil
+	case '"':
+		var s string
+		err := json.Unmarshal(raw, &s)
+		return s, err
+	case 't', 'f':
+		return bytes.Equal(raw, []byte("true")), nil
+	case 'n':
+		return nil, nil
+	default:
+		return json.Number(raw), nil
+	}
+}
+
+// Scanner reads the entries of a log file line by line. Empty lines are skipped.
+type Scanner struct {
+	scanner *bufio.Scanner
+	line    int
+	entry   logging.Entry
+	err     error
+}
+
+// NewScanner returns a Scanner reading from r.
+func NewScanner(r io.Reader) *Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxLineSize)
+	return &Scanner{scanner: scanner}
+}
+
+// Scan advances to the next entry, which is then available through Entry. It returns false at the end of the input or
+// at the first line which cannot be parsed, Err returns the error then.
+func (s *Scanner) Scan() bool {
+	for s.err == nil && s.scanner.Scan() {
+		s.line++
+		line := s.scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		entry, err := Line(line)
+		if err != nil {
+			s.err = fmt.Errorf(lineFailMsg, s.line, err)
+			return false
+		}
+		s.entry = entry
+		return true
+	}
+	if s.err == nil {
+		s.err = s.scanner.Err()
+	}
+	return false
+}
+
+// Entry returns the entry read by the last call to Scan.
+func (s *Scanner) Entry() logging.Entry {
+	return s.entry
+}
+
+// Err returns the first error which occurred while reading the input, nil at its end.
+func (s *Scanner) Err() error {
+	return s.err
+}
@@ -0,0 +1,121 @@
+package parse
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	logging "github.com/k8snetworkplumbingwg/cni-log"
+)
+
+func TestParse(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "parse Suite")
+}
+
+var _ = Describe("parse", func() {
+	var out bytes.Buffer
+
+	BeforeEach(func() {
+		out.Reset()
+		logging.SetOutput(&out)
+		logging.SetLogStderr(false)
+		logging.SetLogLevel(logging.DebugLevel)
+		DeferCleanup(func() { logging.SetFormatter(nil) })
+	})
+
+	logMessages := func() []string {
+		logging.InfoStructured("adding interface", "ifname", "net1", "mtu", 1500, "up", true)
+		logging.WarningStructured(`say "hi"`, "path", "/var/run/netns/cni-1", "line", "a\nb\tc", "ctl", "\x1b[31m",
+			"pod name", "a=b", "empty", "")
+		_ = logging.ErrorStructured("failed", "error", errors.New("no space left on device"), "ips", []string{"a", "b"})
+		logging.Warningf("printf %s", "message")
+		return strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	}
+
+	for _, format := range []struct {
+		name      string
+		formatter logging.Formatter
+	}{
+		{"JSON", logging.JSONFormatter{}},
+		{"logfmt", logging.LogfmtFormatter{}},
+	} {
+		format := format
+		It("parses "+format.name+" lines back into the lines", func() {
+			logging.SetFormatter(format.formatter)
+			lines := logMessages()
+			Expect(lines).To(HaveLen(4))
+			for _, line := range lines {
+				entry, err := Line(line)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(format.formatter.Format(entry))).To(Equal(line))
+			}
+		})
+	}
+
+	It("parses structured text lines back into the lines", func() {
+		lines := logMessages()
+		for _, line := range lines[:3] {
+			entry, err := Line(line)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(logging.TextFormatter{}.Format(entry))).To(Equal(line))
+		}
+	})
+
+	It("takes time, level and message from the fields", func() {
+		entry, err := Line(`time="2024-01-02T03:04:05.123Z" level="warning" msg="hello world" pod="a"`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entry.Time).To(Equal(time.Date(2024, 1, 2, 3, 4, 5, 123000000, time.UTC)))
+		Expect(entry.Level).To(Equal(logging.WarningLevel))
+		Expect(entry.Message).To(Equal("hello world"))
+		Expect(entry.Fields).To(Equal([]interface{}{
+			"time", "2024-01-02T03:04:05.123Z", "level", "warning", "msg", "hello world", "pod", "a",
+		}))
+	})
+
+	It("decodes JSON values", func() {
+		entry, err := Line(`{"msg":"m","mtu":1500,"up":true,"none":null,"ips":["a"],"obj":{"a":1}}`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entry.Fields).To(Equal([]interface{}{
+			"msg", "m", "mtu", json.Number("1500"), "up", true, "none", nil,
+			"ips", json.RawMessage(`["a"]`), "obj", json.RawMessage(`{"a":1}`),
+		}))
+	})
+
+	It("parses printf style text lines with the default prefix", func() {
+		entry, err := Line("2024-01-02T03:04:05Z [warning] say \"hi\"")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entry.Time).To(Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)))
+		Expect(entry.Level).To(Equal(logging.WarningLevel))
+		Expect(entry.Message).To(Equal(`say "hi"`))
+		Expect(entry.Fields).To(BeNil())
+	})
+
+	It("rejects lines of unknown formats", func() {
+		for _, line := range []string{"hello world", `{"a":1} x`, `a="b`, `a=b"c`, "I0102 03:04:05.000000 1 x] y"} {
+			_, err := Line(line)
+			Expect(err).To(MatchError(ErrUnknownFormat), line)
+		}
+	})
+
+	It("scans log files", func() {
+		logging.SetFormatter(logging.LogfmtFormatter{})
+		logMessages()
+		out.WriteString("\ninvalid\n")
+
+		s := NewScanner(&out)
+		var messages []string
+		for s.Scan() {
+			messages = append(messages, s.Entry().Message)
+		}
+		Expect(messages).To(Equal([]string{"adding interface", `say "hi"`, "failed", "printf message"}))
+		Expect(s.Err()).To(MatchError(ContainSubstring("line 6: parse: unknown log line format")))
+		Expect(errors.Is(s.Err(), ErrUnknownFormat)).To(BeTrue())
+	})
+})
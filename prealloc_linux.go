@@ -0,0 +1,37 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+
+package logging
+
+import (
+	"os"
+	"syscall"
+)
+
+// fallocFlKeepSize allocates disk space without changing the apparent file size (FALLOC_FL_KEEP_SIZE).
+const fallocFlKeepSize = 0x01
+
+// preallocate reserves size bytes of disk space for filename without changing its size, so that appending to the
+// file does not fragment it.
+func preallocate(filename string, size int64) error {
+	f, err := os.OpenFile(filename, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return syscall.Fallocate(int(f.Fd()), fallocFlKeepSize, 0, size)
+}
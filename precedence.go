@@ -0,0 +1,425 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const readConfigFileFailMsg = "cni-log: unable to read configuration file '%s': %v"
+
+// ConfigSource identifies where a configuration value comes from. Sources with a higher value take precedence over
+// sources with a lower value.
+type ConfigSource int
+
+// Configuration sources in order of precedence.
+const (
+	// SourceDefault is the built-in default value.
+	SourceDefault ConfigSource = iota
+	// SourceFile is a configuration file loaded with LoadConfigFile.
+	SourceFile
+	// SourceEnv is the environment, see ConfigureFromEnv.
+	SourceEnv
+	// SourceNetConf is the network configuration, see ApplyConfig.
+	SourceNetConf
+	// SourceAPI is a call of SetLogLevel, SetLogFile, SetLogStderr, SetLogOptions or SetConfig.
+	SourceAPI
+
+	numConfigSources
+)
+
+// String converts a ConfigSource into its string representation.
+func (s ConfigSource) String() string {
+	switch s {
+	case SourceDefault:
+		return "default"
+	case SourceFile:
+		return "file"
+	case SourceEnv:
+		return "env"
+	case SourceNetConf:
+		return "netconf"
+	case SourceAPI:
+		return "api"
+	}
+	return fmt.Sprintf("ConfigSource(%d)", int(s))
+}
+
+// ConfigValue is an effective configuration value together with the source which set it.
+type ConfigValue struct {
+	// Name is the name of the setting as used in Config, e.g. "logLevel" or "logOptions.maxSize".
+	Name   string
+	Value  interface{}
+	Source ConfigSource
+}
+
+// String returns the value in the form "name=value (source)".
+func (v ConfigValue) String() string {
+	return fmt.Sprintf("%s=%v (%s)", v.Name, v.Value, v.Source)
+}
+
+// configLayer holds the settings of a single configuration source. Unset settings are nil.
+type configLayer struct {
+	logLevel    *Level
+	logFile     *string
+	logToStderr *bool
+	logOptions  LogOptions
+	format      *string
+	prefix      *string
+
+	secondaryLogFile *string
+	secondaryFormat  *string
+	routes           *[]Route
+}
+
+// configLayers holds the settings of all sources, indexed by ConfigSource. The defaults are built in, so the first
+// layer is always empty.
+var configLayers [numConfigSources]configLayer
+
+// newConfigLayer returns the settings of config. An empty log file or log level is unset.
+func newConfigLayer(config *Config) configLayer {
+	layer := configLayer{logToStderr: config.LogToStderr}
+	if config.LogLevel != "" {
+		level := StringToLevel(config.LogLevel)
+		layer.logLevel = &level
+	}
+	if config.LogFile != "" {
+		layer.logFile = &config.LogFile
+	}
+	if config.LogOptions != nil {
+		layer.logOptions = *config.LogOptions
+	}
+	if config.Format != "" {
+		layer.format = &config.Format
+	}
+	if config.Prefix != "" {
+		layer.prefix = &config.Prefix
+	}
+	if config.SecondaryLogFile != "" {
+		layer.secondaryLogFile = &config.SecondaryLogFile
+	}
+	if config.SecondaryFormat != "" {
+		layer.secondaryFormat = &config.SecondaryFormat
+	}
+	layer.routes = copyRoutes(config.Routes)
+	return layer
+}
+
+// LoadConfigFile loads a logging configuration file, e.g. one provided by the operator on every node. The file has
+// the format of the "logging" stanza of a network configuration, see ParseConfig, in JSON or, if its name ends in
+// ".yaml" or ".yml", in YAML; unknown fields are an error. Its settings take precedence over the defaults only, see
+// ExplainConfig. If the file or the resulting configuration is invalid, an error is returned and nothing is changed,
+// except that the emergency log file, see SetEmergencyLogFile, is used if the configured log file cannot be opened.
+func LoadConfigFile(filename string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf(readConfigFileFailMsg, filename, err)
+	}
+	return loadConfigData(filename, data)
+}
+
+// loadConfigData applies the content of the configuration file filename, see LoadConfigFile.
+func loadConfigData(filename string, data []byte) error {
+	if ext := filepath.Ext(filename); ext == ".yaml" || ext == ".yml" {
+		var err error
+		if data, err = yamlToJSON(data); err != nil {
+			return fmt.Errorf(readConfigFileFailMsg, filename, err)
+		}
+	}
+
+	config := &Config{}
+	if err := decodeJSON(data, config, true); err != nil {
+		return fmt.Errorf(readConfigFileFailMsg, filename, err)
+	}
+	if config.LogLevel != "" && StringToLevel(config.LogLevel) == InvalidLevel {
+		return configErrorf(ErrInvalidLevel, invalidLevelFailMsg, config.LogLevel)
+	}
+	return applyConfigLayer(SourceFile, newConfigLayer(config))
+}
+
+// yamlToJSON converts a YAML document to JSON, so that it is decoded with the same checks as JSON configuration files.
+// An empty document is an empty object.
+func yamlToJSON(data []byte) ([]byte, error) {
+	var v interface{}
+	if err := yaml.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	if v == nil {
+		v = map[string]interface{}{}
+	}
+	return json.Marshal(v)
+}
+
+// applyConfigLayer replaces the settings of source and configures the logger with the merged settings of all sources
+// in a single step. If the merged configuration is invalid, an error is returned and nothing is changed; only if the
+// log file cannot be opened, the emergency log file is used instead.
+func applyConfigLayer(source ConfigSource, layer configLayer) error {
+	mu.Lock()
+	defer unlockAndPublish()
+	return setConfigLayer(source, layer)
+}
+
+// setConfigLayer works like applyConfigLayer. The caller must hold mu.
+func setConfigLayer(source ConfigSource, layer configLayer) error {
+	layers := configLayers
+	layers[source] = layer
+	previous, _ := mergeConfigLayers(&configLayers)
+	merged, _ := mergeConfigLayers(&layers)
+
+	var format Formatter
+	var prefix Prefixer
+	var err error
+	if merged.format != nil {
+		if format, err = lookupFormatter(*merged.format); err != nil {
+			return err
+		}
+	}
+	if merged.prefix != nil {
+		if prefix, err = lookupPrefixer(*merged.prefix); err != nil {
+			return err
+		}
+	}
+	var secondaryFormat Formatter
+	if merged.secondaryFormat != nil {
+		if secondaryFormat, err = lookupFormatter(*merged.secondaryFormat); err != nil {
+			return err
+		}
+	}
+	var secondaryLogFile string
+	if merged.secondaryLogFile != nil {
+		if secondaryLogFile, err = resolveLogFile(*merged.secondaryLogFile); err != nil {
+			return err
+		}
+	}
+
+	var routes []Route
+	var routeFiles []string
+	if merged.routes != nil {
+		routes = *merged.routes
+		if routeFiles, err = resolveRoutes(routes); err != nil {
+			return err
+		}
+	}
+
+	// Outputs set with SetOutput or SetDailyLogFiles are only replaced if the configured log file changes.
+	switchOutput := configOwnsOutput() || !equalStringPointers(previous.logFile, merged.logFile)
+	var logFile string
+	var stream *streamWriter
+	if switchOutput && merged.logFile != nil && *merged.logFile != "" {
+		var isStream bool
+		if stream, isStream, err = openStream(*merged.logFile); err != nil {
+			return err
+		}
+		if !isStream {
+			fp, err := resolvePath(*merged.logFile)
+			if err != nil {
+				useEmergencyLogFile(*merged.logFile)
+				return err
+			}
+			if !isLogFileWritable(fp) {
+				useEmergencyLogFile(*merged.logFile)
+				return configErrorf(ErrUnwritableLogFile, unwritableFailMsg, *merged.logFile)
+			}
+			logFile = fp
+		}
+	}
+	// The writers are created before anything is changed, they open their files on the first write.
+	secondaryWriter := secondaryLogFileWriter(secondaryLogFile)
+	activeRoutes := newRoutes(routes, routeFiles)
+
+	configLayers = layers
+	// The configuration is in effect even if a replaced file cannot be closed, so this is not an error of the
+	// configuration.
+	if err := replaceSecondaryLogWriter(secondaryWriter); err != nil {
+		recordWriteFailure(err)
+	}
+	if err := replaceRoutes(activeRoutes); err != nil {
+		recordWriteFailure(err)
+	}
+	if switchOutput {
+		setLogOptions(&merged.logOptions)
+	} else {
+		setFileOptions(&merged.logOptions)
+	}
+	if stream != nil {
+		enableStreamLogging(stream)
+	} else if logFile != "" {
+		enableFileLogging(logFile)
+	} else if switchOutput {
+		disableFileLogging()
+	}
+	logToStderr = true
+	if merged.logToStderr != nil {
+		logToStderr = *merged.logToStderr
+	}
+	logLevel = defaultLogLevel
+	if merged.logLevel != nil {
+		logLevel = *merged.logLevel
+	}
+	// The formatter and the prefixer can also be set through SetFormatter and SetPrefixer, so they are only restored to
+	// the defaults if the configuration selected them before.
+	if format != nil || previous.format != nil {
+		stderrFormatter, fileFormatter, syslogFormatter = format, format, format
+	}
+	if prefix != nil {
+		prefixer = prefix
+	} else if previous.prefix != nil {
+		prefixer = newDefaultPrefixer()
+	}
+	if secondaryFormat != nil || previous.secondaryFormat != nil {
+		secondaryFormatter = secondaryFormat
+	}
+
+	if !isLoggingEnabled(minimumLevel) {
+		fmt.Fprint(os.Stderr, logFileReqFailMsg)
+	}
+	return nil
+}
+
+// equalStringPointers returns true if a and b are both nil or point to equal strings.
+func equalStringPointers(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// effectiveLogOptions returns the log options merged from all sources. The caller must hold mu.
+func effectiveLogOptions() *LogOptions {
+	merged, _ := mergeConfigLayers(&configLayers)
+	return &merged.logOptions
+}
+
+// mergeConfigLayers merges the settings of all sources; each setting is taken from the source with the highest
+// precedence which sets it. It also returns the source of every set setting by name.
+func mergeConfigLayers(layers *[numConfigSources]configLayer) (configLayer, map[string]ConfigSource) {
+	merged := configLayer{}
+	sources := map[string]ConfigSource{}
+	for source := SourceFile; source < numConfigSources; source++ {
+		layer := &layers[source]
+		if layer.logLevel != nil {
+			merged.logLevel = layer.logLevel
+			sources["logLevel"] = source
+		}
+		if layer.logFile != nil {
+			merged.logFile = layer.logFile
+			sources["logFile"] = source
+		}
+		if layer.logToStderr != nil {
+			merged.logToStderr = layer.logToStderr
+			sources["logToStderr"] = source
+		}
+		if layer.format != nil {
+			merged.format = layer.format
+			sources["format"] = source
+		}
+		if layer.prefix != nil {
+			merged.prefix = layer.prefix
+			sources["prefix"] = source
+		}
+		if layer.secondaryLogFile != nil {
+			merged.secondaryLogFile = layer.secondaryLogFile
+			sources["secondaryLogFile"] = source
+		}
+		if layer.secondaryFormat != nil {
+			merged.secondaryFormat = layer.secondaryFormat
+			sources["secondaryFormat"] = source
+		}
+		if layer.routes != nil {
+			merged.routes = layer.routes
+			sources["routes"] = source
+		}
+
+		from := reflect.ValueOf(layer.logOptions)
+		to := reflect.ValueOf(&merged.logOptions).Elem()
+		for i := 0; i < from.NumField(); i++ {
+			if !from.Field(i).IsNil() {
+				to.Field(i).Set(from.Field(i))
+				sources[logOptionName(i)] = source
+			}
+		}
+	}
+	return merged, sources
+}
+
+// logOptionName returns the name of the i-th field of LogOptions, e.g. "logOptions.maxSize".
+func logOptionName(i int) string {
+	tag := reflect.TypeOf(LogOptions{}).Field(i).Tag.Get("json")
+	return "logOptions." + strings.Split(tag, ",")[0]
+}
+
+// ExplainConfig returns the effective value of every setting together with the source which set it, so that operators
+// can find out why a setting is what it is once it can be set in several places. Each setting is taken from the source
+// with the highest precedence which sets it:
+//
+//	defaults < LoadConfigFile < ConfigureFromEnv < ApplyConfig < SetLogLevel, SetLogFile, SetLogStderr, SetLogOptions,
+//	                                                             SetConfig
+//
+// The format and the prefix are reported by name; a formatter or prefixer set with SetFormatter or SetPrefixer has no
+// name and is not reflected.
+func ExplainConfig() []ConfigValue {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	merged, sources := mergeConfigLayers(&configLayers)
+	format, prefix := FormatText, PrefixDefault
+	if merged.format != nil {
+		format = *merged.format
+	}
+	if merged.prefix != nil {
+		prefix = *merged.prefix
+	}
+	logFile := logFileWriter.filename
+	if w, ok := logWriter.(*streamWriter); ok {
+		logFile = w.name
+	}
+	secondaryLogFile, secondaryFormat := "", FormatJSON
+	if secondaryLogWriter != nil {
+		secondaryLogFile = secondaryLogWriter.filename
+	}
+	if merged.secondaryFormat != nil {
+		secondaryFormat = *merged.secondaryFormat
+	}
+	routes := []Route{}
+	if merged.routes != nil {
+		routes = *merged.routes
+	}
+	values := []ConfigValue{
+		{Name: "logLevel", Value: logLevel},
+		{Name: "logFile", Value: logFile},
+		{Name: "logToStderr", Value: logToStderr},
+		{Name: "format", Value: format},
+		{Name: "prefix", Value: prefix},
+		{Name: "secondaryLogFile", Value: secondaryLogFile},
+		{Name: "secondaryFormat", Value: secondaryFormat},
+		{Name: "routes", Value: routes},
+	}
+	options := reflect.ValueOf(currentLogOptions()).Elem()
+	for i := 0; i < options.NumField(); i++ {
+		values = append(values, ConfigValue{Name: logOptionName(i), Value: options.Field(i).Elem().Interface()})
+	}
+
+	for i := range values {
+		values[i].Source = sources[values[i].Name]
+	}
+	return values
+}
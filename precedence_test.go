@@ -0,0 +1,123 @@
+package logging
+
+import (
+	"bytes"
+	"os"
+	"path"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Configuration precedence", func() {
+	var logFile, configFile string
+
+	explain := func(name string) ConfigValue {
+		for _, v := range ExplainConfig() {
+			if v.Name == name {
+				return v
+			}
+		}
+		Fail("no configuration value " + name)
+		return ConfigValue{}
+	}
+
+	BeforeEach(func() {
+		initLogger()
+		logFile = path.Join(os.TempDir(), "test-precedence.log")
+		configFile = path.Join(os.TempDir(), "test-precedence.json")
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(logFile)).To(Succeed())
+		Expect(os.RemoveAll(configFile)).To(Succeed())
+	})
+
+	writeConfigFile := func(content string) {
+		Expect(os.WriteFile(configFile, []byte(content), 0600)).To(Succeed())
+	}
+
+	It("reports the defaults", func() {
+		Expect(explain("logLevel")).To(Equal(ConfigValue{Name: "logLevel", Value: defaultLogLevel, Source: SourceDefault}))
+		Expect(explain("logOptions.maxSize").String()).To(Equal("logOptions.maxSize=100 (default)"))
+	})
+
+	It("takes each setting from the source with the highest precedence", func() {
+		writeConfigFile(`{"logLevel": "error", "logFile": "` + logFile + `", "logOptions": {"maxSize": 10, "maxAge": 1}}`)
+		Expect(LoadConfigFile(configFile)).To(Succeed())
+		Expect(os.Setenv(EnvLogLevel, "warning")).To(Succeed())
+		DeferCleanup(os.Unsetenv, EnvLogLevel)
+		Expect(ConfigureFromEnv()).To(Succeed())
+		Expect(ApplyConfig(&Config{LogLevel: "debug", LogOptions: &LogOptions{MaxSize: getPrimitivePointer(20)}})).To(Succeed())
+
+		Expect(GetLogLevel()).To(Equal(DebugLevel))
+		Expect(explain("logLevel").Source).To(Equal(SourceNetConf))
+		Expect(explain("logFile")).To(Equal(ConfigValue{Name: "logFile", Value: logFile, Source: SourceFile}))
+		Expect(explain("logOptions.maxSize")).To(Equal(ConfigValue{Name: "logOptions.maxSize", Value: 20, Source: SourceNetConf}))
+		Expect(explain("logOptions.maxAge")).To(Equal(ConfigValue{Name: "logOptions.maxAge", Value: 1, Source: SourceFile}))
+		Expect(explain("logToStderr").Source).To(Equal(SourceDefault))
+
+		SetLogLevel(TraceLevel)
+		Expect(ApplyConfig(&Config{LogLevel: "error"})).To(Succeed())
+		Expect(GetLogLevel()).To(Equal(TraceLevel))
+		Expect(explain("logLevel").Source).To(Equal(SourceAPI))
+		Expect(explain("logOptions.maxSize").Source).To(Equal(SourceFile))
+	})
+
+	It("falls back to the other sources for options missing from SetLogOptions", func() {
+		Expect(ApplyConfig(&Config{LogOptions: &LogOptions{MaxBackups: getPrimitivePointer(2)}})).To(Succeed())
+		SetLogOptions(&LogOptions{MaxAge: getPrimitivePointer(3)})
+		Expect(currentLumberjack().MaxBackups).To(Equal(2))
+		Expect(currentLumberjack().MaxAge).To(Equal(3))
+		Expect(explain("logOptions.maxAge").Source).To(Equal(SourceAPI))
+	})
+
+	It("reads YAML configuration files", func() {
+		configFile = path.Join(os.TempDir(), "test-precedence.yaml")
+		writeConfigFile("logLevel: error\nlogOptions:\n  maxSize: 10\n")
+		Expect(LoadConfigFile(configFile)).To(Succeed())
+		Expect(GetLogLevel()).To(Equal(ErrorLevel))
+		Expect(explain("logOptions.maxSize")).To(Equal(ConfigValue{Name: "logOptions.maxSize", Value: 10, Source: SourceFile}))
+
+		writeConfigFile("loglevel: debug\n")
+		Expect(LoadConfigFile(configFile)).To(MatchError(ContainSubstring("loglevel")))
+	})
+
+	When("the configuration file is invalid", func() {
+		It("returns an error and keeps the current configuration", func() {
+			Expect(LoadConfigFile(configFile)).NotTo(Succeed())
+			writeConfigFile(`{"loglevel": "debug"}`)
+			Expect(LoadConfigFile(configFile)).To(MatchError(ContainSubstring("loglevel")))
+			writeConfigFile(`{"logLevel": "verbose"}`)
+			Expect(LoadConfigFile(configFile)).NotTo(Succeed())
+			Expect(explain("logLevel").Source).To(Equal(SourceDefault))
+		})
+	})
+
+	When("the output was set with SetOutput or SetDailyLogFiles", func() {
+		It("keeps it unless the configured log file changes", func() {
+			out := &bytes.Buffer{}
+			SetOutput(out)
+			Expect(ApplyConfig(&Config{LogLevel: "debug"})).To(Succeed())
+			Expect(SetConfig(Config{LogToStderr: getPrimitivePointer(false)})).To(Succeed())
+			Debugf(debugMsg)
+			Expect(out.String()).To(ContainSubstring(debugMsg))
+
+			Expect(ApplyConfig(&Config{LogFile: logFile})).To(Succeed())
+			Infof(infoMsg)
+			Expect(out.String()).NotTo(ContainSubstring(infoMsg))
+			Expect(logFileContains(logFile, infoMsg)).To(BeTrue())
+		})
+
+		It("keeps the daily log files", func() {
+			dir, err := os.MkdirTemp("", "cni-log-precedence")
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(os.RemoveAll, dir)
+			Expect(SetDailyLogFiles(dir, "plugin", 7)).To(Succeed())
+			Expect(ApplyConfig(&Config{LogLevel: "debug"})).To(Succeed())
+			_, isDaily := logWriter.(*dailyWriter)
+			Expect(isDaily).To(BeTrue())
+			Expect(Close()).To(Succeed())
+		})
+	})
+})
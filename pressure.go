@@ -0,0 +1,176 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+const defaultHighPressure = 0.75
+
+// pressureSource identifies a bounded buffer of the logger.
+type pressureSource int
+
+// The bounded buffers whose fullness makes up the pressure.
+const (
+	// pressureAsync is the buffer of asynchronous logging, bounded by AsyncOptions.MaxBufferSize.
+	pressureAsync pressureSource = iota
+	// pressureFallback is the buffer of the log file fallback, bounded by FallbackOptions.BufferSize.
+	pressureFallback
+
+	numPressureSources
+)
+
+// PressureOptions configures the back-pressure handler, see SetPressureHandler.
+type PressureOptions struct {
+	// High is the pressure at which Handler is called with true, 0.75 by default.
+	High float64
+	// Low is the pressure at which Handler is called with false once High was reached, half of High by default.
+	Low float64
+	// Handler is called whenever the pressure crosses High, or falls back to Low, with the current pressure.
+	Handler func(high bool, pressure float64)
+}
+
+// pressureEvent is a call of the handler.
+type pressureEvent struct {
+	high     bool
+	pressure float64
+}
+
+// pressureMonitor calls the handler whenever the pressure crosses the thresholds. It is safe for concurrent use.
+type pressureMonitor struct {
+	mu      sync.Mutex
+	options *PressureOptions
+	high    bool
+	// pending are the calls of the handler which have not been made yet, running is set while a goroutine makes them.
+	pending []pressureEvent
+	running bool
+}
+
+var (
+	// pressureLevels holds the fullness of every bounded buffer as bits of a float64, updated atomically.
+	pressureLevels [numPressureSources]uint64
+	// pressure calls the handler set with SetPressureHandler.
+	pressure = &pressureMonitor{}
+)
+
+// Pressure returns the fullness of the fullest bounded buffer of the logger, from 0 for empty to 1 for full, at which
+// point messages are dropped: the buffer of asynchronous logging if AsyncOptions.MaxBufferSize is set, and the buffer
+// of the log file fallback if FallbackOptions.BufferSize is set. It is 0 without bounded buffers. Daemons can poll it to
+// shed optional verbosity before messages are lost, or use SetPressureHandler.
+func Pressure() float64 {
+	var p float64
+	for i := range pressureLevels {
+		if level := math.Float64frombits(atomic.LoadUint64(&pressureLevels[i])); level > p {
+			p = level
+		}
+	}
+	return p
+}
+
+// SetPressureHandler calls options.Handler with true once the pressure, see Pressure, reaches options.High, and with
+// false once it falls back to options.Low, e.g.
+//
+//	logging.SetPressureHandler(&logging.PressureOptions{Handler: func(high bool, _ float64) {
+//		if high {
+//			logging.SetLevelFor("netlink", logging.InfoLevel)
+//		} else {
+//			logging.SetLevelFor("netlink", logging.DebugLevel)
+//		}
+//	}})
+//
+// The handler is called on a goroutine of its own, one call at a time and in order, so it may log and change the
+// configuration. Passing nil removes the handler, which is the default.
+func SetPressureHandler(options *PressureOptions) {
+	pressure.mu.Lock()
+	defer pressure.mu.Unlock()
+
+	pressure.high = false
+	pressure.pending = nil
+	if options == nil || options.Handler == nil {
+		pressure.options = nil
+		return
+	}
+	o := *options
+	if o.High <= 0 {
+		o.High = defaultHighPressure
+	}
+	if o.Low <= 0 || o.Low >= o.High {
+		o.Low = o.High / 2
+	}
+	pressure.options = &o
+}
+
+// reportPressure records the fullness of a bounded buffer, used divided by size, and calls the handler if the pressure
+// crossed a threshold.
+func reportPressure(source pressureSource, used, size int) {
+	var level float64
+	if size > 0 {
+		level = math.Min(float64(used)/float64(size), 1)
+	}
+	if math.Float64frombits(atomic.SwapUint64(&pressureLevels[source], math.Float64bits(level))) == level {
+		return
+	}
+	pressure.update(Pressure())
+}
+
+// update calls the handler if p crossed a threshold.
+func (m *pressureMonitor) update(p float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch {
+	case m.options == nil:
+		return
+	case !m.high && p >= m.options.High:
+		m.high = true
+	case m.high && p <= m.options.Low:
+		m.high = false
+	default:
+		return
+	}
+	m.pending = append(m.pending, pressureEvent{high: m.high, pressure: p})
+	if !m.running {
+		m.running = true
+		go m.run()
+	}
+}
+
+// run makes the pending calls of the handler until there are none left.
+func (m *pressureMonitor) run() {
+	for {
+		m.mu.Lock()
+		if len(m.pending) == 0 || m.options == nil {
+			m.running = false
+			m.mu.Unlock()
+			return
+		}
+		event, handler := m.pending[0], m.options.Handler
+		m.pending = m.pending[1:]
+		m.mu.Unlock()
+
+		handler(event.high, event.pressure)
+	}
+}
+
+// resetPressure removes the handler and forgets the fullness of the buffers.
+func resetPressure() {
+	SetPressureHandler(nil)
+	for i := range pressureLevels {
+		atomic.StoreUint64(&pressureLevels[i], 0)
+	}
+}
@@ -0,0 +1,81 @@
+package logging
+
+import (
+	"bytes"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Back-pressure", func() {
+	var out bytes.Buffer
+	// bufferSize fits four lines of infoMsg and a half.
+	var bufferSize int
+
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+		out = bytes.Buffer{}
+		SetOutput(&out)
+		Infof(infoMsg)
+		bufferSize = 4*out.Len() + out.Len()/2
+		out.Reset()
+	})
+
+	AfterEach(func() {
+		initLogger()
+	})
+
+	It("is 0 without bounded buffers", func() {
+		SetAsync(&AsyncOptions{BatchSize: 1 << 20, MaxAge: time.Hour})
+		Infof(infoMsg)
+		Expect(Pressure()).To(BeZero())
+	})
+
+	It("reports the fullness of the asynchronous buffer and drops messages once it is full", func() {
+		SetAsync(&AsyncOptions{BatchSize: 1 << 20, MaxAge: time.Hour, MaxBufferSize: bufferSize})
+		var errs []error
+		SetErrorHandler(func(err error) { errs = append(errs, err) })
+
+		Infof(infoMsg)
+		Expect(Pressure()).To(BeNumerically("~", 0.22, 0.05))
+		for i := 0; i < 4; i++ {
+			Infof(infoMsg)
+		}
+		Expect(errs).NotTo(BeEmpty())
+		Expect(errs[0]).To(MatchError(ContainSubstring("buffer is full")))
+
+		Flush()
+		Expect(Pressure()).To(BeZero())
+	})
+
+	It("reports the fullness of the fallback buffer", func() {
+		failing := &failingWriter{failing: true}
+		SetOutput(failing)
+		SetFileFallback(&FallbackOptions{Sink: &captureSink{}, RetryInterval: time.Hour, BufferSize: 4})
+		Infof(infoMsg)
+		Expect(Pressure()).To(Equal(0.25))
+		SetFileFallback(nil)
+		Expect(Pressure()).To(BeZero())
+	})
+
+	It("calls the handler when the pressure crosses the thresholds", func() {
+		calls := make(chan bool, 4)
+		SetPressureHandler(&PressureOptions{High: 0.5, Handler: func(high bool, pressure float64) {
+			calls <- high
+		}})
+		SetAsync(&AsyncOptions{BatchSize: 1 << 20, MaxAge: time.Hour, MaxBufferSize: bufferSize})
+
+		Infof(infoMsg)
+		Consistently(calls, 50*time.Millisecond).ShouldNot(Receive())
+		Infof(infoMsg)
+		Infof(infoMsg)
+		Eventually(calls).Should(Receive(BeTrue()))
+		Infof(infoMsg)
+		Consistently(calls, 50*time.Millisecond).ShouldNot(Receive())
+
+		Flush()
+		Eventually(calls).Should(Receive(BeFalse()))
+	})
+})
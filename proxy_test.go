@@ -0,0 +1,120 @@
+package logging
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+// startConnectProxy starts an HTTP proxy which tunnels a single CONNECT request. The value of the Proxy-Authorization
+// header is sent to auth.
+func startConnectProxy(auth chan<- string) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).NotTo(HaveOccurred())
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		req, err := http.ReadRequest(bufio.NewReader(conn))
+		if err != nil || req.Method != http.MethodConnect {
+			return
+		}
+		auth <- req.Header.Get("Proxy-Authorization")
+		target, err := net.Dial("tcp", req.Host)
+		if err != nil {
+			fmt.Fprint(conn, "HTTP/1.1 502 Bad Gateway\r\n\r\n")
+			return
+		}
+		defer target.Close()
+		fmt.Fprint(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+		go func() { _, _ = io.Copy(conn, target) }()
+		_, _ = io.Copy(target, conn)
+	}()
+	return listener
+}
+
+var _ = Describe("Proxies", func() {
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+	})
+
+	AfterEach(func() {
+		Expect(DisableSyslog()).To(Succeed())
+	})
+
+	It("tunnels remote syslog through the proxy", func() {
+		server, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		defer server.Close()
+
+		auth := make(chan string, 1)
+		proxy := startConnectProxy(auth)
+		defer proxy.Close()
+
+		Expect(SetProxy("http://user:secret@" + proxy.Addr().String())).To(Succeed())
+		Expect(SetSyslog("tcp", server.Addr().String(), "cni-test")).To(Succeed())
+		Expect(auth).To(Receive(Equal("Basic dXNlcjpzZWNyZXQ=")))
+
+		conn, err := server.Accept()
+		Expect(err).NotTo(HaveOccurred())
+		defer conn.Close()
+		Infof(infoMsg)
+		reader := bufio.NewReader(conn)
+		var length int
+		_, err = fmt.Fscanf(reader, "%d ", &length)
+		Expect(err).NotTo(HaveOccurred())
+		msg := make([]byte, length)
+		_, err = io.ReadFull(reader, msg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(msg)).To(HaveSuffix(infoMsg))
+	})
+
+	It("reports proxies refusing the connection", func() {
+		proxy := startConnectProxy(make(chan string, 1))
+		defer proxy.Close()
+
+		Expect(SetProxy("http://" + proxy.Addr().String())).To(Succeed())
+		Expect(SetSyslog("tcp", "127.0.0.1:1", "cni-test")).To(MatchError(ContainSubstring("502")))
+	})
+
+	It("rejects invalid proxy URLs", func() {
+		Expect(SetProxy("socks5://proxy:1080")).NotTo(Succeed())
+		Expect(SetProxy("proxy:3128")).NotTo(Succeed())
+		Expect(SetProxy("")).To(Succeed())
+	})
+
+	It("uses the proxy configured in the environment", func() {
+		for key, value := range map[string]string{
+			"HTTPS_PROXY": "http://secure-proxy:3128",
+			"HTTP_PROXY":  "http://proxy:3128",
+			"NO_PROXY":    "internal.example",
+		} {
+			Expect(os.Setenv(key, value)).To(Succeed())
+			DeferCleanup(os.Unsetenv, key)
+		}
+		SetProxyFromEnvironment()
+		proxy := loadSnapshot().proxy
+
+		u, err := proxy(&url.URL{Scheme: "https", Host: "collector.example:6514"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(u.Host).To(Equal("secure-proxy:3128"))
+		u, err = proxy(&url.URL{Scheme: "http", Host: "collector.example:514"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(u.Host).To(Equal("proxy:3128"))
+		u, err = proxy(&url.URL{Scheme: "http", Host: "collector.internal.example:514"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(u).To(BeNil())
+	})
+})
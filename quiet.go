@@ -0,0 +1,98 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+const (
+	componentKey = "component"
+	// maxSuppressedCounters bounds the number of distinct levels and components quiet mode keeps track of.
+	maxSuppressedCounters = 1024
+)
+
+// suppressedKey identifies the messages counted by quiet mode.
+type suppressedKey struct {
+	level     Level
+	component string
+}
+
+// suppressedCounter counts the messages which quiet mode does not write. It is safe for concurrent use.
+type suppressedCounter struct {
+	mu     sync.Mutex
+	counts map[suppressedKey]uint64
+}
+
+// suppressed counts the messages suppressed by quiet mode since the logger was initialized.
+var suppressed = &suppressedCounter{counts: map[suppressedKey]uint64{}}
+
+// add counts a suppressed message. Once maxSuppressedCounters keys are tracked, messages of new components are counted
+// without component.
+func (c *suppressedCounter) add(level Level, component string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := suppressedKey{level: level, component: component}
+	if _, ok := c.counts[key]; !ok && len(c.counts) >= maxSuppressedCounters {
+		key.component = ""
+	}
+	c.counts[key]++
+}
+
+// reset discards all counts.
+func (c *suppressedCounter) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts = map[suppressedKey]uint64{}
+}
+
+// SetQuietLevel enables quiet mode: messages more verbose than level are not written to any output, but counted by
+// level and component in Stats, so that operators can quantify the suppressed noise before deciding to raise the
+// verbosity. Only messages which pass the logging level are counted, e.g. with SetLogLevel(DebugLevel) and
+// SetQuietLevel(WarningLevel), info and debug messages are counted and trace messages are dropped as usual. Since the
+// messages are still counted, Enabled keeps reporting true for them. The component of a structured message is the value
+// of its "component" field, printf style messages have none. Passing InvalidLevel disables quiet mode, which is the
+// default.
+func SetQuietLevel(level Level) {
+	mu.Lock()
+	defer unlockAndPublish()
+	if level != InvalidLevel && !validateLogLevel(level) {
+		fmt.Fprintf(os.Stderr, setLevelFailMsg, level)
+		return
+	}
+	quietLevel = level
+}
+
+// quiet returns true if a message of the given level is suppressed by quiet mode, and counts it. args are the
+// alternating keys and values of a structured message.
+func (s *snapshot) quiet(level Level, args []interface{}) bool {
+	if s.quietLevel == InvalidLevel || level <= s.quietLevel {
+		return false
+	}
+	suppressed.add(level, component(args))
+	return true
+}
+
+// component returns the value of the "component" field of args, "" if there is none.
+func component(args []interface{}) string {
+	for i := 0; i < len(args)-1; i += 2 {
+		if key, ok := args[i].(string); ok && key == componentKey {
+			return argToString(evaluateLazy(args[i+1 : i+2])[0])
+		}
+	}
+	return ""
+}
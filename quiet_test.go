@@ -0,0 +1,63 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Quiet mode", func() {
+	var sink *captureSink
+
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+		sink = &captureSink{}
+		AddSink(sink)
+		SetLogLevel(DebugLevel)
+		SetQuietLevel(WarningLevel)
+	})
+
+	It("counts the suppressed messages by level and component instead of writing them", func() {
+		Warningf(warningMsg)
+		Infof(infoMsg)
+		InfoStructured(infoMsg, "component", "ipam")
+		With("component", "ipam").DebugStructured(debugMsg)
+		DebugStructured(debugMsg, "component", "cni")
+		TraceStructured(traceMsg, "component", "ipam")
+
+		Expect(sink.entries).To(HaveLen(1))
+		Expect(sink.entries[0].Message).To(ContainSubstring(warningMsg))
+		Expect(Stats().Suppressed).To(Equal([]SuppressedCount{
+			{Level: InfoLevel, Component: "", Count: 1},
+			{Level: InfoLevel, Component: "ipam", Count: 1},
+			{Level: DebugLevel, Component: "cni", Count: 1},
+			{Level: DebugLevel, Component: "ipam", Count: 1},
+		}))
+	})
+
+	It("writes all messages again once disabled", func() {
+		SetQuietLevel(InvalidLevel)
+		DebugStructured(debugMsg)
+		Expect(sink.entries).To(HaveLen(1))
+		Expect(Stats().Suppressed).To(BeEmpty())
+	})
+
+	It("exposes the counts in the Prometheus text format", func() {
+		InfoStructured(infoMsg, "component", `a"b`)
+		var out bytes.Buffer
+		Expect(Stats().WritePrometheus(&out)).To(Succeed())
+		Expect(out.String()).To(ContainSubstring(
+			"# TYPE cni_log_suppressed_messages_total counter\n" +
+				`cni_log_suppressed_messages_total{level="info",component="a\"b"} 1` + "\n"))
+	})
+
+	It("marshals the statistics as JSON", func() {
+		DebugStructured(debugMsg)
+		data, err := json.Marshal(Stats())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(HaveSuffix(`"suppressed":[{"level":5,"component":"","count":1}]}`))
+	})
+})
@@ -0,0 +1,353 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	defaultSamplingInterval = time.Second
+	// maxSamplingCounters bounds the number of distinct messages sampling keeps track of.
+	maxSamplingCounters = 4096
+
+	suppressedSummaryMsg = "log messages suppressed"
+)
+
+// SamplingOptions configures the sampling of the messages of a logging level. Within every interval, the first First
+// occurrences of a message are logged, and after that every Thereafter-th occurrence. Messages are identical if they
+// have the same level and format string, or the same msg for structured messages.
+type SamplingOptions struct {
+	// Interval is the length of a sampling interval, one second by default.
+	Interval time.Duration
+	// First is the number of occurrences of a message which are logged per interval.
+	First int
+	// Thereafter logs every Thereafter-th occurrence after the first ones, 0 drops all of them.
+	Thereafter int
+}
+
+// RateLimitOptions configures a token bucket which limits the rate of all log messages.
+type RateLimitOptions struct {
+	// Rate is the number of messages per second which are logged on average.
+	Rate float64
+	// Burst is the number of messages which are logged in a burst, at least 1.
+	Burst int
+}
+
+// FieldRateLimitOptions limits the number of messages per value of a field, so that the messages about one resource,
+// e.g. a crash-looping pod, cannot drown out the messages about all others.
+type FieldRateLimitOptions struct {
+	// Key is the field whose values are limited separately, e.g. "containerID".
+	Key string
+	// Limit is the number of messages per value which are logged within an interval.
+	Limit int
+	// Interval is the length of an interval, one second by default.
+	Interval time.Duration
+}
+
+// samplingKey identifies a message for sampling.
+type samplingKey struct {
+	level Level
+	msg   string
+}
+
+// samplingCounter counts the occurrences of a message within the current interval.
+type samplingCounter struct {
+	start time.Time
+	count int
+}
+
+// limiter samples and rate limits log messages and reports the suppressed messages periodically.
+type limiter struct {
+	mu       sync.Mutex
+	now      func() time.Time
+	sampling map[Level]SamplingOptions
+	counters map[samplingKey]*samplingCounter
+
+	rateLimit *RateLimitOptions
+	tokens    float64
+	refilled  time.Time
+
+	fieldLimit    *FieldRateLimitOptions
+	fieldCounters map[string]*samplingCounter
+
+	sampled      int
+	rateLimited  int
+	throttled    int
+	summaryTimer *time.Timer
+	// summary writes the summary of the suppressed messages.
+	summary func(sampled, rateLimited, throttled int)
+}
+
+// newLimiter returns a limiter which neither samples nor rate limits.
+func newLimiter(summary func(sampled, rateLimited, throttled int)) *limiter {
+	return &limiter{
+		now:           time.Now,
+		sampling:      map[Level]SamplingOptions{},
+		counters:      map[samplingKey]*samplingCounter{},
+		fieldCounters: map[string]*samplingCounter{},
+		summary:       summary,
+	}
+}
+
+// allow returns true if a message of the given level should be logged. The fields of structured messages are looked
+// up in the alternating keys and values of args and context for the limit per field value. Fatal and panic messages
+// are always logged.
+func (l *limiter) allow(level Level, msg string, args, context []interface{}) bool {
+	if level <= PanicLevel {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if !l.sample(level, msg, now) {
+		l.sampled++
+		l.scheduleSummary()
+		return false
+	}
+	if !l.limitField(args, context, now) {
+		l.throttled++
+		l.scheduleSummary()
+		return false
+	}
+	if !l.takeToken(now) {
+		l.rateLimited++
+		l.scheduleSummary()
+		return false
+	}
+	return true
+}
+
+// limitField returns true if the limit of the value of the limited field is not reached yet. Messages without the
+// field are not limited. The caller must hold l.mu.
+func (l *limiter) limitField(args, context []interface{}, now time.Time) bool {
+	if l.fieldLimit == nil {
+		return true
+	}
+	value, ok := fieldValue(l.fieldLimit.Key, args)
+	if !ok {
+		if value, ok = fieldValue(l.fieldLimit.Key, context); !ok {
+			return true
+		}
+	}
+
+	c, ok := l.fieldCounters[value]
+	if !ok || now.Sub(c.start) >= l.fieldLimit.Interval {
+		if !ok && len(l.fieldCounters) >= maxSamplingCounters {
+			l.fieldCounters = map[string]*samplingCounter{}
+		}
+		c = &samplingCounter{start: now}
+		l.fieldCounters[value] = c
+	}
+	c.count++
+	return c.count <= l.fieldLimit.Limit
+}
+
+// fieldValue returns the string representation of the value of key in the alternating keys and values of args.
+func fieldValue(key string, args []interface{}) (string, bool) {
+	for i := 0; i < len(args)-1; i += 2 {
+		if k, ok := args[i].(string); ok && k == key {
+			return argToString(evaluateLazy(args[i+1 : i+2])[0]), true
+		}
+	}
+	return "", false
+}
+
+// sample returns true if the message is sampled. The caller must hold l.mu.
+func (l *limiter) sample(level Level, msg string, now time.Time) bool {
+	options, ok := l.sampling[level]
+	if !ok {
+		return true
+	}
+
+	key := samplingKey{level: level, msg: msg}
+	c, ok := l.counters[key]
+	if !ok || now.Sub(c.start) >= options.Interval {
+		if !ok && len(l.counters) >= maxSamplingCounters {
+			l.counters = map[samplingKey]*samplingCounter{}
+		}
+		c = &samplingCounter{start: now}
+		l.counters[key] = c
+	}
+
+	c.count++
+	if c.count <= options.First {
+		return true
+	}
+	return options.Thereafter > 0 && (c.count-options.First)%options.Thereafter == 0
+}
+
+// takeToken takes a token from the bucket. It returns false if the bucket is empty. The caller must hold l.mu.
+func (l *limiter) takeToken(now time.Time) bool {
+	if l.rateLimit == nil {
+		return true
+	}
+
+	burst := float64(l.rateLimit.Burst)
+	l.tokens += now.Sub(l.refilled).Seconds() * l.rateLimit.Rate
+	if l.tokens > burst {
+		l.tokens = burst
+	}
+	l.refilled = now
+
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// summaryInterval returns the interval of the summary of the suppressed messages: the shortest sampling or field limit
+// interval, one second without any. The caller must hold l.mu.
+func (l *limiter) summaryInterval() time.Duration {
+	interval := defaultSamplingInterval
+	for _, options := range l.sampling {
+		if options.Interval < interval {
+			interval = options.Interval
+		}
+	}
+	if l.fieldLimit != nil && l.fieldLimit.Interval < interval {
+		interval = l.fieldLimit.Interval
+	}
+	return interval
+}
+
+// scheduleSummary makes sure that the suppressed messages are reported at the end of the current summary interval.
+// The caller must hold l.mu.
+func (l *limiter) scheduleSummary() {
+	if l.summaryTimer == nil {
+		l.summaryTimer = time.AfterFunc(l.summaryInterval(), l.reportSuppressed)
+	}
+}
+
+// reportSuppressed writes the summary of the messages suppressed since the last summary.
+func (l *limiter) reportSuppressed() {
+	l.mu.Lock()
+	sampled, rateLimited, throttled := l.sampled, l.rateLimited, l.throttled
+	l.sampled, l.rateLimited, l.throttled = 0, 0, 0
+	l.summaryTimer = nil
+	l.mu.Unlock()
+
+	if sampled > 0 || rateLimited > 0 || throttled > 0 {
+		l.summary(sampled, rateLimited, throttled)
+	}
+}
+
+// stop stops the summary timer without reporting the messages suppressed so far.
+func (l *limiter) stop() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.summaryTimer != nil {
+		l.summaryTimer.Stop()
+		l.summaryTimer = nil
+	}
+}
+
+// writeSuppressedSummary logs the summary of the suppressed messages as a warning which is not subject to sampling or
+// rate limiting.
+func writeSuppressedSummary(sampled, rateLimited, throttled int) {
+	writeStructured(loadSnapshot(), WarningLevel, suppressedSummaryMsg, false, "sampled", sampled,
+		"rateLimited", rateLimited, "throttled", throttled)
+}
+
+// SetSampling configures the sampling of the messages of the given level, so that retry loops do not flood the log:
+// within every interval, only the first occurrences of a message are logged, and after that every n-th occurrence.
+// Passing nil disables sampling for the level. Fatal and panic messages are never sampled. The number of suppressed
+// messages is reported periodically in a "log messages suppressed" warning.
+func SetSampling(level Level, options *SamplingOptions) {
+	mu.Lock()
+	defer unlockAndPublish()
+
+	l := currentLimiter()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if options == nil {
+		delete(l.sampling, level)
+		return
+	}
+	o := *options
+	if o.Interval <= 0 {
+		o.Interval = defaultSamplingInterval
+	}
+	l.sampling[level] = o
+	l.counters = map[samplingKey]*samplingCounter{}
+}
+
+// SetRateLimit limits the rate of all log messages with a token bucket: on average options.Rate messages per second are
+// logged, with bursts of up to options.Burst messages. Passing nil disables the rate limit. Fatal and panic messages
+// are never rate limited. The number of suppressed messages is reported periodically in a "log messages suppressed"
+// warning.
+func SetRateLimit(options *RateLimitOptions) {
+	mu.Lock()
+	defer unlockAndPublish()
+
+	l := currentLimiter()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if options == nil {
+		l.rateLimit = nil
+		return
+	}
+	o := *options
+	if o.Burst < 1 {
+		o.Burst = 1
+	}
+	l.rateLimit = &o
+	l.tokens = float64(o.Burst)
+	l.refilled = l.now()
+}
+
+// SetFieldRateLimit limits the number of messages per value of a field, e.g. to at most 5 messages per containerID
+// per minute:
+//
+//	SetFieldRateLimit(&FieldRateLimitOptions{Key: "containerID", Limit: 5, Interval: time.Minute})
+//
+// The field is looked up in the arguments and the context of structured messages, including the CNI context, see
+// EnableCNIContext. Messages without the field are not limited. Passing nil disables the limit. Fatal and panic
+// messages are never limited. The number of suppressed messages is reported periodically in a "log messages
+// suppressed" warning.
+func SetFieldRateLimit(options *FieldRateLimitOptions) {
+	mu.Lock()
+	defer unlockAndPublish()
+
+	l := currentLimiter()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.fieldCounters = map[string]*samplingCounter{}
+	if options == nil {
+		l.fieldLimit = nil
+		return
+	}
+	o := *options
+	if o.Interval <= 0 {
+		o.Interval = defaultSamplingInterval
+	}
+	l.fieldLimit = &o
+}
+
+// currentLimiter returns the limiter, creating it if necessary. The caller must hold mu.
+func currentLimiter() *limiter {
+	if logLimiter == nil {
+		logLimiter = newLimiter(writeSuppressedSummary)
+	}
+	return logLimiter
+}
@@ -0,0 +1,144 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sampling and rate limiting", func() {
+	var out *syncBuffer
+	var now time.Time
+
+	BeforeEach(func() {
+		initLogger()
+		out = &syncBuffer{}
+		SetOutput(out)
+		SetLogStderr(false)
+		SetLogLevel(DebugLevel)
+		SetFileFields("msg", "sampled", "rateLimited")
+
+		now = time.Now()
+		currentLimiter().now = func() time.Time { return now }
+	})
+
+	AfterEach(func() {
+		initLogger()
+	})
+
+	count := func(msg string) int {
+		return strings.Count(out.String(), fmt.Sprintf("msg=%q\n", msg))
+	}
+
+	It("logs the first messages and then every n-th", func() {
+		SetSampling(DebugLevel, &SamplingOptions{Interval: time.Hour, First: 2, Thereafter: 3})
+		for i := 0; i < 11; i++ {
+			DebugStructured(debugMsg)
+			InfoStructured(infoMsg)
+		}
+		// 1, 2, 5, 8, 11
+		Expect(count(debugMsg)).To(Equal(5))
+		Expect(count(infoMsg)).To(Equal(11))
+	})
+
+	It("samples identical messages independently", func() {
+		SetSampling(WarningLevel, &SamplingOptions{Interval: time.Hour, First: 1})
+		for i := 0; i < 3; i++ {
+			Warningf("retrying %d", i)
+			Warningf("other")
+		}
+		Expect(strings.Count(out.String(), "retrying")).To(Equal(1))
+		Expect(strings.Count(out.String(), "other")).To(Equal(1))
+	})
+
+	It("starts counting again in the next interval", func() {
+		SetSampling(DebugLevel, &SamplingOptions{Interval: time.Minute, First: 1})
+		DebugStructured(debugMsg)
+		DebugStructured(debugMsg)
+		now = now.Add(time.Minute)
+		DebugStructured(debugMsg)
+		Expect(count(debugMsg)).To(Equal(2))
+	})
+
+	It("limits the rate of all messages", func() {
+		SetRateLimit(&RateLimitOptions{Rate: 1, Burst: 2})
+		for i := 0; i < 5; i++ {
+			InfoStructured(infoMsg)
+		}
+		Expect(count(infoMsg)).To(Equal(2))
+
+		now = now.Add(time.Second)
+		InfoStructured(infoMsg)
+		InfoStructured(infoMsg)
+		Expect(count(infoMsg)).To(Equal(3))
+	})
+
+	It("never suppresses panic messages", func() {
+		SetRateLimit(&RateLimitOptions{Rate: 1, Burst: 1})
+		for i := 0; i < 3; i++ {
+			Panicf(panicMsg)
+		}
+		Expect(strings.Count(out.String(), panicMsg)).To(Equal(3))
+	})
+
+	It("reports the number of suppressed messages", func() {
+		SetSampling(DebugLevel, &SamplingOptions{Interval: 20 * time.Millisecond, First: 1})
+		SetRateLimit(&RateLimitOptions{Rate: 0.001, Burst: 2})
+		for i := 0; i < 4; i++ {
+			DebugStructured(debugMsg)
+		}
+		InfoStructured(infoMsg)
+		InfoStructured(infoMsg)
+		Eventually(out.String).Should(ContainSubstring(
+			fmt.Sprintf("msg=%q sampled=\"3\" rateLimited=\"1\"\n", suppressedSummaryMsg)))
+	})
+
+	It("stops sampling and rate limiting when disabled", func() {
+		SetSampling(DebugLevel, &SamplingOptions{First: 1})
+		SetRateLimit(&RateLimitOptions{Rate: 0.001, Burst: 1})
+		SetSampling(DebugLevel, nil)
+		SetRateLimit(nil)
+		for i := 0; i < 3; i++ {
+			DebugStructured(debugMsg)
+		}
+		Expect(count(debugMsg)).To(Equal(3))
+	})
+
+	It("limits the messages per value of a field", func() {
+		SetFieldRateLimit(&FieldRateLimitOptions{Key: "containerID", Limit: 2, Interval: time.Minute})
+		for i := 0; i < 5; i++ {
+			InfoStructured(infoMsg, "containerID", "crash-looping")
+			With("containerID", "healthy").DebugStructured(debugMsg)
+			WarningStructured(warningMsg)
+		}
+		Expect(count(infoMsg)).To(Equal(2))
+		Expect(count(debugMsg)).To(Equal(2))
+		Expect(count(warningMsg)).To(Equal(5))
+
+		now = now.Add(time.Minute)
+		InfoStructured(infoMsg, "containerID", "crash-looping")
+		Expect(count(infoMsg)).To(Equal(3))
+	})
+
+	It("looks up the limited field in the CNI context", func() {
+		Expect(os.Setenv("CNI_CONTAINERID", "abc")).To(Succeed())
+		DeferCleanup(os.Unsetenv, "CNI_CONTAINERID")
+		EnableCNIContext()
+		SetFieldRateLimit(&FieldRateLimitOptions{Key: "containerID", Limit: 1})
+		InfoStructured(infoMsg)
+		InfoStructured(infoMsg)
+		Expect(count(infoMsg)).To(Equal(1))
+	})
+
+	It("reports the number of throttled messages", func() {
+		SetFileFields("msg", "throttled")
+		SetFieldRateLimit(&FieldRateLimitOptions{Key: "pod", Limit: 1, Interval: 20 * time.Millisecond})
+		InfoStructured(infoMsg, "pod", "pod-a")
+		InfoStructured(infoMsg, "pod", "pod-a")
+		Eventually(out.String).Should(ContainSubstring(fmt.Sprintf("msg=%q throttled=\"1\"\n", suppressedSummaryMsg)))
+	})
+})
@@ -0,0 +1,111 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	redactedValue         = "[REDACTED]"
+	invalidPatternFailMsg = "cni-log: invalid redaction pattern '%s': %v"
+)
+
+// RedactionOptions define the values which are redacted from log messages, see SetRedaction.
+type RedactionOptions struct {
+	// Keys are the keys of structured fields whose values are redacted, e.g. "password" or "token". Keys are matched
+	// case-insensitively.
+	Keys []string
+	// Patterns are regular expressions. The matching substrings of messages and of the values of structured fields are
+	// redacted, e.g. `Bearer \S+`.
+	Patterns []string
+}
+
+// redactor replaces sensitive values with redactedValue.
+type redactor struct {
+	keys     map[string]bool
+	patterns []*regexp.Regexp
+}
+
+// SetRedaction replaces sensitive values with "[REDACTED]" before any output or sink receives a message: the values of
+// structured fields with one of the given keys, and the substrings of printf style messages, structured messages and
+// structured values matching one of the given patterns. CNI network configurations may embed credentials, e.g. of IPAM
+// backends, which must not end up in the logs. Passing nil disables redaction. It returns an error if a pattern is
+// invalid, in which case the redaction is left unchanged.
+func SetRedaction(options *RedactionOptions) error {
+	var r *redactor
+	if options != nil {
+		r = &redactor{keys: map[string]bool{}}
+		for _, key := range options.Keys {
+			r.keys[strings.ToLower(key)] = true
+		}
+		for _, pattern := range options.Patterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf(invalidPatternFailMsg, pattern, err)
+			}
+			r.patterns = append(r.patterns, re)
+		}
+	}
+
+	mu.Lock()
+	defer unlockAndPublish()
+	logRedactor = r
+	return nil
+}
+
+// message returns msg with all substrings matching the patterns of r redacted.
+func (r *redactor) message(msg string) string {
+	if r == nil {
+		return msg
+	}
+	for _, re := range r.patterns {
+		msg = re.ReplaceAllString(msg, redactedValue)
+	}
+	return msg
+}
+
+// fields returns the key/value pairs of fields with the values of the keys of r and all substrings of values matching
+// the patterns of r redacted. fields is only copied if a value is redacted.
+func (r *redactor) fields(fields []interface{}) []interface{} {
+	if r == nil {
+		return fields
+	}
+
+	var redacted []interface{}
+	for i := 0; i < len(fields)-1; i += 2 {
+		var value interface{}
+		if r.keys[strings.ToLower(argToString(fields[i]))] {
+			value = redactedValue
+		} else if len(r.patterns) > 0 {
+			if s := argToString(fields[i+1]); r.message(s) != s {
+				value = r.message(s)
+			}
+		}
+		if value == nil {
+			continue
+		}
+		if redacted == nil {
+			redacted = append(make([]interface{}, 0, len(fields)), fields...)
+		}
+		redacted[i+1] = value
+	}
+	if redacted == nil {
+		return fields
+	}
+	return redacted
+}
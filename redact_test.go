@@ -0,0 +1,47 @@
+package logging
+
+import (
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Redaction", func() {
+	var sink *captureSink
+
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+		sink = &captureSink{}
+		AddSink(sink)
+		Expect(SetRedaction(&RedactionOptions{
+			Keys:     []string{"password"},
+			Patterns: []string{`Bearer \S+`},
+		})).To(Succeed())
+	})
+
+	It("redacts the values of registered keys", func() {
+		InfoStructured(infoMsg, "user", "admin", "Password", "hunter2", "routes", []string{"10.0.0.0/8"})
+		Expect(sink.entries[0].String()).To(HaveSuffix(`user="admin" Password="[REDACTED]" routes="[10.0.0.0/8]"`))
+	})
+
+	It("redacts substrings matching the patterns", func() {
+		Infof("calling IPAM with Authorization: %s", "Bearer abc.def")
+		InfoStructured("header Bearer abc.def", "header", "Bearer abc.def", "status", 200)
+		Expect(sink.entries[0].Message).To(Equal("calling IPAM with Authorization: [REDACTED]"))
+		Expect(sink.entries[1].Message).To(Equal("header [REDACTED]"))
+		Expect(sink.entries[1].String()).To(HaveSuffix(`msg="header [REDACTED]" header="[REDACTED]" status="200"`))
+		Expect(sink.entries[1].Fields[len(sink.entries[1].Fields)-1]).To(Equal(200))
+	})
+
+	It("redacts the errors returned by ErrorStructured", func() {
+		SetLogLevel(PanicLevel)
+		Expect(ErrorStructured(errorMsg, "password", "hunter2")).To(MatchError(HaveSuffix(`password="[REDACTED]"`)))
+	})
+
+	It("rejects invalid patterns and can be disabled", func() {
+		Expect(SetRedaction(&RedactionOptions{Patterns: []string{"("}})).NotTo(Succeed())
+		Expect(SetRedaction(nil)).To(Succeed())
+		InfoStructured(infoMsg, "password", "hunter2")
+		Expect(sink.entries[0].String()).To(HaveSuffix(`password="hunter2"`))
+	})
+})
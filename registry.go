@@ -0,0 +1,135 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	unknownFormatFailMsg = "cni-log: unknown format '%s', registered formats: %v"
+	unknownPrefixFailMsg = "cni-log: unknown prefix '%s', registered prefixes: %v"
+)
+
+// Names of the built-in formatters and prefixers, see RegisterFormatter and RegisterPrefixer.
+const (
+	FormatText    = "text"
+	FormatLogfmt  = "logfmt"
+	FormatJSON    = "json"
+	PrefixDefault = "default"
+	PrefixKlog    = "klog"
+)
+
+// klogTimestampFormat is the timestamp format of the klog header, "mmdd hh:mm:ss.uuuuuu".
+const klogTimestampFormat = "0102 15:04:05.000000"
+
+// formatters and prefixers map the names which can be used in the configuration to formatters and prefixers. They are
+// guarded by mu.
+var (
+	formatters = map[string]Formatter{
+		FormatText:   TextFormatter{},
+		FormatLogfmt: LogfmtFormatter{},
+		FormatJSON:   JSONFormatter{},
+	}
+	prefixers = map[string]Prefixer{
+		PrefixDefault: newDefaultPrefixer(),
+		PrefixKlog:    klogPrefixer{},
+	}
+)
+
+// RegisterFormatter makes f selectable by name through the "format" setting of the configuration, see Config. It
+// replaces a formatter registered before under the same name, including the built-in "text", "logfmt" and "json".
+func RegisterFormatter(name string, f Formatter) {
+	mu.Lock()
+	defer mu.Unlock()
+	formatters[name] = f
+}
+
+// RegisterPrefixer makes p selectable by name through the "prefix" setting of the configuration, see Config. It
+// replaces a prefixer registered before under the same name, including the built-in "default" and "klog".
+func RegisterPrefixer(name string, p Prefixer) {
+	mu.Lock()
+	defer mu.Unlock()
+	prefixers[name] = p
+}
+
+// checkRegistered returns an error if a non-empty format or prefix is not registered.
+func checkRegistered(format, prefix string) error {
+	mu.RLock()
+	defer mu.RUnlock()
+	if _, err := lookupFormatter(format); err != nil {
+		return err
+	}
+	_, err := lookupPrefixer(prefix)
+	return err
+}
+
+// lookupFormatter returns the formatter registered as name, nil for an empty name. The caller must hold mu.
+func lookupFormatter(name string) (Formatter, error) {
+	if name == "" {
+		return nil, nil
+	}
+	f, ok := formatters[name]
+	if !ok {
+		names := make([]string, 0, len(formatters))
+		for n := range formatters {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf(unknownFormatFailMsg, name, names)
+	}
+	return f, nil
+}
+
+// lookupPrefixer returns the prefixer registered as name, nil for an empty name. The caller must hold mu.
+func lookupPrefixer(name string) (Prefixer, error) {
+	if name == "" {
+		return nil, nil
+	}
+	p, ok := prefixers[name]
+	if !ok {
+		names := make([]string, 0, len(prefixers))
+		for n := range prefixers {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf(unknownPrefixFailMsg, name, names)
+	}
+	return p, nil
+}
+
+// klogPrefixer creates the header used by klog, "Lmmdd hh:mm:ss.uuuuuu pid] ", so that the messages of a plugin line
+// up with the messages of the Kubernetes components around it.
+type klogPrefixer struct{}
+
+// CreatePrefix implements the Prefixer interface.
+func (klogPrefixer) CreatePrefix(loggingLevel Level) string {
+	return fmt.Sprintf("%c%s %7d] ", klogSeverity(loggingLevel), logTime().Format(klogTimestampFormat), logPid())
+}
+
+// klogSeverity returns the klog severity character of level. klog has no debug or trace severity, verbose messages
+// are info messages.
+func klogSeverity(level Level) byte {
+	switch level {
+	case PanicLevel, FatalLevel:
+		return 'F'
+	case ErrorLevel:
+		return 'E'
+	case WarningLevel:
+		return 'W'
+	}
+	return 'I'
+}
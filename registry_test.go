@@ -0,0 +1,73 @@
+package logging
+
+import (
+	"os"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Formatter and prefixer registry", func() {
+	BeforeEach(func() {
+		initLogger()
+	})
+
+	It("selects a built-in formatter by name", func() {
+		Expect(ApplyConfig(&Config{Format: FormatJSON})).To(Succeed())
+		out := captureStdErrEvent(Infof, infoMsg)
+		Expect(out).To(HavePrefix(`{"time":`))
+		Expect(out).To(ContainSubstring(`"msg":"` + infoMsg + `"`))
+	})
+
+	It("selects the klog prefixer by name", func() {
+		Expect(ApplyConfig(&Config{Prefix: PrefixKlog})).To(Succeed())
+		out := captureStdErrEvent(Warningf, warningMsg)
+		Expect(out).To(MatchRegexp(`^W\d{4} \d{2}:\d{2}:\d{2}\.\d{6} +%d\] %s\n$`, os.Getpid(), warningMsg))
+	})
+
+	It("selects a registered formatter by name", func() {
+		RegisterFormatter("upper", FormatterFunc(func(entry Entry) []byte {
+			return []byte("UPPER " + entry.Message)
+		}))
+		DeferCleanup(func() {
+			mu.Lock()
+			defer mu.Unlock()
+			delete(formatters, "upper")
+		})
+
+		Expect(ApplyConfig(&Config{Format: "upper"})).To(Succeed())
+		Expect(captureStdErrEvent(Infof, infoMsg)).To(Equal("UPPER " + infoMsg + "\n"))
+	})
+
+	It("rejects unknown names", func() {
+		_, err := ParseConfig([]byte(`{"logging": {"format": "xml"}}`))
+		Expect(err).To(MatchError(ContainSubstring("unknown format 'xml'")))
+		Expect(ApplyConfig(&Config{LogLevel: "debug", Prefix: "glog"})).To(MatchError(ContainSubstring("unknown prefix 'glog'")))
+		Expect(GetLogLevel()).To(Equal(defaultLogLevel))
+	})
+
+	It("restores the defaults once the configuration no longer selects them", func() {
+		Expect(ApplyConfig(&Config{Format: FormatLogfmt, Prefix: PrefixKlog})).To(Succeed())
+		Expect(ApplyConfig(nil)).To(Succeed())
+		Expect(stderrFormatter).To(BeNil())
+		Expect(captureStdErrEvent(Infof, infoMsg)).To(MatchRegexp(`^\S+ \[info\] %s\n$`, infoMsg))
+	})
+
+	It("keeps a formatter set through the API", func() {
+		SetFormatter(JSONFormatter{})
+		Expect(ApplyConfig(&Config{LogLevel: "debug"})).To(Succeed())
+		Expect(stderrFormatter).To(Equal(JSONFormatter{}))
+	})
+
+	It("reads the names from the environment", func() {
+		Expect(os.Setenv(EnvLogFormat, FormatLogfmt)).To(Succeed())
+		DeferCleanup(os.Unsetenv, EnvLogFormat)
+		Expect(ConfigureFromEnv()).To(Succeed())
+		Expect(stderrFormatter).To(Equal(LogfmtFormatter{}))
+		for _, v := range ExplainConfig() {
+			if v.Name == "format" {
+				Expect(v.String()).To(Equal("format=logfmt (env)"))
+			}
+		}
+	})
+})
@@ -0,0 +1,79 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import "os"
+
+const reopenFailedMsg = "failed to reopen the log files"
+
+// Reopen closes the log file, the error log file and the secondary log file. They are reopened, or created if they were
+// moved away, by the next message written to them. This supports rotating the log files with external tools like
+// logrotate instead of the built-in rotation: the rotated file is renamed and the "postrotate" script makes the process
+// call Reopen, e.g. through EnableReopenOnSignal. Messages logged after the rename go to a new file anyway, but only
+// Reopen releases the renamed file right away, so that logrotate can compress it. The last error of closing the files
+// is returned.
+func Reopen() error {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	var err error
+	if d, ok := logWriter.(*dailyWriter); ok {
+		err = d.Close()
+	}
+	for _, w := range append([]*fileWriter{logFileWriter, errorLogWriter, secondaryLogWriter}, routeWriters()...) {
+		if w == nil {
+			continue
+		}
+		if closeErr := w.close(); closeErr != nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// EnableReopenOnSignal calls Reopen whenever the process receives sig, usually syscall.SIGHUP, so that the classic
+// logrotate configuration works:
+//
+//	postrotate
+//	    kill -HUP $(cat /run/mynet.pid)
+//	endscript
+//
+// Errors are logged. Enabling it again replaces the previous signal.
+func EnableReopenOnSignal(sig os.Signal) {
+	mu.Lock()
+	defer unlockAndPublish()
+	stopReopenOnSignal()
+	reopenHandler = startSignalHandler(func(os.Signal) {
+		if err := Reopen(); err != nil {
+			_ = ErrorStructured(reopenFailedMsg, "err", err)
+		}
+	}, sig)
+}
+
+// DisableReopenOnSignal stops reopening the log files on a signal, which is the default. The signal is reset to its
+// default behavior.
+func DisableReopenOnSignal() {
+	mu.Lock()
+	defer unlockAndPublish()
+	stopReopenOnSignal()
+}
+
+// stopReopenOnSignal stops reopening the log files on a signal if it is enabled. The caller must hold mu.
+func stopReopenOnSignal() {
+	if reopenHandler != nil {
+		reopenHandler.stop()
+		reopenHandler = nil
+	}
+}
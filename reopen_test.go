@@ -0,0 +1,68 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package logging
+
+import (
+	"os"
+	"path"
+	"syscall"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Reopening the log files", func() {
+	var dir, logFile, rotatedFile string
+
+	BeforeEach(func() {
+		initLogger()
+		var err error
+		dir, err = os.MkdirTemp("", "cni-log-reopen")
+		Expect(err).NotTo(HaveOccurred())
+		logFile = path.Join(dir, "plugin.log")
+		rotatedFile = path.Join(dir, "plugin.log.1")
+		SetLogFile(logFile)
+		SetLogStderr(false)
+		Expect(SetErrorLogFile(path.Join(dir, "plugin.error.log"), nil)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		DisableReopenOnSignal()
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	isOpen := func(w *fileWriter) func() bool {
+		return func() bool {
+			w.mu.Lock()
+			defer w.mu.Unlock()
+			return w.info != nil
+		}
+	}
+
+	rotate := func() {
+		Warningf(warningMsg)
+		Expect(isOpen(logFileWriter)()).To(BeTrue())
+		Expect(isOpen(errorLogWriter)()).To(BeTrue())
+		Expect(os.Rename(logFile, rotatedFile)).To(Succeed())
+	}
+
+	It("closes the log files, which are reopened by the next message", func() {
+		rotate()
+		Expect(Reopen()).To(Succeed())
+		Expect(isOpen(logFileWriter)()).To(BeFalse())
+		Expect(isOpen(errorLogWriter)()).To(BeFalse())
+
+		Infof(infoMsg)
+		Expect(logFileContains(logFile, infoMsg)).To(BeTrue())
+		Expect(logFileContains(logFile, warningMsg)).To(BeFalse())
+		Expect(logFileContains(rotatedFile, warningMsg)).To(BeTrue())
+	})
+
+	It("reopens the log files on a signal", func() {
+		EnableReopenOnSignal(syscall.SIGHUP)
+		rotate()
+		Expect(syscall.Kill(syscall.Getpid(), syscall.SIGHUP)).To(Succeed())
+		Eventually(isOpen(logFileWriter)).Should(BeFalse())
+		Eventually(isOpen(errorLogWriter)).Should(BeFalse())
+	})
+})
@@ -0,0 +1,231 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	defaultResolverTimeout   = 100 * time.Millisecond
+	defaultResolverCacheTTL  = time.Minute
+	defaultResolverCacheSize = 1024
+
+	resolverPanicFailMsg = "cni-log: resolver panicked looking up %q: %v"
+)
+
+// Resolver looks up additional fields for the value of a field of a structured log message, e.g. the pod name and
+// namespace of a container ID. It returns alternating keys and values. ctx is not canceled when a log call stops
+// waiting for the lookup, only once the lookup took longer than the CacheTTL of its ResolverOptions.
+type Resolver func(ctx context.Context, value string) ([]interface{}, error)
+
+// ResolverOptions configures the caching and the timeout of a Resolver. Zero values select the defaults.
+type ResolverOptions struct {
+	// Timeout is the time a log call waits for the resolver, 100ms by default. A lookup which takes longer is not
+	// aborted, its result is cached for later log calls. Log calls for a value which is being looked up wait for the
+	// running lookup instead of starting another one.
+	Timeout time.Duration
+	// CacheTTL is the time the result of a lookup is cached, one minute by default. Failed lookups are cached as well,
+	// so that a slow or unavailable backend does not delay every log call.
+	CacheTTL time.Duration
+	// CacheSize is the maximum number of cached lookups, 1024 by default.
+	CacheSize int
+}
+
+// fieldResolver caches the results of a Resolver.
+type fieldResolver struct {
+	resolve   Resolver
+	timeout   time.Duration
+	ttl       time.Duration
+	cacheSize int
+	now       func() time.Time
+
+	mu      sync.Mutex
+	cache   map[string]resolvedFields
+	pending map[string]*pendingLookup
+}
+
+// pendingLookup is a running lookup. fields is set before done is closed.
+type pendingLookup struct {
+	done   chan struct{}
+	fields []interface{}
+}
+
+// resolvedFields is a cached lookup result.
+type resolvedFields struct {
+	fields  []interface{}
+	expires time.Time
+}
+
+// newFieldResolver returns a fieldResolver for r. options may be nil.
+func newFieldResolver(r Resolver, options *ResolverOptions) *fieldResolver {
+	if options == nil {
+		options = &ResolverOptions{}
+	}
+	fr := &fieldResolver{
+		resolve:   r,
+		timeout:   options.Timeout,
+		ttl:       options.CacheTTL,
+		cacheSize: options.CacheSize,
+		now:       time.Now,
+		cache:     map[string]resolvedFields{},
+		pending:   map[string]*pendingLookup{},
+	}
+	if fr.timeout <= 0 {
+		fr.timeout = defaultResolverTimeout
+	}
+	if fr.ttl <= 0 {
+		fr.ttl = defaultResolverCacheTTL
+	}
+	if fr.cacheSize <= 0 {
+		fr.cacheSize = defaultResolverCacheSize
+	}
+	return fr
+}
+
+// lookup returns the fields for value, from the cache if possible. It returns nil if the lookup failed or did not
+// finish within the timeout. Only one lookup runs per value at a time.
+func (fr *fieldResolver) lookup(value string) []interface{} {
+	fr.mu.Lock()
+	cached, ok := fr.cache[value]
+	if ok && fr.now().Before(cached.expires) {
+		fr.mu.Unlock()
+		return cached.fields
+	}
+	p, running := fr.pending[value]
+	if !running {
+		p = &pendingLookup{done: make(chan struct{})}
+		fr.pending[value] = p
+		go fr.run(value, p)
+	}
+	fr.mu.Unlock()
+
+	timer := time.NewTimer(fr.timeout)
+	defer timer.Stop()
+	select {
+	case <-p.done:
+		return p.fields
+	case <-timer.C:
+		return nil
+	}
+}
+
+// run looks up value and caches the result. The lookup outlives the log call which started it, so its context is only
+// canceled once the result would have expired from the cache anyway.
+func (fr *fieldResolver) run(value string, p *pendingLookup) {
+	ctx, cancel := context.WithTimeout(context.Background(), fr.ttl)
+	defer cancel()
+	fields, err := fr.call(ctx, value)
+	if err != nil || len(fields)%2 != 0 {
+		fields = nil
+	}
+	p.fields = fields
+	fr.store(value, fields)
+	close(p.done)
+}
+
+// call calls the resolver. A panic of the resolver is turned into an error, which is passed to the error handler, see
+// SetErrorHandler, since the lookup runs in its own goroutine where nothing else would recover it.
+func (fr *fieldResolver) call(ctx context.Context, value string) (fields []interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			fields, err = nil, fmt.Errorf(resolverPanicFailMsg, value, r)
+			if handler := loadSnapshot().errorHandler; handler != nil {
+				handler(err)
+			}
+		}
+	}()
+	return fr.resolve(ctx, value)
+}
+
+// store caches the fields of value and ends its pending lookup. If the cache is full, expired entries are removed, and
+// the whole cache if that does not free any space.
+func (fr *fieldResolver) store(value string, fields []interface{}) {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+
+	delete(fr.pending, value)
+
+	now := fr.now()
+	if len(fr.cache) >= fr.cacheSize {
+		for k, v := range fr.cache {
+			if !now.Before(v.expires) {
+				delete(fr.cache, k)
+			}
+		}
+		if len(fr.cache) >= fr.cacheSize {
+			fr.cache = map[string]resolvedFields{}
+		}
+	}
+	fr.cache[value] = resolvedFields{fields: fields, expires: now.Add(fr.ttl)}
+}
+
+// enrich appends the fields resolved for the values of fields which have a resolver. Resolved keys which are already
+// part of the message are skipped.
+func enrich(resolvers map[string]*fieldResolver, fields []interface{}) []interface{} {
+	if len(resolvers) == 0 {
+		return fields
+	}
+
+	var added []interface{}
+	for i := 0; i < len(fields)-1; i += 2 {
+		fr, ok := resolvers[argToString(fields[i])]
+		if !ok {
+			continue
+		}
+		added = append(added, fr.lookup(argToString(fields[i+1]))...)
+	}
+	if len(added) == 0 {
+		return fields
+	}
+
+	keys := make(map[string]bool, len(fields)/2)
+	for i := 0; i < len(fields)-1; i += 2 {
+		keys[argToString(fields[i])] = true
+	}
+	for i := 0; i < len(added)-1; i += 2 {
+		key := argToString(added[i])
+		if keys[key] {
+			continue
+		}
+		keys[key] = true
+		fields = append(fields, key, added[i+1])
+	}
+	return fields
+}
+
+// SetResolver registers a resolver which adds fields to structured log messages containing the field key, e.g. the
+// pod name and namespace for a "containerID" field, for daemons which only receive runtime IDs. Lookups are cached and
+// log calls wait at most for the configured timeout; options may be nil to use the defaults. Passing a nil resolver
+// removes the resolver of key.
+func SetResolver(key string, r Resolver, options *ResolverOptions) {
+	mu.Lock()
+	defer unlockAndPublish()
+
+	// Log calls use the map after releasing mu, so it is never modified in place.
+	updated := make(map[string]*fieldResolver, len(resolvers)+1)
+	for k, v := range resolvers {
+		updated[k] = v
+	}
+	if r == nil {
+		delete(updated, key)
+	} else {
+		updated[key] = newFieldResolver(r, options)
+	}
+	resolvers = updated
+}
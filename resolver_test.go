@@ -0,0 +1,153 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Resolvers", func() {
+	var sink *captureSink
+	var calls int32
+
+	podResolver := func(ctx context.Context, containerID string) ([]interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		if containerID == "unknown" {
+			return nil, errors.New("not found")
+		}
+		return []interface{}{"pod", "pod-" + containerID, "namespace", "default"}, nil
+	}
+
+	BeforeEach(func() {
+		initLogger()
+		sink = &captureSink{}
+		AddSink(sink)
+		SetLogStderr(false)
+		atomic.StoreInt32(&calls, 0)
+	})
+
+	It("adds the resolved fields and caches them", func() {
+		SetResolver("containerID", podResolver, nil)
+		InfoStructured(infoMsg, "containerID", "abc")
+		InfoStructured(infoMsg, "containerID", "abc")
+
+		Expect(sink.entries).To(HaveLen(2))
+		for _, entry := range sink.entries {
+			Expect(entry.String()).To(HaveSuffix(fmt.Sprintf(
+				`msg=%q containerID="abc" pod="pod-abc" namespace="default"`, infoMsg)))
+		}
+		Expect(atomic.LoadInt32(&calls)).To(Equal(int32(1)))
+	})
+
+	It("does not overwrite fields of the message", func() {
+		SetResolver("containerID", podResolver, nil)
+		InfoStructured(infoMsg, "containerID", "abc", "pod", "explicit")
+		Expect(sink.entries[0].String()).To(HaveSuffix(`containerID="abc" pod="explicit" namespace="default"`))
+	})
+
+	It("logs the message unchanged if the lookup fails", func() {
+		SetResolver("containerID", podResolver, nil)
+		InfoStructured(infoMsg, "containerID", "unknown")
+		InfoStructured(infoMsg, "containerID", "unknown")
+		Expect(sink.entries[1].String()).To(HaveSuffix(`containerID="unknown"`))
+		Expect(atomic.LoadInt32(&calls)).To(Equal(int32(1)))
+	})
+
+	It("does not wait longer than the timeout and caches late results", func() {
+		release := make(chan struct{})
+		SetResolver("containerID", func(ctx context.Context, containerID string) ([]interface{}, error) {
+			<-release
+			return []interface{}{"pod", "slow"}, nil
+		}, &ResolverOptions{Timeout: 10 * time.Millisecond})
+
+		start := time.Now()
+		InfoStructured(infoMsg, "containerID", "abc")
+		Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+		Expect(sink.entries[0].String()).To(HaveSuffix(`containerID="abc"`))
+
+		close(release)
+		Eventually(func() string {
+			InfoStructured(infoMsg, "containerID", "abc")
+			return sink.entries[len(sink.entries)-1].String()
+		}).Should(HaveSuffix(`containerID="abc" pod="slow"`))
+	})
+
+	It("does not cancel lookups which take longer than the timeout", func() {
+		release := make(chan struct{})
+		SetResolver("containerID", func(ctx context.Context, containerID string) ([]interface{}, error) {
+			select {
+			case <-release:
+				return []interface{}{"pod", "slow"}, nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}, &ResolverOptions{Timeout: 10 * time.Millisecond})
+
+		InfoStructured(infoMsg, "containerID", "abc")
+		Expect(sink.entries[0].String()).To(HaveSuffix(`containerID="abc"`))
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		Eventually(func() string {
+			InfoStructured(infoMsg, "containerID", "abc")
+			return sink.entries[len(sink.entries)-1].String()
+		}).Should(HaveSuffix(`containerID="abc" pod="slow"`))
+	})
+
+	It("runs one lookup per value at a time", func() {
+		release := make(chan struct{})
+		SetResolver("containerID", func(ctx context.Context, containerID string) ([]interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			<-release
+			return []interface{}{"pod", "slow"}, nil
+		}, &ResolverOptions{Timeout: time.Millisecond})
+
+		for i := 0; i < 10; i++ {
+			InfoStructured(infoMsg, "containerID", "abc")
+		}
+		close(release)
+		Eventually(func() string {
+			InfoStructured(infoMsg, "containerID", "abc")
+			return sink.entries[len(sink.entries)-1].String()
+		}).Should(HaveSuffix(`containerID="abc" pod="slow"`))
+		Expect(atomic.LoadInt32(&calls)).To(Equal(int32(1)))
+	})
+
+	It("recovers a panicking resolver and reports it to the error handler", func() {
+		errs := make(chan error, 1)
+		SetErrorHandler(func(err error) { errs <- err })
+		SetResolver("containerID", func(ctx context.Context, containerID string) ([]interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			panic("boom")
+		}, nil)
+
+		InfoStructured(infoMsg, "containerID", "abc")
+		InfoStructured(infoMsg, "containerID", "abc")
+		Expect(sink.entries[1].String()).To(HaveSuffix(`containerID="abc"`))
+		Eventually(errs).Should(Receive(MatchError(`cni-log: resolver panicked looking up "abc": boom`)))
+		Expect(atomic.LoadInt32(&calls)).To(Equal(int32(1)))
+	})
+
+	It("expires cached results", func() {
+		SetResolver("containerID", podResolver, nil)
+		now := time.Now()
+		resolvers["containerID"].now = func() time.Time { return now }
+
+		InfoStructured(infoMsg, "containerID", "abc")
+		now = now.Add(2 * defaultResolverCacheTTL)
+		InfoStructured(infoMsg, "containerID", "abc")
+		Expect(atomic.LoadInt32(&calls)).To(Equal(int32(2)))
+	})
+
+	It("removes resolvers", func() {
+		SetResolver("containerID", podResolver, nil)
+		SetResolver("containerID", nil, nil)
+		InfoStructured(infoMsg, "containerID", "abc")
+		Expect(sink.entries[0].String()).To(HaveSuffix(`containerID="abc"`))
+		Expect(atomic.LoadInt32(&calls)).To(BeZero())
+	})
+})
@@ -0,0 +1,277 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	defaultRingEntries   = 1024
+	defaultRingEntrySize = 1024
+
+	// The ring file starts with a header holding ringMagic, the size of the slots and their number, followed by the
+	// slots. A slot holds the sequence number of its entry, the length of the entry and the entry itself.
+	ringMagic        = "CNILOGR1"
+	ringHeaderSize   = 64
+	ringSlotOverhead = 12
+
+	ringFormatFailMsg = "cni-log: %s is not a ring file"
+)
+
+// errRingUnsupported is returned by SetRingFile on platforms without memory mapped files.
+var errRingUnsupported = errors.New("cni-log: ring files are not supported on this platform")
+
+// RingOptions configures the ring file, see SetRingFile.
+type RingOptions struct {
+	// Entries is the number of entries the ring file holds, 1024 by default.
+	Entries int
+	// EntrySize is the maximum size of an entry in bytes, 1024 by default. Longer entries are truncated.
+	EntrySize int
+}
+
+// ringBuffer writes the lines it receives to the slots of a memory mapped file. Each Write is one entry.
+type ringBuffer struct {
+	mu       sync.Mutex
+	file     *os.File
+	data     []byte
+	slotSize int
+	slots    int
+	seq      uint64
+}
+
+// SetRingFile keeps the last entries in a memory mapped file at filename, which the kernel writes back even if the
+// process is killed, e.g. by the OOM killer, so that the last moments of a crashed daemon can be recovered with
+// ReadRingFile or cni-logview. The file has a fixed size of options.Entries slots of options.EntrySize bytes and
+// receives all messages which pass the level set with SetLogLevel, rendered like those of the log file. An existing
+// ring file of the same geometry is continued, so its entries survive a restart until they are overwritten. nil
+// options use the default values. An empty filename disables the ring file. Ring files are only supported on Unix.
+func SetRingFile(filename string, options *RingOptions) error {
+	mu.Lock()
+	defer unlockAndPublish()
+
+	if filename == "" {
+		return closeRingFile()
+	}
+	fp, err := resolvePath(filename)
+	if err != nil {
+		return err
+	}
+	o := RingOptions{Entries: defaultRingEntries, EntrySize: defaultRingEntrySize}
+	if options != nil && options.Entries > 0 {
+		o.Entries = options.Entries
+	}
+	if options != nil && options.EntrySize > 0 {
+		o.EntrySize = options.EntrySize
+	}
+
+	ring, err := openRing(fp, o.EntrySize+ringSlotOverhead, o.Entries)
+	if err != nil {
+		return err
+	}
+	if err := closeRingFile(); err != nil {
+		_ = ring.close()
+		return err
+	}
+	ringFile = ring
+	return nil
+}
+
+// closeRingFile closes and disables the ring file. The caller must hold mu.
+func closeRingFile() error {
+	if ringFile == nil {
+		return nil
+	}
+	err := ringFile.close()
+	ringFile = nil
+	return err
+}
+
+// ringSink returns the sink of the ring file, nil if it is disabled. verbose is the most verbose level of all outputs.
+// The caller must hold mu.
+func ringSink(verbose Level) Sink {
+	if ringFile == nil {
+		return nil
+	}
+	return withLevel(&writerSink{out: ringFile, formatter: fileFormatter, fields: fileFields, ascii: asciiOnly,
+		maxSize: maxEntrySize}, InvalidLevel, verbose)
+}
+
+// openRing opens or creates the ring file at path and maps it.
+func openRing(path string, slotSize, slots int) (*ringBuffer, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	size := ringHeaderSize + slotSize*slots
+	info, err := f.Stat()
+	if err == nil && info.Size() != int64(size) {
+		// A file of another geometry is started over.
+		if err = f.Truncate(0); err == nil {
+			err = f.Truncate(int64(size))
+		}
+	}
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	data, err := mapFile(f, size)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	r := &ringBuffer{file: f, data: data, slotSize: slotSize, slots: slots}
+	if !r.validHeader() {
+		for i := range data {
+			data[i] = 0
+		}
+		copy(data, ringMagic)
+		binary.LittleEndian.PutUint32(data[8:], uint32(slotSize))
+		binary.LittleEndian.PutUint32(data[12:], uint32(slots))
+	}
+	for _, entry := range ringEntries(data) {
+		if entry.seq > r.seq {
+			r.seq = entry.seq
+		}
+	}
+	return r, nil
+}
+
+// validHeader returns true if the mapped file has the header of a ring file of the same geometry.
+func (r *ringBuffer) validHeader() bool {
+	return string(r.data[:len(ringMagic)]) == ringMagic &&
+		binary.LittleEndian.Uint32(r.data[8:]) == uint32(r.slotSize) &&
+		binary.LittleEndian.Uint32(r.data[12:]) == uint32(r.slots)
+}
+
+// Write implements io.Writer. p is truncated to the size of a slot. The slot is invalidated before its entry is
+// replaced, so that an entry which is interrupted by a crash is not mistaken for a complete one.
+func (r *ringBuffer) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.data == nil {
+		return 0, os.ErrClosed
+	}
+
+	line := bytes.TrimSuffix(p, []byte("\n"))
+	if size := r.slotSize - ringSlotOverhead; len(line) > size {
+		line = line[:size]
+		if start := lastRuneStart(line); !utf8.FullRune(line[start:]) {
+			line = line[:start]
+		}
+	}
+
+	r.seq++
+	offset := ringHeaderSize + int(r.seq%uint64(r.slots))*r.slotSize
+	slot := r.data[offset : offset+r.slotSize]
+	binary.LittleEndian.PutUint64(slot, 0)
+	n := copy(slot[ringSlotOverhead:], line)
+	binary.LittleEndian.PutUint32(slot[8:], uint32(n))
+	binary.LittleEndian.PutUint64(slot, r.seq)
+	return len(p), nil
+}
+
+// lastRuneStart returns the index of the start of the last, possibly incomplete, rune of p.
+func lastRuneStart(p []byte) int {
+	i := len(p) - 1
+	for i > 0 && !utf8.RuneStart(p[i]) {
+		i--
+	}
+	return i
+}
+
+// Sync commits the ring file to stable storage.
+func (r *ringBuffer) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	return r.file.Sync()
+}
+
+// close unmaps and closes the ring file.
+func (r *ringBuffer) close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.data == nil {
+		return nil
+	}
+	err := unmapFile(r.data)
+	if closeErr := r.file.Close(); err == nil {
+		err = closeErr
+	}
+	r.data, r.file = nil, nil
+	return err
+}
+
+// ringEntry is an entry read from a ring file.
+type ringEntry struct {
+	seq  uint64
+	line string
+}
+
+// ringEntries returns the complete entries of the ring file data, oldest first.
+func ringEntries(data []byte) []ringEntry {
+	if len(data) < ringHeaderSize || string(data[:len(ringMagic)]) != ringMagic {
+		return nil
+	}
+	slotSize := int(binary.LittleEndian.Uint32(data[8:]))
+	slots := int(binary.LittleEndian.Uint32(data[12:]))
+	if slotSize <= ringSlotOverhead || len(data) < ringHeaderSize+slotSize*slots {
+		return nil
+	}
+
+	var entries []ringEntry
+	for i := 0; i < slots; i++ {
+		slot := data[ringHeaderSize+i*slotSize : ringHeaderSize+(i+1)*slotSize]
+		seq := binary.LittleEndian.Uint64(slot)
+		n := int(binary.LittleEndian.Uint32(slot[8:]))
+		if seq == 0 || n > slotSize-ringSlotOverhead {
+			continue
+		}
+		entries = append(entries, ringEntry{seq: seq, line: string(slot[ringSlotOverhead : ringSlotOverhead+n])})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].seq < entries[j].seq
+	})
+	return entries
+}
+
+// ReadRingFile returns the lines of the entries held by the ring file at filename, oldest first. It works on all
+// platforms and while the file is written to, e.g. to read the ring file of a crashed daemon.
+func ReadRingFile(filename string) ([]string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(data, []byte(ringMagic)) {
+		return nil, fmt.Errorf(ringFormatFailMsg, filename)
+	}
+	entries := ringEntries(data)
+	lines := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		lines = append(lines, entry.line)
+	}
+	return lines, nil
+}
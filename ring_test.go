@@ -0,0 +1,91 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package logging
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Ring file", func() {
+	var path string
+
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+		dir, err := os.MkdirTemp("", "cni-log-ring")
+		Expect(err).NotTo(HaveOccurred())
+		path = filepath.Join(dir, "log.ring")
+		DeferCleanup(os.RemoveAll, dir)
+		DeferCleanup(initLogger)
+	})
+
+	It("keeps the last entries", func() {
+		Expect(SetRingFile(path, &RingOptions{Entries: 3})).To(Succeed())
+		for _, msg := range []string{"a", "b", "c", "d"} {
+			Infof(msg)
+		}
+		DebugStructured(debugMsg)
+
+		lines, err := ReadRingFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lines).To(HaveLen(3))
+		Expect(lines[0]).To(HaveSuffix("[info] b"))
+		Expect(lines[2]).To(HaveSuffix("[info] d"))
+
+		info, err := os.Stat(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Size()).To(Equal(int64(ringHeaderSize + 3*(defaultRingEntrySize+ringSlotOverhead))))
+	})
+
+	It("truncates long entries at a character boundary", func() {
+		Expect(SetRingFile(path, &RingOptions{Entries: 1, EntrySize: 16})).To(Succeed())
+		_, err := ringFile.Write([]byte("0123456789abcdä€\n"))
+		Expect(err).NotTo(HaveOccurred())
+
+		lines, err := ReadRingFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lines).To(Equal([]string{"0123456789abcdä"}))
+	})
+
+	It("continues an existing ring file of the same geometry", func() {
+		Expect(SetRingFile(path, &RingOptions{Entries: 3})).To(Succeed())
+		Infof("before")
+		Expect(SetRingFile("", nil)).To(Succeed())
+
+		Expect(SetRingFile(path, &RingOptions{Entries: 3})).To(Succeed())
+		Infof("after")
+		lines, err := ReadRingFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lines).To(HaveLen(2))
+		Expect(lines[0]).To(HaveSuffix("before"))
+		Expect(lines[1]).To(HaveSuffix("after"))
+
+		Expect(SetRingFile(path, &RingOptions{Entries: 4})).To(Succeed())
+		Expect(ReadRingFile(path)).To(BeEmpty())
+	})
+
+	It("skips entries which were interrupted", func() {
+		Expect(SetRingFile(path, &RingOptions{Entries: 2})).To(Succeed())
+		Infof("complete")
+		Infof("interrupted")
+		slot := ringFile.data[ringHeaderSize:]
+		binary.LittleEndian.PutUint64(slot, 0)
+
+		lines, err := ReadRingFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lines).To(HaveLen(1))
+		Expect(lines[0]).To(HaveSuffix("complete"))
+	})
+
+	It("rejects files which are not ring files", func() {
+		Expect(os.WriteFile(path, []byte(strings.Repeat("x", 100)), 0644)).To(Succeed())
+		_, err := ReadRingFile(path)
+		Expect(err).To(MatchError(ContainSubstring("is not a ring file")))
+	})
+})
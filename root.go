@@ -0,0 +1,101 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// maxSymlinks bounds the number of symbolic links followed when resolving a path, like MAXSYMLINKS on Linux.
+	maxSymlinks = 40
+
+	rootDirFailMsg     = "cni-log: root directory '%s' is not an absolute path of a directory"
+	symlinkLoopFailMsg = "cni-log: too many levels of symbolic links in '%s'"
+)
+
+// SetRootDir makes the logger resolve the paths of the log file, the error log file and the directory of daily log
+// files relative to root, e.g. "/host" for a CNI binary which runs in a container with the host file system mounted
+// at /host. Symbolic links in the directories of a path are resolved as if root were "/", so an absolute link like
+// /host/var/log -> /var/lib/log stays inside root, and ".." never leaves it. Like without a root directory, the log
+// file itself must not be a symbolic link unless the symlink policy allows it, see SetSymlinkPolicy. Relative paths
+// are relative to root. The emergency log file is not resolved relative to root, since it has to be usable if root is
+// not. The root directory applies to the paths set after the call. An empty root disables the resolution, which is the
+// default.
+func SetRootDir(root string) error {
+	if root != "" {
+		if info, err := os.Stat(root); err != nil || !info.IsDir() || !filepath.IsAbs(root) {
+			return fmt.Errorf(rootDirFailMsg, root)
+		}
+		root = filepath.Clean(root)
+	}
+
+	mu.Lock()
+	defer unlockAndPublish()
+	rootDir = root
+	return nil
+}
+
+// joinRoot resolves path relative to root. Symbolic links in the directories of path are followed within root, a
+// symbolic link as the last element of path is only followed if followLast is set and an error otherwise. Elements
+// which do not exist yet are taken as they are.
+func joinRoot(root, path string, followLast bool) (string, error) {
+	resolved := string(filepath.Separator)
+	remaining := path
+	links := 0
+	for remaining != "" {
+		var elem string
+		if i := strings.IndexRune(remaining, filepath.Separator); i >= 0 {
+			elem, remaining = remaining[:i], remaining[i+1:]
+		} else {
+			elem, remaining = remaining, ""
+		}
+
+		switch elem {
+		case "", ".":
+			continue
+		case "..":
+			resolved = filepath.Dir(resolved)
+			continue
+		}
+
+		next := filepath.Join(resolved, elem)
+		info, err := os.Lstat(filepath.Join(root, next))
+		if err != nil || info.Mode()&os.ModeSymlink == 0 {
+			resolved = next
+			continue
+		}
+		if remaining == "" && !followLast {
+			return "", configErrorf(ErrSymlinkRejected, symlinkEvalFailMsg, path)
+		}
+
+		links++
+		if links > maxSymlinks {
+			return "", configErrorf(ErrSymlinkRejected, symlinkLoopFailMsg, path)
+		}
+		target, err := os.Readlink(filepath.Join(root, next))
+		if err != nil {
+			return "", err
+		}
+		if filepath.IsAbs(target) {
+			resolved = string(filepath.Separator)
+		}
+		remaining = target + string(filepath.Separator) + remaining
+	}
+	return filepath.Join(root, resolved), nil
+}
@@ -0,0 +1,63 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Root directory", func() {
+	var root string
+
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+		var err error
+		root, err = os.MkdirTemp("", "cni-log-root")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(root, "var", "lib", "log"), 0755)).To(Succeed())
+		Expect(SetRootDir(root)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(root)).To(Succeed())
+	})
+
+	It("resolves the log file relative to the root directory", func() {
+		Expect(ApplyConfig(&Config{LogFile: "/var/log/plugin.log"})).To(Succeed())
+		Infof(infoMsg)
+		Expect(logFileContains(filepath.Join(root, "var", "log", "plugin.log"), infoMsg)).To(BeTrue())
+	})
+
+	It("follows absolute symbolic links within the root directory", func() {
+		Expect(os.Symlink("/var/lib/log", filepath.Join(root, "logs"))).To(Succeed())
+		SetLogFile("/logs/plugin.log")
+		Infof(infoMsg)
+		Expect(logFileContains(filepath.Join(root, "var", "lib", "log", "plugin.log"), infoMsg)).To(BeTrue())
+	})
+
+	It("does not leave the root directory", func() {
+		Expect(joinRoot(root, "/../../plugin.log", false)).To(Equal(filepath.Join(root, "plugin.log")))
+		Expect(os.Symlink("../../../..", filepath.Join(root, "var", "lib", "up"))).To(Succeed())
+		Expect(joinRoot(root, "/var/lib/up/etc/plugin.log", false)).To(Equal(filepath.Join(root, "etc", "plugin.log")))
+	})
+
+	It("rejects a symbolic link as log file and symbolic link loops", func() {
+		Expect(os.Symlink("/var/lib/log/plugin.log", filepath.Join(root, "plugin.log"))).To(Succeed())
+		_, err := joinRoot(root, "/plugin.log", false)
+		Expect(err).To(MatchError(ContainSubstring("symbolic links")))
+
+		Expect(os.Symlink("/loop", filepath.Join(root, "loop"))).To(Succeed())
+		_, err = joinRoot(root, "/loop/plugin.log", false)
+		Expect(err).To(MatchError(ContainSubstring("too many levels")))
+	})
+
+	It("rejects invalid root directories", func() {
+		Expect(SetRootDir("relative")).To(MatchError(ContainSubstring("not an absolute path")))
+		Expect(SetRootDir(filepath.Join(root, "missing"))).To(HaveOccurred())
+		Expect(SetRootDir("")).To(Succeed())
+		Expect(rootDir).To(BeEmpty())
+	})
+})
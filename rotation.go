@@ -0,0 +1,195 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"os"
+	"sync"
+
+	lumberjack "gopkg.in/natefinch/lumberjack.v2"
+)
+
+// RotatingWriter writes a log file and rotates it. It is the backend of the log file, the error log file and the
+// secondary log file, see SetRotatingWriter. The default backend is lumberjack. The writers are safe for concurrent use.
+type RotatingWriter interface {
+	// Write appends p to the log file, opening the file first if needed. Write rotates the log file if the write would
+	// make it exceed MaxSize.
+	Write(p []byte) (int, error)
+	// Close closes the log file. The next write opens it again and appends to it.
+	Close() error
+	// Rotate closes the log file, moves it aside and opens a new log file.
+	Rotate() error
+	// Configure sets the log file and the rotation options. It is called before the first write and whenever one of
+	// them changes. The current log file is closed if the filename changes. Configure may be called while background
+	// work of the backend, e.g. removing old log files, is still running, so it must switch to a new configuration
+	// rather than change state that background work reads without locking.
+	Configure(filename string, options RotationOptions)
+}
+
+// RotationOptions are the rotation options passed to a RotatingWriter: the LogOptions with the defaults filled in.
+type RotationOptions struct {
+	// MaxSize is the size in megabytes at which the log file is rotated.
+	MaxSize int
+	// MaxAge is the number of days rotated log files are kept, 0 keeps them regardless of their age.
+	MaxAge int
+	// MaxBackups is the number of rotated log files which are kept, 0 keeps all of them.
+	MaxBackups int
+	// Compress compresses the rotated log files with gzip.
+	Compress bool
+	// LocalTime stamps the rotated log files with the local time instead of UTC.
+	LocalTime bool
+}
+
+// SetRotatingWriter replaces the backend of the log file, the error log file and the secondary log file with the
+// RotatingWriters returned by newWriter, one per file. The open log files are closed, the new backends reopen them on
+// the next write. Passing nil restores the default backend, lumberjack. NewNonRotatingWriter disables rotation.
+func SetRotatingWriter(newWriter func() RotatingWriter) {
+	mu.Lock()
+	defer unlockAndPublish()
+
+	rotatingWriterFactory = newWriter
+	logFileWriter.setBackend(newRotatingWriter())
+	for _, w := range append([]*fileWriter{errorLogWriter, secondaryLogWriter}, routeWriters()...) {
+		if w != nil {
+			w.setBackend(newRotatingWriter())
+		}
+	}
+}
+
+// newRotatingWriter returns a new backend for a log file, see SetRotatingWriter. The caller must hold mu.
+func newRotatingWriter() RotatingWriter {
+	if rotatingWriterFactory != nil {
+		return rotatingWriterFactory()
+	}
+	return newLumberjackWriter()
+}
+
+// lumberjackWriter is the default RotatingWriter. Lumberjack reads its options without locking when it removes old log
+// files in the background, so a lumberjack.Logger is never changed once created: Configure replaces it instead.
+type lumberjackWriter struct {
+	mu     sync.Mutex
+	logger *lumberjack.Logger
+}
+
+// newLumberjackWriter returns a lumberjackWriter without a log file.
+func newLumberjackWriter() *lumberjackWriter {
+	return &lumberjackWriter{logger: &lumberjack.Logger{}}
+}
+
+// Write implements the RotatingWriter interface.
+func (w *lumberjackWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.logger.Write(p)
+}
+
+// Close implements the RotatingWriter interface.
+func (w *lumberjackWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.logger.Close()
+}
+
+// Rotate implements the RotatingWriter interface.
+func (w *lumberjackWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.logger.Rotate()
+}
+
+// Configure implements the RotatingWriter interface. If the log file or the options change, the current logger is
+// closed and replaced by a new one.
+func (w *lumberjackWriter) Configure(filename string, options RotationOptions) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	current := RotationOptions{
+		MaxSize:    w.logger.MaxSize,
+		MaxAge:     w.logger.MaxAge,
+		MaxBackups: w.logger.MaxBackups,
+		Compress:   w.logger.Compress,
+		LocalTime:  w.logger.LocalTime,
+	}
+	if w.logger.Filename == filename && current == options {
+		return
+	}
+	_ = w.logger.Close()
+	w.logger = &lumberjack.Logger{
+		Filename:   filename,
+		MaxSize:    options.MaxSize,
+		MaxAge:     options.MaxAge,
+		MaxBackups: options.MaxBackups,
+		Compress:   options.Compress,
+		LocalTime:  options.LocalTime,
+	}
+}
+
+// nonRotatingWriter is a RotatingWriter which appends to the log file without ever rotating it.
+type nonRotatingWriter struct {
+	mu       sync.Mutex
+	filename string
+	file     *os.File
+}
+
+// NewNonRotatingWriter returns a RotatingWriter which appends to the log file without ever rotating it, e.g. if the
+// log files are rotated by logrotate, see Reopen. Rotate does nothing and the rotation options are ignored.
+func NewNonRotatingWriter() RotatingWriter {
+	return &nonRotatingWriter{}
+}
+
+// Write implements the RotatingWriter interface.
+func (w *nonRotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		f, err := os.OpenFile(w.filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return 0, err
+		}
+		w.file = f
+	}
+	return w.file.Write(p)
+}
+
+// Close implements the RotatingWriter interface.
+func (w *nonRotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// Rotate implements the RotatingWriter interface.
+func (w *nonRotatingWriter) Rotate() error {
+	return nil
+}
+
+// Configure implements the RotatingWriter interface.
+func (w *nonRotatingWriter) Configure(filename string, _ RotationOptions) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.filename != filename && w.file != nil {
+		_ = w.file.Close()
+		w.file = nil
+	}
+	w.filename = filename
+}
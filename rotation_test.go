@@ -0,0 +1,148 @@
+package logging
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+	lumberjack "gopkg.in/natefinch/lumberjack.v2"
+)
+
+// currentLumberjack returns the lumberjack.Logger the log file is currently written with.
+func currentLumberjack() *lumberjack.Logger {
+	w := logFileWriter.backend.(*lumberjackWriter)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.logger
+}
+
+// recordingWriter is a RotatingWriter which records what it is asked to do.
+type recordingWriter struct {
+	mu        sync.Mutex
+	out       bytes.Buffer
+	filename  string
+	options   RotationOptions
+	rotations int
+	closed    int
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.out.Write(p)
+}
+
+func (w *recordingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed++
+	return nil
+}
+
+func (w *recordingWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.rotations++
+	return nil
+}
+
+func (w *recordingWriter) Configure(filename string, options RotationOptions) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.filename, w.options = filename, options
+}
+
+var _ = Describe("Rotating writers", func() {
+	var logDir string
+	var logFile string
+
+	BeforeEach(func() {
+		var err error
+		logDir, err = os.MkdirTemp("", "cni-log-backend")
+		Expect(err).NotTo(HaveOccurred())
+		initLogger()
+		SetLogStderr(false)
+		logFile = path.Join(logDir, "backend.log")
+		SetLogFile(logFile)
+	})
+
+	AfterEach(func() {
+		initLogger()
+		Expect(os.RemoveAll(logDir)).To(Succeed())
+	})
+
+	It("writes the log files through the configured backend", func() {
+		var backends []*recordingWriter
+		SetRotatingWriter(func() RotatingWriter {
+			w := &recordingWriter{}
+			backends = append(backends, w)
+			return w
+		})
+		Expect(SetErrorLogFile(path.Join(logDir, "error.log"), nil)).To(Succeed())
+		SetLogOptions(&LogOptions{MaxSize: getPrimitivePointer(7), Compress: getPrimitivePointer(false)})
+		Infof(infoMsg)
+		_ = Errorf(errorMsg)
+		Expect(Rotate()).To(Succeed())
+
+		Expect(backends).To(HaveLen(2))
+		Expect(backends[0].filename).To(Equal(logFile))
+		Expect(backends[0].options).To(Equal(RotationOptions{MaxSize: 7, MaxAge: 5, MaxBackups: 5}))
+		Expect(backends[0].out.String()).To(ContainSubstring(infoMsg))
+		Expect(backends[0].rotations).To(Equal(1))
+		Expect(backends[1].filename).To(Equal(path.Join(logDir, "error.log")))
+		Expect(backends[1].out.String()).To(ContainSubstring(errorMsg))
+		Expect(backends[1].out.String()).NotTo(ContainSubstring(infoMsg))
+		Expect(backends[1].rotations).To(Equal(1))
+	})
+
+	It("closes the log file of the replaced backend", func() {
+		first := &recordingWriter{}
+		SetRotatingWriter(func() RotatingWriter { return first })
+		Infof(infoMsg)
+		SetRotatingWriter(nil)
+		Infof(warningMsg)
+
+		Expect(first.closed).To(BeNumerically(">", 0))
+		Expect(logFileWriter.backend).To(BeAssignableToTypeOf(&lumberjackWriter{}))
+		Expect(logFileContains(logFile, warningMsg)).To(BeTrue())
+		Expect(logFileContains(logFile, infoMsg)).To(BeFalse())
+	})
+
+	It("replaces the lumberjack logger instead of changing a used one", func() {
+		Infof(infoMsg)
+		used := currentLumberjack()
+		SetLogOptions(&LogOptions{MaxSize: getPrimitivePointer(1)})
+		Expect(currentLumberjack()).NotTo(BeIdenticalTo(used))
+		Expect(used.MaxSize).To(Equal(defaultMaxSize))
+		Expect(currentLumberjack().MaxSize).To(Equal(1))
+
+		reconfigured := currentLumberjack()
+		SetLogOptions(&LogOptions{MaxSize: getPrimitivePointer(1)})
+		Expect(currentLumberjack()).To(BeIdenticalTo(reconfigured))
+
+		Infof(warningMsg)
+		Expect(logFileContains(logFile, infoMsg)).To(BeTrue())
+		Expect(logFileContains(logFile, warningMsg)).To(BeTrue())
+	})
+
+	It("does not rotate with the non-rotating writer", func() {
+		SetRotatingWriter(NewNonRotatingWriter)
+		SetLogOptions(&LogOptions{MaxSize: getPrimitivePointer(1)})
+		value := string(bytes.Repeat([]byte("x"), 64*1024))
+		for i := 0; i < 20; i++ {
+			InfoStructured(infoMsg, "value", value)
+		}
+		Expect(Rotate()).To(Succeed())
+
+		entries, err := os.ReadDir(logDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+		info, err := os.Stat(logFile)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Size()).To(BeNumerically(">", megabyte))
+		Expect(Stats().LastRotation).To(BeZero())
+	})
+})
@@ -0,0 +1,228 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import "fmt"
+
+const invalidRouteFailMsg = "cni-log: invalid route %d: field and logFile are required"
+
+// Route sends the messages with a field value to a log file of their own, e.g. the messages with network=storage-net
+// to /var/log/cni/storage.log, see SetRoutes.
+type Route struct {
+	// Field is the key of the field, e.g. "network".
+	Field string `json:"field"`
+	// Value is the value the field must have, compared with its rendered string.
+	Value string `json:"value"`
+	// LogFile is the path of the log file which receives the matching messages.
+	LogFile string `json:"logFile"`
+	// Exclusive keeps the matching messages out of the log file, so that a high-volume network does not flood it.
+	Exclusive bool `json:"exclusive,omitempty"`
+}
+
+// route is a Route together with the writer of its log file.
+type route struct {
+	Route
+	writer *fileWriter
+}
+
+// matches returns true if the entry has the field value of the route. The field is looked up in the fields of a
+// structured entry and in the fields appended to a printf style entry.
+func (r *route) matches(entry Entry) bool {
+	value, ok := fieldValue(r.Field, entry.Fields)
+	if !ok {
+		value, ok = fieldValue(r.Field, entry.extra)
+	}
+	return ok && value == r.Value
+}
+
+// SetRoutes replaces the routing rules: every message with the field value of a route is written to the log file of
+// the route as well, e.g.
+//
+//	logging.SetRoutes([]logging.Route{{Field: "network", Value: "storage-net", LogFile: "/var/log/cni/storage.log",
+//		Exclusive: true}})
+//
+// A message matching several routes is written to each of their log files, once per file. Exclusive routes keep their
+// messages out of the log file; the other outputs still receive them. The route log files are rotated according to the
+// options of the log file, see SetLogOptions, and use its formatter, fields and level, see SetFileFields and
+// SetFileLogLevel. The routes can also be set through the "routes" setting of the configuration, see Config. nil
+// removes the routes. If a route is invalid or its log file is not writable, an error is returned and nothing is
+// changed.
+func SetRoutes(routes []Route) error {
+	mu.Lock()
+	defer unlockAndPublish()
+
+	filenames, err := resolveRoutes(routes)
+	if err != nil {
+		return err
+	}
+	configLayers[SourceAPI].routes = copyRoutes(routes)
+	return setRoutes(routes, filenames)
+}
+
+// copyRoutes returns a copy of routes, nil if routes is nil.
+func copyRoutes(routes []Route) *[]Route {
+	if routes == nil {
+		return nil
+	}
+	c := append([]Route{}, routes...)
+	return &c
+}
+
+// resolveRoutes checks routes and returns the resolved paths of their log files, see resolvePath.
+func resolveRoutes(routes []Route) ([]string, error) {
+	filenames := make([]string, len(routes))
+	for i, r := range routes {
+		if r.Field == "" || r.LogFile == "" {
+			return nil, fmt.Errorf(invalidRouteFailMsg, i)
+		}
+		fp, err := resolveLogFile(r.LogFile)
+		if err != nil {
+			return nil, err
+		}
+		filenames[i] = fp
+	}
+	return filenames, nil
+}
+
+// setRoutes replaces the routes by routes, whose log files have been resolved to filenames already. The caller must
+// hold mu.
+func setRoutes(routes []Route, filenames []string) error {
+	return replaceRoutes(newRoutes(routes, filenames))
+}
+
+// newRoutes returns the routes with their writers, whose log files have been resolved to filenames already. Routes to
+// the same log file share a writer, and the writers of the current routes are reused. The files are opened on the
+// first write. The caller must hold mu.
+func newRoutes(routes []Route, filenames []string) []*route {
+	writers := map[string]*fileWriter{}
+	for _, w := range routeWriters() {
+		writers[w.filename] = w
+	}
+
+	// Log calls use the slice after releasing mu, so it is never modified in place.
+	active := make([]*route, 0, len(routes))
+	for i, r := range routes {
+		w, ok := writers[filenames[i]]
+		if !ok {
+			w = newFileWriter(newRotatingWriter(), filenames[i])
+			w.setOptions(currentLogOptions())
+			writers[filenames[i]] = w
+		}
+		active = append(active, &route{Route: r, writer: w})
+	}
+	return active
+}
+
+// replaceRoutes makes active the routes and closes the writers which are not used anymore. The caller must hold mu.
+func replaceRoutes(active []*route) error {
+	unused := map[*fileWriter]bool{}
+	for _, w := range routeWriters() {
+		unused[w] = true
+	}
+	for _, r := range active {
+		delete(unused, r.writer)
+	}
+	logRoutes = active
+
+	var err error
+	for w := range unused {
+		if closeErr := w.close(); closeErr != nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// routeWriters returns the writers of the route log files, each once. The caller must hold mu.
+func routeWriters() []*fileWriter {
+	var writers []*fileWriter
+	seen := map[*fileWriter]bool{}
+	for _, r := range logRoutes {
+		if !seen[r.writer] {
+			seen[r.writer] = true
+			writers = append(writers, r.writer)
+		}
+	}
+	return writers
+}
+
+// routeSink writes the entries matching any of its routes to a route log file.
+type routeSink struct {
+	routes []*route
+	sink   Sink
+}
+
+// Write implements the Sink interface.
+func (s *routeSink) Write(entry Entry) error {
+	for _, r := range s.routes {
+		if r.matches(entry) {
+			return s.sink.Write(entry)
+		}
+	}
+	return nil
+}
+
+// exclusiveSink keeps the entries matching any of its exclusive routes out of the log file.
+type exclusiveSink struct {
+	routes []*route
+	sink   Sink
+}
+
+// Write implements the Sink interface.
+func (s *exclusiveSink) Write(entry Entry) error {
+	for _, r := range s.routes {
+		if r.matches(entry) {
+			return nil
+		}
+	}
+	return s.sink.Write(entry)
+}
+
+// Flush implements the Flush() error method of sinks which buffer messages.
+func (s *exclusiveSink) Flush() error {
+	return flushWriter(s.sink)
+}
+
+// withoutRouted returns sink, the sink of the log file, restricted to the entries which no exclusive route matches. The
+// caller must hold mu.
+func withoutRouted(sink Sink) Sink {
+	var exclusive []*route
+	for _, r := range logRoutes {
+		if r.Exclusive {
+			exclusive = append(exclusive, r)
+		}
+	}
+	if len(exclusive) == 0 {
+		return sink
+	}
+	return &exclusiveSink{routes: exclusive, sink: sink}
+}
+
+// routeSinks returns the sinks of the route log files, one per file. verbose is the most verbose level of all outputs.
+// The caller must hold mu.
+func routeSinks(verbose Level) []Sink {
+	var sinks []Sink
+	for _, w := range routeWriters() {
+		var routes []*route
+		for _, r := range logRoutes {
+			if r.writer == w {
+				routes = append(routes, r)
+			}
+		}
+		sinks = append(sinks, withLevel(&routeSink{routes: routes, sink: &writerSink{out: w, formatter: fileFormatter,
+			fields: fileFields, ascii: asciiOnly, maxSize: maxEntrySize, name: statsRoute}}, fileLogLevel, verbose))
+	}
+	return sinks
+}
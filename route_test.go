@@ -0,0 +1,102 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Routes", func() {
+	var dir, logFile, storageLog string
+
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+		var err error
+		dir, err = os.MkdirTemp("", "cni-log-route")
+		Expect(err).NotTo(HaveOccurred())
+		logFile = filepath.Join(dir, "plugin.log")
+		storageLog = filepath.Join(dir, "storage.log")
+		SetLogFile(logFile)
+	})
+
+	AfterEach(func() {
+		Expect(Close()).To(Succeed())
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("writes the messages with the field value to the route log file as well", func() {
+		Expect(SetRoutes([]Route{{Field: "network", Value: "storage-net", LogFile: storageLog}})).To(Succeed())
+		InfoStructured(infoMsg, "network", "storage-net")
+		InfoStructured(warningMsg, "network", "default")
+		Expect(logFileContains(storageLog, infoMsg)).To(BeTrue())
+		Expect(logFileContains(storageLog, warningMsg)).To(BeFalse())
+		Expect(logFileContains(logFile, infoMsg)).To(BeTrue())
+		Expect(logFileContains(logFile, warningMsg)).To(BeTrue())
+	})
+
+	It("keeps the messages of exclusive routes out of the log file", func() {
+		Expect(SetRoutes([]Route{{Field: "network", Value: "storage-net", LogFile: storageLog, Exclusive: true}})).
+			To(Succeed())
+		InfoStructured(infoMsg, "network", "storage-net")
+		Expect(logFileContains(storageLog, infoMsg)).To(BeTrue())
+		Expect(logFileContains(logFile, infoMsg)).To(BeFalse())
+	})
+
+	It("writes a message matching several routes to the same file once", func() {
+		Expect(SetRoutes([]Route{
+			{Field: "network", Value: "storage-net", LogFile: storageLog},
+			{Field: "pod", Value: "db-0", LogFile: storageLog},
+		})).To(Succeed())
+		InfoStructured(infoMsg, "network", "storage-net", "pod", "db-0")
+		contents, err := os.ReadFile(storageLog)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(ContainSubstring(infoMsg))
+		Expect(routeWriters()).To(HaveLen(1))
+		Expect(len(contents)).To(BeNumerically("<", 2*len(infoMsg)+100))
+	})
+
+	It("routes by the fields of a Logger", func() {
+		Expect(SetRoutes([]Route{{Field: "network", Value: "storage-net", LogFile: storageLog}})).To(Succeed())
+		With("network", "storage-net").InfoStructured(infoMsg)
+		Expect(logFileContains(storageLog, infoMsg)).To(BeTrue())
+	})
+
+	It("is configured declaratively and removed with nil", func() {
+		config, err := ParseConfig([]byte(`{"logging": {"routes": [
+			{"field": "network", "value": "storage-net", "logFile": "` + storageLog + `", "exclusive": true}
+		]}}`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ApplyConfig(config)).To(Succeed())
+		Expect(GetConfig().Routes).To(Equal(config.Routes))
+
+		Expect(ApplyConfig(nil)).To(Succeed())
+		Expect(logRoutes).To(BeEmpty())
+	})
+
+	It("rejects invalid routes and keeps the current ones", func() {
+		Expect(SetRoutes([]Route{{Field: "network", Value: "storage-net", LogFile: storageLog}})).To(Succeed())
+		Expect(SetRoutes([]Route{{Value: "storage-net", LogFile: storageLog}})).To(MatchError(ContainSubstring("route 0")))
+		Expect(SetRoutes([]Route{{Field: "network", LogFile: "/proc/storage.log"}})).NotTo(Succeed())
+		Expect(logRoutes).To(HaveLen(1))
+	})
+
+	It("changes nothing if a route of a configuration is invalid", func() {
+		secondaryLog := filepath.Join(dir, "plugin.jsonl")
+		Expect(SetRoutes([]Route{{Field: "network", Value: "storage-net", LogFile: storageLog}})).To(Succeed())
+		err := SetConfig(Config{
+			LogLevel:         "debug",
+			LogFile:          filepath.Join(dir, "other.log"),
+			SecondaryLogFile: secondaryLog,
+			Routes:           []Route{{Field: "network", LogFile: "/proc/storage.log"}},
+		})
+		Expect(err).To(HaveOccurred())
+		Expect(GetLogLevel()).To(Equal(InfoLevel))
+		Expect(logFileWriter.filename).To(Equal(logFile))
+		Expect(secondaryLogWriter).To(BeNil())
+		Expect(logRoutes).To(HaveLen(1))
+		Expect(logRoutes[0].writer.filename).To(Equal(storageLog))
+	})
+})
@@ -0,0 +1,53 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+const (
+	ruleChangeMsg       = "firewall rule changed"
+	ruleChangeFailedMsg = "firewall rule change failed"
+
+	ruleOpKey    = "op"
+	ruleTableKey = "table"
+	ruleChainKey = "chain"
+	ruleKey      = "rule"
+)
+
+// LogRuleChange logs a change of an iptables or nftables rule made by the plugin with standardized fields, so that
+// rule changes, the events most looked for during connectivity incidents, are found by the same query across plugins:
+// "op", e.g. "append", "insert" or "delete", "table", "chain" and "rule", the rule specification. Changes are logged at
+// info level. A failed change, err is set, is logged at error level with the fields of ErrorStructuredErr, and the
+// returned error wraps err. It returns nil otherwise.
+func LogRuleChange(op, table, chain, rule string, err error) error {
+	return logRuleChange(loadSnapshot(), err, ruleChangeArgs(op, table, chain, rule)...)
+}
+
+// LogRuleChange works like the LogRuleChange function with the context of l.
+func (l *Logger) LogRuleChange(op, table, chain, rule string, err error) error {
+	return logRuleChange(l.snapshot(), err, l.args(ruleChangeArgs(op, table, chain, rule))...)
+}
+
+// ruleChangeArgs returns the fields of a rule change.
+func ruleChangeArgs(op, table, chain, rule string) []interface{} {
+	return []interface{}{ruleOpKey, op, ruleTableKey, table, ruleChainKey, chain, ruleKey, rule}
+}
+
+// logRuleChange logs a rule change with the configuration s.
+func logRuleChange(s *snapshot, err error, args ...interface{}) error {
+	if err != nil {
+		return errorStructuredErr(s, err, ruleChangeFailedMsg, args...)
+	}
+	writeStructured(s, InfoLevel, ruleChangeMsg, true, args...)
+	return nil
+}
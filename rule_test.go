@@ -0,0 +1,47 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Logging firewall rule changes", func() {
+	var sink *captureSink
+
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+		sink = &captureSink{}
+		AddSink(sink)
+	})
+
+	It("logs a change with the standardized fields", func() {
+		Expect(LogRuleChange("append", "nat", "CNI-HOSTPORT-DNAT", "-p tcp --dport 8080 -j DNAT", nil)).To(Succeed())
+
+		Expect(sink.entries).To(HaveLen(1))
+		Expect(sink.entries[0].Level).To(Equal(InfoLevel))
+		Expect(sink.entries[0].String()).To(HaveSuffix(`msg="firewall rule changed" op="append" table="nat" ` +
+			`chain="CNI-HOSTPORT-DNAT" rule="-p tcp --dport 8080 -j DNAT"`))
+	})
+
+	It("logs a failed change as an error", func() {
+		cause := errors.New("Chain already exists")
+		err := LogRuleChange("insert", "filter", "CNI-FORWARD", "-j ACCEPT", fmt.Errorf("iptables: %w", cause))
+
+		Expect(errors.Is(err, cause)).To(BeTrue())
+		Expect(sink.entries).To(HaveLen(1))
+		Expect(sink.entries[0].Level).To(Equal(ErrorLevel))
+		Expect(sink.entries[0].String()).To(ContainSubstring(`msg="firewall rule change failed" op="insert" ` +
+			`table="filter" chain="CNI-FORWARD" rule="-j ACCEPT" error="iptables: Chain already exists" ` +
+			`cause="Chain already exists"`))
+	})
+
+	It("adds the context of a Logger", func() {
+		Expect(With("containerID", "c1").LogRuleChange("delete", "nat", "POSTROUTING", "-j MASQUERADE", nil)).
+			To(Succeed())
+		Expect(sink.entries[0].String()).To(ContainSubstring(`containerID="c1" op="delete"`))
+	})
+})
@@ -0,0 +1,84 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SetSanitize enables or disables the escaping of control characters in printf style messages and in the keys of
+// structured messages, which are written unquoted. Raw newlines, carriage returns or ANSI escape sequences in such
+// user-supplied text could otherwise forge log entries or corrupt log parsers. Newlines and carriage returns are
+// escaped as \n and \r, other control characters as \xXX or \uXXXX. The values of structured messages are quoted by
+// all formatters and need no sanitizing. Sanitizing is enabled by default.
+func SetSanitize(enable bool) {
+	mu.Lock()
+	defer unlockAndPublish()
+	sanitize = enable
+}
+
+// needsEscape returns true for the control characters escaped by sanitizeString. Tabs are kept.
+func needsEscape(r rune) bool {
+	return r != '\t' && unicode.IsControl(r)
+}
+
+// sanitizeString escapes the control characters of s. s is returned as is if it does not contain any.
+func sanitizeString(s string) string {
+	if strings.IndexFunc(s, needsEscape) < 0 {
+		return s
+	}
+
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case !needsEscape(r):
+			b.WriteRune(r)
+		case r < utf8.RuneSelf:
+			fmt.Fprintf(&b, `\x%02x`, r)
+		default:
+			fmt.Fprintf(&b, `\u%04x`, r)
+		}
+	}
+	return b.String()
+}
+
+// sanitizeKeys returns the alternating keys and values of args with the control characters of the keys escaped. args
+// is only copied if a key is escaped.
+func sanitizeKeys(args []interface{}) []interface{} {
+	var sanitized []interface{}
+	for i := 0; i < len(args)-1; i += 2 {
+		key, ok := args[i].(string)
+		if !ok {
+			continue
+		}
+		if escaped := sanitizeString(key); escaped != key {
+			if sanitized == nil {
+				sanitized = append(make([]interface{}, 0, len(args)), args...)
+			}
+			sanitized[i] = escaped
+		}
+	}
+	if sanitized == nil {
+		return args
+	}
+	return sanitized
+}
@@ -0,0 +1,43 @@
+package logging
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sanitizing", func() {
+	var out *bytes.Buffer
+
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+		out = &bytes.Buffer{}
+		SetOutput(out)
+	})
+
+	It("escapes control characters in printf style messages", func() {
+		Infof("pod %s", "a\n2024-01-01T00:00:00Z [error] forged\r\x1b[31mred\u0085\tend")
+		Expect(out.String()).To(HaveSuffix(`pod a\n2024-01-01T00:00:00Z [error] forged\r\x1b[31mred\u0085` + "\tend\n"))
+		Expect(bytes.Count(out.Bytes(), []byte("\n"))).To(Equal(1))
+	})
+
+	It("escapes control characters in structured keys", func() {
+		InfoStructured(infoMsg, "a\nlevel", "b\nc")
+		Expect(out.String()).To(HaveSuffix(`a\nlevel="b\nc"` + "\n"))
+		Expect(bytes.Count(out.Bytes(), []byte("\n"))).To(Equal(1))
+	})
+
+	It("keeps stack traces readable", func() {
+		Panicf(panicMsg)
+		Expect(out.String()).To(ContainSubstring("goroutine "))
+		Expect(bytes.Count(out.Bytes(), []byte("\n"))).To(BeNumerically(">", 4))
+	})
+
+	It("can be disabled", func() {
+		SetSanitize(false)
+		Infof("a\nb")
+		Expect(out.String()).To(HaveSuffix("a\nb\n"))
+	})
+})
@@ -0,0 +1,60 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+// SchemaVersion is the version of the format of the log entries, which is written in the "schema" field if enabled
+// with SetSchemaField. Within a schema version
+//
+//   - the keys of the fields written by the logger itself, see SchemaKeys, are neither renamed nor removed,
+//   - the format of their values, e.g. of the timestamp and the level names, does not change,
+//   - new fields may be added, so parsers must ignore unknown keys.
+//
+// Any other change increments SchemaVersion.
+const SchemaVersion = 1
+
+const schemaKey = "schema"
+
+// schemaKeys are the keys of the fields written by the logger itself in the current schema version. The tests pin
+// them, together with the formats of the values, so that incompatible changes require incrementing SchemaVersion.
+var schemaKeys = []string{
+	"time", "level", "msg", schemaKey,
+	chunkIDKey, chunkKey,
+	captureTimeKey, sendTimeKey,
+	"sampled", "rateLimited",
+	"repeated", "window",
+	loggingErrorKey,
+	errorKey, causeKey,
+	loggerNameKey, callerKey,
+	driftKey, findingsKey,
+	stackTraceKey,
+	"throttled",
+	escalatedForKey,
+	hashChainKey,
+	ruleOpKey, ruleTableKey, ruleChainKey, ruleKey,
+}
+
+// SchemaKeys returns the keys of the fields written by the logger itself in the current schema version.
+func SchemaKeys() []string {
+	return append([]string(nil), schemaKeys...)
+}
+
+// SetSchemaField adds a "schema" field with the SchemaVersion to every log message, so that downstream parsers can
+// handle future changes of the format. Structured messages carry it after the structured prefix, printf style messages
+// at the end.
+func SetSchemaField(enable bool) {
+	mu.Lock()
+	defer unlockAndPublish()
+	schemaField = enable
+}
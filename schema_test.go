@@ -0,0 +1,66 @@
+package logging
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+// If any of these tests fails, the change breaks the format of schema version 1: increment SchemaVersion, update the
+// pinned values and document the change.
+var _ = Describe("Schema version 1", func() {
+	var sink *captureSink
+
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+		sink = &captureSink{}
+		AddSink(sink)
+	})
+
+	It("is the current schema version", func() {
+		Expect(SchemaVersion).To(Equal(1))
+	})
+
+	It("pins the keys written by the logger", func() {
+		Expect(SchemaKeys()).To(Equal([]string{
+			"time", "level", "msg", "schema", "chunk_id", "chunk", "capture_time", "send_time",
+			"sampled", "rateLimited", "repeated", "window", "logging_error",
+			"error", "cause", "logger", "caller", "drift", "findings",
+			"stacktrace", "throttled", "escalatedFor", "prev_hash",
+			"op", "table", "chain", "rule",
+		}))
+
+		var keys []string
+		for _, f := range DefaultPrefixFields() {
+			keys = append(keys, f.Key)
+		}
+		Expect(keys).To(Equal([]string{"time", "level", "msg"}))
+	})
+
+	It("pins the format of the values", func() {
+		Expect(defaultTimestampFormat).To(Equal(time.RFC3339Nano))
+		levels := map[Level]string{}
+		for _, level := range []Level{FatalLevel, PanicLevel, ErrorLevel, WarningLevel, InfoLevel, DebugLevel, TraceLevel} {
+			levels[level] = level.String()
+		}
+		Expect(levels).To(Equal(map[Level]string{
+			FatalLevel: "fatal", PanicLevel: "panic", ErrorLevel: "error", WarningLevel: "warning",
+			InfoLevel: "info", DebugLevel: "debug", TraceLevel: "trace",
+		}))
+		Expect(newDefaultPrefixer().(*defaultPrefixer).prefixFormat).To(Equal("%s [%s] "))
+	})
+
+	It("writes the schema field if enabled", func() {
+		InfoStructured(infoMsg)
+		Expect(sink.entries[0].String()).NotTo(ContainSubstring("schema"))
+
+		SetSchemaField(true)
+		InfoStructured(infoMsg, "a", "b")
+		Infof(infoMsg)
+		Expect(sink.entries[1].String()).To(MatchRegexp(fmt.Sprintf(`msg=%q schema="1" a="b"$`, infoMsg)))
+		Expect(sink.entries[2].String()).To(HaveSuffix(infoMsg + ` schema="1"`))
+	})
+})
@@ -0,0 +1,106 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+// SetSecondaryLogFile writes the messages of the log file to filename as well, rendered by formatter, so that e.g. a
+// human-readable .log file and a .jsonl file for structured pipelines can be kept side by side during a migration of
+// the tooling. A nil formatter renders JSON. The secondary log file is rotated according to the options of the log
+// file, see SetLogOptions, and uses its fields and level, see SetFileFields and SetFileLogLevel. It can also be set
+// through the "secondaryLogFile" and "secondaryFormat" settings of the configuration, see Config. An empty filename
+// disables the secondary log file. If filename is not writable, an error is returned and nothing is changed.
+func SetSecondaryLogFile(filename string, formatter Formatter) error {
+	mu.Lock()
+	defer unlockAndPublish()
+
+	fp, err := resolveLogFile(filename)
+	if err != nil {
+		return err
+	}
+	configLayers[SourceAPI].secondaryLogFile = &filename
+	secondaryFormatter = formatter
+	return setSecondaryLogFile(fp)
+}
+
+// resolveLogFile resolves filename, see resolvePath, and checks that it is writable. An empty filename is returned as
+// is.
+func resolveLogFile(filename string) (string, error) {
+	if filename == "" {
+		return "", nil
+	}
+	fp, err := resolvePath(filename)
+	if err != nil {
+		return "", err
+	}
+	if !isLogFileWritable(fp) {
+		return "", configErrorf(ErrUnwritableLogFile, unwritableFailMsg, filename)
+	}
+	return fp, nil
+}
+
+// setSecondaryLogFile sets the secondary log file to filename, which must have been resolved already. The caller must
+// hold mu.
+func setSecondaryLogFile(filename string) error {
+	return replaceSecondaryLogWriter(secondaryLogFileWriter(filename))
+}
+
+// secondaryLogFileWriter returns the writer of the secondary log file filename, which must have been resolved already:
+// the current writer if it writes filename already, nil if filename is empty. The file is opened on the first write.
+// The caller must hold mu.
+func secondaryLogFileWriter(filename string) *fileWriter {
+	if filename == "" {
+		return nil
+	}
+	if secondaryLogWriter != nil && secondaryLogWriter.filename == filename {
+		return secondaryLogWriter
+	}
+	w := newFileWriter(newRotatingWriter(), filename)
+	w.setOptions(currentLogOptions())
+	return w
+}
+
+// replaceSecondaryLogWriter makes w the writer of the secondary log file and closes the previous one. The caller must
+// hold mu.
+func replaceSecondaryLogWriter(w *fileWriter) error {
+	previous := secondaryLogWriter
+	secondaryLogWriter = w
+	if previous == nil || previous == w {
+		return nil
+	}
+	return previous.close()
+}
+
+// closeSecondaryLogFile closes and disables the secondary log file. The caller must hold mu.
+func closeSecondaryLogFile() error {
+	if secondaryLogWriter == nil {
+		return nil
+	}
+	err := secondaryLogWriter.close()
+	secondaryLogWriter = nil
+	return err
+}
+
+// secondaryLogSink returns the sink of the secondary log file, nil if it is disabled. verbose is the most verbose level
+// of all outputs. The caller must hold mu.
+func secondaryLogSink(verbose Level) Sink {
+	if secondaryLogWriter == nil {
+		return nil
+	}
+	formatter := secondaryFormatter
+	if formatter == nil {
+		formatter = JSONFormatter{}
+	}
+	return withLevel(&writerSink{out: secondaryLogWriter, formatter: formatter, fields: fileFields, ascii: asciiOnly,
+		maxSize: maxEntrySize, name: statsSecondaryLog}, fileLogLevel, verbose)
+}
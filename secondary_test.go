@@ -0,0 +1,86 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Secondary log file", func() {
+	var dir, logFile, secondaryLogFile string
+
+	BeforeEach(func() {
+		initLogger()
+		var err error
+		dir, err = os.MkdirTemp("", "cni-log-secondary")
+		Expect(err).NotTo(HaveOccurred())
+		logFile = path.Join(dir, "plugin.log")
+		secondaryLogFile = path.Join(dir, "plugin.jsonl")
+		SetLogFile(logFile)
+		SetLogStderr(false)
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	readFile := func(filename string) string {
+		data, err := os.ReadFile(filename)
+		Expect(err).NotTo(HaveOccurred())
+		return string(data)
+	}
+
+	It("receives the messages of the log file as JSON", func() {
+		SetLogOptions(&LogOptions{MaxSize: getPrimitivePointer(1)})
+		Expect(SetSecondaryLogFile(secondaryLogFile, nil)).To(Succeed())
+		InfoStructured(infoMsg, "pod", "pod-a")
+
+		Expect(readFile(logFile)).To(MatchRegexp(fmt.Sprintf(`^time=".*" level="info" msg=%q pod="pod-a"\n$`, infoMsg)))
+		Expect(readFile(secondaryLogFile)).To(MatchRegexp(
+			fmt.Sprintf(`^\{"time":".*","level":"info","msg":%q,"pod":"pod-a"\}\n$`, infoMsg)))
+		Expect(secondaryLogWriter.backend.(*lumberjackWriter).logger.MaxSize).To(Equal(1))
+	})
+
+	It("follows the level and the fields of the log file", func() {
+		Expect(SetSecondaryLogFile(secondaryLogFile, LogfmtFormatter{})).To(Succeed())
+		SetFileLogLevel(ErrorLevel)
+		SetFileFields("msg")
+		Warningf(warningMsg)
+		_ = ErrorStructured(errorMsg, "pod", "pod-a")
+		Expect(readFile(secondaryLogFile)).To(Equal(fmt.Sprintf("msg=%q\n", errorMsg)))
+	})
+
+	It("is set through the configuration", func() {
+		conf, err := ParseConfig([]byte(`{"logging": {"logFile": "` + logFile + `", "secondaryLogFile": "` +
+			secondaryLogFile + `", "secondaryFormat": "logfmt"}}`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ApplyConfig(conf)).To(Succeed())
+		Infof(infoMsg)
+		Expect(readFile(secondaryLogFile)).To(MatchRegexp(fmt.Sprintf(`^time=\S+ level=info msg=%q\n$`, infoMsg)))
+		Expect(ExplainConfig()).To(ContainElements(
+			ConfigValue{Name: "secondaryLogFile", Value: secondaryLogFile, Source: SourceNetConf},
+			ConfigValue{Name: "secondaryFormat", Value: FormatLogfmt, Source: SourceNetConf}))
+
+		Expect(ApplyConfig(&Config{LogFile: logFile})).To(Succeed())
+		Expect(secondaryLogWriter).To(BeNil())
+		Expect(ApplyConfig(&Config{SecondaryLogFile: secondaryLogFile, SecondaryFormat: "yaml"})).NotTo(Succeed())
+	})
+
+	It("can be disabled", func() {
+		Expect(SetSecondaryLogFile(secondaryLogFile, nil)).To(Succeed())
+		Expect(SetSecondaryLogFile("", nil)).To(Succeed())
+		Infof(infoMsg)
+		Expect(logFileContains(logFile, infoMsg)).To(BeTrue())
+		Expect(logFileContains(secondaryLogFile, infoMsg)).To(BeFalse())
+	})
+
+	It("rejects unwritable files", func() {
+		Expect(SetSecondaryLogFile(secondaryLogFile, nil)).To(Succeed())
+		Expect(SetSecondaryLogFile("/proc/cni-log/plugin.jsonl", nil)).To(MatchError(ContainSubstring("not writable")))
+		Infof(infoMsg)
+		Expect(logFileContains(secondaryLogFile, infoMsg)).To(BeTrue())
+	})
+})
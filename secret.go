@@ -0,0 +1,76 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+const (
+	secretMask     = "***"
+	revealedSuffix = 4
+)
+
+// SecretValue is a sensitive value which is never written to the logs, see Secret.
+type SecretValue struct {
+	value  interface{}
+	reveal bool
+}
+
+// Secret wraps a sensitive value, e.g. a token or password from the network configuration, so that it is rendered as
+// "***" by all logging functions, formatters and outputs:
+//
+//	logging.InfoStructured("registered with IPAM backend", "url", url, "token", logging.Secret(token))
+//
+// This does not depend on the key the value is logged with.
+func Secret(value interface{}) SecretValue {
+	return SecretValue{value: value}
+}
+
+// RevealLast4 returns a copy of s which is rendered with the last 4 characters of the value, e.g. "***c3f1", so that
+// different secrets can be told apart. Values of up to 4 characters are masked completely.
+func (s SecretValue) RevealLast4() SecretValue {
+	s.reveal = true
+	return s
+}
+
+// String implements fmt.Stringer.
+func (s SecretValue) String() string {
+	if !s.reveal {
+		return secretMask
+	}
+	value := []rune(argToString(s.value))
+	if len(value) <= revealedSuffix {
+		return secretMask
+	}
+	return secretMask + string(value[len(value)-revealedSuffix:])
+}
+
+// GoString implements fmt.GoStringer, so that %#v does not reveal the value either.
+func (s SecretValue) GoString() string {
+	return s.String()
+}
+
+// Format implements fmt.Formatter, so that the value is masked with every verb.
+func (s SecretValue) Format(f fmt.State, _ rune) {
+	_, _ = io.WriteString(f, s.String())
+}
+
+// MarshalJSON implements json.Marshaler.
+func (s SecretValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
@@ -0,0 +1,40 @@
+package logging
+
+import (
+	"bytes"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Secrets", func() {
+	const token = "s3cr3t-t0k3n-c3f1"
+	var sink *captureSink
+
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+		sink = &captureSink{}
+		AddSink(sink)
+	})
+
+	It("masks secrets in all messages and formats", func() {
+		out := &bytes.Buffer{}
+		AddOutput(out)
+		SetFileFormatter(JSONFormatter{})
+
+		InfoStructured(infoMsg, "token", Secret(token))
+		Infof("token %s %v %+v %#v %q %x %d", Secret(token), Secret(token), Secret(token), Secret(token), Secret(token),
+			Secret(token), Secret(token))
+		Expect(sink.entries[0].String()).To(HaveSuffix(`token="***"`))
+		Expect(sink.entries[1].Message).To(Equal("token *** *** *** *** *** *** ***"))
+		Expect(out.String()).To(ContainSubstring(`"token":"***"`))
+		Expect(out.String()).NotTo(ContainSubstring("s3cr3t"))
+	})
+
+	It("reveals the last 4 characters on request", func() {
+		Expect(Secret(token).RevealLast4().String()).To(Equal("***c3f1"))
+		Expect(fmt.Sprint(Secret(1234).RevealLast4())).To(Equal("***"))
+	})
+})
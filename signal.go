@@ -0,0 +1,133 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"os"
+	"os/signal"
+)
+
+const (
+	// signalBufferSize is the number of signals which are buffered while a signal is being handled.
+	signalBufferSize = 4
+
+	signalLevelMsg = "logging level changed by signal"
+)
+
+// signalHandler handles the signals it was started for until it is stopped.
+type signalHandler struct {
+	signals chan os.Signal
+	done    chan struct{}
+}
+
+// startSignalHandler calls handle for every signal of sigs the process receives until the handler is stopped. nil
+// signals are ignored.
+func startSignalHandler(handle func(os.Signal), sigs ...os.Signal) *signalHandler {
+	h := &signalHandler{signals: make(chan os.Signal, signalBufferSize), done: make(chan struct{})}
+	var notify []os.Signal
+	for _, sig := range sigs {
+		if sig != nil {
+			notify = append(notify, sig)
+		}
+	}
+	// Notify without signals relays all signals.
+	if len(notify) > 0 {
+		signal.Notify(h.signals, notify...)
+	}
+	go h.run(handle)
+	return h
+}
+
+// run calls handle for the signals until the handler is stopped.
+func (h *signalHandler) run(handle func(os.Signal)) {
+	for {
+		select {
+		case sig := <-h.signals:
+			handle(sig)
+		case <-h.done:
+			return
+		}
+	}
+}
+
+// stop stops handling the signals and resets them to their default behavior.
+func (h *signalHandler) stop() {
+	signal.Stop(h.signals)
+	close(h.done)
+}
+
+// signalToggle changes the logging level when it receives its signals.
+type signalToggle struct {
+	raise, lower os.Signal
+	handler      *signalHandler
+}
+
+// EnableSignalLevelToggle makes the process more verbose by one level whenever it receives the signal raise, and less
+// verbose by one level whenever it receives the signal lower, which may be nil. Operators can then capture the debug
+// messages of a running daemon with e.g. kill -USR1 and return to the previous level with kill -USR2:
+//
+//	logging.EnableSignalLevelToggle(syscall.SIGUSR1, syscall.SIGUSR2)
+//
+// The level is changed like with SetLogLevel, within FatalLevel and TraceLevel, and every change is reported by a
+// "logging level changed by signal" warning. Enabling the toggle again replaces the previous signals.
+func EnableSignalLevelToggle(raise, lower os.Signal) {
+	mu.Lock()
+	defer unlockAndPublish()
+	stopSignalToggle()
+	t := &signalToggle{raise: raise, lower: lower}
+	t.handler = startSignalHandler(t.handle, raise, lower)
+	levelToggle = t
+}
+
+// DisableSignalLevelToggle stops changing the logging level on signals, which is the default. The signals are reset to
+// their default behavior.
+func DisableSignalLevelToggle() {
+	mu.Lock()
+	defer unlockAndPublish()
+	stopSignalToggle()
+}
+
+// stopSignalToggle stops the signal level toggle if it is enabled. The caller must hold mu.
+func stopSignalToggle() {
+	if levelToggle == nil {
+		return
+	}
+	levelToggle.handler.stop()
+	levelToggle = nil
+}
+
+// handle changes the logging level on sig.
+func (t *signalToggle) handle(sig os.Signal) {
+	step := Level(1)
+	if sig == t.lower && sig != t.raise {
+		step = -1
+	}
+	if level, ok := t.change(step); ok {
+		WarningStructured(signalLevelMsg, "signal", sig.String(), "newLevel", level)
+	}
+}
+
+// change changes the logging level by step unless it would leave the valid levels or the toggle was stopped.
+func (t *signalToggle) change(step Level) (Level, bool) {
+	mu.Lock()
+	defer unlockAndPublish()
+	level := logLevel + step
+	if levelToggle != t || level < minimumLevel || level > maximumLevel {
+		return logLevel, false
+	}
+	logLevel = level
+	configLayers[SourceAPI].logLevel = &level
+	return level, true
+}
@@ -0,0 +1,64 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package logging
+
+import (
+	"syscall"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Signal level toggle", func() {
+	var sink *captureSink
+
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+		sink = &captureSink{}
+		AddSink(sink)
+		SetLogLevel(InfoLevel)
+		EnableSignalLevelToggle(syscall.SIGUSR1, syscall.SIGUSR2)
+		DeferCleanup(DisableSignalLevelToggle)
+	})
+
+	notices := func() []string {
+		sink.mu.Lock()
+		defer sink.mu.Unlock()
+		var notices []string
+		for _, entry := range sink.entries {
+			if entry.Message == signalLevelMsg {
+				notices = append(notices, entry.String())
+			}
+		}
+		return notices
+	}
+
+	It("raises and lowers the level", func() {
+		Expect(syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)).To(Succeed())
+		Eventually(GetLogLevel).Should(Equal(DebugLevel))
+		Eventually(notices).Should(ConsistOf(ContainSubstring(`signal="user defined signal 1" newLevel="debug"`)))
+
+		Expect(syscall.Kill(syscall.Getpid(), syscall.SIGUSR2)).To(Succeed())
+		Eventually(GetLogLevel).Should(Equal(InfoLevel))
+		Expect(ExplainConfig()).To(ContainElement(ConfigValue{Name: "logLevel", Value: InfoLevel, Source: SourceAPI}))
+	})
+
+	It("keeps the level within the valid levels", func() {
+		SetLogLevel(TraceLevel)
+		Expect(syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)).To(Succeed())
+		Consistently(GetLogLevel, 100*time.Millisecond).Should(Equal(TraceLevel))
+		Expect(syscall.Kill(syscall.Getpid(), syscall.SIGUSR2)).To(Succeed())
+		Eventually(GetLogLevel).Should(Equal(DebugLevel))
+		Expect(notices()).To(HaveLen(1))
+	})
+
+	It("stops changing the level once disabled", func() {
+		DisableSignalLevelToggle()
+		EnableSignalLevelToggle(syscall.SIGUSR2, nil)
+		Expect(syscall.Kill(syscall.Getpid(), syscall.SIGUSR2)).To(Succeed())
+		Eventually(GetLogLevel).Should(Equal(DebugLevel))
+		Expect(levelToggle.lower).To(BeNil())
+	})
+})
@@ -0,0 +1,344 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	captureTimeKey = "capture_time"
+	sendTimeKey    = "send_time"
+)
+
+// Entry is a single log message as it is passed to the sinks.
+type Entry struct {
+	// Time is the time the message was logged at.
+	Time time.Time
+	// Level is the logging level of the message.
+	Level Level
+	// Message is the message without any prefix: the formatted message of the printf style functions, msg of the
+	// structured logging functions.
+	Message string
+	// Fields are the alternating keys and values of a structured log message, including the fields of the structured
+	// prefix. They are nil for messages of the printf style functions.
+	Fields []interface{}
+
+	structured bool
+	// prefix is the prefix of the configured Prefixer for messages of the printf style functions.
+	prefix string
+	// extra holds fields which are appended to a printf style message, e.g. the chunk fields if the entry was split.
+	extra []interface{}
+	// escalated is set if the entry is only logged because its field value is escalated, see SetEscalation.
+	escalated bool
+}
+
+// NewEntry returns a structured entry, e.g. to write entries read back with the parse package to a sink again. fields
+// are the alternating keys and values of all fields of the message, including the structured prefix.
+func NewEntry(t time.Time, level Level, msg string, fields ...interface{}) Entry {
+	return Entry{Time: t, Level: level, Message: msg, Fields: fields, structured: true}
+}
+
+// withFields returns a copy of the entry with the alternating keys and values of args appended: to the fields of a
+// structured entry, after the message of a printf style entry.
+func (e Entry) withFields(args ...interface{}) Entry {
+	if e.structured {
+		fields := make([]interface{}, 0, len(e.Fields)+len(args))
+		e.Fields = append(append(fields, e.Fields...), args...)
+		return e
+	}
+	extra := make([]interface{}, 0, len(e.extra)+len(args))
+	e.extra = append(append(extra, e.extra...), args...)
+	return e
+}
+
+// Structured returns true if the entry was logged by one of the structured logging functions.
+func (e Entry) Structured() bool {
+	return e.structured
+}
+
+// String renders the entry the way it is written to the log file: with the configured prefix for the printf style
+// functions, as key="value" pairs for the structured logging functions.
+func (e Entry) String() string {
+	return e.render(nil)
+}
+
+// WithSendTime returns a copy of the entry with a capture_time field, the time the entry was logged at, and a
+// send_time field, sendTime, appended. Remote sinks use it so that collectors can measure buffering delays and correct
+// for the clock skew of the node. Sinks which buffer entries call it when they actually send them.
+func (e Entry) WithSendTime(sendTime time.Time) Entry {
+	return e.withFields(captureTimeKey, e.Time.Format(defaultTimestampFormat), sendTimeKey,
+		sendTime.Format(defaultTimestampFormat))
+}
+
+// render renders the entry. Structured entries are restricted to the allowed fields unless allowed is nil.
+func (e Entry) render(allowed map[string]bool) string {
+	if e.structured {
+		return renderStructured(e.Fields, allowed)
+	}
+	if len(e.extra) > 0 {
+		return e.prefix + e.Message + " " + renderStructured(e.extra, nil)
+	}
+	return e.prefix + e.Message
+}
+
+// Sink is a destination of log messages. Sinks receive every message which matches the configured logging level and
+// must be safe for concurrent use. Errors returned by Write are passed to the error handler, see SetErrorHandler. If a
+// sink buffers messages, it can implement Flush() error, which is called by Flush and Close.
+type Sink interface {
+	Write(entry Entry) error
+}
+
+// SinkFunc is an adapter which allows the use of an ordinary function as a Sink.
+type SinkFunc func(Entry) error
+
+// Write implements the Sink interface.
+func (f SinkFunc) Write(entry Entry) error {
+	return f(entry)
+}
+
+// AnnotateSendTime returns a sink which adds capture_time and send_time fields to the entries before passing them to
+// sink, see Entry.WithSendTime. The send time is the time the entry is passed to sink.
+func AnnotateSendTime(sink Sink) Sink {
+	return SinkFunc(func(entry Entry) error {
+		return sink.Write(entry.WithSendTime(logTime()))
+	})
+}
+
+// writerSink writes the rendered entries to an io.Writer, e.g. stderr, the log file or a custom output.
+type writerSink struct {
+	out       io.Writer
+	formatter Formatter
+	fields    map[string]bool
+	ascii     bool
+	maxSize   int
+	// name is the name of the output in the statistics, see Stats. Outputs without a name are not counted.
+	name string
+	// chain is the hash chain of the lines, see SetHashChain, and chainSeed the log file it continues.
+	chain     *hashChain
+	chainSeed string
+	// theme holds the markers prefixed to the lines of the console, see SetConsoleTheme.
+	theme Theme
+}
+
+// Write implements the Sink interface.
+func (s *writerSink) Write(entry Entry) error {
+	if s.chain != nil {
+		return s.chain.write(s, entry, s.chainSeed)
+	}
+
+	var err error
+	for _, line := range splitEntry(entry, s.maxSize, s.fields, s.render) {
+		if writeErr := s.writeLine(line); writeErr != nil {
+			err = writeErr
+		}
+	}
+	return err
+}
+
+// writeLine writes a rendered line.
+func (s *writerSink) writeLine(line string) error {
+	if err := doWrite(s.out, line); err != nil {
+		return err
+	}
+	writtenBytes.add(s.name, len(line)+1)
+	return nil
+}
+
+// render renders an entry as it is written.
+func (s *writerSink) render(entry Entry) string {
+	return s.theme[entry.Level] + formatLine(s.ascii, "%s", formatEntry(s.formatter, entry, s.fields))
+}
+
+// syslogSink writes the rendered entries to syslog.
+type syslogSink struct {
+	w         *syslogWriter
+	formatter Formatter
+	fields    map[string]bool
+	ascii     bool
+	maxSize   int
+	sendTime  bool
+	hook      PayloadHook
+}
+
+// Write implements the Sink interface. Entries are split to fit into a datagram of the transport as well.
+func (s *syslogSink) Write(entry Entry) error {
+	if s.sendTime {
+		entry = entry.WithSendTime(logTime())
+	}
+	maxSize := s.maxSize
+	if limit := s.w.maxMessageSize(entry.Level); limit > 0 && (maxSize <= 0 || limit < maxSize) {
+		maxSize = limit
+	}
+
+	var err error
+	for _, line := range splitEntry(entry, maxSize, s.fields, s.render) {
+		if s.hook != nil {
+			payload, hookErr := s.hook([]byte(strings.TrimRight(line, "\n")))
+			if hookErr != nil {
+				err = fmt.Errorf(payloadFailMsg, hookErr)
+				continue
+			}
+			line = string(payload)
+		}
+		if writeErr := s.w.write(entry.Level, line); writeErr != nil {
+			err = writeErr
+			continue
+		}
+		writtenBytes.add(statsSyslog, len(line))
+	}
+	return err
+}
+
+// render renders an entry as it is written.
+func (s *syslogSink) render(entry Entry) string {
+	return formatLine(s.ascii, "%s", formatEntry(s.formatter, entry, s.fields))
+}
+
+// journaldSink writes the entries to the systemd journal.
+type journaldSink struct {
+	w      *journaldWriter
+	fields map[string]bool
+}
+
+// Write implements the Sink interface. The fields of structured entries are forwarded as journal fields.
+func (s *journaldSink) Write(entry Entry) error {
+	if !entry.structured {
+		return s.w.write(entry.Level, entry.render(nil), nil, nil)
+	}
+	return s.w.write(entry.Level, entry.Message, entry.Fields, s.fields)
+}
+
+// stderrWriter writes to os.Stderr as of the time of the write, so that redirecting os.Stderr takes effect although
+// the sinks are created up front.
+type stderrWriter struct{}
+
+// Write implements io.Writer.
+func (stderrWriter) Write(p []byte) (int, error) {
+	return os.Stderr.Write(p)
+}
+
+// stdoutWriter writes to the current os.Stdout.
+type stdoutWriter struct{}
+
+// Write implements io.Writer.
+func (stdoutWriter) Write(p []byte) (int, error) {
+	return os.Stdout.Write(p)
+}
+
+// activeSinks returns the sinks messages are currently written to: the built-in outputs as configured, followed by the
+// sinks added with AddSink. The caller must hold mu.
+func activeSinks() []Sink {
+	sinks := make([]Sink, 0, 4+len(extraOutputs)+len(customSinks))
+	verbose := mostVerboseLevel()
+	var stderrSink, fileSink Sink
+	if logToStderr {
+		stderrSink = withLevel(&writerSink{out: stderrWriter{}, formatter: stderrFormatter, fields: stderrFields,
+			ascii: asciiOnly, maxSize: maxEntrySize, name: statsStderr, theme: consoleTheme}, stderrLogLevel, verbose)
+	}
+	if out := fileOutput(); out != nil {
+		sink := &writerSink{out: out, formatter: fileFormatter, fields: fileFields, ascii: asciiOnly,
+			maxSize: maxEntrySize, name: statsFile}
+		if logHashChain != nil {
+			sink.chain, sink.fields = logHashChain, allowHashChain(fileFields)
+			if logWriter == logFileWriter {
+				sink.chainSeed = logFileWriter.filename
+			}
+		}
+		fileSink = withoutRouted(withLevel(withFallback(sink), fileLogLevel, verbose))
+	}
+	switch {
+	case stderrFailover && stderrSink != nil && fileSink != nil:
+		sinks = append(sinks, FailoverSink(fileSink, stderrSink))
+	case stderrSink != nil && fileSink != nil:
+		sinks = append(sinks, stderrSink, fileSink)
+	case stderrSink != nil:
+		sinks = append(sinks, stderrSink)
+	case fileSink != nil:
+		sinks = append(sinks, fileSink)
+	}
+	if logToStdout {
+		sinks = append(sinks, withLevel(&writerSink{out: stdoutWriter{}, formatter: stderrFormatter, fields: stderrFields,
+			ascii: asciiOnly, maxSize: maxEntrySize, name: statsStdout, theme: consoleTheme}, stderrLogLevel, verbose))
+	}
+	if sink := secondaryLogSink(verbose); sink != nil {
+		sinks = append(sinks, sink)
+	}
+	if sink := errorLogSink(verbose); sink != nil {
+		sinks = append(sinks, sink)
+	}
+	sinks = append(sinks, routeSinks(verbose)...)
+	if sink := ringSink(verbose); sink != nil {
+		sinks = append(sinks, sink)
+	}
+	for _, out := range extraOutputs {
+		sinks = append(sinks, withLevel(&writerSink{out: out, formatter: fileFormatter, fields: fileFields,
+			ascii: asciiOnly, maxSize: maxEntrySize, name: statsOutput}, fileLogLevel, verbose))
+	}
+	if syslogOutput != nil {
+		sinks = append(sinks, withLevel(&syslogSink{w: syslogOutput, formatter: syslogFormatter, fields: syslogFields,
+			ascii: asciiOnly, maxSize: maxEntrySize, sendTime: syslogSendTime, hook: syslogPayloadHook}, InvalidLevel,
+			verbose))
+	}
+	if journaldOutput != nil {
+		sinks = append(sinks, withLevel(&journaldSink{w: journaldOutput, fields: journaldFields}, InvalidLevel, verbose))
+	}
+	for _, sink := range customSinks {
+		sinks = append(sinks, withLevel(sink, InvalidLevel, verbose))
+	}
+	return sinks
+}
+
+// AddSink registers a sink which receives all log messages in addition to the other outputs, e.g. to send them to an
+// HTTP endpoint or to capture them in tests. Sinks receive the unfiltered entries, field allow-lists and ASCII-only
+// mode only apply to the built-in outputs.
+func AddSink(sink Sink) {
+	if sink == nil {
+		return
+	}
+
+	mu.Lock()
+	defer unlockAndPublish()
+
+	// Log calls use the slice after releasing mu, so it is never modified in place.
+	sinks := make([]Sink, 0, len(customSinks)+1)
+	customSinks = append(append(sinks, customSinks...), sink)
+}
+
+// RemoveSink removes a sink added with AddSink. A sink which buffers messages is flushed first. Sinks are compared
+// with ==, sinks of types which are not comparable, e.g. a SinkFunc, cannot be removed.
+func RemoveSink(sink Sink) {
+	if sink == nil || !reflect.TypeOf(sink).Comparable() {
+		return
+	}
+
+	mu.Lock()
+	defer unlockAndPublish()
+
+	sinks := make([]Sink, 0, len(customSinks))
+	for _, s := range customSinks {
+		if reflect.TypeOf(s).Comparable() && s == sink {
+			_ = flushWriter(s)
+			continue
+		}
+		sinks = append(sinks, s)
+	}
+	customSinks = sinks
+}
@@ -0,0 +1,156 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+// captureSink records all entries written to it.
+type captureSink struct {
+	mu      sync.Mutex
+	entries []Entry
+	flushed int
+}
+
+func (s *captureSink) Write(entry Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+func (s *captureSink) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flushed++
+	return nil
+}
+
+var _ = Describe("Sinks", func() {
+	var sink *captureSink
+
+	BeforeEach(func() {
+		initLogger()
+		sink = &captureSink{}
+		AddSink(sink)
+		SetLogStderr(false)
+	})
+
+	It("passes printf style messages to the sink", func() {
+		Warningf("%s %d", warningMsg, 1)
+		Expect(sink.entries).To(HaveLen(1))
+
+		entry := sink.entries[0]
+		Expect(entry.Level).To(Equal(WarningLevel))
+		Expect(entry.Message).To(Equal(warningMsg + " 1"))
+		Expect(entry.Fields).To(BeNil())
+		Expect(entry.Structured()).To(BeFalse())
+		Expect(entry.Time).NotTo(BeZero())
+		Expect(entry.String()).To(MatchRegexp(fmt.Sprintf(`^.* \[%s\] %s 1$`, warningStr, warningMsg)))
+	})
+
+	It("passes structured messages with all fields to the sink", func() {
+		SetFileFields("msg")
+		InfoStructured(infoMsg, "pod", "pod-a")
+		Expect(sink.entries).To(HaveLen(1))
+
+		entry := sink.entries[0]
+		Expect(entry.Level).To(Equal(InfoLevel))
+		Expect(entry.Message).To(Equal(infoMsg))
+		Expect(entry.Structured()).To(BeTrue())
+		Expect(entry.Fields).To(ContainElements("level", "msg", infoMsg, "pod", "pod-a"))
+		Expect(entry.String()).To(MatchRegexp(fmt.Sprintf(`^time=".*" level=%q msg=%q pod="pod-a"$`, infoStr, infoMsg)))
+	})
+
+	It("only passes messages which match the logging level", func() {
+		SetLogLevel(WarningLevel)
+		Infof(infoMsg)
+		Expect(sink.entries).To(BeEmpty())
+	})
+
+	It("flushes and removes sinks", func() {
+		Flush()
+		Expect(sink.flushed).To(Equal(1))
+
+		RemoveSink(sink)
+		Expect(sink.flushed).To(Equal(2))
+		Infof(infoMsg)
+		Expect(sink.entries).To(BeEmpty())
+	})
+
+	It("accepts functions as sinks", func() {
+		var messages []string
+		AddSink(SinkFunc(func(entry Entry) error {
+			messages = append(messages, entry.Message)
+			return nil
+		}))
+		Infof(infoMsg)
+		Expect(messages).To(Equal([]string{infoMsg}))
+	})
+
+	It("annotates entries with the capture and send time", func() {
+		remote := &captureSink{}
+		AddSink(AnnotateSendTime(remote))
+		Infof(infoMsg)
+		Expect(remote.entries).To(HaveLen(1))
+
+		entry := remote.entries[0]
+		Expect(entry.String()).To(MatchRegexp(fmt.Sprintf(`%s capture_time=".*" send_time=".*"$`, infoMsg)))
+		Expect(sink.entries[0].String()).NotTo(ContainSubstring("send_time"))
+
+		sendTime := entry.Time.Add(time.Second)
+		sent := sink.entries[0].WithSendTime(sendTime)
+		Expect(sent.String()).To(HaveSuffix(fmt.Sprintf("capture_time=%q send_time=%q",
+			entry.Time.Format(defaultTimestampFormat), sendTime.Format(defaultTimestampFormat))))
+	})
+})
+
+var _ = Describe("Stdout output", func() {
+	var stdout *os.File
+	var r, w *os.File
+
+	BeforeEach(func() {
+		initLogger()
+		var err error
+		r, w, err = os.Pipe()
+		Expect(err).NotTo(HaveOccurred())
+		stdout = os.Stdout
+		os.Stdout = w
+	})
+
+	AfterEach(func() {
+		os.Stdout = stdout
+		Expect(r.Close()).To(Succeed())
+	})
+
+	readStdout := func() string {
+		Expect(w.Close()).To(Succeed())
+		data, err := io.ReadAll(r)
+		Expect(err).NotTo(HaveOccurred())
+		return string(data)
+	}
+
+	It("writes to stdout with the settings of stderr", func() {
+		SetLogStderr(false)
+		SetLogStdout(true)
+		SetStderrFormatter(JSONFormatter{})
+		SetStderrLogLevel(WarningLevel)
+		Expect(captureStdErrEvent(Warningf, warningMsg)).To(BeEmpty())
+		Infof(infoMsg)
+
+		out := readStdout()
+		Expect(out).To(ContainSubstring(`"msg":"` + warningMsg + `"`))
+		Expect(out).NotTo(ContainSubstring(infoMsg))
+	})
+
+	It("is disabled by default", func() {
+		Expect(captureStdErrEvent(Infof, infoMsg)).To(ContainSubstring(infoMsg))
+		Expect(readStdout()).To(BeEmpty())
+	})
+})
@@ -0,0 +1,102 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"fmt"
+	"os"
+)
+
+// levelSink passes the entries up to a level to its sink, and the escalated entries if the level follows the level set
+// with SetLogLevel.
+type levelSink struct {
+	sink    Sink
+	level   Level
+	follows bool
+}
+
+// Write implements the Sink interface.
+func (s *levelSink) Write(entry Entry) error {
+	if entry.Level > s.level && !(s.follows && entry.escalated) {
+		return nil
+	}
+	return s.sink.Write(entry)
+}
+
+// Flush flushes the sink if it buffers messages.
+func (s *levelSink) Flush() error {
+	return flushWriter(s.sink)
+}
+
+// SetStderrLogLevel sets the logging level of stderr independently of the other outputs, e.g. to write debug messages
+// to the log file, but only errors to stderr, which the container runtime may forward to the journal. Passing
+// InvalidLevel makes stderr follow the level set with SetLogLevel again.
+func SetStderrLogLevel(level Level) {
+	mu.Lock()
+	defer unlockAndPublish()
+	setOutputLogLevel(&stderrLogLevel, level)
+}
+
+// SetFileLogLevel sets the logging level of the log file and the outputs set with SetOutput or AddOutput independently
+// of the other outputs. Passing InvalidLevel makes them follow the level set with SetLogLevel again.
+func SetFileLogLevel(level Level) {
+	mu.Lock()
+	defer unlockAndPublish()
+	setOutputLogLevel(&fileLogLevel, level)
+}
+
+// setOutputLogLevel sets the logging level of an output. The caller must hold mu.
+func setOutputLogLevel(outputLevel *Level, level Level) {
+	if level != InvalidLevel && !validateLogLevel(level) {
+		fmt.Fprintf(os.Stderr, setLevelFailMsg, level)
+		return
+	}
+	*outputLevel = level
+}
+
+// withLevel returns sink restricted to own, the level of its output, or to the level set with SetLogLevel if own is
+// InvalidLevel. Restricting an output which follows the level set with SetLogLevel is not necessary if that level is
+// not below verbose, the most verbose level of all outputs, which log calls check anyway. Outputs with a level of
+// their own are always restricted, so that escalated messages and the messages of Loggers with a more verbose level
+// are kept from them. The caller must hold mu.
+func withLevel(sink Sink, own, verbose Level) Sink {
+	level, follows := outputLevel(own), own == InvalidLevel
+	if sink == nil || (level >= verbose && follows) {
+		return sink
+	}
+	return &levelSink{sink: sink, level: level, follows: follows}
+}
+
+// outputLevel returns the logging level of an output, the level set with SetLogLevel if it has none. The caller must
+// hold mu.
+func outputLevel(level Level) Level {
+	if level == InvalidLevel {
+		return logLevel
+	}
+	return level
+}
+
+// mostVerboseLevel returns the most verbose logging level of all outputs, which is the level log calls check first.
+// The caller must hold mu.
+func mostVerboseLevel() Level {
+	level := logLevel
+	if (logToStderr || logToStdout) && outputLevel(stderrLogLevel) > level {
+		level = outputLevel(stderrLogLevel)
+	}
+	if (isFileLoggingEnabled() || len(extraOutputs) > 0 || len(logRoutes) > 0) && outputLevel(fileLogLevel) > level {
+		level = outputLevel(fileLogLevel)
+	}
+	return level
+}
@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"bytes"
+	"os"
+	"path"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Per-output logging levels", func() {
+	var logFile string
+
+	BeforeEach(func() {
+		initLogger()
+		logFile = path.Join(os.TempDir(), "test-sinklevel.log")
+		SetLogFile(logFile)
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(logFile)).To(Succeed())
+	})
+
+	It("writes debug messages to the log file and only errors to stderr", func() {
+		SetFileLogLevel(DebugLevel)
+		SetStderrLogLevel(ErrorLevel)
+		Expect(Enabled(DebugLevel)).To(BeTrue())
+		Expect(Enabled(TraceLevel)).To(BeFalse())
+		Expect(GetLogLevel()).To(Equal(defaultLogLevel))
+
+		Expect(captureStdErrEvent(Debugf, debugMsg)).To(BeEmpty())
+		Expect(captureStdErrEvent(Infof, infoMsg)).To(BeEmpty())
+		Expect(captureStdErrEvent(func(format string, a ...interface{}) { _ = Errorf(format, a...) }, errorMsg)).To(
+			ContainSubstring(errorMsg))
+		Expect(logFileContains(logFile, debugMsg)).To(BeTrue())
+		Expect(logFileContains(logFile, infoMsg)).To(BeTrue())
+		Expect(logFileContains(logFile, errorMsg)).To(BeTrue())
+	})
+
+	It("applies the global level to the other outputs", func() {
+		sink := &captureSink{}
+		AddSink(sink)
+		var out bytes.Buffer
+		AddOutput(&out)
+		SetFileLogLevel(TraceLevel)
+
+		TraceStructured(traceMsg)
+		InfoStructured(infoMsg)
+		Expect(sink.entries).To(HaveLen(1))
+		Expect(sink.entries[0].Message).To(Equal(infoMsg))
+		Expect(out.String()).To(ContainSubstring(traceMsg))
+	})
+
+	It("follows the global level again after InvalidLevel", func() {
+		SetStderrLogLevel(TraceLevel)
+		SetStderrLogLevel(InvalidLevel)
+		Expect(captureStdErrEvent(Debugf, debugMsg)).To(BeEmpty())
+		SetLogLevel(DebugLevel)
+		Expect(captureStdErrEvent(Debugf, debugMsg)).To(ContainSubstring(debugMsg))
+	})
+
+	It("ignores invalid levels", func() {
+		SetStderrLogLevel(ErrorLevel)
+		Expect(captureStdErr(SetStderrLogLevel, Level(42))).To(ContainSubstring("cannot set logging level"))
+		Expect(captureStdErrEvent(Warningf, warningMsg)).To(BeEmpty())
+	})
+})
@@ -0,0 +1,190 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.21
+
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+)
+
+// slogHandler is a slog.Handler writing to the cni-log outputs.
+type slogHandler struct {
+	// goas holds the attributes and groups added with WithAttrs and WithGroup, in order.
+	goas []groupOrAttrs
+}
+
+// groupOrAttrs is either a group opened with WithGroup or attributes added with WithAttrs.
+type groupOrAttrs struct {
+	group string
+	attrs []slog.Attr
+}
+
+// NewSlogHandler returns a slog.Handler which writes to the cni-log outputs with the global configuration:
+//
+//	slog.SetDefault(slog.New(logging.NewSlogHandler()))
+//
+// Attributes keep their type, so the JSONFormatter writes numbers and booleans as such, and groups are written as
+// nested objects in the order of their attributes instead of being flattened. The slog levels are mapped to the
+// cni-log levels: error, warning, info and debug, and levels below debug are trace.
+func NewSlogHandler() slog.Handler {
+	return &slogHandler{}
+}
+
+// LevelFromSlog returns the cni-log level of a slog level.
+func LevelFromSlog(level slog.Level) Level {
+	switch {
+	case level >= slog.LevelError:
+		return ErrorLevel
+	case level >= slog.LevelWarn:
+		return WarningLevel
+	case level >= slog.LevelInfo:
+		return InfoLevel
+	case level >= slog.LevelDebug:
+		return DebugLevel
+	default:
+		return TraceLevel
+	}
+}
+
+// Enabled implements slog.Handler.
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return Enabled(LevelFromSlog(level))
+}
+
+// Handle implements slog.Handler.
+func (h *slogHandler) Handle(_ context.Context, r slog.Record) error {
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+
+	// Nest the attributes of the record into the open groups, innermost first. Groups without attributes are omitted.
+	for i := len(h.goas) - 1; i >= 0; i-- {
+		goa := h.goas[i]
+		if goa.group == "" {
+			attrs = append(goa.attrs[:len(goa.attrs):len(goa.attrs)], attrs...)
+		} else if len(attrs) > 0 {
+			attrs = []slog.Attr{slog.Group(goa.group, attrsToAny(attrs)...)}
+		}
+	}
+
+	args := make([]interface{}, 0, 2*len(attrs))
+	for _, a := range attrs {
+		args = appendSlogAttr(args, a)
+	}
+	printStructured(LevelFromSlog(r.Level), r.Message, args...)
+	return nil
+}
+
+// WithAttrs implements slog.Handler.
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return h.with(groupOrAttrs{attrs: attrs})
+}
+
+// WithGroup implements slog.Handler.
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return h.with(groupOrAttrs{group: name})
+}
+
+// with returns a copy of the handler with goa added.
+func (h *slogHandler) with(goa groupOrAttrs) *slogHandler {
+	goas := make([]groupOrAttrs, 0, len(h.goas)+1)
+	return &slogHandler{goas: append(append(goas, h.goas...), goa)}
+}
+
+// attrsToAny converts attrs for slog.Group.
+func attrsToAny(attrs []slog.Attr) []any {
+	args := make([]any, len(attrs))
+	for i, a := range attrs {
+		args[i] = a
+	}
+	return args
+}
+
+// appendSlogAttr appends the key and the value of a to args, following the rules of slog.Handler: values are
+// resolved, empty attributes and empty groups are omitted and the attributes of groups without a key are inlined.
+// Groups are appended as slogGroup, all other values with their Go type.
+func appendSlogAttr(args []interface{}, a slog.Attr) []interface{} {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return args
+	}
+	if a.Value.Kind() != slog.KindGroup {
+		return append(args, a.Key, a.Value.Any())
+	}
+
+	group := a.Value.Group()
+	if len(group) == 0 {
+		return args
+	}
+	if a.Key == "" {
+		for _, ga := range group {
+			args = appendSlogAttr(args, ga)
+		}
+		return args
+	}
+	return append(args, a.Key, slogGroup(group))
+}
+
+// slogGroup is the value of a slog group. The JSONFormatter writes it as a nested object, the text formatters as
+// {key=value ...}.
+type slogGroup []slog.Attr
+
+// fields returns the resolved keys and values of the group.
+func (g slogGroup) fields() []interface{} {
+	var fields []interface{}
+	for _, a := range g {
+		fields = appendSlogAttr(fields, a)
+	}
+	return fields
+}
+
+// String implements fmt.Stringer.
+func (g slogGroup) String() string {
+	fields := g.fields()
+	pairs := make([]string, 0, len(fields)/2)
+	for i := 0; i < len(fields)-1; i += 2 {
+		pairs = append(pairs, argToString(fields[i])+"="+argToString(fields[i+1]))
+	}
+	return "{" + strings.Join(pairs, " ") + "}"
+}
+
+// MarshalJSON implements json.Marshaler. The attributes keep their order.
+func (g slogGroup) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	fields := g.fields()
+	b.WriteByte('{')
+	for i := 0; i < len(fields)-1; i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.Write(jsonValue(argToString(fields[i])))
+		b.WriteByte(':')
+		b.Write(jsonValue(fields[i+1]))
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
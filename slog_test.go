@@ -0,0 +1,68 @@
+//go:build go1.21
+
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("slog handler", func() {
+	var out bytes.Buffer
+
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+		out = bytes.Buffer{}
+		SetOutput(&out)
+		SetFileFormatter(JSONFormatter{})
+		SetFileFields("msg", "pod", "req", "n", "ok", "d")
+	})
+
+	It("maps slog levels to levels", func() {
+		Expect(LevelFromSlog(slog.LevelError)).To(Equal(ErrorLevel))
+		Expect(LevelFromSlog(slog.LevelWarn)).To(Equal(WarningLevel))
+		Expect(LevelFromSlog(slog.LevelInfo)).To(Equal(InfoLevel))
+		Expect(LevelFromSlog(slog.LevelDebug)).To(Equal(DebugLevel))
+		Expect(LevelFromSlog(slog.LevelDebug - 4)).To(Equal(TraceLevel))
+	})
+
+	It("keeps the types of the attributes", func() {
+		slog.New(NewSlogHandler()).Info(infoMsg, "n", 3, "ok", true, "d", time.Second)
+		Expect(out.String()).To(Equal(`{"msg":"` + infoMsg + `","n":3,"ok":true,"d":"1s"}` + "\n"))
+	})
+
+	It("writes groups as nested objects", func() {
+		log := slog.New(NewSlogHandler()).With("pod", "pod-a").WithGroup("req").With("id", 7)
+		log.Info(infoMsg, slog.Group("net", "if", "eth0", slog.Group("empty")), "ok", false)
+		Expect(out.String()).To(Equal(
+			`{"msg":"` + infoMsg + `","pod":"pod-a","req":{"id":7,"net":{"if":"eth0"},"ok":false}}` + "\n"))
+	})
+
+	It("omits groups without attributes and inlines groups without a key", func() {
+		slog.New(NewSlogHandler()).WithGroup("req").Info(infoMsg, slog.Group("", "n", 1))
+		Expect(out.String()).To(Equal(`{"msg":"` + infoMsg + `","req":{"n":1}}` + "\n"))
+
+		out.Reset()
+		slog.New(NewSlogHandler()).WithGroup("req").Info(infoMsg)
+		Expect(out.String()).To(Equal(`{"msg":"` + infoMsg + `"}` + "\n"))
+	})
+
+	It("renders groups for the text formatter", func() {
+		SetFileFormatter(nil)
+		slog.New(NewSlogHandler()).Info(infoMsg, slog.Group("req", "id", 7, "pod", "pod-a"))
+		Expect(out.String()).To(HaveSuffix(`req="{id=7 pod=pod-a}"` + "\n"))
+	})
+
+	It("filters messages by level", func() {
+		log := slog.New(NewSlogHandler())
+		Expect(log.Enabled(context.Background(), slog.LevelDebug)).To(BeFalse())
+		log.Debug(debugMsg)
+		Expect(out.String()).To(BeEmpty())
+	})
+})
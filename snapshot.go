@@ -0,0 +1,142 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import "sync/atomic"
+
+// snapshot is the effective configuration used by log calls. It is immutable once published, so log calls read it
+// without taking mu.
+type snapshot struct {
+	level       Level
+	scopedLevel Level
+	outputLevel Level
+	// levelCap is the most verbose level logged with the snapshot, see setLevel.
+	levelCap           Level
+	quietLevel         Level
+	sinks              []Sink
+	hooks              []Hook
+	panicHooks         []PanicHook
+	prefixer           Prefixer
+	structuredPrefixer StructuredPrefixer
+	resolvers          map[string]*fieldResolver
+	cniContext         []interface{}
+	limiter            *limiter
+	dedup              *deduplicator
+	escalator          *escalator
+	exitFunc           func(int)
+	proxy              proxyFunc
+	schema             bool
+	strict             bool
+	callerInfo         bool
+	callerSkip         int
+	stackTraceLevel    Level
+	stackTraceOptions  StackTraceOptions
+	redactor           *redactor
+	sanitize           bool
+	errorHandler       func(error)
+	deterministic      *deterministic
+	crashRecord        *crashRecorder
+	loggerLevels       map[string]Level
+	loggerSinks        map[string][]Sink
+	// loggerPrefixers and loggerStructuredPrefixers are the prefixers set with SetPrefixerFor and
+	// SetStructuredPrefixerFor by name.
+	loggerPrefixers           map[string]Prefixer
+	loggerStructuredPrefixers map[string]StructuredPrefixer
+	levelRules                []levelRule
+	ruleLevel                 Level
+	// name is the name of the Logger the snapshot was derived for, see GetLogger.
+	name string
+}
+
+// current holds the published *snapshot.
+var current atomic.Value
+
+// loadSnapshot returns the published configuration.
+func loadSnapshot() *snapshot {
+	return current.Load().(*snapshot)
+}
+
+// enabled returns true if messages of the given level are logged to at least one output.
+func (s *snapshot) enabled(level Level) bool {
+	return level <= s.outputLevel && level <= s.levelCap && len(s.sinks) > 0
+}
+
+// setLevel makes level the level of the messages logged with s, a copy of the published snapshot, regardless of the
+// level set with SetLogLevel: a more verbose level works like Logger.WithLevel, a less verbose one restricts all
+// outputs.
+func (s *snapshot) setLevel(level Level) {
+	s.levelCap = level
+	s.scopedLevel = InvalidLevel
+	if level > s.level {
+		s.scopedLevel = level
+	}
+}
+
+// fields returns all fields of a structured message: the structured prefix, the schema, the call site and the CNI
+// context if enabled, and args.
+func (s *snapshot) fields(level Level, msg string, args ...interface{}) []interface{} {
+	if cni := s.cniContext; len(cni) > 0 {
+		args = append(cni[:len(cni):len(cni)], args...)
+	}
+	if s.callerInfo {
+		args = append([]interface{}{callerKey, s.callSite()}, args...)
+	}
+	if s.schema {
+		args = append([]interface{}{schemaKey, SchemaVersion}, args...)
+	}
+	return structuredFields(s, level, msg, args...)
+}
+
+// unlockAndPublish publishes a snapshot of the configuration and releases mu, which the caller must hold for writing.
+// Every change of the configuration must be released through it to become visible to log calls.
+func unlockAndPublish() {
+	current.Store(&snapshot{
+		level:                     logLevel,
+		scopedLevel:               InvalidLevel,
+		outputLevel:               mostVerboseLevel(),
+		levelCap:                  maximumLevel,
+		quietLevel:                quietLevel,
+		sinks:                     activeSinks(),
+		hooks:                     hooks,
+		panicHooks:                panicHooks,
+		prefixer:                  prefixer,
+		structuredPrefixer:        structuredPrefixer,
+		resolvers:                 resolvers,
+		cniContext:                cniContext,
+		limiter:                   logLimiter,
+		dedup:                     logDedup,
+		escalator:                 logEscalator,
+		exitFunc:                  exitFunc,
+		proxy:                     networkProxy,
+		schema:                    schemaField,
+		strict:                    strictMode,
+		callerInfo:                callerInfo,
+		callerSkip:                callerSkip,
+		stackTraceLevel:           stackTraceLevel,
+		stackTraceOptions:         stackTraceOptions,
+		redactor:                  logRedactor,
+		sanitize:                  sanitize,
+		errorHandler:              errorHandler,
+		deterministic:             deterministicOutput,
+		crashRecord:               crashRecordWriter,
+		loggerLevels:              loggerLevels,
+		loggerSinks:               loggerSinks,
+		loggerPrefixers:           loggerPrefixers,
+		loggerStructuredPrefixers: loggerStructuredPrefixers,
+		levelRules:                levelRules,
+		ruleLevel:                 mostVerboseRuleLevel(),
+	})
+	mu.Unlock()
+}
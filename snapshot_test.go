@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"os"
+	"path"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Configuration snapshots", func() {
+	var logFile string
+
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+		logFile = path.Join(os.TempDir(), "test-snapshot.log")
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(logFile)).To(Succeed())
+	})
+
+	It("makes configuration changes visible to log calls", func() {
+		sink := &captureSink{}
+		AddSink(sink)
+		Debugf(debugMsg)
+		SetLogLevel(DebugLevel)
+		Debugf(debugMsg)
+		Expect(sink.entries).To(HaveLen(1))
+		Expect(GetLogLevel()).To(Equal(DebugLevel))
+	})
+
+	It("reports whether a level is enabled", func() {
+		SetLogStderr(true)
+		Expect(Enabled(InfoLevel)).To(BeTrue())
+		Expect(IsDebugEnabled()).To(BeFalse())
+		SetLogLevel(DebugLevel)
+		Expect(IsDebugEnabled()).To(BeTrue())
+		Expect(IsTraceEnabled()).To(BeFalse())
+		SetLogLevel(TraceLevel)
+		Expect(IsTraceEnabled()).To(BeTrue())
+
+		SetLogStderr(false)
+		Expect(Enabled(ErrorLevel)).To(BeFalse())
+	})
+
+	It("allows changing the configuration while logging", func() {
+		var wg sync.WaitGroup
+		done := make(chan struct{})
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					select {
+					case <-done:
+						return
+					default:
+						Infof(infoMsg)
+						InfoStructured(infoMsg, "a", "b")
+					}
+				}
+			}()
+		}
+
+		for i := 0; i < 50; i++ {
+			SetLogFile(logFile)
+			SetLogOptions(&LogOptions{MaxSize: getPrimitivePointer(i + 1)})
+			SetLogLevel(Level(i%2) + InfoLevel)
+			SetDefaultPrefixer()
+			SetLogFile("")
+		}
+		close(done)
+		wg.Wait()
+	})
+})
@@ -0,0 +1,101 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+const (
+	defaultStackTraceLevel = PanicLevel
+	stackTraceKey          = "stacktrace"
+	stackTraceHeader       = "========= Stack trace output ========"
+	stackTraceFooter       = "========= Stack trace output end ========"
+	truncatedStackTrace    = "...\n"
+)
+
+// StackTraceOptions control the stack traces attached to messages, see SetStackTraceLevel.
+type StackTraceOptions struct {
+	// MaxDepth limits the number of frames of a stack trace, 0 means no limit.
+	MaxDepth int
+	// SkipLoggingFrames omits the frames of the logging functions at the top of the stack trace, so that it starts at
+	// the call site of the log statement.
+	SkipLoggingFrames bool
+}
+
+// SetStackTraceLevel sets the least severe level whose messages carry a stack trace: printf style messages are
+// followed by the stack trace, structured messages carry it in a "stacktrace" field. The default is PanicLevel, so
+// fatal and panic messages carry stack traces; ErrorLevel adds them to errors as well, InvalidLevel disables them.
+func SetStackTraceLevel(level Level) {
+	mu.Lock()
+	defer unlockAndPublish()
+	stackTraceLevel = level
+}
+
+// SetStackTraceOptions sets the options of the stack traces. Passing nil restores the defaults: full stack traces of
+// the goroutine in the format of runtime/debug.Stack.
+func SetStackTraceOptions(options *StackTraceOptions) {
+	mu.Lock()
+	defer unlockAndPublish()
+	stackTraceOptions = StackTraceOptions{}
+	if options != nil {
+		stackTraceOptions = *options
+	}
+}
+
+// stackTraceEnabled returns true if messages of the given level carry a stack trace.
+func (s *snapshot) stackTraceEnabled(level Level) bool {
+	return level >= minimumLevel && level <= s.stackTraceLevel
+}
+
+// stackTrace returns the stack trace of the calling goroutine. Without options, it is the one of runtime/debug.Stack.
+func stackTrace(options StackTraceOptions) string {
+	if options.MaxDepth <= 0 && !options.SkipLoggingFrames {
+		return string(debug.Stack())
+	}
+
+	pcs := make([]uintptr, maxCallDepth)
+	for {
+		n := runtime.Callers(2, pcs)
+		if n < len(pcs) {
+			pcs = pcs[:n]
+			break
+		}
+		pcs = make([]uintptr, 2*len(pcs))
+	}
+
+	var b strings.Builder
+	frames := runtime.CallersFrames(pcs)
+	depth := 0
+	skipping := options.SkipLoggingFrames
+	for more := true; more; {
+		var frame runtime.Frame
+		frame, more = frames.Next()
+		if skipping && isInternalFrame(frame) {
+			continue
+		}
+		skipping = false
+		if options.MaxDepth > 0 && depth == options.MaxDepth {
+			b.WriteString(truncatedStackTrace)
+			break
+		}
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		depth++
+	}
+	return b.String()
+}
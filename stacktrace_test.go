@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Stack traces", func() {
+	var sink *captureSink
+
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+		sink = &captureSink{}
+		AddSink(sink)
+	})
+
+	stack := func(entry Entry) string {
+		for i := 0; i < len(entry.Fields)-1; i += 2 {
+			if entry.Fields[i] == stackTraceKey {
+				return entry.Fields[i+1].(string)
+			}
+		}
+		return ""
+	}
+
+	It("attaches stack traces to panic messages by default", func() {
+		PanicStructured(panicMsg)
+		_ = ErrorStructured(errorMsg)
+		Panicf(panicMsg)
+		Expect(stack(sink.entries[0])).To(HavePrefix("goroutine "))
+		Expect(stack(sink.entries[1])).To(BeEmpty())
+		Expect(sink.entries[2].Message).To(Equal(panicMsg))
+		Expect(sink.entries[3].Message).To(Equal(stackTraceHeader))
+		Expect(sink.entries[4].Message).To(HavePrefix("goroutine "))
+		Expect(sink.entries[5].Message).To(Equal(stackTraceFooter))
+	})
+
+	It("attaches stack traces to the messages up to the configured level", func() {
+		SetStackTraceLevel(ErrorLevel)
+		_ = ErrorStructured(errorMsg)
+		_ = Errorf(errorMsg)
+		WarningStructured(warningMsg)
+		Expect(stack(sink.entries[0])).NotTo(BeEmpty())
+		Expect(sink.entries[2].Message).To(Equal(stackTraceHeader))
+		Expect(stack(sink.entries[5])).To(BeEmpty())
+
+		SetStackTraceLevel(InvalidLevel)
+		PanicStructured(panicMsg)
+		Expect(stack(sink.entries[6])).To(BeEmpty())
+	})
+
+	It("limits the depth and skips the frames of the logging functions", func() {
+		SetStackTraceOptions(&StackTraceOptions{MaxDepth: 2, SkipLoggingFrames: true})
+		PanicStructured(panicMsg)
+		trace := stack(sink.entries[0])
+		Expect(strings.Split(trace, "\n")[1]).To(ContainSubstring("stacktrace_test.go:"))
+		Expect(strings.Count(trace, "\n\t")).To(Equal(2))
+		Expect(trace).To(HaveSuffix(truncatedStackTrace))
+
+		SetStackTraceOptions(nil)
+		PanicStructured(panicMsg)
+		Expect(stack(sink.entries[1])).To(HavePrefix("goroutine "))
+	})
+})
@@ -0,0 +1,219 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Names of the outputs whose written bytes are counted, see LoggerStats.
+const (
+	statsStderr       = "stderr"
+	statsStdout       = "stdout"
+	statsFile         = "file"
+	statsErrorLog     = "errorLog"
+	statsSecondaryLog = "secondaryLog"
+	statsRoute        = "route"
+	statsOutput       = "output"
+	statsSyslog       = "syslog"
+)
+
+// LoggerStats is a snapshot of the statistics of the logger, see Stats.
+type LoggerStats struct {
+	// Messages holds the number of entries written per level.
+	Messages []MessageCount `json:"messages"`
+	// Outputs holds the number of bytes written per built-in output: "stderr", "stdout", "file", "errorLog", "output"
+	// for the outputs set with SetOutput and AddOutput, "route" for the route log files, see SetRoutes, and "syslog".
+	Outputs []OutputBytes `json:"outputs"`
+	// LastWriteError is the last error of an output or a sink which failed to write a message, empty if there was
+	// none, and LastWriteErrorTime the time it occurred at.
+	LastWriteError     string    `json:"lastWriteError,omitempty"`
+	LastWriteErrorTime time.Time `json:"lastWriteErrorTime"`
+	// LastRotation is the time the log file was last rotated, zero if it was not rotated.
+	LastRotation time.Time `json:"lastRotation"`
+	// Suppressed holds the number of messages suppressed by quiet mode per level and component, see SetQuietLevel.
+	Suppressed []SuppressedCount `json:"suppressed"`
+}
+
+// MessageCount is the number of entries of a level written to the outputs.
+type MessageCount struct {
+	Level Level  `json:"level"`
+	Count uint64 `json:"count"`
+}
+
+// OutputBytes is the number of bytes written to an output.
+type OutputBytes struct {
+	Output string `json:"output"`
+	Bytes  uint64 `json:"bytes"`
+}
+
+// writeFailure is the last write failure.
+type writeFailure struct {
+	err  string
+	time time.Time
+}
+
+// byteCounter counts the bytes written per output. It is safe for concurrent use.
+type byteCounter struct {
+	mu     sync.Mutex
+	counts map[string]uint64
+}
+
+var (
+	// messageCounts counts the entries written per level, updated atomically.
+	messageCounts [maximumLevel + 1]uint64
+	// writtenBytes counts the bytes written per output.
+	writtenBytes = &byteCounter{counts: map[string]uint64{}}
+	// lastWriteFailure holds the last writeFailure.
+	lastWriteFailure atomic.Value
+	// lastRotation is the time in Unix nanoseconds the log file was last rotated, updated atomically.
+	lastRotation int64
+)
+
+// add counts n bytes written to output. Outputs without a name are not counted.
+func (c *byteCounter) add(output string, n int) {
+	if output == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[output] += uint64(n)
+}
+
+// countMessage counts an entry written to the outputs.
+func countMessage(level Level) {
+	if level >= minimumLevel && level <= maximumLevel {
+		atomic.AddUint64(&messageCounts[level], 1)
+	}
+}
+
+// recordWriteFailure records err as the last write failure.
+func recordWriteFailure(err error) {
+	lastWriteFailure.Store(writeFailure{err: err.Error(), time: time.Now()})
+}
+
+// recordRotation records the rotation of the log file.
+func recordRotation() {
+	atomic.StoreInt64(&lastRotation, time.Now().UnixNano())
+}
+
+// resetStats discards all statistics.
+func resetStats() {
+	suppressed.reset()
+	for level := range messageCounts {
+		atomic.StoreUint64(&messageCounts[level], 0)
+	}
+	writtenBytes.mu.Lock()
+	writtenBytes.counts = map[string]uint64{}
+	writtenBytes.mu.Unlock()
+	lastWriteFailure.Store(writeFailure{})
+	atomic.StoreInt64(&lastRotation, 0)
+}
+
+// SuppressedCount is the number of messages of a level and component suppressed by quiet mode.
+type SuppressedCount struct {
+	Level     Level  `json:"level"`
+	Component string `json:"component"`
+	Count     uint64 `json:"count"`
+}
+
+// Stats returns the statistics of the logger since it was initialized, e.g. to expose them on the status endpoint of a
+// daemon. The counts are sorted by level, output and component.
+func Stats() LoggerStats {
+	stats := LoggerStats{Messages: []MessageCount{}, Outputs: []OutputBytes{}}
+	for level := range messageCounts {
+		if count := atomic.LoadUint64(&messageCounts[level]); count > 0 {
+			stats.Messages = append(stats.Messages, MessageCount{Level: Level(level), Count: count})
+		}
+	}
+
+	writtenBytes.mu.Lock()
+	for output, n := range writtenBytes.counts {
+		stats.Outputs = append(stats.Outputs, OutputBytes{Output: output, Bytes: n})
+	}
+	writtenBytes.mu.Unlock()
+	sort.Slice(stats.Outputs, func(i, j int) bool {
+		return stats.Outputs[i].Output < stats.Outputs[j].Output
+	})
+
+	if failure, ok := lastWriteFailure.Load().(writeFailure); ok {
+		stats.LastWriteError, stats.LastWriteErrorTime = failure.err, failure.time
+	}
+	if rotation := atomic.LoadInt64(&lastRotation); rotation != 0 {
+		stats.LastRotation = time.Unix(0, rotation)
+	}
+
+	suppressed.mu.Lock()
+	counts := make([]SuppressedCount, 0, len(suppressed.counts))
+	for key, count := range suppressed.counts {
+		counts = append(counts, SuppressedCount{Level: key.level, Component: key.component, Count: count})
+	}
+	suppressed.mu.Unlock()
+
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Level != counts[j].Level {
+			return counts[i].Level < counts[j].Level
+		}
+		return counts[i].Component < counts[j].Component
+	})
+	stats.Suppressed = counts
+	return stats
+}
+
+// labelEscaper escapes label values of the Prometheus text format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// WritePrometheus writes the statistics to w in the Prometheus text exposition format, so that daemons can serve them
+// on their metrics endpoint without depending on a Prometheus client library:
+//
+//	# TYPE cni_log_messages_total counter
+//	cni_log_messages_total{level="info"} 1024
+func (s LoggerStats) WritePrometheus(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintln(b, "# HELP cni_log_messages_total Entries written to the outputs.")
+	fmt.Fprintln(b, "# TYPE cni_log_messages_total counter")
+	for _, c := range s.Messages {
+		fmt.Fprintf(b, "cni_log_messages_total{level=\"%s\"} %d\n", c.Level, c.Count)
+	}
+	fmt.Fprintln(b, "# HELP cni_log_written_bytes_total Bytes written to the outputs.")
+	fmt.Fprintln(b, "# TYPE cni_log_written_bytes_total counter")
+	for _, o := range s.Outputs {
+		fmt.Fprintf(b, "cni_log_written_bytes_total{output=\"%s\"} %d\n", o.Output, o.Bytes)
+	}
+	if !s.LastWriteErrorTime.IsZero() {
+		fmt.Fprintln(b, "# HELP cni_log_last_write_error_timestamp_seconds Time of the last failed write.")
+		fmt.Fprintln(b, "# TYPE cni_log_last_write_error_timestamp_seconds gauge")
+		fmt.Fprintf(b, "cni_log_last_write_error_timestamp_seconds %d\n", s.LastWriteErrorTime.Unix())
+	}
+	if !s.LastRotation.IsZero() {
+		fmt.Fprintln(b, "# HELP cni_log_last_rotation_timestamp_seconds Time of the last rotation of the log file.")
+		fmt.Fprintln(b, "# TYPE cni_log_last_rotation_timestamp_seconds gauge")
+		fmt.Fprintf(b, "cni_log_last_rotation_timestamp_seconds %d\n", s.LastRotation.Unix())
+	}
+	fmt.Fprintln(b, "# HELP cni_log_suppressed_messages_total Messages counted but not written in quiet mode.")
+	fmt.Fprintln(b, "# TYPE cni_log_suppressed_messages_total counter")
+	for _, c := range s.Suppressed {
+		fmt.Fprintf(b, "cni_log_suppressed_messages_total{level=\"%s\",component=\"%s\"} %d\n", c.Level,
+			labelEscaper.Replace(c.Component), c.Count)
+	}
+	return b.Flush()
+}
@@ -0,0 +1,85 @@
+package logging
+
+import (
+	"bytes"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Stats", func() {
+	var out *failingWriter
+
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+		out = &failingWriter{}
+		SetOutput(out)
+	})
+
+	It("counts the messages per level and the bytes per output", func() {
+		var extra bytes.Buffer
+		AddOutput(&extra)
+		InfoStructured(infoMsg)
+		Infof(infoMsg)
+		Warningf(warningMsg)
+		DebugStructured(debugMsg)
+
+		stats := Stats()
+		Expect(stats.Messages).To(Equal([]MessageCount{{Level: WarningLevel, Count: 1}, {Level: InfoLevel, Count: 2}}))
+		Expect(stats.Outputs).To(Equal([]OutputBytes{
+			{Output: "file", Bytes: uint64(out.buf.Len())},
+			{Output: "output", Bytes: uint64(extra.Len())},
+		}))
+	})
+
+	It("records the last write error", func() {
+		Expect(Stats().LastWriteError).To(BeEmpty())
+		SetErrorHandler(func(error) {})
+		out.failing = true
+		start := time.Now()
+		Infof(infoMsg)
+
+		stats := Stats()
+		Expect(stats.LastWriteError).To(Equal("no space left on device"))
+		Expect(stats.LastWriteErrorTime).To(BeTemporally(">=", start))
+		Expect(stats.Outputs).To(BeEmpty())
+	})
+
+	It("records the last rotation of daily log files", func() {
+		dir, err := os.MkdirTemp("", "cni-log-stats")
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(os.RemoveAll, dir)
+
+		now := time.Date(2024, 1, 3, 23, 59, 0, 0, time.Local)
+		w := newDailyWriter(dir, "plugin", 0)
+		w.now = func() time.Time { return now }
+		defer w.Close()
+
+		_, err = w.Write([]byte("first\n"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(Stats().LastRotation).To(BeZero())
+
+		now = now.Add(2 * time.Minute)
+		_, err = w.Write([]byte("second\n"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(Stats().LastRotation).NotTo(BeZero())
+	})
+
+	It("exposes the statistics in the Prometheus text format", func() {
+		Infof(infoMsg)
+		out.failing = true
+		SetErrorHandler(func(error) {})
+		Infof(infoMsg)
+
+		var b bytes.Buffer
+		Expect(Stats().WritePrometheus(&b)).To(Succeed())
+		Expect(b.String()).To(ContainSubstring("# TYPE cni_log_messages_total counter\n" +
+			`cni_log_messages_total{level="info"} 2` + "\n"))
+		Expect(b.String()).To(MatchRegexp(`cni_log_written_bytes_total\{output="file"\} \d+\n`))
+		Expect(b.String()).To(MatchRegexp(`cni_log_last_write_error_timestamp_seconds \d+\n`))
+		Expect(b.String()).NotTo(ContainSubstring("cni_log_last_rotation_timestamp_seconds"))
+	})
+})
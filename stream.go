@@ -0,0 +1,108 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	stdoutPath = "/dev/stdout"
+	stderrPath = "/dev/stderr"
+	fdPrefix   = "fd:"
+
+	invalidFdFailMsg     = "cni-log: invalid file descriptor in '%s'"
+	notWritableFdFailMsg = "cni-log: file descriptor in '%s' is not open for writing"
+)
+
+// streamFiles holds the files opened for "fd:N" log files. They are never released, since the garbage collector
+// would close the file descriptors otherwise. Guarded by mu.
+var streamFiles = map[uintptr]*os.File{}
+
+// streamWriter writes the log messages directly to a stream, e.g. the stdout of a container, instead of a log file.
+// It does not expose Sync, since streams are usually pipes which cannot be synced.
+type streamWriter struct {
+	name string
+	// file is the file of a file descriptor, nil for stdout and stderr, which are looked up on every write like the
+	// stderr output.
+	file *os.File
+}
+
+// Write implements io.Writer.
+func (w *streamWriter) Write(p []byte) (int, error) {
+	switch {
+	case w.file != nil:
+		return w.file.Write(p)
+	case w.name == stdoutPath:
+		return os.Stdout.Write(p)
+	default:
+		return os.Stderr.Write(p)
+	}
+}
+
+// openStream returns a writer for the pseudo paths /dev/stdout, /dev/stderr and "fd:N", where N is a file descriptor
+// open for writing other than stdin. It returns false if filename is no pseudo path. The caller must hold mu.
+func openStream(filename string) (*streamWriter, bool, error) {
+	switch {
+	case filename == stdoutPath || filename == stderrPath:
+		return &streamWriter{name: filename}, true, nil
+	case !strings.HasPrefix(filename, fdPrefix):
+		return nil, false, nil
+	}
+
+	fd, err := strconv.ParseUint(strings.TrimPrefix(filename, fdPrefix), 10, 0)
+	if err != nil {
+		return nil, true, fmt.Errorf(invalidFdFailMsg, filename)
+	}
+	switch fd {
+	case 0:
+		return nil, true, fmt.Errorf(notWritableFdFailMsg, filename)
+	case 1:
+		return &streamWriter{name: stdoutPath}, true, nil
+	case 2:
+		return &streamWriter{name: stderrPath}, true, nil
+	}
+	f, ok := streamFiles[uintptr(fd)]
+	if !ok {
+		// os.NewFile accepts any descriptor, check it before wrapping it in a file which is never released
+		if !isWritableFd(uintptr(fd)) {
+			return nil, true, fmt.Errorf(notWritableFdFailMsg, filename)
+		}
+		if f = os.NewFile(uintptr(fd), filename); f == nil {
+			return nil, true, fmt.Errorf(invalidFdFailMsg, filename)
+		}
+		if _, err := f.Stat(); err != nil {
+			return nil, true, fmt.Errorf(invalidFdFailMsg, filename)
+		}
+		streamFiles[uintptr(fd)] = f
+	}
+	return &streamWriter{name: filename, file: f}, true, nil
+}
+
+// enableStreamLogging makes the logger write the messages for the log file to w. The caller must hold mu.
+func enableStreamLogging(w *streamWriter) {
+	logFileWriter.setFilename("")
+	setLogWriter(w)
+}
+
+// isStreamLoggingEnabled returns true if the messages for the log file are written to a stream. The caller must hold
+// mu.
+func isStreamLoggingEnabled() bool {
+	_, ok := logWriter.(*streamWriter)
+	return ok
+}
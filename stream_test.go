@@ -0,0 +1,77 @@
+package logging
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Stream log targets", func() {
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+	})
+
+	It("writes to stdout", func() {
+		r, w, err := os.Pipe()
+		Expect(err).NotTo(HaveOccurred())
+		stdout := os.Stdout
+		os.Stdout = w
+		defer func() { os.Stdout = stdout }()
+
+		SetLogFile("/dev/stdout")
+		Infof(infoMsg)
+		Expect(w.Close()).To(Succeed())
+		line, err := bufio.NewReader(r).ReadString('\n')
+		Expect(err).NotTo(HaveOccurred())
+		Expect(line).To(ContainSubstring(infoMsg))
+		Expect(logFileWriter.filename).To(BeEmpty())
+	})
+
+	It("writes to a file descriptor and keeps it across option changes", func() {
+		r, w, err := os.Pipe()
+		Expect(err).NotTo(HaveOccurred())
+		defer r.Close()
+
+		SetLogFile(fmt.Sprintf("fd:%d", w.Fd()))
+		SetLogOptions(&LogOptions{MaxSize: getPrimitivePointer(1)})
+		Infof(infoMsg)
+		line, err := bufio.NewReader(r).ReadString('\n')
+		Expect(err).NotTo(HaveOccurred())
+		Expect(line).To(ContainSubstring(infoMsg))
+
+		for _, value := range ExplainConfig() {
+			if value.Name == "logFile" {
+				Expect(value.Value).To(Equal(fmt.Sprintf("fd:%d", w.Fd())))
+			}
+		}
+	})
+
+	It("accepts the pseudo paths in the configuration", func() {
+		Expect(ApplyConfig(&Config{LogFile: "/dev/stderr"})).To(Succeed())
+		Expect(captureStdErrEvent(Infof, infoMsg)).To(ContainSubstring(infoMsg))
+		Expect(ApplyConfig(&Config{LogFile: "fd:x"})).To(MatchError(ContainSubstring("invalid file descriptor")))
+	})
+
+	It("rejects invalid file descriptors", func() {
+		Expect(captureStdErr(SetLogFile, "fd:-1")).To(ContainSubstring("invalid file descriptor"))
+		Expect(isFileLoggingEnabled()).To(BeFalse())
+	})
+
+	It("rejects file descriptors which are not open for writing", func() {
+		r, w, err := os.Pipe()
+		Expect(err).NotTo(HaveOccurred())
+		defer r.Close()
+		closed := w.Fd()
+		Expect(w.Close()).To(Succeed())
+
+		Expect(captureStdErr(SetLogFile, "fd:0")).To(ContainSubstring("is not open for writing"))
+		Expect(captureStdErr(SetLogFile, fmt.Sprintf("fd:%d", r.Fd()))).To(ContainSubstring("is not open for writing"))
+		Expect(captureStdErr(SetLogFile, fmt.Sprintf("fd:%d", closed))).To(ContainSubstring("is not open for writing"))
+		Expect(isFileLoggingEnabled()).To(BeFalse())
+		Expect(isStreamLoggingEnabled()).To(BeFalse())
+	})
+})
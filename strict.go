@@ -0,0 +1,65 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import "fmt"
+
+const (
+	loggingErrorKey   = "logging_error"
+	malformedFailMsg  = "cni-log: malformed structured logging call: %s"
+	danglingArgFormat = "%s, dropped %q"
+)
+
+// SetStrictMode makes malformed structured logging calls panic, e.g. calls with an odd number of arguments or a
+// structured prefixer returning an odd number of values. This is meant for tests, so that such calls are caught early.
+// Without strict mode, which is the default, the message is logged with a "logging_error" field describing the problem
+// and the error handler is called, see SetErrorHandler; a logging library must not crash the plugin.
+func SetStrictMode(enable bool) {
+	mu.Lock()
+	defer unlockAndPublish()
+	strictMode = enable
+}
+
+// SetErrorHandler sets a function which is called with an error for every malformed structured logging call, for every
+// failed write to an output or sink and for every panic of a resolver, see SetResolver, e.g. to count them in a metric
+// or to fall back on another output. Write failures are passed as a *WriteError. Passing nil removes the handler; write failures are then reported to stderr at
+// most once a minute. The handler is called synchronously and must not log itself.
+func SetErrorHandler(handler func(error)) {
+	mu.Lock()
+	defer unlockAndPublish()
+	errorHandler = handler
+}
+
+// evenArgs returns args with an even length. A dangling last argument is dropped and described, together with failure,
+// in a "logging_error" field appended to the returned args. In strict mode, it panics instead with output, the
+// rendering of the fields logged so far.
+func (s *snapshot) evenArgs(output, failure string, args []interface{}) []interface{} {
+	if len(args)%2 == 0 {
+		return args
+	}
+
+	if s.strict {
+		if output != "" {
+			output += " "
+		}
+		panic(output + fmt.Sprintf("logging_failure=%q", failure))
+	}
+	reason := fmt.Sprintf(danglingArgFormat, failure, argToString(args[len(args)-1]))
+	if s.errorHandler != nil {
+		s.errorHandler(fmt.Errorf(malformedFailMsg, reason))
+	}
+	even := make([]interface{}, 0, len(args)+1)
+	return append(append(even, args[:len(args)-1]...), loggingErrorKey, reason)
+}
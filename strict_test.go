@@ -0,0 +1,53 @@
+package logging
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Malformed structured logging calls", func() {
+	var sink *captureSink
+	var errs []error
+
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+		sink = &captureSink{}
+		AddSink(sink)
+		errs = nil
+		SetErrorHandler(func(err error) { errs = append(errs, err) })
+	})
+
+	It("logs a logging_error field instead of panicking on an odd number of arguments", func() {
+		InfoStructured(infoMsg, "a", "b", "c")
+		Expect(sink.entries[0].String()).To(HaveSuffix(fmt.Sprintf(`a="b" logging_error="%s, dropped \"c\""`,
+			structuredLoggingOddArguments)))
+		Expect(errs).To(ConsistOf(MatchError(ContainSubstring(structuredLoggingOddArguments))))
+	})
+
+	It("logs a logging_error field for invalid structured prefixes", func() {
+		SetStructuredPrefixer(StructuredPrefixerFunc(func(Level, string) []interface{} {
+			return []interface{}{"component", "ipam", "invalid"}
+		}))
+		InfoStructured(infoMsg, "a", "b")
+		Expect(sink.entries[0].String()).To(Equal(fmt.Sprintf(`component="ipam" logging_error="%s, dropped \"invalid\"" a="b"`,
+			structuredPrefixerOddArguments)))
+		Expect(errs).To(HaveLen(1))
+	})
+
+	It("adds a logging_error field to the context of loggers", func() {
+		With("pod").InfoStructured(infoMsg)
+		Expect(sink.entries[0].String()).To(HaveSuffix(fmt.Sprintf(`logging_error="%s, dropped \"pod\""`,
+			structuredLoggingOddArguments)))
+		Expect(errs).To(HaveLen(1))
+	})
+
+	It("panics in strict mode", func() {
+		SetStrictMode(true)
+		Expect(func() { InfoStructured(infoMsg, "a") }).To(PanicWith(MatchRegexp(
+			fmt.Sprintf(`msg=%q logging_failure=%q$`, infoMsg, structuredLoggingOddArguments))))
+		Expect(errs).To(BeEmpty())
+	})
+})
@@ -0,0 +1,117 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"errors"
+	"fmt"
+)
+
+// writew writes a structured message with the alternating keys and values of args, whose msg is format rendered with
+// a. The message is only rendered if it is logged.
+func writew(s *snapshot, level Level, format string, a []interface{}, args []interface{}) {
+	if s.enabled(level) || s.escalated(level, args) || level <= s.ruleLevel {
+		writeStructured(s, level, fmt.Sprintf(format, a...), true, args...)
+	}
+}
+
+// errorw writes a structured error message with the alternating keys and values of args, whose msg is format rendered
+// with a, and returns it as an error. Like the error returned by Errorf, it wraps the arguments of %w verbs.
+func errorw(s *snapshot, format string, a []interface{}, args []interface{}) error {
+	formatted := fmt.Errorf(format, a...)
+	err := errorStructured(s, formatted.Error(), args...)
+	if wrapped := errors.Unwrap(formatted); wrapped != nil {
+		return &structuredError{msg: err.Error(), err: wrapped}
+	}
+	return err
+}
+
+// Fatalw works like FatalFields, but renders the message from format and a like Fatalf.
+func Fatalw(format string, a []interface{}, fields ...Field) {
+	writew(loadSnapshot(), FatalLevel, format, a, fieldArgs(fields))
+	Flush()
+	exit(1)
+}
+
+// Panicw works like PanicFields, but renders the message from format and a like Panicf.
+func Panicw(format string, a []interface{}, fields ...Field) {
+	writew(loadSnapshot(), PanicLevel, format, a, fieldArgs(fields))
+}
+
+// Errorw works like ErrorFields, but renders the message from format and a like Errorf. The returned error wraps the
+// arguments of %w verbs.
+func Errorw(format string, a []interface{}, fields ...Field) error {
+	return errorw(loadSnapshot(), format, a, fieldArgs(fields))
+}
+
+// Warningw works like WarningFields, but renders the message from format and a like Warningf.
+func Warningw(format string, a []interface{}, fields ...Field) {
+	writew(loadSnapshot(), WarningLevel, format, a, fieldArgs(fields))
+}
+
+// Infow works like InfoFields, but renders the message from format and a like Infof, e.g.
+//
+//	logging.Infow("added interface %s", []interface{}{args.IfName}, logging.Int("mtu", mtu))
+func Infow(format string, a []interface{}, fields ...Field) {
+	writew(loadSnapshot(), InfoLevel, format, a, fieldArgs(fields))
+}
+
+// Debugw works like DebugFields, but renders the message from format and a like Debugf.
+func Debugw(format string, a []interface{}, fields ...Field) {
+	writew(loadSnapshot(), DebugLevel, format, a, fieldArgs(fields))
+}
+
+// Tracew works like TraceFields, but renders the message from format and a like Tracef.
+func Tracew(format string, a []interface{}, fields ...Field) {
+	writew(loadSnapshot(), TraceLevel, format, a, fieldArgs(fields))
+}
+
+// Fatalw works like FatalFields, but renders the message from format and a like Fatalf.
+func (l *Logger) Fatalw(format string, a []interface{}, fields ...Field) {
+	writew(l.snapshot(), FatalLevel, format, a, l.args(fieldArgs(fields)))
+	Flush()
+	exit(1)
+}
+
+// Panicw works like PanicFields, but renders the message from format and a like Panicf.
+func (l *Logger) Panicw(format string, a []interface{}, fields ...Field) {
+	writew(l.snapshot(), PanicLevel, format, a, l.args(fieldArgs(fields)))
+}
+
+// Errorw works like ErrorFields, but renders the message from format and a like Errorf. The returned error wraps the
+// arguments of %w verbs.
+func (l *Logger) Errorw(format string, a []interface{}, fields ...Field) error {
+	return errorw(l.snapshot(), format, a, l.args(fieldArgs(fields)))
+}
+
+// Warningw works like WarningFields, but renders the message from format and a like Warningf.
+func (l *Logger) Warningw(format string, a []interface{}, fields ...Field) {
+	writew(l.snapshot(), WarningLevel, format, a, l.args(fieldArgs(fields)))
+}
+
+// Infow works like InfoFields, but renders the message from format and a like Infof.
+func (l *Logger) Infow(format string, a []interface{}, fields ...Field) {
+	writew(l.snapshot(), InfoLevel, format, a, l.args(fieldArgs(fields)))
+}
+
+// Debugw works like DebugFields, but renders the message from format and a like Debugf.
+func (l *Logger) Debugw(format string, a []interface{}, fields ...Field) {
+	writew(l.snapshot(), DebugLevel, format, a, l.args(fieldArgs(fields)))
+}
+
+// Tracew works like TraceFields, but renders the message from format and a like Tracef.
+func (l *Logger) Tracew(format string, a []interface{}, fields ...Field) {
+	writew(l.snapshot(), TraceLevel, format, a, l.args(fieldArgs(fields)))
+}
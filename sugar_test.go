@@ -0,0 +1,64 @@
+package logging
+
+import (
+	"errors"
+	"io/fs"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Format and fields", func() {
+	var sink *captureSink
+
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+		SetLogLevel(TraceLevel)
+		sink = &captureSink{}
+		AddSink(sink)
+	})
+
+	It("renders the message like printf and logs the fields like a structured message", func() {
+		Infow("added interface %s to %s", []interface{}{"net1", "ns1"}, String("mac", "0a:58:0a:f4:00:05"),
+			Int("mtu", 1400))
+		Expect(sink.entries).To(HaveLen(1))
+		Expect(sink.entries[0].Structured()).To(BeTrue())
+		Expect(sink.entries[0].Message).To(Equal("added interface net1 to ns1"))
+		Expect(sink.entries[0].String()).To(HaveSuffix(
+			`msg="added interface net1 to ns1" mac="0a:58:0a:f4:00:05" mtu="1400"`))
+	})
+
+	It("does not render filtered messages", func() {
+		SetLogLevel(InfoLevel)
+		rendered := false
+		Debugw("%v", []interface{}{Lazy(func() interface{} {
+			rendered = true
+			return "value"
+		})})
+		Expect(sink.entries).To(BeEmpty())
+		Expect(rendered).To(BeFalse())
+	})
+
+	It("returns an error wrapping the arguments of %w verbs", func() {
+		err := Errorw("cannot open %s: %w", []interface{}{"netns", fs.ErrNotExist}, String("ifname", "net1"))
+		Expect(errors.Is(err, fs.ErrNotExist)).To(BeTrue())
+		Expect(err.Error()).To(HaveSuffix(`msg="cannot open netns: file does not exist" ifname="net1"`))
+		Expect(sink.entries[0].String()).To(Equal(err.Error()))
+
+		Expect(errors.Unwrap(Errorw("no %s", []interface{}{"route"}))).To(BeNil())
+	})
+
+	It("adds the context of a Logger", func() {
+		With("containerID", "c1").Warningw("retrying in %ds", []interface{}{5}, Int("attempt", 2))
+		Expect(sink.entries[0].String()).To(HaveSuffix(`msg="retrying in 5s" containerID="c1" attempt="2"`))
+	})
+
+	It("exits after fatal messages", func() {
+		exitCode := 0
+		SetExitFunc(func(code int) { exitCode = code })
+		Fatalw("cannot continue: %s", []interface{}{"no IPAM"})
+		Expect(exitCode).To(Equal(1))
+		Expect(sink.entries[0].Message).To(Equal("cannot continue: no IPAM"))
+	})
+})
@@ -0,0 +1,124 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	symlinkDirFailMsg     = "cni-log: symlink policy directory must not be empty"
+	symlinkOutsideFailMsg = "cni-log: path '%s' resolves to '%s' outside of '%s'"
+)
+
+type symlinkMode int
+
+const (
+	symlinkDeny symlinkMode = iota
+	symlinkResolveWithin
+	symlinkAllow
+)
+
+// SymlinkPolicy decides whether the log files may be symbolic links, see SetSymlinkPolicy.
+type SymlinkPolicy struct {
+	mode symlinkMode
+	dir  string
+}
+
+var (
+	// SymlinkPolicyDeny rejects log files which are symbolic links. Symbolic links in the directories of a path are
+	// followed. This is the default.
+	SymlinkPolicyDeny = SymlinkPolicy{mode: symlinkDeny}
+	// SymlinkPolicyAllow follows all symbolic links, wherever they point to.
+	SymlinkPolicyAllow = SymlinkPolicy{mode: symlinkAllow}
+)
+
+// SymlinkPolicyResolveWithin resolves all symbolic links of a path, including the log file itself, and accepts the
+// path only if its target is inside dir. dir is resolved as well, so it may be a symbolic link itself, e.g. /var/log
+// on hosts which keep their logs on another partition. The log files are opened at their resolved path.
+func SymlinkPolicyResolveWithin(dir string) SymlinkPolicy {
+	return SymlinkPolicy{mode: symlinkResolveWithin, dir: dir}
+}
+
+// SetSymlinkPolicy sets how symbolic links are handled in the paths of the log file, the error log file, the secondary
+// log file, the ring file and the directory of daily log files. A path which violates the policy is rejected like an
+// unwritable path. With a root directory, see SetRootDir, symbolic links are followed within the root directory and
+// the directory of SymlinkPolicyResolveWithin is relative to it. The policy applies to the paths set after the call.
+func SetSymlinkPolicy(policy SymlinkPolicy) error {
+	if policy.mode == symlinkResolveWithin && policy.dir == "" {
+		return fmt.Errorf(symlinkDirFailMsg)
+	}
+
+	mu.Lock()
+	defer unlockAndPublish()
+	symlinkPolicy = policy
+	return nil
+}
+
+// resolveSymlinks applies the symlink policy to path. The caller must hold mu.
+func resolveSymlinks(path string) (string, error) {
+	policy := symlinkPolicy
+	if rootDir != "" {
+		resolved, err := joinRoot(rootDir, path, policy.mode != symlinkDeny)
+		if err != nil || policy.mode != symlinkResolveWithin {
+			return resolved, err
+		}
+		return resolveWithin(rootDir, path, resolved, policy.dir)
+	}
+
+	switch policy.mode {
+	case symlinkAllow:
+		return filepath.Clean(path), nil
+	case symlinkResolveWithin:
+		// Without a root directory, the links are resolved within "/", which follows them wherever they point to.
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return "", err
+		}
+		resolved, err := joinRoot(string(filepath.Separator), abs, true)
+		if err != nil {
+			return "", err
+		}
+		return resolveWithin(string(filepath.Separator), path, resolved, policy.dir)
+	}
+
+	if isSymLink(path) {
+		return "", configErrorf(ErrSymlinkRejected, symlinkEvalFailMsg, path)
+	}
+	return filepath.Clean(path), nil
+}
+
+// resolveWithin returns resolved, the resolution of path within root, if it is inside dir resolved within root.
+func resolveWithin(root, path, resolved, dir string) (string, error) {
+	if root == string(filepath.Separator) {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return "", err
+		}
+		dir = abs
+	}
+	dir, err := joinRoot(root, dir, true)
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(dir, resolved)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", configErrorf(ErrSymlinkRejected, symlinkOutsideFailMsg, path, resolved, dir)
+	}
+	return resolved, nil
+}
@@ -0,0 +1,94 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Symlink policy", func() {
+	var dir string
+
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+		var err error
+		dir, err = os.MkdirTemp("", "cni-log-symlink")
+		Expect(err).NotTo(HaveOccurred())
+		dir, err = filepath.EvalSymlinks(dir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(dir, "data", "log"), 0755)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(dir, "other"), 0755)).To(Succeed())
+		// /var/log -> /data/log, as on hosts which keep their logs on another partition.
+		Expect(os.MkdirAll(filepath.Join(dir, "var"), 0755)).To(Succeed())
+		Expect(os.Symlink("../data/log", filepath.Join(dir, "var", "log"))).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("rejects a symbolic link as log file by default", func() {
+		link := filepath.Join(dir, "var", "log", "plugin.log")
+		Expect(os.Symlink(filepath.Join(dir, "data", "log", "target.log"), link)).To(Succeed())
+		Expect(captureStdErr(SetLogFile, link)).To(ContainSubstring("unable to evaluate symbolic links"))
+		Expect(logFileWriter.filename).To(BeEmpty())
+	})
+
+	It("follows symbolic links which stay inside the allowed directory", func() {
+		Expect(SetSymlinkPolicy(SymlinkPolicyResolveWithin(filepath.Join(dir, "var", "log")))).To(Succeed())
+		link := filepath.Join(dir, "var", "log", "plugin.log")
+		Expect(os.Symlink("target.log", link)).To(Succeed())
+
+		SetLogFile(link)
+		Expect(logFileWriter.filename).To(Equal(filepath.Join(dir, "data", "log", "target.log")))
+		Infof(infoMsg)
+		Expect(logFileContains(filepath.Join(dir, "data", "log", "target.log"), infoMsg)).To(BeTrue())
+
+		// The previous log file was written, so lumberjack's cleanup may still run while the log file is switched.
+		SetLogFile(filepath.Join(dir, "var", "log", "new", "plugin.log"))
+		Expect(logFileWriter.filename).To(Equal(filepath.Join(dir, "data", "log", "new", "plugin.log")))
+		Warningf(warningMsg)
+		Expect(logFileContains(filepath.Join(dir, "data", "log", "new", "plugin.log"), warningMsg)).To(BeTrue())
+		Expect(logFileContains(filepath.Join(dir, "data", "log", "target.log"), warningMsg)).To(BeFalse())
+	})
+
+	It("rejects symbolic links which leave the allowed directory", func() {
+		Expect(SetSymlinkPolicy(SymlinkPolicyResolveWithin(filepath.Join(dir, "var", "log")))).To(Succeed())
+		link := filepath.Join(dir, "var", "log", "plugin.log")
+		Expect(os.Symlink(filepath.Join(dir, "other", "plugin.log"), link)).To(Succeed())
+		Expect(captureStdErr(SetLogFile, link)).To(ContainSubstring("outside of"))
+		Expect(logFileWriter.filename).To(BeEmpty())
+
+		dangling := filepath.Join(dir, "var", "log", "dangling.log")
+		Expect(os.Symlink(filepath.Join(dir, "missing", "plugin.log"), dangling)).To(Succeed())
+		_, err := resolvePath(dangling)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("follows any symbolic link if allowed", func() {
+		Expect(SetSymlinkPolicy(SymlinkPolicyAllow)).To(Succeed())
+		link := filepath.Join(dir, "var", "log", "plugin.log")
+		Expect(os.Symlink(filepath.Join(dir, "other", "plugin.log"), link)).To(Succeed())
+		SetLogFile(link)
+		Infof(infoMsg)
+		Expect(logFileContains(filepath.Join(dir, "other", "plugin.log"), infoMsg)).To(BeTrue())
+	})
+
+	It("resolves the links within the root directory", func() {
+		Expect(SetRootDir(dir)).To(Succeed())
+		Expect(SetSymlinkPolicy(SymlinkPolicyResolveWithin("/var/log"))).To(Succeed())
+		Expect(os.Symlink("/data/log/target.log", filepath.Join(dir, "data", "log", "plugin.log"))).To(Succeed())
+		Expect(resolvePath("/var/log/plugin.log")).To(Equal(filepath.Join(dir, "data", "log", "target.log")))
+
+		Expect(os.Symlink("/other/plugin.log", filepath.Join(dir, "data", "log", "escape.log"))).To(Succeed())
+		_, err := resolvePath("/var/log/escape.log")
+		Expect(err).To(MatchError(ContainSubstring("outside of")))
+	})
+
+	It("requires a directory to resolve within", func() {
+		Expect(SetSymlinkPolicy(SymlinkPolicyResolveWithin(""))).To(HaveOccurred())
+	})
+})
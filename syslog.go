@@ -0,0 +1,312 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// syslogFacility is the syslog facility of all messages, LOG_DAEMON.
+	syslogFacility = 3
+
+	syslogTimestamp5424 = "2006-01-02T15:04:05.000000Z07:00"
+	syslogNilValue      = "-"
+
+	syslogConnectFailMsg = "cni-log: unable to connect to syslog: %v"
+
+	// syslogMaxUDPSize is the size of the messages all syslog servers must accept via UDP (RFC 5426).
+	syslogMaxUDPSize = 2048
+	// syslogMaxLocalSize is the default maximum message size of the common local syslog daemons.
+	syslogMaxLocalSize = 8192
+)
+
+// Syslog severities.
+const (
+	syslogCrit    = 2
+	syslogErr     = 3
+	syslogWarning = 4
+	syslogInfo    = 6
+	syslogDebug   = 7
+)
+
+// syslogSocketPaths are the paths of the local syslog socket on the different platforms.
+var syslogSocketPaths = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// syslogWriter writes log messages to syslog, either to the local syslog daemon or to a remote one in RFC 5424 format.
+type syslogWriter struct {
+	mu       sync.Mutex
+	network  string
+	addr     string
+	tag      string
+	hostname string
+	// tlsConfig secures the connection to a remote syslog server if set.
+	tlsConfig *tls.Config
+	conn      net.Conn
+	// localNetwork is the network of the connection to the local syslog daemon, "unixgram" or "unix".
+	localNetwork string
+}
+
+// syslogSeverity maps a logging level to a syslog severity.
+func syslogSeverity(level Level) int {
+	switch level {
+	case FatalLevel, PanicLevel:
+		return syslogCrit
+	case ErrorLevel:
+		return syslogErr
+	case WarningLevel:
+		return syslogWarning
+	case InfoLevel:
+		return syslogInfo
+	default:
+		return syslogDebug
+	}
+}
+
+// newSyslogWriter connects to syslog. An empty network connects to the local syslog daemon. A remote syslog server is
+// connected to via TLS if tlsConfig is set.
+func newSyslogWriter(network, addr, tag string, tlsConfig *tls.Config) (*syslogWriter, error) {
+	if tag == "" {
+		tag = filepath.Base(os.Args[0])
+	}
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = syslogNilValue
+	}
+
+	w := &syslogWriter{
+		network:   network,
+		addr:      addr,
+		tag:       tag,
+		hostname:  hostname,
+		tlsConfig: tlsConfig,
+	}
+	if err := w.connect(); err != nil {
+		return nil, fmt.Errorf(syslogConnectFailMsg, err)
+	}
+	return w, nil
+}
+
+// isLocal returns true if the writer logs to the local syslog daemon.
+func (w *syslogWriter) isLocal() bool {
+	return w.network == ""
+}
+
+// connect (re)connects to syslog. The caller must hold w.mu unless w is not shared yet.
+func (w *syslogWriter) connect() error {
+	if w.conn != nil {
+		w.conn.Close()
+		w.conn = nil
+	}
+
+	if !w.isLocal() {
+		conn, err := dialNetwork(w.network, w.addr, w.tlsConfig)
+		if err != nil {
+			return err
+		}
+		w.conn = conn
+		return nil
+	}
+
+	paths := syslogSocketPaths
+	if w.addr != "" {
+		paths = []string{w.addr}
+	}
+	for _, network := range []string{"unixgram", "unix"} {
+		for _, path := range paths {
+			conn, err := net.Dial(network, path)
+			if err == nil {
+				w.conn = conn
+				w.localNetwork = network
+				return nil
+			}
+		}
+	}
+	return errors.New("no local syslog socket found")
+}
+
+// format formats a syslog message. Local messages use the traditional format understood by all local syslog
+// daemons, remote messages use RFC 5424.
+func (w *syslogWriter) format(level Level, msg string) string {
+	priority := syslogFacility*8 + syslogSeverity(level)
+	msg = strings.TrimRight(msg, "\n")
+
+	if w.isLocal() {
+		return fmt.Sprintf("<%d>%s %s[%d]: %s", priority, logTime().Format(time.Stamp), w.tag, logPid(), msg)
+	}
+
+	line := fmt.Sprintf("<%d>1 %s %s %s %d %s %s %s", priority, logTime().Format(syslogTimestamp5424), w.hostname,
+		w.tag, logPid(), syslogNilValue, syslogNilValue, msg)
+	if strings.HasPrefix(w.network, "udp") {
+		return line
+	}
+	// Stream transports use octet counting framing (RFC 6587).
+	return fmt.Sprintf("%d %s", len(line), line)
+}
+
+// maxMessageSize returns the maximum size of a log message of the given level which fits into a single datagram of
+// the transport, 0 for stream transports.
+func (w *syslogWriter) maxMessageSize(level Level) int {
+	limit := 0
+	switch {
+	case strings.HasPrefix(w.network, "udp"):
+		limit = syslogMaxUDPSize
+	case w.isLocal():
+		w.mu.Lock()
+		stream := w.localNetwork == "unix"
+		w.mu.Unlock()
+		if !stream {
+			limit = syslogMaxLocalSize
+		}
+	default:
+		return 0
+	}
+	return limit - len(w.format(level, ""))
+}
+
+// write writes a log message of the given level. If the connection is broken, syslog is reconnected once.
+func (w *syslogWriter) write(level Level, msg string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn != nil {
+		if _, err := w.conn.Write([]byte(w.record(level, msg))); err == nil {
+			return nil
+		}
+	}
+
+	if err := w.connect(); err != nil {
+		return err
+	}
+	// The reconnected socket may be a stream socket where the previous one was a datagram socket or vice versa.
+	_, err := w.conn.Write([]byte(w.record(level, msg)))
+	return err
+}
+
+// record returns the formatted message as it is written to the connection. Messages sent to the local syslog daemon
+// over a stream socket are terminated by a newline like log/syslog does, since nothing else separates them. The caller
+// must hold w.mu.
+func (w *syslogWriter) record(level Level, msg string) string {
+	line := w.format(level, msg)
+	if w.isLocal() && w.localNetwork == "unix" {
+		return line + "\n"
+	}
+	return line
+}
+
+// close closes the connection to syslog.
+func (w *syslogWriter) close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+// SetSyslog enables logging to syslog in addition to the other outputs, which allows collecting logs on nodes without
+// writable host paths. An empty network logs to the local syslog daemon through its socket, addr optionally overriding
+// the socket path (/dev/log by default). Otherwise, messages are sent to the syslog server at addr via network ("udp"
+// or "tcp") in RFC 5424 format. tag identifies the program and defaults to the name of the executable. Logging levels
+// are mapped to syslog severities, all messages use the daemon facility.
+func SetSyslog(network, addr, tag string) error {
+	w, err := newSyslogWriter(network, addr, tag, nil)
+	if err != nil {
+		return err
+	}
+	setSyslogWriter(w)
+	return nil
+}
+
+// SetSyslogTLS works like SetSyslog, but sends the messages to the syslog server at addr via TLS (RFC 5425), since
+// the traffic from the node to the server may cross untrusted networks. options configures the verification of the
+// server and the client certificate; nil options verify the server against the certificate authorities of the system.
+func SetSyslogTLS(addr, tag string, options *TLSOptions) error {
+	if options == nil {
+		options = &TLSOptions{}
+	}
+	tlsConfig, err := options.tlsConfig()
+	if err != nil {
+		return err
+	}
+
+	w, err := newSyslogWriter("tcp", addr, tag, tlsConfig)
+	if err != nil {
+		return err
+	}
+	setSyslogWriter(w)
+	return nil
+}
+
+// setSyslogWriter replaces the syslog output by w.
+func setSyslogWriter(w *syslogWriter) {
+	mu.Lock()
+	defer unlockAndPublish()
+
+	if syslogOutput != nil {
+		_ = syslogOutput.close()
+	}
+	syslogOutput = w
+}
+
+// DisableSyslog disables logging to syslog.
+func DisableSyslog() error {
+	mu.Lock()
+	defer unlockAndPublish()
+
+	if syslogOutput == nil {
+		return nil
+	}
+	err := syslogOutput.close()
+	syslogOutput = nil
+	return err
+}
+
+// SetSyslogSendTime adds capture_time and send_time fields to the messages sent to syslog, so that a remote collector
+// can measure delays and correct for the clock skew of the node. See Entry.WithSendTime.
+func SetSyslogSendTime(enable bool) {
+	mu.Lock()
+	defer unlockAndPublish()
+	syslogSendTime = enable
+}
+
+// SetSyslogPayloadHook sets a hook which transforms the message part of every syslog message before it is sent, e.g.
+// to encrypt or compress it; the syslog header stays readable so that the server can still route the messages. The
+// size limits of datagram transports apply to the untransformed message. Syslog messages are text, so hooks producing
+// binary data should encode it, e.g. in base64. Passing nil removes the hook.
+func SetSyslogPayloadHook(hook PayloadHook) {
+	mu.Lock()
+	defer unlockAndPublish()
+	syslogPayloadHook = hook
+}
+
+// SetSyslogFields restricts the fields of structured log messages which are written to syslog to the provided keys.
+// Calling it without any keys writes all fields again.
+func SetSyslogFields(keys ...string) {
+	mu.Lock()
+	defer unlockAndPublish()
+	syslogFields = fieldSet(keys)
+}
@@ -0,0 +1,229 @@
+package logging
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Syslog output", func() {
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+		SetLogLevel(DebugLevel)
+	})
+
+	AfterEach(func() {
+		Expect(DisableSyslog()).To(Succeed())
+	})
+
+	readDatagram := func(conn net.PacketConn) string {
+		buf := make([]byte, 4096)
+		n, _, err := conn.ReadFrom(buf)
+		Expect(err).NotTo(HaveOccurred())
+		return string(buf[:n])
+	}
+
+	When("logging to a remote syslog server via UDP", func() {
+		It("sends RFC 5424 messages with the mapped severity", func() {
+			conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			defer conn.Close()
+
+			Expect(SetSyslog("udp", conn.LocalAddr().String(), "cni-test")).To(Succeed())
+
+			Warningf(warningMsg)
+			Expect(readDatagram(conn)).To(MatchRegexp(
+				fmt.Sprintf(`^<28>1 \S+ \S+ cni-test %d - - .*\[warning\] %s$`, os.Getpid(), warningMsg)))
+
+			Debugf(debugMsg)
+			Expect(readDatagram(conn)).To(HavePrefix("<31>1 "))
+
+			InfoStructured(infoMsg, "a", "b")
+			Expect(readDatagram(conn)).To(MatchRegexp(fmt.Sprintf(`^<30>1 .* level=%q msg=%q a="b"$`, infoStr, infoMsg)))
+		})
+
+		It("annotates messages with the capture and send time", func() {
+			conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			defer conn.Close()
+
+			Expect(SetSyslog("udp", conn.LocalAddr().String(), "cni-test")).To(Succeed())
+			SetSyslogSendTime(true)
+
+			Infof(infoMsg)
+			Expect(readDatagram(conn)).To(MatchRegexp(fmt.Sprintf(`%s capture_time=".+" send_time=".+"$`, infoMsg)))
+
+			InfoStructured(infoMsg, "a", "b")
+			Expect(readDatagram(conn)).To(MatchRegexp(`a="b" capture_time=".+" send_time=".+"$`))
+		})
+	})
+
+	When("logging to a remote syslog server via TCP", func() {
+		It("frames messages with their length", func() {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			defer listener.Close()
+
+			Expect(SetSyslog("tcp", listener.Addr().String(), "cni-test")).To(Succeed())
+			conn, err := listener.Accept()
+			Expect(err).NotTo(HaveOccurred())
+			defer conn.Close()
+
+			_ = ErrorStructured(errorMsg)
+			reader := bufio.NewReader(conn)
+			var length int
+			_, err = fmt.Fscanf(reader, "%d ", &length)
+			Expect(err).NotTo(HaveOccurred())
+			msg := make([]byte, length)
+			_, err = reader.Read(msg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(msg)).To(MatchRegexp(fmt.Sprintf(`^<27>1 .* msg=%q$`, errorMsg)))
+		})
+	})
+
+	When("logging to a remote syslog server via TLS", func() {
+		It("authenticates the server and the client", func() {
+			dir, err := os.MkdirTemp("", "cni-log-syslog-tls")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(dir)
+			certFile, keyFile := writeTestCertificate(dir)
+
+			cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+			Expect(err).NotTo(HaveOccurred())
+			leaf, err := x509.ParseCertificate(cert.Certificate[0])
+			Expect(err).NotTo(HaveOccurred())
+			pool := x509.NewCertPool()
+			pool.AddCert(leaf)
+			listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+				Certificates: []tls.Certificate{cert},
+				ClientAuth:   tls.RequireAndVerifyClientCert,
+				ClientCAs:    pool,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			defer listener.Close()
+
+			received := make(chan string, 1)
+			go func() {
+				defer GinkgoRecover()
+				conn, err := listener.Accept()
+				Expect(err).NotTo(HaveOccurred())
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				var length int
+				_, err = fmt.Fscanf(reader, "%d ", &length)
+				Expect(err).NotTo(HaveOccurred())
+				msg := make([]byte, length)
+				_, err = io.ReadFull(reader, msg)
+				Expect(err).NotTo(HaveOccurred())
+				received <- string(msg)
+			}()
+
+			Expect(SetSyslogTLS(listener.Addr().String(), "cni-test", &TLSOptions{
+				CAFile: certFile, CertFile: certFile, KeyFile: keyFile, ServerName: "localhost",
+			})).To(Succeed())
+			Warningf(warningMsg)
+			Eventually(received).Should(Receive(MatchRegexp(fmt.Sprintf(`^<28>1 .* %s$`, warningMsg))))
+		})
+
+		It("fails if the server is not trusted", func() {
+			dir, err := os.MkdirTemp("", "cni-log-syslog-tls")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(dir)
+			certFile, keyFile := writeTestCertificate(dir)
+			cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+			Expect(err).NotTo(HaveOccurred())
+
+			listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+			Expect(err).NotTo(HaveOccurred())
+			defer listener.Close()
+			go func() {
+				conn, err := listener.Accept()
+				if err == nil {
+					_ = conn.(*tls.Conn).Handshake()
+					conn.Close()
+				}
+			}()
+
+			Expect(SetSyslogTLS(listener.Addr().String(), "cni-test", nil)).NotTo(Succeed())
+		})
+	})
+
+	When("a payload hook is set", func() {
+		It("transforms the message part", func() {
+			conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			defer conn.Close()
+
+			Expect(SetSyslog("udp", conn.LocalAddr().String(), "cni-test")).To(Succeed())
+			SetSyslogFields("msg")
+			SetSyslogPayloadHook(func(payload []byte) ([]byte, error) {
+				return []byte(base64.StdEncoding.EncodeToString(payload)), nil
+			})
+
+			InfoStructured(infoMsg)
+			encoded := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("msg=%q", infoMsg)))
+			Expect(readDatagram(conn)).To(MatchRegexp(fmt.Sprintf(`^<30>1 .* cni-test %d - - %s$`, os.Getpid(), encoded)))
+		})
+	})
+
+	When("logging to the local syslog daemon", func() {
+		It("sends messages to the local socket", func() {
+			socket := path.Join(os.TempDir(), "cni-log-test-syslog.sock")
+			_ = os.Remove(socket)
+			conn, err := net.ListenPacket("unixgram", socket)
+			Expect(err).NotTo(HaveOccurred())
+			defer os.Remove(socket)
+			defer conn.Close()
+
+			Expect(SetSyslog("", socket, "cni-test")).To(Succeed())
+			SetSyslogFields("msg")
+
+			InfoStructured(infoMsg, "a", "b")
+			Expect(readDatagram(conn)).To(MatchRegexp(fmt.Sprintf(`^<30>\w{3} [ \d]\d \d\d:\d\d:\d\d cni-test\[%d\]: msg=%q$`,
+				os.Getpid(), infoMsg)))
+		})
+	})
+
+	When("logging to the local syslog daemon via a stream socket", func() {
+		It("terminates every message with a newline", func() {
+			socket := path.Join(os.TempDir(), "cni-log-test-syslog-stream.sock")
+			_ = os.Remove(socket)
+			listener, err := net.Listen("unix", socket)
+			Expect(err).NotTo(HaveOccurred())
+			defer os.Remove(socket)
+			defer listener.Close()
+
+			Expect(SetSyslog("", socket, "cni-test")).To(Succeed())
+			conn, err := listener.Accept()
+			Expect(err).NotTo(HaveOccurred())
+			defer conn.Close()
+			SetSyslogFields("msg")
+
+			InfoStructured(infoMsg)
+			WarningStructured(warningMsg)
+			reader := bufio.NewReader(conn)
+			first, err := reader.ReadString('\n')
+			Expect(err).NotTo(HaveOccurred())
+			Expect(first).To(HaveSuffix(fmt.Sprintf("]: msg=%q\n", infoMsg)))
+			second, err := reader.ReadString('\n')
+			Expect(err).NotTo(HaveOccurred())
+			Expect(second).To(MatchRegexp(fmt.Sprintf(`^<28>.*\]: msg=%q\n$`, warningMsg)))
+		})
+	})
+
+	When("syslog is not reachable", func() {
+		It("returns an error", func() {
+			Expect(SetSyslog("", path.Join(os.TempDir(), "does-not-exist.sock"), "")).NotTo(Succeed())
+		})
+	})
+})
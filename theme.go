@@ -0,0 +1,75 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+// Theme maps logging levels to the markers prefixed to the messages on the console, see SetConsoleTheme. Levels
+// without a marker are not decorated.
+type Theme map[Level]string
+
+// ANSI escape sequences of the color theme.
+const (
+	ansiReset   = "\x1b[0m"
+	ansiRed     = "\x1b[1;31m"
+	ansiMagenta = "\x1b[1;35m"
+	ansiYellow  = "\x1b[33m"
+	ansiGreen   = "\x1b[32m"
+	ansiCyan    = "\x1b[36m"
+	ansiGray    = "\x1b[90m"
+)
+
+// ColorTheme returns a theme which marks every message with a short, colored level tag, e.g. a red "ERR", for
+// terminals which support ANSI colors.
+func ColorTheme() Theme {
+	return Theme{
+		FatalLevel:   ansiMagenta + "FTL" + ansiReset + " ",
+		PanicLevel:   ansiMagenta + "PNC" + ansiReset + " ",
+		ErrorLevel:   ansiRed + "ERR" + ansiReset + " ",
+		WarningLevel: ansiYellow + "WRN" + ansiReset + " ",
+		InfoLevel:    ansiGreen + "INF" + ansiReset + " ",
+		DebugLevel:   ansiCyan + "DBG" + ansiReset + " ",
+		TraceLevel:   ansiGray + "TRC" + ansiReset + " ",
+	}
+}
+
+// EmojiTheme returns a theme which marks every message with an emoji, for terminals without ANSI colors.
+func EmojiTheme() Theme {
+	return Theme{
+		FatalLevel:   "💀 ",
+		PanicLevel:   "💥 ",
+		ErrorLevel:   "❌ ",
+		WarningLevel: "⚠️ ",
+		InfoLevel:    "🔵 ",
+		DebugLevel:   "🐛 ",
+		TraceLevel:   "🔍 ",
+	}
+}
+
+// SetConsoleTheme enables the quick-scan mode of the console: the messages written to stderr and stdout are prefixed
+// with the marker of their level in theme, e.g. ColorTheme or EmojiTheme, so that developers running a plugin manually,
+// e.g. under cnitool, can spot errors at a glance. The markers are written as they are, even with SetASCIIOnly, and
+// the log file and the other outputs are never decorated. Passing nil disables it, which is the default.
+func SetConsoleTheme(theme Theme) {
+	mu.Lock()
+	defer unlockAndPublish()
+
+	// The sinks use the map after releasing mu, so it is copied.
+	consoleTheme = nil
+	if theme != nil {
+		consoleTheme = make(Theme, len(theme))
+		for level, marker := range theme {
+			consoleTheme[level] = marker
+		}
+	}
+}
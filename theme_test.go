@@ -0,0 +1,52 @@
+package logging
+
+import (
+	"bytes"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Console themes", func() {
+	var out bytes.Buffer
+
+	BeforeEach(func() {
+		initLogger()
+		out = bytes.Buffer{}
+		SetOutput(&out)
+	})
+
+	It("prefixes the console messages with the marker of their level", func() {
+		SetConsoleTheme(ColorTheme())
+		errStr := captureStdErrEvent(func(msg string, a ...interface{}) { _ = Errorf(msg, a...) }, errorMsg)
+		Expect(errStr).To(HavePrefix(ansiRed + "ERR" + ansiReset + " "))
+		Expect(errStr).To(ContainSubstring(errorMsg))
+
+		errStr = captureStdErrEvent(WarningStructured, warningMsg, "a", "b")
+		Expect(errStr).To(HavePrefix(ansiYellow + "WRN" + ansiReset + " time="))
+	})
+
+	It("does not decorate the log file and the other outputs", func() {
+		SetConsoleTheme(EmojiTheme())
+		_ = captureStdErrEvent(Warningf, warningMsg)
+		Expect(out.String()).To(ContainSubstring(warningMsg))
+		Expect(out.String()).NotTo(ContainSubstring("⚠️"))
+	})
+
+	It("uses a copy of custom themes and leaves levels without a marker alone", func() {
+		theme := Theme{WarningLevel: "!! "}
+		SetConsoleTheme(theme)
+		theme[WarningLevel] = "changed "
+		Expect(captureStdErrEvent(Warningf, warningMsg)).To(HavePrefix("!! "))
+
+		errStr := captureStdErrEvent(func(msg string, a ...interface{}) { _ = Errorf(msg, a...) }, errorMsg)
+		Expect(strings.HasPrefix(errStr, "!!")).To(BeFalse())
+	})
+
+	It("is disabled with nil", func() {
+		SetConsoleTheme(ColorTheme())
+		SetConsoleTheme(nil)
+		Expect(captureStdErrEvent(Warningf, warningMsg)).NotTo(ContainSubstring("\x1b["))
+	})
+})
@@ -0,0 +1,77 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+const (
+	tlsConfigFailMsg = "cni-log: invalid TLS configuration: %v"
+	payloadFailMsg   = "cni-log: unable to transform the payload: %v"
+)
+
+// TLSOptions configures the TLS connection to a remote log collector, whose traffic may cross untrusted networks.
+type TLSOptions struct {
+	// CAFile is the PEM file of the certificate authorities which verify the certificate of the collector. Defaults to
+	// the certificate authorities of the system.
+	CAFile string `json:"caFile,omitempty"`
+	// CertFile and KeyFile are the PEM files of the client certificate and key presented to the collector, if it
+	// requires clients to authenticate.
+	CertFile string `json:"certFile,omitempty"`
+	KeyFile  string `json:"keyFile,omitempty"`
+	// ServerName is sent via SNI and verified against the certificate of the collector. Defaults to the host of the
+	// collector's address.
+	ServerName string `json:"serverName,omitempty"`
+}
+
+// PayloadHook transforms the payload of a log message before it is shipped to a remote collector, e.g. to encrypt or
+// compress it. Returning an error drops the message.
+type PayloadHook func(payload []byte) ([]byte, error)
+
+// tlsConfig returns the TLS configuration defined by the options.
+func (o *TLSOptions) tlsConfig() (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName: o.ServerName,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if o.CAFile != "" {
+		pem, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf(tlsConfigFailMsg, err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf(tlsConfigFailMsg, fmt.Sprintf("no certificates in '%s'", o.CAFile))
+		}
+	}
+
+	if (o.CertFile == "") != (o.KeyFile == "") {
+		return nil, fmt.Errorf(tlsConfigFailMsg, errors.New("the client certificate and key must be set together"))
+	}
+	if o.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf(tlsConfigFailMsg, err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
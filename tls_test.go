@@ -0,0 +1,80 @@
+package logging
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+// writeTestCertificate writes a self-signed certificate for localhost, which can be used as CA, server and client
+// certificate, and its key to dir.
+func writeTestCertificate(dir string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	Expect(err).NotTo(HaveOccurred())
+
+	certFile, keyFile = path.Join(dir, "cert.pem"), path.Join(dir, "key.pem")
+	Expect(os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)).To(Succeed())
+	Expect(os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)).To(Succeed())
+	return certFile, keyFile
+}
+
+var _ = Describe("TLS options", func() {
+	var dir, certFile, keyFile string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "cni-log-tls")
+		Expect(err).NotTo(HaveOccurred())
+		certFile, keyFile = writeTestCertificate(dir)
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("loads the certificate authorities and the client certificate", func() {
+		config, err := (&TLSOptions{CAFile: certFile, CertFile: certFile, KeyFile: keyFile, ServerName: "collector"}).tlsConfig()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config.RootCAs).NotTo(BeNil())
+		Expect(config.Certificates).To(HaveLen(1))
+		Expect(config.ServerName).To(Equal("collector"))
+		Expect(config.MinVersion).To(BeNumerically(">=", tls.VersionTLS12))
+	})
+
+	It("rejects invalid options", func() {
+		_, err := (&TLSOptions{CAFile: path.Join(os.TempDir(), "does-not-exist.pem")}).tlsConfig()
+		Expect(err).To(HaveOccurred())
+		_, err = (&TLSOptions{CAFile: keyFile}).tlsConfig()
+		Expect(err).To(MatchError(ContainSubstring("no certificates")))
+		_, err = (&TLSOptions{CertFile: certFile}).tlsConfig()
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,149 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"bytes"
+	"os"
+	"time"
+)
+
+const (
+	reloadConfigFailedMsg = "failed to reload the logging configuration file"
+	// defaultConfigWatchInterval is the default interval at which a watched configuration file is checked for changes.
+	defaultConfigWatchInterval = 2 * time.Second
+)
+
+// configWatchInterval is the interval at which a watched configuration file is checked for changes. Guarded by mu.
+var configWatchInterval = defaultConfigWatchInterval
+
+// configWatch reloads a configuration file when its content changes.
+type configWatch struct {
+	filename string
+	data     []byte
+	lastErr  string
+	done     chan struct{}
+	// interval receives the new interval set with SetConfigWatchInterval.
+	interval chan time.Duration
+}
+
+// WatchConfigFile loads the configuration file filename like LoadConfigFile and reloads it whenever its content
+// changes, so that e.g. the logging configuration of a daemon can be changed by editing the ConfigMap mounted into its
+// pod. The file is polled every two seconds by default, see SetConfigWatchInterval, rather than watched for file system
+// events, which also detects the symbolic link swaps Kubernetes uses to update mounted ConfigMaps. Every change is applied in a single step. If the file becomes unreadable
+// or invalid, the previous configuration stays in effect and the error is logged, once per distinct error. Watching
+// another file stops watching the previous one. The error of the initial load is returned, the file is watched
+// nonetheless.
+func WatchConfigFile(filename string) error {
+	data, err := os.ReadFile(filename)
+	if err == nil {
+		err = loadConfigData(filename, data)
+	}
+
+	w := &configWatch{filename: filename, data: data, done: make(chan struct{}), interval: make(chan time.Duration, 1)}
+	if err != nil {
+		w.lastErr = err.Error()
+	}
+	mu.Lock()
+	stopConfigWatch()
+	configWatcher = w
+	interval := configWatchInterval
+	mu.Unlock()
+
+	go w.run(interval)
+	return err
+}
+
+// SetConfigWatchInterval sets the interval at which the file passed to WatchConfigFile is checked for changes, e.g. a
+// shorter one for tests or a longer one for nodes running many daemons. It applies to a running watch as well. An
+// interval <= 0 restores the default of two seconds.
+func SetConfigWatchInterval(interval time.Duration) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if interval <= 0 {
+		interval = defaultConfigWatchInterval
+	}
+	configWatchInterval = interval
+	if configWatcher != nil {
+		// replace an interval the watch has not picked up yet
+		select {
+		case <-configWatcher.interval:
+		default:
+		}
+		configWatcher.interval <- interval
+	}
+}
+
+// StopWatchingConfigFile stops watching the configuration file, see WatchConfigFile. The configuration loaded from it
+// stays in effect.
+func StopWatchingConfigFile() {
+	mu.Lock()
+	defer mu.Unlock()
+	stopConfigWatch()
+}
+
+// stopConfigWatch stops watching the configuration file if it is watched. The caller must hold mu.
+func stopConfigWatch() {
+	if configWatcher != nil {
+		close(configWatcher.done)
+		configWatcher = nil
+	}
+}
+
+// run checks the file for changes every interval until the watch is stopped.
+func (w *configWatch) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.check()
+		case interval = <-w.interval:
+			ticker.Reset(interval)
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// check reloads the file if its content changed.
+func (w *configWatch) check() {
+	data, err := os.ReadFile(w.filename)
+	if err == nil {
+		if bytes.Equal(data, w.data) || w.stopped() {
+			return
+		}
+		err = loadConfigData(w.filename, data)
+	}
+	if err == nil {
+		w.data, w.lastErr = data, ""
+		return
+	}
+	if err.Error() != w.lastErr {
+		w.lastErr = err.Error()
+		_ = ErrorStructured(reloadConfigFailedMsg, "file", w.filename, "err", err)
+	}
+}
+
+// stopped returns true if the watch was stopped.
+func (w *configWatch) stopped() bool {
+	select {
+	case <-w.done:
+		return true
+	default:
+		return false
+	}
+}
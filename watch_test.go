@@ -0,0 +1,93 @@
+package logging
+
+import (
+	"os"
+	"path"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Watching the configuration file", func() {
+	var sink *captureSink
+	var configFile string
+
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+		sink = &captureSink{}
+		AddSink(sink)
+		configFile = path.Join(os.TempDir(), "test-watch.json")
+		SetConfigWatchInterval(10 * time.Millisecond)
+		DeferCleanup(func() {
+			StopWatchingConfigFile()
+			SetConfigWatchInterval(0)
+			Expect(os.RemoveAll(configFile)).To(Succeed())
+		})
+	})
+
+	writeConfigFile := func(content string) {
+		Expect(os.WriteFile(configFile, []byte(content), 0600)).To(Succeed())
+	}
+
+	reloadErrors := func() int {
+		sink.mu.Lock()
+		defer sink.mu.Unlock()
+		n := 0
+		for _, entry := range sink.entries {
+			if entry.Message == reloadConfigFailedMsg {
+				n++
+			}
+		}
+		return n
+	}
+
+	It("applies the changes of the file", func() {
+		writeConfigFile(`{"logLevel": "error"}`)
+		Expect(WatchConfigFile(configFile)).To(Succeed())
+		Expect(GetLogLevel()).To(Equal(ErrorLevel))
+
+		writeConfigFile(`{"logLevel": "debug", "format": "json"}`)
+		Eventually(GetLogLevel).Should(Equal(DebugLevel))
+		Expect(ExplainConfig()).To(ContainElement(ConfigValue{Name: "format", Value: "json", Source: SourceFile}))
+	})
+
+	It("keeps the configuration if the file becomes invalid", func() {
+		writeConfigFile(`{"logLevel": "debug"}`)
+		Expect(WatchConfigFile(configFile)).To(Succeed())
+
+		writeConfigFile(`{"logLevel": "verbose"}`)
+		Eventually(reloadErrors).Should(Equal(1))
+		Consistently(reloadErrors, 100*time.Millisecond).Should(Equal(1))
+		Expect(GetLogLevel()).To(Equal(DebugLevel))
+
+		writeConfigFile(`{"logLevel": "warning"}`)
+		Eventually(GetLogLevel).Should(Equal(WarningLevel))
+	})
+
+	It("loads the file once it is created", func() {
+		Expect(WatchConfigFile(configFile)).NotTo(Succeed())
+		writeConfigFile(`{"logLevel": "error"}`)
+		Eventually(GetLogLevel).Should(Equal(ErrorLevel))
+	})
+
+	It("stops watching the file", func() {
+		writeConfigFile(`{"logLevel": "error"}`)
+		Expect(WatchConfigFile(configFile)).To(Succeed())
+		StopWatchingConfigFile()
+
+		writeConfigFile(`{"logLevel": "debug"}`)
+		Consistently(GetLogLevel, 100*time.Millisecond).Should(Equal(ErrorLevel))
+	})
+	It("applies a new interval to a running watch", func() {
+		SetConfigWatchInterval(time.Hour)
+		writeConfigFile(`{"logLevel": "error"}`)
+		Expect(WatchConfigFile(configFile)).To(Succeed())
+
+		writeConfigFile(`{"logLevel": "debug"}`)
+		Consistently(GetLogLevel, 100*time.Millisecond).Should(Equal(ErrorLevel))
+		SetConfigWatchInterval(10 * time.Millisecond)
+		Eventually(GetLogLevel).Should(Equal(DebugLevel))
+	})
+})
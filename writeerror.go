@@ -0,0 +1,67 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	writeFailMsg = "cni-log: failed to write log message: %v"
+	// selfLogInterval is the minimum interval between two write failures reported to stderr without an error handler.
+	selfLogInterval = time.Minute
+)
+
+// lastSelfLog is the time in Unix nanoseconds of the last write failure reported to stderr.
+var lastSelfLog int64
+
+// WriteError is passed to the error handler when an output or a sink fails to write a message, e.g. because the disk
+// is full. Err is the error returned by the output.
+type WriteError struct {
+	Err error
+}
+
+// Error implements the error interface.
+func (e *WriteError) Error() string {
+	return fmt.Sprintf(writeFailMsg, e.Err)
+}
+
+// Unwrap returns the error returned by the output.
+func (e *WriteError) Unwrap() error {
+	return e.Err
+}
+
+// reportWriteError passes a write failure to the error handler. Without a handler, the failure is reported to stderr,
+// at most once per selfLogInterval so that a full disk does not double the output.
+func (s *snapshot) reportWriteError(err error) {
+	writeErr := &WriteError{Err: err}
+	recordWriteFailure(err)
+	if s.errorHandler != nil {
+		s.errorHandler(writeErr)
+		return
+	}
+
+	now := time.Now().UnixNano()
+	last := atomic.LoadInt64(&lastSelfLog)
+	if last != 0 && now-last < int64(selfLogInterval) {
+		return
+	}
+	if atomic.CompareAndSwapInt64(&lastSelfLog, last, now) {
+		fmt.Fprintln(os.Stderr, writeErr.Error())
+	}
+}
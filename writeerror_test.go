@@ -0,0 +1,50 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2/dsl/core"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Write failures", func() {
+	var out *failingWriter
+
+	BeforeEach(func() {
+		initLogger()
+		SetLogStderr(false)
+		out = &failingWriter{failing: true}
+		SetOutput(out)
+	})
+
+	It("passes write failures to the error handler", func() {
+		var errs []error
+		SetErrorHandler(func(err error) { errs = append(errs, err) })
+		Infof(infoMsg)
+		InfoStructured(infoMsg)
+
+		Expect(errs).To(HaveLen(2))
+		var writeErr *WriteError
+		Expect(errors.As(errs[0], &writeErr)).To(BeTrue())
+		Expect(writeErr.Err).To(MatchError("no space left on device"))
+		Expect(errs[0]).To(MatchError("cni-log: failed to write log message: no space left on device"))
+	})
+
+	It("does not call the error handler if a write succeeds", func() {
+		var errs []error
+		SetErrorHandler(func(err error) { errs = append(errs, err) })
+		out.failing = false
+		Infof(infoMsg)
+		Expect(errs).To(BeEmpty())
+		Expect(out.buf.String()).To(ContainSubstring(infoMsg))
+	})
+
+	It("reports write failures to stderr once without an error handler", func() {
+		errStr := captureStdErr(func(int) {
+			Infof(infoMsg)
+			Warningf(warningMsg)
+		}, 0)
+		Expect(errStr).To(Equal(fmt.Sprintf(writeFailMsg, "no space left on device") + "\n"))
+	})
+})